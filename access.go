@@ -0,0 +1,136 @@
+package gocache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// WithAccessTracking records how often and when each item was last read,
+// with the given resolution: a read only updates the access time if it's
+// older than that, which keeps frequently read items from writing it on
+// every Get. With tracking enabled, eviction removes the least recently used
+// items first (among those of the same priority) instead of the oldest.
+func WithAccessTracking(resolution time.Duration) Option {
+	return func(c *Cache) {
+		c.trackAccess = true
+		c.accessResolution = int64(resolution)
+	}
+}
+
+// accessRecord holds the reads of an item
+type accessRecord struct {
+	last  atomic.Int64  // last read in UnixNano
+	count atomic.Uint64 // number of reads
+}
+
+// touch records a read of item at now
+func (c *Cache) touch(item Item, now int64) {
+	if item.accessed == nil {
+		return
+	}
+	item.accessed.count.Add(1)
+	if now-item.accessed.last.Load() >= c.accessResolution {
+		item.accessed.last.Store(now)
+	}
+}
+
+// lastUsed returns when the item was last read, or written if it was never
+// read or access tracking is disabled
+func (item Item) lastUsed() int64 {
+	if item.accessed != nil {
+		if t := item.accessed.last.Load(); t > 0 {
+			return t
+		}
+	}
+	return item.Created
+}
+
+// newAccessRecord returns the access record of a new item, nil unless
+// WithAccessTracking is set
+func (c *Cache) newAccessRecord() *accessRecord {
+	if !c.trackAccess {
+		return nil
+	}
+	return new(accessRecord)
+}
+
+// hits returns the number of reads of the item, 0 without access tracking
+func (item Item) hits() uint64 {
+	if item.accessed == nil {
+		return 0
+	}
+	return item.accessed.count.Load()
+}
+
+// Accessed returns when the item stored under key was created and last
+// read. accessed equals created if the item wasn't read since, or access
+// tracking is disabled.
+func (c *Cache) Accessed(key string) (created, accessed time.Time, found bool) {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, found := c.items[key]
+	if !found || c.staleLocked(item, time.Now().UnixNano()) {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(0, item.Created), time.Unix(0, item.lastUsed()), true
+}
+
+// AccessCount returns how many times the item stored under key was read
+// since it was written. It's always 0 without WithAccessTracking.
+func (c *Cache) AccessCount(key string) (uint64, bool) {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, found := c.items[key]
+	if !found || c.staleLocked(item, time.Now().UnixNano()) {
+		return 0, false
+	}
+	return item.hits(), true
+}
+
+// OldestKeys returns up to n live keys, oldest write first
+func (c *Cache) OldestKeys(n int) []string {
+	return c.keysBy(n, func(item Item) int64 { return item.Created })
+}
+
+// LeastRecentlyUsed returns up to n live keys, least recently read first.
+// Without WithAccessTracking this is the same as OldestKeys.
+func (c *Cache) LeastRecentlyUsed(n int) []string {
+	return c.keysBy(n, Item.lastUsed)
+}
+
+// keysBy returns up to n live keys ordered by ascending time
+func (c *Cache) keysBy(n int, at func(Item) int64) []string {
+	type entry struct {
+		key  string
+		when int64
+	}
+
+	c.mu.RLock()
+	now := time.Now().UnixNano()
+	entries := make([]entry, 0, len(c.items))
+	for k, v := range c.items {
+		if !c.staleLocked(v, now) {
+			entries = append(entries, entry{key: k, when: at(v)})
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].when < entries[j].when
+	})
+	if n < len(entries) {
+		entries = entries[:n]
+	}
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
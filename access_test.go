@@ -0,0 +1,77 @@
+package gocache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOldestKeys(t *testing.T) {
+	c := New(0)
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, k)
+		time.Sleep(time.Millisecond)
+	}
+	c.Set("a", "rewritten")
+
+	if got := c.OldestKeys(2); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Fatalf("Expected [b c], got %v", got)
+	}
+	if got := c.LeastRecentlyUsed(10); !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Fatalf("Expected LeastRecentlyUsed to match OldestKeys without tracking, got %v", got)
+	}
+}
+
+func TestAccessTracking(t *testing.T) {
+	c := New(0, WithAccessTracking(0))
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, k)
+		time.Sleep(time.Millisecond)
+	}
+	c.GetBytes("a")
+
+	if got := c.LeastRecentlyUsed(3); !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Fatalf("Expected [b c a], got %v", got)
+	}
+	created, accessed, found := c.Accessed("a")
+	if !found || !accessed.After(created) {
+		t.Fatalf("Expected a's access time after its creation, got %v %v", created, accessed)
+	}
+	if _, _, found := c.Accessed("missing"); found {
+		t.Fatal("Expected missing key not to be found")
+	}
+
+	c.GetBytes("a")
+	if n, found := c.AccessCount("a"); !found || n != 2 {
+		t.Fatalf("Expected 2 reads of a, got %d", n)
+	}
+	if n, _ := c.AccessCount("b"); n != 0 {
+		t.Fatalf("Expected no reads of b, got %d", n)
+	}
+}
+
+func TestAccessTrackingResolution(t *testing.T) {
+	c := New(0, WithAccessTracking(time.Hour))
+	c.Set("a", "a")
+	c.GetBytes("a")
+	_, first, _ := c.Accessed("a")
+	time.Sleep(time.Millisecond)
+	c.GetBytes("a")
+	if _, second, _ := c.Accessed("a"); !second.Equal(first) {
+		t.Fatal("Expected reads within the resolution not to update the access time")
+	}
+}
+
+func TestAccessTrackingEvictsLRU(t *testing.T) {
+	c := New(0, WithAccessTracking(0), WithMaxBytes(3*11))
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, "0123456789")
+		time.Sleep(time.Millisecond)
+	}
+	c.GetBytes("a")
+	c.Set("d", "0123456789")
+
+	if !c.Exists("a") || c.Exists("b") {
+		t.Fatal("Expected the least recently used item b to be evicted instead of a")
+	}
+}
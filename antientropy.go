@@ -0,0 +1,202 @@
+package gocache
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"time"
+)
+
+// ErrInvalidBuckets is returned by Digest and BucketKeys for a bucket count
+// that isn't positive or a bucket out of range
+var ErrInvalidBuckets = errors.New("gocache: invalid digest buckets")
+
+// DigestReplica is a Replica that can be compared with its primary, letting
+// the Replicator find and repair entries that diverged, e.g. after writes to
+// the replica or a restart of a remote replica
+type DigestReplica interface {
+	Replica
+
+	// Digest returns a hash of the live items in each of the given number
+	// of buckets, as computed by (*Cache).Digest
+	Digest(buckets int) ([]uint64, error)
+
+	// BucketKeys returns the live keys in a bucket
+	BucketKeys(bucket, buckets int) ([]string, error)
+}
+
+// Digest hashes the live items (key, value and expiration) into the given
+// number of buckets, including items spilled to the overflow store, which
+// are read back without being faulted in. Two caches holding the same items
+// have the same digest, so comparing digests bucket by bucket finds the keys
+// to resync without transferring them. It returns ErrInvalidBuckets if
+// buckets isn't positive.
+func (c *Cache) Digest(buckets int) ([]uint64, error) {
+	if buckets <= 0 {
+		return nil, ErrInvalidBuckets
+	}
+	digest := make([]uint64, buckets)
+
+	c.mu.RLock()
+	now := time.Now().UnixNano()
+	for k, v := range c.items {
+		if c.staleLocked(v, now) {
+			continue
+		}
+		digest[bucketOf(k, buckets)] ^= itemHash(k, v)
+	}
+	spilled := c.liveSpilledLocked(nil)
+	c.mu.RUnlock()
+
+	for k := range spilled {
+		if item, ok := c.peekSpilled(k); ok {
+			digest[bucketOf(k, buckets)] ^= itemHash(k, item)
+		}
+	}
+	return digest, nil
+}
+
+// BucketKeys returns the live keys in bucket, of the given number of buckets
+// used by Digest, including spilled keys. It returns ErrInvalidBuckets if
+// buckets isn't positive or bucket isn't one of them.
+func (c *Cache) BucketKeys(bucket, buckets int) ([]string, error) {
+	if buckets <= 0 || bucket < 0 || bucket >= buckets {
+		return nil, ErrInvalidBuckets
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var keys []string
+	now := time.Now().UnixNano()
+	for k, v := range c.items {
+		if bucketOf(k, buckets) == bucket && !c.staleLocked(v, now) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range c.liveSpilledLocked(func(k string) bool { return bucketOf(k, buckets) == bucket }) {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// liveSpilledLocked returns the versions of the spilled items not removed by
+// Flush whose keys match, or of all of them if match is nil. The caller must
+// hold the read lock.
+func (c *Cache) liveSpilledLocked(match func(key string) bool) map[string]uint64 {
+	spilled := make(map[string]uint64)
+	for k, version := range c.spilled {
+		if version > c.flushedAt && (match == nil || match(k)) {
+			spilled[k] = version
+		}
+	}
+	return spilled
+}
+
+// bucketOf returns the digest bucket of key. It must be stable across
+// processes, so it doesn't use maphash.
+func bucketOf(key string, buckets int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(buckets))
+}
+
+// itemHash hashes the replicated state of an item
+func itemHash(key string, item Item) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(key)))
+	h.Write(buf[:])
+	h.Write([]byte(key))
+	h.Write(item.Value)
+	binary.LittleEndian.PutUint64(buf[:], uint64(item.Expiration))
+	h.Write(buf[:])
+	return h.Sum64()
+}
+
+// repair compares the digests of the primary and the replica and resyncs the
+// buckets that differ. It's called by the stream goroutine between batches,
+// and gives up for this round if changes were published meanwhile, since
+// those would be applied after, and so overwrite, the repaired state.
+func (r *Replicator) repair(replica DigestReplica, s *subscriber) error {
+	buckets := r.config.AntiEntropyBuckets
+	remote, err := replica.Digest(buckets)
+	if err != nil {
+		return r.fail(err)
+	}
+	local, _ := r.cache.Digest(buckets)
+
+	remoteKeys := make(map[int][]string)
+	for b := range local {
+		if b < len(remote) && local[b] == remote[b] {
+			continue
+		}
+		keys, err := replica.BucketKeys(b, buckets)
+		if err != nil {
+			return r.fail(err)
+		}
+		remoteKeys[b] = keys
+	}
+	if len(remoteKeys) == 0 {
+		return nil
+	}
+
+	c := r.cache
+	diverged := func(k string) bool {
+		_, ok := remoteKeys[bucketOf(k, buckets)]
+		return ok
+	}
+
+	// Read the spilled items of the diverged buckets without holding the
+	// lock; those spilled or faulted in meanwhile are left to the next round
+	c.mu.RLock()
+	spilled := c.liveSpilledLocked(diverged)
+	c.mu.RUnlock()
+	loaded := make(map[string]Item)
+	for k, version := range spilled {
+		if item, ok := c.peekSpilled(k); ok {
+			item.version = version
+			loaded[k] = item
+		}
+	}
+
+	c.mu.RLock()
+	if len(s.ch) > 0 {
+		c.mu.RUnlock()
+		return nil
+	}
+	var changes []Change
+	now := time.Now().UnixNano()
+	for k, v := range c.items {
+		if !diverged(k) || c.staleLocked(v, now) {
+			continue
+		}
+		changes = append(changes, Change{Seq: c.changeSeq, Kind: ChangeSet, Key: k, Value: v.Value, Expiration: v.Expiration, Format: v.format})
+	}
+	for k, v := range loaded {
+		if version, cold := c.spilled[k]; cold && version == v.version {
+			changes = append(changes, Change{Seq: c.changeSeq, Kind: ChangeSet, Key: k, Value: v.Value, Expiration: v.Expiration, Format: v.format})
+		}
+	}
+	for _, keys := range remoteKeys {
+		for _, k := range keys {
+			if v, found := c.items[k]; found && !c.staleLocked(v, now) {
+				continue
+			}
+			if version, cold := c.spilled[k]; cold && version > c.flushedAt {
+				if _, ok := loaded[k]; ok || version != spilled[k] {
+					continue
+				}
+			}
+			changes = append(changes, Change{Seq: c.changeSeq, Kind: ChangeDelete, Key: k})
+		}
+	}
+	c.mu.RUnlock()
+
+	if err := r.apply(changes); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.stats.Repairs += len(changes)
+	r.mu.Unlock()
+	return nil
+}
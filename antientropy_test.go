@@ -0,0 +1,135 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	a, b := New(0), New(0)
+	for _, c := range []*Cache{a, b} {
+		c.Set("x", "1")
+		c.SetWithExpireAt("y", "2", time.Unix(2e9, 0))
+	}
+	da, _ := a.Digest(16)
+	db, _ := b.Digest(16)
+	for i := range da {
+		if da[i] != db[i] {
+			t.Fatalf("Expected equal digests for equal caches, bucket %d differs", i)
+		}
+	}
+
+	b.Set("x", "changed")
+	db, _ = b.Digest(16)
+	bucket := bucketOf("x", 16)
+	if da[bucket] == db[bucket] {
+		t.Fatal("Expected the bucket of a changed key to differ")
+	}
+	if keys, _ := b.BucketKeys(bucket, 16); len(keys) == 0 || !contains(keys, "x") {
+		t.Fatalf("Expected x in bucket %d, got %v", bucket, keys)
+	}
+}
+
+func TestDigestInvalidBuckets(t *testing.T) {
+	c := New(0)
+	c.Set("x", "1")
+	for _, buckets := range []int{0, -1} {
+		if _, err := c.Digest(buckets); !errors.Is(err, ErrInvalidBuckets) {
+			t.Fatalf("Expected ErrInvalidBuckets for %d buckets, got %v", buckets, err)
+		}
+		if _, err := c.BucketKeys(0, buckets); !errors.Is(err, ErrInvalidBuckets) {
+			t.Fatalf("Expected ErrInvalidBuckets from BucketKeys for %d buckets, got %v", buckets, err)
+		}
+	}
+	if _, err := c.BucketKeys(16, 16); !errors.Is(err, ErrInvalidBuckets) {
+		t.Fatalf("Expected ErrInvalidBuckets for a bucket out of range, got %v", err)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAntiEntropyRepair(t *testing.T) {
+	primary := New(0)
+	replica := New(0)
+	for _, k := range []string{"a", "b", "c"} {
+		primary.Set(k, k)
+	}
+
+	r := primary.Replicate(replica, ReplicationConfig{AntiEntropyInterval: 5 * time.Millisecond, AntiEntropyBuckets: 8})
+	defer r.Stop()
+	waitFor(t, "initial sync", func() bool { return replica.Count() == 3 })
+
+	// Diverge the replica behind the replicator's back
+	replica.Set("a", "stale")
+	replica.Delete("b")
+	replica.Set("rogue", "x")
+
+	waitFor(t, "repair", func() bool {
+		a, _ := replica.GetString("a")
+		return a == "a" && replica.Exists("b") && !replica.Exists("rogue")
+	})
+	if r.Stats().Repairs == 0 {
+		t.Fatal("Expected repairs to be counted")
+	}
+}
+
+func TestDigestSpilled(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	a := New(0, WithMaxBytes(10), WithOverflow(store))
+	b := New(0)
+	for _, c := range []*Cache{a, b} {
+		c.Set("x", "value-x")
+		c.Set("y", "value-y") // spills x in a
+	}
+	if !a.spilledKey("x") {
+		t.Fatal("Expected x to be spilled")
+	}
+
+	da, _ := a.Digest(16)
+	db, _ := b.Digest(16)
+	for i := range da {
+		if da[i] != db[i] {
+			t.Fatalf("Expected spilled items in the digest, bucket %d differs", i)
+		}
+	}
+	if keys, _ := a.BucketKeys(bucketOf("x", 16), 16); !contains(keys, "x") {
+		t.Fatalf("Expected the spilled key x in its bucket, got %v", keys)
+	}
+	if !a.spilledKey("x") {
+		t.Fatal("Digest shouldn't fault spilled items in")
+	}
+}
+
+func TestAntiEntropyRepairSpilled(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	primary := New(0, WithMaxBytes(10), WithOverflow(store))
+	replica := New(0)
+
+	r := primary.Replicate(replica, ReplicationConfig{AntiEntropyInterval: 5 * time.Millisecond, AntiEntropyBuckets: 8})
+	defer r.Stop()
+	primary.Set("a", "value-a")
+	primary.Set("b", "value-b") // spills a
+	waitFor(t, "initial sync", func() bool { return replica.Count() == 2 })
+	if !primary.spilledKey("a") {
+		t.Fatal("Expected a to be spilled")
+	}
+
+	// The spilled key is repaired, not deleted
+	replica.Set("a", "stale")
+	waitFor(t, "repair", func() bool {
+		a, _ := replica.GetString("a")
+		return a == "value-a"
+	})
+	time.Sleep(20 * time.Millisecond)
+	if a, _ := replica.GetString("a"); a != "value-a" {
+		t.Fatalf("Expected the replica to keep a, got %q", a)
+	}
+}
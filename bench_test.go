@@ -0,0 +1,129 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const benchKeys = 1024
+
+func benchKey(i int) string {
+	return fmt.Sprintf("key%d", i%benchKeys)
+}
+
+// BenchmarkGetParallel measures read throughput of the RWMutex-guarded map
+func BenchmarkGetParallel(b *testing.B) {
+	c := New(0)
+	for i := 0; i < benchKeys; i++ {
+		c.Set(benchKey(i), "value")
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.GetBytes(benchKey(i))
+			i++
+		}
+	})
+}
+
+// BenchmarkGetParallelWrites mixes in 5% writes, where writers contend with readers
+func BenchmarkGetParallelWrites(b *testing.B) {
+	c := New(0)
+	for i := 0; i < benchKeys; i++ {
+		c.Set(benchKey(i), "value")
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%20 == 0 {
+				c.Set(benchKey(i), "value")
+			} else {
+				c.GetBytes(benchKey(i))
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkReadOptimizedGetParallel is BenchmarkGetParallel with
+// WithReadOptimized
+func BenchmarkReadOptimizedGetParallel(b *testing.B) {
+	c := New(0, WithReadOptimized())
+	for i := 0; i < benchKeys; i++ {
+		c.Set(benchKey(i), "value")
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.GetBytes(benchKey(i))
+			i++
+		}
+	})
+}
+
+// BenchmarkReadOptimizedGetParallelWrites is BenchmarkGetParallelWrites
+// with WithReadOptimized
+func BenchmarkReadOptimizedGetParallelWrites(b *testing.B) {
+	c := New(0, WithReadOptimized())
+	for i := 0; i < benchKeys; i++ {
+		c.Set(benchKey(i), "value")
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%20 == 0 {
+				c.Set(benchKey(i), "value")
+			} else {
+				c.GetBytes(benchKey(i))
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkSyncMapGetParallel is the sync.Map baseline for BenchmarkGetParallel
+func BenchmarkSyncMapGetParallel(b *testing.B) {
+	var m sync.Map
+	for i := 0; i < benchKeys; i++ {
+		m.Store(benchKey(i), Item{Value: []byte("value")})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Load(benchKey(i))
+			i++
+		}
+	})
+}
+
+// BenchmarkAtomicMapGetParallel is the copy-on-write map baseline for
+// BenchmarkGetParallel: reads load an immutable map through an atomic pointer
+func BenchmarkAtomicMapGetParallel(b *testing.B) {
+	var p atomic.Pointer[map[string]Item]
+	m := make(map[string]Item, benchKeys)
+	for i := 0; i < benchKeys; i++ {
+		m[benchKey(i)] = Item{Value: []byte("value")}
+	}
+	p.Store(&m)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = (*p.Load())[benchKey(i)]
+			i++
+		}
+	})
+}
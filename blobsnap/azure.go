@@ -0,0 +1,125 @@
+package blobsnap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Azure is a BlobStore backed by an Azure Blob Storage container,
+// authenticated with a shared access signature granting read, write and
+// list permissions on it
+type Azure struct {
+	// ContainerURL is the URL of the container, such as
+	// https://account.blob.core.windows.net/snapshots
+	ContainerURL string
+	// SAS is the shared access signature query string, with or without the
+	// leading '?'
+	SAS string
+
+	// Client sends the requests, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// NewAzure returns an Azure store for the container at containerURL
+func NewAzure(containerURL, sas string) *Azure {
+	return &Azure{ContainerURL: containerURL, SAS: sas}
+}
+
+func (a *Azure) Put(ctx context.Context, name string, data []byte) error {
+	header := http.Header{"X-Ms-Blob-Type": {"BlockBlob"}}
+	resp, err := a.do(ctx, http.MethodPut, name, nil, header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (a *Azure) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := a.do(ctx, http.MethodGet, name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (a *Azure) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+	for {
+		resp, err := a.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Blobs struct {
+				Blob []struct {
+					Name string
+				}
+			}
+			NextMarker string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("blobsnap: listing %s: %w", a.ContainerURL, err)
+		}
+		for _, b := range result.Blobs.Blob {
+			names = append(names, b.Name)
+		}
+		if result.NextMarker == "" {
+			break
+		}
+		query.Set("marker", result.NextMarker)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// do sends a request for the blob name, or the container if name is empty,
+// and returns the response if it succeeded. A 404 is reported as
+// ErrNotFound.
+func (a *Azure) do(ctx context.Context, method, name string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u, err := url.Parse(strings.TrimSuffix(a.ContainerURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		u.Path += "/" + name
+	}
+	sas, err := url.ParseQuery(strings.TrimPrefix(a.SAS, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("blobsnap: invalid SAS: %w", err)
+	}
+	for key, values := range query {
+		sas[key] = values
+	}
+	u.RawQuery = sas.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("X-Ms-Version", "2021-08-06")
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return checkResponse(resp, method, "/"+name)
+}
@@ -0,0 +1,103 @@
+package blobsnap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAzure serves the subset of the Blob Storage API used by Azure, listing
+// two blobs per page
+type fakeAzure struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("sig") != "secret" {
+		http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/container/")
+	switch {
+	case r.Method == http.MethodPut:
+		if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+			http.Error(w, "MissingRequiredHeader", http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case query.Get("comp") == "list":
+		var names []string
+		for n := range f.blobs {
+			if strings.HasPrefix(n, query.Get("prefix")) && n > query.Get("marker") {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		type blob struct{ Name string }
+		var result struct {
+			XMLName xml.Name `xml:"EnumerationResults"`
+			Blobs   struct {
+				Blob []blob
+			}
+			NextMarker string
+		}
+		for _, n := range names {
+			if len(result.Blobs.Blob) == 2 {
+				result.NextMarker = result.Blobs.Blob[1].Name
+				break
+			}
+			result.Blobs.Blob = append(result.Blobs.Blob, blob{n})
+		}
+		xml.NewEncoder(w).Encode(result)
+	default:
+		data, ok := f.blobs[name]
+		if !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func TestAzure(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(&fakeAzure{blobs: make(map[string][]byte)})
+	defer server.Close()
+	store := NewAzure(server.URL+"/container", "?sv=2021-08-06&sig=secret")
+
+	for _, name := range []string{"snap/3", "snap/1", "snap/2", "other"} {
+		if err := store.Put(ctx, name, []byte(name)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	data, err := store.Get(ctx, "snap/1")
+	if err != nil || string(data) != "snap/1" {
+		t.Fatalf("Expected snap/1, got %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "snap/4"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	names, err := store.List(ctx, "snap/")
+	if err != nil || strings.Join(names, ",") != "snap/1,snap/2,snap/3" {
+		t.Fatalf("Expected the three snap/ blobs, got %v, %v", names, err)
+	}
+
+	store.SAS = "sig=wrong"
+	if _, err := store.Get(ctx, "snap/1"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected a 403 error, got %v", err)
+	}
+}
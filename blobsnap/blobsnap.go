@@ -0,0 +1,148 @@
+// Package blobsnap saves gocache snapshots to object storage and restores
+// them, so ephemeral containers can warm-start from a shared bucket:
+//
+//	store := blobsnap.NewS3("https://s3.eu-west-1.amazonaws.com", "eu-west-1", "my-bucket", keyID, secret)
+//	n, err := blobsnap.RestoreLatest(ctx, cache, store, "sessions/")
+//	...
+//	err = blobsnap.SaveTimestamped(ctx, cache, store, "sessions/")
+//
+// Adapters for S3, GCS and Azure Blob Storage talk to the services' HTTP
+// APIs directly, with no SDK dependencies; any other storage can be used by
+// implementing BlobStore.
+package blobsnap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// ErrNotFound is returned by BlobStore.Get for a missing blob, and by
+// RestoreLatest when there's no snapshot to restore
+var ErrNotFound = errors.New("blobsnap: blob not found")
+
+// timestampLayout names the snapshots written by SaveTimestamped, so they
+// sort chronologically
+const timestampLayout = "20060102T150405.000000000Z"
+
+// BlobStore is a bucket of named blobs. Implementations must be safe for
+// concurrent use.
+type BlobStore interface {
+	// Put stores data under name, replacing any existing blob
+	Put(ctx context.Context, name string, data []byte) error
+	// Get returns the blob stored under name, or ErrNotFound
+	Get(ctx context.Context, name string) ([]byte, error)
+	// List returns the names of the blobs starting with prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Save writes a snapshot of c to store under name
+func Save(ctx context.Context, c *gocache.Cache, store BlobStore, name string) error {
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		return err
+	}
+	return store.Put(ctx, name, buf.Bytes())
+}
+
+// Restore loads the snapshot stored under name into c, see LoadSnapshot. It
+// returns the number of entries stored.
+func Restore(ctx context.Context, c *gocache.Cache, store BlobStore, name string, opts ...gocache.SnapshotOption) (int, error) {
+	data, err := store.Get(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return c.LoadSnapshot(bytes.NewReader(data), opts...)
+}
+
+// SaveTimestamped writes a snapshot of c to store under prefix followed by
+// the current UTC time, and returns the name it used. Snapshots of the same
+// prefix sort by age, for RestoreLatest.
+func SaveTimestamped(ctx context.Context, c *gocache.Cache, store BlobStore, prefix string) (string, error) {
+	name := prefix + time.Now().UTC().Format(timestampLayout)
+	return name, Save(ctx, c, store, name)
+}
+
+// RestoreLatest loads the most recent snapshot written by SaveTimestamped
+// with prefix into c. It returns ErrNotFound if there is none.
+func RestoreLatest(ctx context.Context, c *gocache.Cache, store BlobStore, prefix string, opts ...gocache.SnapshotOption) (int, error) {
+	names, err := store.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	var latest string
+	for _, name := range names {
+		if _, err := time.Parse(timestampLayout, strings.TrimPrefix(name, prefix)); err == nil && name > latest {
+			latest = name
+		}
+	}
+	if latest == "" {
+		return 0, ErrNotFound
+	}
+	return Restore(ctx, c, store, latest, opts...)
+}
+
+// Dir is a BlobStore keeping blobs as files in a local directory, for
+// development and tests, or a mounted network volume. Names may contain
+// slashes, which map to subdirectories.
+type Dir string
+
+func (d Dir) Put(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (d Dir) Get(ctx context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (d Dir) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(string(d), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return err
+		}
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	sort.Strings(names)
+	return names, err
+}
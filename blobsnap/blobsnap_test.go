@@ -0,0 +1,66 @@
+package blobsnap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestDirPutGetList(t *testing.T) {
+	ctx := context.Background()
+	store := Dir(t.TempDir())
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	for _, name := range []string{"b/2", "a/1", "b/1"} {
+		if err := store.Put(ctx, name, []byte(name)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	data, err := store.Get(ctx, "b/2")
+	if err != nil || string(data) != "b/2" {
+		t.Fatalf("Expected b/2, got %q, %v", data, err)
+	}
+	names, err := store.List(ctx, "b/")
+	if err != nil || len(names) != 2 || names[0] != "b/1" || names[1] != "b/2" {
+		t.Fatalf("Expected [b/1 b/2], got %v, %v", names, err)
+	}
+}
+
+func TestRestoreLatest(t *testing.T) {
+	ctx := context.Background()
+	store := Dir(t.TempDir())
+
+	if _, err := RestoreLatest(ctx, gocache.New(0), store, "app/"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	c := gocache.New(0)
+	c.Set("k", "old")
+	if _, err := SaveTimestamped(ctx, c, store, "app/"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	c.Set("k", "new")
+	name, err := SaveTimestamped(ctx, c, store, "app/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Blobs that aren't timestamped snapshots are ignored
+	if err := store.Put(ctx, "app/zzz", []byte("junk")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	restored := gocache.New(0)
+	n, err := RestoreLatest(ctx, restored, store, "app/")
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 entry restored from %s, got %d, %v", name, n, err)
+	}
+	if v, _ := restored.GetString("k"); v != "new" {
+		t.Fatalf("Expected new, got %q", v)
+	}
+}
@@ -0,0 +1,130 @@
+package blobsnap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	emptyHash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signV4(req, emptyHash, "service", "us-east-1", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", now)
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Fatalf("Expected %s, got %s", expected, got)
+	}
+}
+
+// fakeS3 serves the subset of the S3 API used by S3, listing two keys per
+// page
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	// S3 signs the path and query as sent, so they must be in the canonical
+	// encoding the client signed
+	if r.URL.EscapedPath() != uriEncode(r.URL.Path, false) || r.URL.RawQuery != canonicalQuery(r.URL.Query()) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.URL.Query().Get("list-type") == "2":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("continuation-token") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		type content struct{ Key string }
+		var result struct {
+			XMLName               xml.Name `xml:"ListBucketResult"`
+			Contents              []content
+			IsTruncated           bool
+			NextContinuationToken string `xml:",omitempty"`
+		}
+		for _, k := range keys {
+			if len(result.Contents) == 2 {
+				result.IsTruncated = true
+				result.NextContinuationToken = result.Contents[1].Key
+				break
+			}
+			result.Contents = append(result.Contents, content{k})
+		}
+		xml.NewEncoder(w).Encode(result)
+	default:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer server.Close()
+	store := NewS3(server.URL, "us-east-1", "bucket", "id", "secret")
+
+	for _, name := range []string{"snap/3", "snap/1", "snap/2", "other"} {
+		if err := store.Put(ctx, name, []byte(name)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	data, err := store.Get(ctx, "snap/2")
+	if err != nil || string(data) != "snap/2" {
+		t.Fatalf("Expected snap/2, got %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "snap/4"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	names, err := store.List(ctx, "snap/")
+	if err != nil || strings.Join(names, ",") != "snap/1,snap/2,snap/3" {
+		t.Fatalf("Expected the three snap/ keys, got %v, %v", names, err)
+	}
+
+	special := "snap/a+b!$:=c d"
+	if err := store.Put(ctx, special, []byte("special")); err != nil {
+		t.Fatalf("Expected a name with reserved characters to be stored, got %v", err)
+	}
+	if data, err := store.Get(ctx, special); err != nil || string(data) != "special" {
+		t.Fatalf("Expected special, got %q, %v", data, err)
+	}
+	if names, err := store.List(ctx, "snap/a+b"); err != nil || len(names) != 1 || names[0] != special {
+		t.Fatalf("Expected %q to be listed, got %v, %v", special, names, err)
+	}
+
+	store.SecretAccessKey = ""
+	store.AccessKeyID = "wrong"
+	if err := store.Put(ctx, "x", nil); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected a 403 error, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package gocache
+
+import (
+	"hash/maphash"
+	"math"
+	"time"
+)
+
+// WithBloomFilter keeps a Bloom filter of the keys ever set, sized for
+// expectedKeys keys at the given false positive rate. Lookups consult it
+// before the map, so misses for keys never stored are answered without
+// faulting in from the overflow store, and MayContain lets callers skip
+// remote tiers for guaranteed misses. Deleted and expired keys stay in the
+// filter until RebuildBloomFilter or Flush.
+func WithBloomFilter(expectedKeys int, falsePositiveRate float64) Option {
+	return func(c *Cache) {
+		c.bloom = newBloomFilter(expectedKeys, falsePositiveRate)
+	}
+}
+
+// bloomFilter is a Bloom filter over keys, using double hashing to derive
+// its hash functions from one 64-bit hash
+type bloomFilter struct {
+	bits   []uint64
+	m      uint64 // number of bits
+	k      uint64 // number of hash functions
+	seed   maphash.Seed
+	expect int
+	rate   float64
+}
+
+// newBloomFilter returns a filter sized for n keys at false positive rate p
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(k, 1)
+	return &bloomFilter{
+		bits:   make([]uint64, (m+63)/64),
+		m:      m,
+		k:      k,
+		seed:   maphash.MakeSeed(),
+		expect: n,
+		rate:   p,
+	}
+}
+
+// add records key in the filter
+func (f *bloomFilter) add(key string) {
+	h1, h2 := f.hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// mayContain reports false if key was definitely never added
+func (f *bloomFilter) mayContain(key string) bool {
+	h1, h2 := f.hashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes returns the two hashes combined into the filter's k hashes
+func (f *bloomFilter) hashes(key string) (uint64, uint64) {
+	h := maphash.String(f.seed, key)
+	// An odd step visits distinct bits for any k below m
+	return h, (h>>32 | h<<32) | 1
+}
+
+// reset clears the filter, keeping its size
+func (f *bloomFilter) reset() {
+	clear(f.bits)
+}
+
+// MayContain reports whether key may hold an item. false means it definitely
+// doesn't; true may be a false positive. Without WithBloomFilter it always
+// returns true.
+func (c *Cache) MayContain(key string) bool {
+	if c.bloom == nil {
+		return true
+	}
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bloom.mayContain(key)
+}
+
+// RebuildBloomFilter replaces the Bloom filter with one holding only the live
+// keys, clearing deleted and expired keys that raise the false positive rate.
+// It does nothing without WithBloomFilter.
+func (c *Cache) RebuildBloomFilter() {
+	if c.bloom == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := newBloomFilter(max(c.bloom.expect, len(c.items)+len(c.spilled)), c.bloom.rate)
+	now := time.Now().UnixNano()
+	for key, item := range c.items {
+		if !c.staleLocked(item, now) {
+			f.add(key)
+		}
+	}
+	for key, version := range c.spilled {
+		if version > c.flushedAt {
+			f.add(key)
+		}
+	}
+	c.bloom = f
+}
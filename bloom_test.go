@@ -0,0 +1,51 @@
+package gocache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	c := New(0, WithBloomFilter(1000, 0.01))
+	for i := 0; i < 1000; i++ {
+		c.Set("key:"+strconv.Itoa(i), i)
+	}
+	for i := 0; i < 1000; i++ {
+		if !c.MayContain("key:"+strconv.Itoa(i)) || !c.Exists("key:"+strconv.Itoa(i)) {
+			t.Fatalf("Expected key:%d to be found", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if c.MayContain("other:" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 300 {
+		t.Fatalf("Expected about 1%% false positives, got %d in 10000", falsePositives)
+	}
+
+	// Deleted keys stay in the filter until it's rebuilt
+	c.Delete("key:0")
+	if !c.MayContain("key:0") {
+		t.Fatal("Expected the deleted key to remain in the filter")
+	}
+	c.RebuildBloomFilter()
+	if !c.MayContain("key:1") {
+		t.Fatal("Expected live keys to be kept by the rebuild")
+	}
+
+	c.Flush()
+	if c.MayContain("key:1") {
+		t.Fatal("Expected Flush to clear the filter")
+	}
+}
+
+func TestBloomFilterDisabled(t *testing.T) {
+	c := New(0)
+	if !c.MayContain("anything") {
+		t.Fatal("Expected MayContain to be true without a filter")
+	}
+	c.RebuildBloomFilter()
+}
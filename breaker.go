@@ -0,0 +1,289 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a loader or remote tier whose
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("gocache: circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call until the cooldown has passed
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial call through to probe the origin
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker
+type BreakerConfig struct {
+	// Window is the period over which the failure ratio is computed.
+	// Defaults to 10 seconds.
+	Window time.Duration
+
+	// MinRequests is the number of calls a window needs before the breaker
+	// may trip. Defaults to 10.
+	MinRequests int
+
+	// FailureRatio is the fraction of failed calls in a window that trips
+	// the breaker. Defaults to 0.5.
+	FailureRatio float64
+
+	// Cooldown is how long the breaker stays open before letting a trial
+	// call through. Defaults to 5 seconds.
+	Cooldown time.Duration
+
+	// OnStateChange, if set, is called after every state transition
+	OnStateChange func(from, to BreakerState)
+}
+
+// BreakerStats holds the counters of a CircuitBreaker
+type BreakerStats struct {
+	State     BreakerState
+	Successes uint64
+	Failures  uint64
+	Rejected  uint64 // calls refused while open
+}
+
+// CircuitBreaker stops calling a failing origin for a while, so that cache
+// misses fail fast instead of each waiting for the origin's full timeout
+type CircuitBreaker struct {
+	cfg BreakerConfig
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trialActive bool
+	stats       BreakerStats
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Second
+	}
+	return &CircuitBreaker{cfg: cfg, windowStart: time.Now()}
+}
+
+// Do calls fn unless the breaker is open, in which case it returns
+// ErrCircuitOpen. The error fn returns is recorded and passed through.
+func (b *CircuitBreaker) Do(fn func() error) error {
+	trial, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.record(trial, err == nil)
+	return err
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advanceLocked(time.Now())
+	return b.state
+}
+
+// Stats returns the breaker's counters
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advanceLocked(time.Now())
+	stats := b.stats
+	stats.State = b.state
+	return stats
+}
+
+// allow decides whether a call may proceed and whether it is the half-open trial
+func (b *CircuitBreaker) allow() (trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advanceLocked(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		b.stats.Rejected++
+		return false, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trialActive {
+			b.stats.Rejected++
+			return false, ErrCircuitOpen
+		}
+		b.trialActive = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record accounts for the outcome of a call
+func (b *CircuitBreaker) record(trial, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.stats.Successes++
+	} else {
+		b.stats.Failures++
+	}
+
+	if trial {
+		b.trialActive = false
+		if success {
+			b.setStateLocked(BreakerClosed)
+		} else {
+			b.setStateLocked(BreakerOpen)
+		}
+		return
+	}
+	if b.state != BreakerClosed {
+		return // A call that started before the breaker tripped
+	}
+
+	b.requests++
+	if !success {
+		b.failures++
+	}
+	if b.requests >= b.cfg.MinRequests &&
+		float64(b.failures) >= b.cfg.FailureRatio*float64(b.requests) {
+		b.setStateLocked(BreakerOpen)
+	}
+}
+
+// advanceLocked applies time-based transitions: starting a new window while
+// closed and moving to half-open once the cooldown has passed
+func (b *CircuitBreaker) advanceLocked(now time.Time) {
+	switch b.state {
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+	case BreakerOpen:
+		if now.Sub(b.openedAt) >= b.cfg.Cooldown {
+			b.setStateLocked(BreakerHalfOpen)
+		}
+	}
+}
+
+// setStateLocked transitions to state and resets the state's bookkeeping
+func (b *CircuitBreaker) setStateLocked(state BreakerState) {
+	from := b.state
+	b.state = state
+
+	now := time.Now()
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerClosed:
+		b.windowStart = now
+		b.requests, b.failures = 0, 0
+	}
+
+	if from != state && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, state)
+	}
+}
+
+// LoadBreaker guards GetOrLoad's loader with breaker. While the breaker is
+// open the loader isn't called and the load fails with ErrCircuitOpen, so
+// ServeStale and LoadFallback answer immediately.
+func LoadBreaker(breaker *CircuitBreaker) LoadOption {
+	return func(o *loadOptions) {
+		o.breaker = breaker
+	}
+}
+
+// guard returns a loader that calls loader through the breaker
+func (b *CircuitBreaker) guard(loader Loader) Loader {
+	return func(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error) {
+		err = b.Do(func() error {
+			var loadErr error
+			value, ttl, loadErr = loader(ctx, key)
+			return loadErr
+		})
+		return value, ttl, err
+	}
+}
+
+// breakerStore guards an OverflowStore with a circuit breaker
+type breakerStore struct {
+	store   OverflowStore
+	breaker *CircuitBreaker
+}
+
+// NewBreakerStore wraps store so that its calls go through breaker. While the
+// breaker is open, spills are dropped and spilled items read as misses.
+func NewBreakerStore(store OverflowStore, breaker *CircuitBreaker) OverflowStore {
+	return &breakerStore{store: store, breaker: breaker}
+}
+
+func (s *breakerStore) Store(key string, value []byte, expiration int64) error {
+	return s.breaker.Do(func() error {
+		return s.store.Store(key, value, expiration)
+	})
+}
+
+func (s *breakerStore) Load(key string) (value []byte, expiration int64, found bool, err error) {
+	err = s.breaker.Do(func() error {
+		var loadErr error
+		value, expiration, found, loadErr = s.store.Load(key)
+		return loadErr
+	})
+	return value, expiration, found, err
+}
+
+func (s *breakerStore) Delete(key string) error {
+	return s.breaker.Do(func() error {
+		return s.store.Delete(key)
+	})
+}
+
+// Health reports ErrCircuitOpen while the breaker is open, or the health of
+// the wrapped store if it implements HealthChecker
+func (s *breakerStore) Health() error {
+	if s.breaker.State() == BreakerOpen {
+		return ErrCircuitOpen
+	}
+	if checker, ok := s.store.(HealthChecker); ok {
+		return checker.Health()
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []BreakerState
+	b := NewCircuitBreaker(BreakerConfig{
+		MinRequests:  4,
+		FailureRatio: 0.5,
+		Cooldown:     20 * time.Millisecond,
+		OnStateChange: func(from, to BreakerState) {
+			transitions = append(transitions, to)
+		},
+	})
+	fail := errors.New("origin down")
+
+	b.Do(func() error { return nil })
+	b.Do(func() error { return nil })
+	b.Do(func() error { return fail })
+	if b.State() != BreakerClosed {
+		t.Fatal("Breaker should stay closed below MinRequests")
+	}
+	b.Do(func() error { return fail })
+	if b.State() != BreakerOpen {
+		t.Fatal("Breaker should open at a 50% failure ratio")
+	}
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("Open breaker should reject calls, got %v (called=%v)", err, called)
+	}
+
+	// After the cooldown a failed trial reopens the breaker...
+	time.Sleep(25 * time.Millisecond)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("Expected half-open after cooldown, got %v", b.State())
+	}
+	b.Do(func() error { return fail })
+	if b.State() != BreakerOpen {
+		t.Fatal("Failed trial should reopen the breaker")
+	}
+
+	// ...and a successful one closes it
+	time.Sleep(25 * time.Millisecond)
+	b.Do(func() error { return nil })
+	if b.State() != BreakerClosed {
+		t.Fatal("Successful trial should close the breaker")
+	}
+
+	stats := b.Stats()
+	if stats.Successes != 3 || stats.Failures != 3 || stats.Rejected != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("Expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("Expected transitions %v, got %v", want, transitions)
+		}
+	}
+}
+
+func TestLoadBreaker(t *testing.T) {
+	c := New(0)
+	b := NewCircuitBreaker(BreakerConfig{MinRequests: 1, Cooldown: time.Minute})
+
+	calls := 0
+	failing := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		calls++
+		return nil, 0, errors.New("origin down")
+	}
+
+	var val string
+	c.GetOrLoad(context.Background(), "key", &val, failing, LoadBreaker(b))
+	err := c.GetOrLoad(context.Background(), "key", &val, failing, LoadBreaker(b), LoadFallback("default"))
+	if err != nil || val != "default" {
+		t.Fatalf("Expected fallback while the breaker is open, got '%s' (err=%v)", val, err)
+	}
+	if calls != 1 {
+		t.Fatalf("Open breaker should skip the loader, got %d calls", calls)
+	}
+
+	err = c.GetOrLoad(context.Background(), "key", &val, failing, LoadBreaker(b))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+}
@@ -3,126 +3,570 @@ package gocache
 import (
 	"encoding/json"
 	"errors"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Item represents a cache item with value and expiration
 type Item struct {
-	Value      []byte // Store all values as byte slices
-	Expiration int64  // 0 means no expiration
-	Created    int64
+	Value          []byte // Store all values as byte slices
+	Expiration     int64  // 0 means no expiration
+	Created        int64
+	softExpiration int64 // see WithSoftTTL, 0 means none
+	own            int64 // expiration the value sets for itself, see TTLProvider; 0 if none
+
+	deps []string // keys this item depends on
+	weak bool     // may be dropped under memory pressure
+	tags []string // tags for group invalidation
+
+	priority Priority  // eviction priority
+	pinned   bool      // exempt from eviction and expiration
+	version  uint64    // unique per write, see Watch
+	shared   bool      // Value is shared through the dedup table
+	cost     int64     // cost charged against the budget set by WithMaxCost
+	stamp    Timestamp // last-write-wins timestamp, see Merge
+
+	accessed *accessRecord // reads of the item, nil unless WithAccessTracking
+	format   Format        // how Value was encoded
+	typeID   uint64        // fingerprint of the stored Go type, see WithTypeChecks
 }
 
 // Cache is a thread-safe in-memory key:value store with optional expiration
 type Cache struct {
 	items           map[string]Item
 	mu              sync.RWMutex
-	cleanupInterval time.Duration
-	stopCleanup     chan bool
+	cleanupInterval time.Duration // guarded by janitorMu
+	janitorMu       sync.Mutex
+	stopJanitor     chan struct{} // closed to stop the running janitor
+
+	// dependents maps a key to the set of keys that depend on it
+	dependents map[string]map[string]struct{}
+
+	// tags maps a tag to the set of keys carrying it
+	tags map[string]map[string]struct{}
+
+	maxBytes int64 // 0 means unbounded
+	size     int64 // total key and value bytes
+
+	costFn  func(key string, value []byte) int64 // see WithMaxCost
+	maxCost int64                                // 0 means unbounded
+	cost    int64                                // total cost of all items
+
+	evictionSamples int              // see WithSampledEviction, 0 means exact order
+	evictLimit      *evictionLimiter // see WithEvictionLimit, nil if unlimited
+
+	version uint64 // incremented on every write
+
+	overflow OverflowStore     // receives evicted items, see WithOverflow
+	spilled  map[string]uint64 // versions of the items held by overflow
+
+	internKeys bool        // see WithKeyInterning
+	dedup      *dedupTable // see WithValueDedup, nil if disabled
+
+	keyPolicy  *KeyPolicy  // see WithKeyPolicy, nil if disabled
+	keyPrivacy *keyPrivacy // see WithKeyHashing, nil to show raw keys
+
+	maxTTL     time.Duration               // see WithMaxTTL, 0 means no cap
+	defaultTTL atomic.Int64                // see WithDefaultTTL, 0 means no expiration
+	namespaces atomic.Pointer[[]namespace] // see WithNamespacePolicy, longest prefix first
+
+	loadMu     sync.Mutex           // guards loads and loadErrors
+	loads      map[string]*loadCall // in-flight GetOrLoad calls by key
+	loadErrors map[string]loadError // errors cached by LoadErrors, by key
+
+	history   *historyLog // see WithHistory, nil if disabled
+	evictions []Eviction  // recent evictions, see RecentEvictions
+
+	profileLabels map[string]pprof.LabelSet // see WithProfiling, nil if disabled
+	latency       *latencyRecorder          // see WithLatencyTracking, nil if disabled
+
+	changeSeq   uint64        // sequence number of the last published change
+	subscribers []*subscriber // see Replicate and WatchPrefix
+	removals    *removalQueue // see WithRemovalListener, nil if none
+	middleware  []Middleware  // see WithMiddleware, outermost first
+	changeLog   *changeRing   // recent changes, nil unless WithChangeLog
+
+	node         string               // see WithLWW
+	clock        int64                // hybrid logical clock, see Timestamp
+	tombstones   map[string]Timestamp // deleted keys, nil unless WithLWW
+	tombstoneTTL time.Duration
+
+	trackAccess      bool  // see WithAccessTracking
+	typeChecks       bool  // see WithTypeChecks
+	accessResolution int64 // minimum interval between access time updates
+	maxIdle          int64 // see WithMaxIdle, 0 if unset
+
+	tenants *tenantTable   // see NewTenantCache, nil if not partitioned
+	bloom   *bloomFilter   // keys ever set, nil unless WithBloomFilter
+	ghosts  *ghostList     // recently evicted keys, nil unless WithGhostList
+	reads   *sync.Map      // live items by key, nil unless WithReadOptimized
+	trace   *traceRecorder // see WithTraceRecording, nil if not recording
+
+	expiries *expiryIndex // see WithExpiryIndex, nil if not indexed
+	writes   *writeQueue  // see WithWriteQueue, nil if writes are synchronous
+	repair   *ReadRepair  // see WithReadRepair, nil to leave corrupt entries
+
+	closed       atomic.Bool
+	failOpen     bool                    // see WithFailOpen
+	degraded     failOpenStats           // errors hidden by WithFailOpen
+	healthChecks map[string]func() error // see WithHealthCheck
+	overflowErr  error                   // last overflow store error, nil after a success
+
+	generation uint64 // number of BumpGeneration calls
+	flushedAt  uint64 // items with a version up to this one are stale
 }
 
 // New creates a new Cache with the provided cleanup interval
 // cleanupInterval: 0 means no automatic cleanup
-func New(cleanupInterval time.Duration) *Cache {
+// opts: optional settings such as WithMaxBytes
+func New(cleanupInterval time.Duration, opts ...Option) *Cache {
 	cache := &Cache{
-		items:           make(map[string]Item),
-		cleanupInterval: cleanupInterval,
-		stopCleanup:     make(chan bool),
+		items:      make(map[string]Item),
+		dependents: make(map[string]map[string]struct{}),
+		tags:       make(map[string]map[string]struct{}),
+		spilled:    make(map[string]uint64),
 	}
 
-	// Start the janitor if cleanup interval > 0
-	if cleanupInterval > 0 {
-		go cache.startJanitor()
+	for _, opt := range opts {
+		opt(cache)
 	}
 
+	// Start the janitor if cleanup interval > 0
+	cache.setCleanupInterval(cleanupInterval)
+
 	return cache
 }
 
-// Set adds an item to the cache with no expiration
+// Set adds an item to the cache with no expiration, or the default TTL set
+// by WithDefaultTTL. Values implementing TTLProvider or ExpiryProvider expire
+// when they say instead.
 func (c *Cache) Set(key string, value interface{}) error {
-	return c.SetWithExpiration(key, value, 0) // 0 means no expiration
+	if expiration := valueExpiration(value); expiration > 0 {
+		return c.set(key, value, expiration)
+	}
+	return c.SetWithExpiration(key, value, time.Duration(c.defaultTTL.Load()))
 }
 
-// SetWithExpiration adds an item to the cache with a specific expiration time
+// SetWithExpiration adds an item to the cache with a specific expiration
+// time. Values implementing TTLProvider or ExpiryProvider never outlive the
+// expiration they set for themselves.
 func (c *Cache) SetWithExpiration(key string, value interface{}, duration time.Duration) error {
-	var bytes []byte
+	return c.set(key, value, expirationFor(duration))
+}
+
+// SetWithExpireAt adds an item to the cache that expires at the given wall
+// clock time, e.g. a token's expiry timestamp or the next midnight. A zero
+// time means no expiration; a time in the past stores an already expired item.
+func (c *Cache) SetWithExpireAt(key string, value interface{}, at time.Time) error {
+	return c.set(key, value, expirationAt(at))
+}
+
+// SetNX adds an item with the given expiration only if key holds no live
+// item, and reports whether it was added. Items spilled to the overflow
+// store count as live.
+func (c *Cache) SetNX(key string, value interface{}, duration time.Duration) (bool, error) {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	bytes, err := encode(value)
+	if err != nil {
+		return false, err
+	}
+	if c.overflow != nil && c.spilledKey(key) {
+		c.lookup(key) // fault the item in, dropping it if it expired
+	}
+
+	added := false
+	item := Item{Value: bytes, Expiration: capToValue(value, expirationFor(duration)), own: valueExpiration(value), format: formatOf(value), typeID: c.fingerprint(value)}
+	claim := func(item Item) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.liveVersionLocked(key, time.Now().UnixNano()) == 0 {
+			c.setLocked(key, item)
+			added = true
+		}
+		return nil
+	}
+	if c.middleware != nil {
+		err = c.storeThrough(key, item, claim)
+	} else {
+		err = claim(item)
+	}
+	return added, err
+}
+
+// set encodes and stores an item with an absolute expiration timestamp
+func (c *Cache) set(key string, value interface{}, expiration int64) error {
+	if c.latency != nil {
+		defer c.latency.observe(opSet, c.latency.start(opSet))
+	}
 	var err error
+	if c.profileLabels != nil {
+		c.profile("set", func() { err = c.store(key, value, expiration) })
+	} else {
+		err = c.store(key, value, expiration)
+	}
+	return c.failOpenWrite(err)
+}
 
-	// Convert the value to []byte
+// store is set without profiling
+func (c *Cache) store(key string, value interface{}, expiration int64) error {
+	bytes, err := encode(value)
+	if err != nil {
+		return err
+	}
+	return c.storeEncoded(key, value, bytes, capToValue(value, expiration))
+}
+
+// storeEncoded stores the encoding of value
+func (c *Cache) storeEncoded(key string, value interface{}, bytes []byte, expiration int64) error {
+	item := Item{Value: bytes, Expiration: expiration, own: valueExpiration(value), format: formatOf(value), typeID: c.fingerprint(value)}
+	if c.middleware != nil {
+		return c.storeThrough(c.mapKey(key), item, func(item Item) error {
+			return c.queueOrStore(key, item)
+		})
+	}
+	return c.queueOrStore(key, item)
+}
+
+// queueOrStore stores an item holding an encoded value, through the write
+// queue if there is one
+func (c *Cache) queueOrStore(key string, item Item) error {
+	if c.writes != nil {
+		return c.enqueueWrite(key, item)
+	}
+	return c.storeItem(key, item)
+}
+
+// storeItem stores an item holding an encoded value
+func (c *Cache) storeItem(key string, item Item) error {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.setLocked(key, item)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// encode converts a value to the byte representation stored in the cache
+func encode(value interface{}) ([]byte, error) {
 	switch v := value.(type) {
 	case []byte:
-		bytes = v
+		return v, nil
 	case string:
-		bytes = []byte(v)
+		return []byte(v), nil
 	default:
 		// Use JSON for everything else
-		bytes, err = json.Marshal(value)
-		if err != nil {
-			return err
-		}
+		return json.Marshal(value)
 	}
+}
 
-	var expiration int64
+// expirationFor converts a duration into an absolute expiration timestamp
+func expirationFor(duration time.Duration) int64 {
 	if duration <= 0 {
 		// 0 or negative means no expiration
-		expiration = 0
-	} else {
-		expiration = time.Now().Add(duration).UnixNano()
+		return 0
 	}
+	return time.Now().Add(duration).UnixNano()
+}
 
-	c.mu.Lock()
-	c.items[key] = Item{
-		Value:      bytes,
-		Expiration: expiration,
-		Created:    time.Now().UnixNano(),
+// clampExpiration caps an expiration timestamp at limit, treating 0 (no
+// expiration) as later than any limit
+func clampExpiration(expiration, limit int64) int64 {
+	if expiration == 0 || expiration > limit {
+		return limit
 	}
-	c.mu.Unlock()
+	return expiration
+}
 
-	return nil
+// expirationAt converts a wall clock time into an expiration timestamp
+func expirationAt(at time.Time) int64 {
+	if at.IsZero() {
+		return 0
+	}
+	return at.UnixNano()
+}
+
+// setLocked stores an item, invalidating anything that depended on the old value.
+// The caller must hold the write lock.
+func (c *Cache) setLocked(key string, item Item) {
+	c.deleteLocked(key, opReplace)
+
+	if c.internKeys {
+		key = internKey(key)
+	}
+	if c.dedup != nil {
+		item.Value, item.shared = c.dedup.acquire(item.Value)
+	}
+
+	now := time.Now()
+	item.Expiration = c.capExpirationLocked(key, item, now)
+
+	if c.costFn != nil {
+		item.cost = c.costFn(key, item.Value)
+	}
+
+	c.version++
+	item.version = c.version
+	item.Created = now.UnixNano()
+	item.accessed = c.newAccessRecord()
+	if item.stamp.Wall == 0 {
+		item.stamp = c.tickLocked()
+	}
+	if c.tombstones != nil {
+		delete(c.tombstones, key)
+	}
+	c.items[key] = item
+	c.indexReadLocked(key, item)
+	if c.bloom != nil {
+		c.bloom.add(key)
+	}
+	if c.ghosts != nil {
+		c.ghosts.remove(key)
+	}
+	if c.trace != nil {
+		c.trace.add(EventSet, key, item.size(key))
+	}
+	c.indexExpirationLocked(key, item)
+	c.size += item.size(key)
+	c.cost += item.cost
+	if c.tenants != nil {
+		c.tenants.add(key, item)
+	}
+	c.linkDepsLocked(key, item.deps)
+	c.linkTagsLocked(key, item.tags)
+	c.record(key, EventSet, item)
+	c.publishLocked(Change{Kind: ChangeSet, Key: key, Value: item.Value, Expiration: item.Expiration, Format: item.format})
+
+	if c.overBudgetLocked() {
+		c.evictLocked()
+	}
+	if c.tenants != nil {
+		if id := tenantOf(key); id != "" {
+			c.enforceTenantQuotaLocked(id)
+		}
+	}
+}
+
+// capExpirationLocked applies the namespace policy of key and WithMaxTTL to
+// the expiration of item, set at now. The value's own expiration and the
+// item's dependencies cap the result last, so a namespace's MinTTL never
+// keeps an item past either. The caller must hold the write lock.
+func (c *Cache) capExpirationLocked(key string, item Item, now time.Time) int64 {
+	expiration := item.Expiration
+	if p := c.namespacePolicy(key); p != nil {
+		expiration = p.clampExpiration(expiration, now)
+	}
+	if c.maxTTL > 0 {
+		expiration = clampExpiration(expiration, now.Add(c.maxTTL).UnixNano())
+	}
+	if item.own > 0 {
+		expiration = clampExpiration(expiration, item.own)
+	}
+	if len(item.deps) > 0 {
+		expiration, _ = c.depsExpirationLocked(expiration, item.deps)
+	}
+	return expiration
+}
+
+// deleteLocked removes an item and cascades to its dependents. op says why
+// the item is removed. The caller must hold the write lock.
+func (c *Cache) deleteLocked(key string, op EventOp) {
+	item, found := c.items[key]
+	if found {
+		c.record(key, op, item)
+		if c.trace != nil && op != opReplace {
+			c.trace.add(op, key, 0)
+		}
+		if c.removals != nil && op != opReplace {
+			c.removals.push(removal{key: key, item: item, op: op})
+		}
+		switch op {
+		case EventEvict, EventExpire:
+			c.recordEvictionLocked(key, op, item)
+			if op == EventEvict && c.ghosts != nil {
+				c.ghosts.add(key, item.size(key))
+			}
+		case EventDelete, EventInvalidate:
+			c.publishLocked(Change{Kind: ChangeDelete, Key: key})
+			if c.tombstones != nil {
+				c.tombstones[key] = c.tickLocked()
+			}
+		}
+		delete(c.items, key)
+		c.unindexReadLocked(key)
+		c.size -= item.size(key)
+		c.cost -= item.cost
+		if c.tenants != nil {
+			c.tenants.remove(key, item)
+		}
+		c.unlinkDepsLocked(key, item.deps)
+		c.unlinkTagsLocked(key, item.tags)
+		if item.shared {
+			c.dedup.release(item.Value)
+		}
+	} else if _, cold := c.spilled[key]; cold {
+		delete(c.spilled, key)
+		c.overflow.Delete(key)
+		if op == EventDelete || op == EventInvalidate {
+			c.publishLocked(Change{Kind: ChangeDelete, Key: key})
+		}
+	}
+	c.invalidateDependentsLocked(key)
+}
+
+// size returns the number of key and value bytes the item accounts for
+func (item Item) size(key string) int64 {
+	return int64(len(key) + len(item.Value))
+}
+
+// staleLocked reports whether an item has expired or predates the last
+// BumpGeneration. The caller must hold the lock.
+func (c *Cache) staleLocked(item Item, now int64) bool {
+	return item.version <= c.flushedAt || item.expired(now)
+}
+
+// expired reports whether the item has expired at the given time.
+// Pinned items never expire.
+func (item Item) expired(now int64) bool {
+	return !item.pinned && item.Expiration > 0 && now > item.Expiration
 }
 
 // GetBytes retrieves raw byte data from the cache
 func (c *Cache) GetBytes(key string) ([]byte, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	item, found := c.get(key)
+	return item.Value, found
+}
 
-	item, found := c.items[key]
+// get returns the live item stored under key and records the read
+func (c *Cache) get(key string) (Item, bool) {
+	if c.latency != nil {
+		defer c.latency.observe(opGet, c.latency.start(opGet))
+	}
+	var item Item
+	var found bool
+	if c.middleware != nil {
+		item, found = c.lookupThrough(key)
+	} else {
+		item, found = c.lookup(key)
+	}
+	if c.ghosts != nil {
+		c.ghosts.observe(c.mapKey(key), found)
+	}
+	if c.trace != nil {
+		c.trace.add(EventGet, c.mapKey(key), 0)
+	}
 	if !found {
-		return nil, false
+		return Item{}, false
+	}
+	if c.history != nil {
+		c.record(c.mapKey(key), EventGet, item)
+	}
+	return item, true
+}
+
+// lookup returns the live item stored under key, faulting it back in from
+// the overflow store if it was spilled there
+func (c *Cache) lookup(key string) (item Item, found bool) {
+	if c.profileLabels != nil {
+		c.profile("get", func() { item, found = c.lookupItem(key) })
+		return item, found
+	}
+	return c.lookupItem(key)
+}
+
+// lookupItem is lookup without profiling
+func (c *Cache) lookupItem(key string) (Item, bool) {
+	key = c.mapKey(key)
+
+	if c.reads != nil {
+		if item, found := c.readIndexed(key); found {
+			now := time.Now().UnixNano()
+			if item.expired(now) {
+				return Item{}, false
+			}
+			c.touch(item, now)
+			return item, true
+		}
+	}
+
+	c.mu.RLock()
+	if c.bloom != nil && !c.bloom.mayContain(key) {
+		c.mu.RUnlock()
+		return Item{}, false
+	}
+	item, found := c.items[key]
+	version, cold := c.spilled[key]
+	flushedAt := c.flushedAt
+	c.mu.RUnlock()
+
+	if !found && cold && version > flushedAt {
+		item, found = c.faultIn(key, version)
 	}
 
-	// Check if the item has expired
-	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
-		return nil, false
+	// Check if the item has expired or was invalidated by BumpGeneration.
+	// Faulted in items are always newer than flushedAt.
+	now := time.Now().UnixNano()
+	if !found || item.version <= flushedAt || item.expired(now) {
+		return Item{}, false
 	}
 
-	return item.Value, true
+	c.touch(item, now)
+	return item, true
 }
 
 // Get retrieves and unmarshals an item from the cache
+//
+// The value is decoded with the codec it was stored with, so items stored
+// with WithCodec(GobCodec) or WithCodec(XMLCodec) decode correctly too.
+// Reading a value stored by a codec into a *string fails with
+// ErrFormatMismatch rather than returning its encoding. With WithTypeChecks,
+// reading a value into a different type fails with ErrTypeMismatch.
 func (c *Cache) Get(key string, target interface{}) (bool, error) {
-	bytes, found := c.GetBytes(key)
+	item, found := c.get(key)
 	if !found {
 		return false, nil
 	}
+	if c.latency != nil {
+		defer c.latency.observe(opDecode, c.latency.start(opDecode))
+	}
+	if err := decodeItem(item, target); err != nil {
+		if c.repairItem(key, item, err) {
+			return c.failOpenRead(c.reloadRepaired(key, target))
+		}
+		return c.failOpenRead(true, err)
+	}
+	return true, nil
+}
 
-	// If target is nil, just return found status
+// decode unmarshals bytes stored by Set into target
+func decode(bytes []byte, target interface{}) error {
+	// If target is nil, there's nothing to decode into
 	if target == nil {
-		return true, nil
+		return nil
 	}
 
 	// Handle string target specially for efficiency
 	if strPtr, ok := target.(*string); ok {
 		*strPtr = string(bytes)
-		return true, nil
+		return nil
 	}
 
 	// Unmarshal for other types
-	return true, json.Unmarshal(bytes, target)
+	return json.Unmarshal(bytes, target)
 }
 
-// GetString gets a string value from the cache
+// GetString gets a string value from the cache. Values stored by a codec
+// are returned in their encoding; Get into a *string rejects them with
+// ErrFormatMismatch instead.
 func (c *Cache) GetString(key string) (string, bool) {
 	bytes, found := c.GetBytes(key)
 	if !found {
@@ -131,36 +575,74 @@ func (c *Cache) GetString(key string) (string, bool) {
 	return string(bytes), true
 }
 
-// Delete removes an item from the cache
+// Delete removes an item from the cache, along with any items that depend on it
 func (c *Cache) Delete(key string) {
+	if c.latency != nil {
+		defer c.latency.observe(opDelete, c.latency.start(opDelete))
+	}
+	key = c.mapKey(key)
+
+	if c.middleware != nil {
+		c.handle(&Operation{Kind: OperationDelete, Key: key}, func(*Operation) error {
+			c.deleteKey(key)
+			return nil
+		})
+		return
+	}
+	c.deleteKey(key)
+}
+
+// deleteKey deletes the item stored under key, after any key policy was
+// applied
+func (c *Cache) deleteKey(key string) {
 	c.mu.Lock()
-	delete(c.items, key)
+	c.deleteLocked(key, EventDelete)
 	c.mu.Unlock()
 }
 
 // Exists checks if a key exists in the cache and is not expired
 func (c *Cache) Exists(key string) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	item, found := c.items[key]
-	if !found {
-		return false
-	}
-
-	// Check if the item has expired
-	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
-		return false
-	}
-
-	return true
+	_, found := c.lookup(key)
+	return found
 }
 
 // Flush removes all items from the cache
 func (c *Cache) Flush() {
 	c.mu.Lock()
+	for key := range c.spilled {
+		c.overflow.Delete(key)
+	}
+	c.spilled = make(map[string]uint64)
 	c.items = make(map[string]Item)
+	c.clearReadsLocked()
+	c.dependents = make(map[string]map[string]struct{})
+	c.tags = make(map[string]map[string]struct{})
+	c.ClearHistory()
+	c.evictions = nil
+	if c.tombstones != nil {
+		c.tombstones = make(map[string]Timestamp)
+	}
+	c.publishLocked(Change{Kind: ChangeFlush})
+	c.size = 0
+	c.cost = 0
+	if c.bloom != nil {
+		c.bloom.reset()
+	}
+	if c.expiries != nil {
+		c.expiries = &expiryIndex{}
+	}
+	if c.tenants != nil {
+		c.tenants.usage = make(map[string]*TenantUsage)
+		c.tenants.keys = make(map[string]map[string]struct{})
+	}
+	if c.dedup != nil {
+		c.dedup = newDedupTable()
+	}
 	c.mu.Unlock()
+
+	c.loadMu.Lock()
+	c.loadErrors = nil
+	c.loadMu.Unlock()
 }
 
 // Count returns the number of items in the cache (including expired items)
@@ -171,48 +653,181 @@ func (c *Cache) Count() int {
 	return count
 }
 
-// DeleteExpired deletes all expired items from the cache
+// CountValid returns the number of items in the cache that haven't expired
+func (c *Cache) CountValid() int {
+	valid, _ := c.countByState()
+	return valid
+}
+
+// CountExpired returns the number of expired items still waiting for the
+// janitor, including items invalidated by BumpGeneration
+func (c *Cache) CountExpired() int {
+	_, expired := c.countByState()
+	return expired
+}
+
+// countByState counts the live and the stale items in one pass, scanning all
+// items under a read lock. The expiry index of WithExpiryIndex doesn't help:
+// it doesn't see items invalidated by BumpGeneration, and its heap can't be
+// counted past the next expiration without popping entries.
+func (c *Cache) countByState() (valid, expired int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	for _, item := range c.items {
+		if c.staleLocked(item, now) {
+			expired++
+		} else {
+			valid++
+		}
+	}
+	return valid, expired
+}
+
+// deleteExpiredBatch is the number of keys deleted per write lock acquisition
+// by DeleteExpired
+const deleteExpiredBatch = 256
+
+// DeleteExpired deletes all expired items from the cache, along with items
+// invalidated by BumpGeneration and items idle for longer than WithMaxIdle
+// allows. Expired keys are collected under a read lock
+// and then deleted in small batches, so readers aren't blocked for the whole
+// scan of a large cache.
 func (c *Cache) DeleteExpired() {
+	if c.profileLabels != nil {
+		c.profile("janitor", c.deleteExpired)
+		return
+	}
+	c.deleteExpired()
+}
+
+// deleteExpired is DeleteExpired without profiling
+func (c *Cache) deleteExpired() {
 	now := time.Now().UnixNano()
 
-	c.mu.Lock()
+	c.mu.RLock()
+	var expired []string
 	for k, v := range c.items {
-		if v.Expiration > 0 && now > v.Expiration {
-			delete(c.items, k)
+		if c.staleLocked(v, now) || c.idleLocked(v, now) {
+			expired = append(expired, k)
 		}
 	}
-	c.mu.Unlock()
+	for k, version := range c.spilled {
+		if version <= c.flushedAt {
+			expired = append(expired, k)
+		}
+	}
+	c.mu.RUnlock()
+
+	for len(expired) > 0 {
+		n := min(len(expired), deleteExpiredBatch)
+
+		c.mu.Lock()
+		for _, k := range expired[:n] {
+			// The item may have been replaced or read since the scan, check again
+			if c.staleKeyLocked(k, now) || c.idleKeyLocked(k, now) {
+				c.deleteLocked(k, EventExpire)
+			}
+		}
+		c.mu.Unlock()
+
+		expired = expired[n:]
+	}
+
+	if c.tombstones != nil {
+		c.mu.Lock()
+		c.purgeTombstonesLocked(now)
+		c.mu.Unlock()
+	}
+	c.purgeLoadErrors(now)
+
+	if c.evictLimit != nil {
+		c.evictDeferred()
+	}
+}
+
+// staleKeyLocked reports whether the item stored under key, in memory or in
+// the overflow store, is stale. The caller must hold the lock.
+func (c *Cache) staleKeyLocked(key string, now int64) bool {
+	if item, found := c.items[key]; found {
+		return c.staleLocked(item, now)
+	}
+	version, cold := c.spilled[key]
+	return cold && version <= c.flushedAt
 }
 
-// startJanitor starts the cleanup goroutine
-func (c *Cache) startJanitor() {
-	ticker := time.NewTicker(c.cleanupInterval)
+// runJanitor deletes expired items every interval until stop is closed
+func (c *Cache) runJanitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			c.DeleteExpired()
-		case <-c.stopCleanup:
+			if validateOnCleanup {
+				if err := c.Validate(); err != nil {
+					panic(err)
+				}
+			}
+		case <-stop:
 			return
 		}
 	}
 }
 
-// StopJanitor stops the cleanup goroutine
+// StartJanitor starts the cleanup goroutine with the given interval, or
+// changes the interval of the running one. It works on caches created with
+// a cleanup interval of 0 and after StopJanitor; an interval of 0 or less
+// stops the janitor.
+func (c *Cache) StartJanitor(interval time.Duration) {
+	c.setCleanupInterval(interval)
+}
+
+// StopJanitor stops the cleanup goroutine. It's safe to call more than
+// once and never blocks; a cleanup pass already in progress finishes in
+// the background.
 func (c *Cache) StopJanitor() {
-	if c.cleanupInterval > 0 {
-		c.stopCleanup <- true
+	c.setCleanupInterval(0)
+}
+
+// setCleanupInterval restarts the janitor with a new interval, stopping it
+// if the interval is 0 or less
+func (c *Cache) setCleanupInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+
+	c.janitorMu.Lock()
+	defer c.janitorMu.Unlock()
+
+	if interval == c.cleanupInterval {
+		return
+	}
+	if c.stopJanitor != nil {
+		close(c.stopJanitor)
+		c.stopJanitor = nil
+	}
+	c.cleanupInterval = interval
+	if interval > 0 {
+		c.stopJanitor = make(chan struct{})
+		go c.runJanitor(interval, c.stopJanitor)
 	}
 }
 
 // TTL returns the time to live for a key
 func (c *Cache) TTL(key string) (time.Duration, error) {
+	if c.overflow != nil {
+		c.lookup(key) // fault the item in if it was spilled
+	}
+	key = c.mapKey(key)
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	item, found := c.items[key]
-	if !found {
+	if !found || item.version <= c.flushedAt {
 		return 0, errors.New("key not found")
 	}
 
@@ -221,9 +836,12 @@ func (c *Cache) TTL(key string) (time.Duration, error) {
 	}
 
 	now := time.Now().UnixNano()
-	if now > item.Expiration {
+	if item.expired(now) {
 		return 0, errors.New("key expired")
 	}
+	if now > item.Expiration {
+		return 0, nil // Pinned past its expiration, expires once unpinned
+	}
 
 	return time.Duration(item.Expiration - now), nil
 }
@@ -1,6 +1,7 @@
 package gocache
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -139,3 +140,121 @@ func TestRawBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestSetWithExpireAt(t *testing.T) {
+	c := New(0)
+
+	deadline := time.Now().Add(time.Hour)
+	c.SetWithExpireAt("token", "secret", deadline)
+
+	ttl, err := c.TTL("token")
+	if err != nil {
+		t.Fatalf("Error getting TTL: %v", err)
+	}
+	if ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("TTL should match the deadline, got %v", ttl)
+	}
+
+	// A deadline in the past stores an expired item
+	c.SetWithExpireAt("token", "old", time.Now().Add(-time.Second))
+	if c.Exists("token") {
+		t.Fatal("Item with a past deadline should be expired")
+	}
+
+	// The zero time means no expiration
+	c.SetWithExpireAt("forever", "value", time.Time{})
+	if ttl, _ := c.TTL("forever"); ttl != -1 {
+		t.Fatalf("Expected infinite TTL, got %v", ttl)
+	}
+
+	c.SetWithOptions("opt", "value", WithTTL(time.Second), WithExpireAt(deadline))
+	if ttl, _ := c.TTL("opt"); ttl <= time.Second {
+		t.Fatalf("WithExpireAt should take precedence over WithTTL, got %v", ttl)
+	}
+}
+
+func TestMaxTTL(t *testing.T) {
+	c := New(0, WithMaxTTL(time.Minute))
+
+	c.SetWithExpiration("long", "value", time.Hour)
+	c.Set("forever", "value")
+	c.SetWithExpiration("short", "value", time.Second)
+
+	for _, key := range []string{"long", "forever"} {
+		ttl, err := c.TTL(key)
+		if err != nil || ttl <= 0 || ttl > time.Minute {
+			t.Fatalf("TTL of %s should be capped at a minute, got %v (err=%v)", key, ttl, err)
+		}
+	}
+	if ttl, _ := c.TTL("short"); ttl > time.Second {
+		t.Fatalf("Shorter TTLs should be kept, got %v", ttl)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	c := New(0)
+
+	for i := 0; i < 1000; i++ {
+		c.SetWithExpiration(fmt.Sprintf("key%d", i), "value", time.Millisecond)
+	}
+	c.Set("forever", "value")
+	time.Sleep(5 * time.Millisecond)
+
+	c.DeleteExpired()
+	if c.Count() != 1 {
+		t.Fatalf("Expected only the non-expiring item to remain, have %d", c.Count())
+	}
+}
+
+func TestStartJanitor(t *testing.T) {
+	c := New(0)
+	c.SetWithExpiration("key", "value", time.Millisecond)
+
+	c.StartJanitor(time.Millisecond)
+	waitFor(t, "the janitor to remove the expired item", func() bool { return c.Count() == 0 })
+
+	c.StopJanitor()
+	c.StopJanitor()
+	c.SetWithExpiration("key", "value", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if c.Count() != 1 {
+		t.Fatal("Expected the stopped janitor to leave the expired item alone")
+	}
+
+	c.StartJanitor(time.Hour)
+	c.StartJanitor(time.Millisecond)
+	waitFor(t, "the restarted janitor to remove the expired item", func() bool { return c.Count() == 0 })
+
+	// The janitor blocks on the lock in DeleteExpired, which must not block
+	// StopJanitor
+	c.mu.Lock()
+	time.Sleep(5 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		c.StopJanitor()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected StopJanitor not to wait for a running cleanup")
+	}
+	c.mu.Unlock()
+}
+
+func TestCountValid(t *testing.T) {
+	c := New(0)
+	c.Set("forever", "value")
+	c.SetWithExpiration("expired", "value", time.Millisecond)
+	c.SetWithExpiration("later", "value", time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if c.Count() != 3 || c.CountValid() != 2 || c.CountExpired() != 1 {
+		t.Fatalf("Expected 3 items, 2 valid and 1 expired, got %d, %d and %d", c.Count(), c.CountValid(), c.CountExpired())
+	}
+
+	c.BumpGeneration()
+	if c.CountValid() != 0 || c.CountExpired() != 3 {
+		t.Fatalf("Expected invalidated items to count as expired, got %d valid and %d expired", c.CountValid(), c.CountExpired())
+	}
+}
@@ -0,0 +1,71 @@
+package chaostest
+
+import (
+	"errors"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// errDecode is returned by reads of a Cacher failed on purpose
+var errDecode = errors.New("chaostest: injected decode failure")
+
+// Cacher is the part of *gocache.Cache most applications use. Applications
+// depending on it rather than on *gocache.Cache can be tested with Wrap.
+type Cacher interface {
+	Get(key string, target interface{}) (bool, error)
+	Set(key string, value interface{}) error
+	SetWithExpiration(key string, value interface{}, duration time.Duration) error
+	Delete(key string)
+}
+
+var _ Cacher = (*gocache.Cache)(nil)
+
+// faultyCacher injects faults in front of a Cacher
+type faultyCacher struct {
+	cacher Cacher
+	in     *Injector
+}
+
+// Wrap returns a Cacher injecting the faults of in in front of cacher. Reads
+// failing to decode return an error wrapping ErrInjected instead of a
+// corrupt value, since a Cacher may not decode at all.
+func Wrap(cacher Cacher, in *Injector) Cacher {
+	return &faultyCacher{cacher: cacher, in: in}
+}
+
+func (f *faultyCacher) Get(key string, target interface{}) (bool, error) {
+	f.in.delay()
+	if f.in.roll(func(c Config) float64 { return c.MissRate }, func(s *Stats) { s.Misses++ }) {
+		return false, nil
+	}
+	if f.in.roll(func(c Config) float64 { return c.DecodeFailureRate }, func(s *Stats) { s.DecodeFailures++ }) {
+		return false, errors.Join(ErrInjected, errDecode)
+	}
+	return f.cacher.Get(key, target)
+}
+
+func (f *faultyCacher) Set(key string, value interface{}) error {
+	f.in.delay()
+	if f.in.roll(func(c Config) float64 { return c.EncodeFailureRate }, func(s *Stats) { s.EncodeFailures++ }) {
+		return ErrInjected
+	}
+	return f.cacher.Set(key, value)
+}
+
+func (f *faultyCacher) SetWithExpiration(key string, value interface{}, duration time.Duration) error {
+	f.in.delay()
+	if f.in.roll(func(c Config) float64 { return c.EncodeFailureRate }, func(s *Stats) { s.EncodeFailures++ }) {
+		return ErrInjected
+	}
+	if duration > 0 {
+		// The expiration moves with the skew, but can't go below the present
+		duration = max(duration+f.in.skew(), time.Nanosecond)
+	}
+	return f.cacher.SetWithExpiration(key, value, duration)
+}
+
+func (f *faultyCacher) Delete(key string) {
+	f.in.delay()
+	f.cacher.Delete(key)
+}
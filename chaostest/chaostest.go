@@ -0,0 +1,182 @@
+// Package chaostest injects faults into a gocache.Cache, so applications can
+// be tested for resilience against a slow, flaky or skewed cache:
+//
+//	faults := chaostest.New(chaostest.Config{
+//		Latency:  5 * time.Millisecond,
+//		MissRate: 0.2,
+//	})
+//	cache := gocache.New(time.Minute, faults.Option())
+//
+// Faults are injected through gocache.WithMiddleware, so they apply to every
+// way the application reaches the cache, including the HTTP and gRPC
+// servers. Applications that depend on the Cacher interface instead can wrap
+// any implementation with Wrap.
+package chaostest
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// ErrInjected is returned by writes failed on purpose
+var ErrInjected = errors.New("chaostest: injected failure")
+
+// corruptValue is returned by reads failed on purpose. It isn't valid in any
+// of the cache's formats, so decoding it fails.
+var corruptValue = []byte{0xff, 0x00, 0xfe}
+
+// Config describes the faults to inject. Rates are probabilities between 0
+// and 1.
+type Config struct {
+	// Latency is added to every operation
+	Latency time.Duration
+
+	// Jitter adds up to this much more latency, uniformly distributed
+	Jitter time.Duration
+
+	// MissRate is the fraction of reads reported as misses
+	MissRate float64
+
+	// DecodeFailureRate is the fraction of reads returning a value that
+	// fails to decode, as if it had been written by an incompatible version
+	DecodeFailureRate float64
+
+	// EncodeFailureRate is the fraction of writes failing with ErrInjected,
+	// as if the value couldn't be serialized
+	EncodeFailureRate float64
+
+	// ClockSkew is added to the expiration of every write, as if the clock
+	// of the writer were ahead by ClockSkew, or behind if it's negative.
+	// Writes without an expiration aren't affected.
+	ClockSkew time.Duration
+
+	// Seed makes the injected faults reproducible, 0 seeds from the clock
+	Seed int64
+}
+
+// Stats counts the faults injected so far
+type Stats struct {
+	Delayed        int
+	Misses         int
+	DecodeFailures int
+	EncodeFailures int
+	Skewed         int
+}
+
+// Injector injects the faults described by a Config. It's safe for
+// concurrent use.
+type Injector struct {
+	mu     sync.Mutex
+	config Config
+	rand   *rand.Rand
+	stats  Stats
+}
+
+// New returns an Injector injecting the faults described by config
+func New(config Config) *Injector {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{config: config, rand: rand.New(rand.NewSource(seed))}
+}
+
+// SetConfig changes the injected faults, e.g. to heal the cache halfway
+// through a test. The random source isn't reseeded.
+func (in *Injector) SetConfig(config Config) {
+	in.mu.Lock()
+	in.config = config
+	in.mu.Unlock()
+}
+
+// Stats returns the number of faults injected so far
+func (in *Injector) Stats() Stats {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.stats
+}
+
+// Option returns a cache option injecting the faults into the cache
+func (in *Injector) Option() gocache.Option {
+	return gocache.WithMiddleware(in.Middleware())
+}
+
+// Middleware returns middleware injecting the faults, to combine with the
+// cache's other middleware
+func (in *Injector) Middleware() gocache.Middleware {
+	return func(next gocache.Handler) gocache.Handler {
+		return func(op *gocache.Operation) error {
+			in.delay()
+
+			switch op.Kind {
+			case gocache.OperationGet:
+				if in.roll(func(c Config) float64 { return c.MissRate }, func(s *Stats) { s.Misses++ }) {
+					op.Found = false
+					return nil
+				}
+				err := next(op)
+				if err == nil && op.Found && in.roll(func(c Config) float64 { return c.DecodeFailureRate }, func(s *Stats) { s.DecodeFailures++ }) {
+					op.Value = corruptValue
+				}
+				return err
+
+			case gocache.OperationSet:
+				if in.roll(func(c Config) float64 { return c.EncodeFailureRate }, func(s *Stats) { s.EncodeFailures++ }) {
+					return ErrInjected
+				}
+				if op.Expiration != 0 {
+					if skew := in.skew(); skew != 0 {
+						op.Expiration += int64(skew)
+					}
+				}
+			}
+			return next(op)
+		}
+	}
+}
+
+// delay sleeps for the configured latency
+func (in *Injector) delay() {
+	in.mu.Lock()
+	d := in.config.Latency
+	if in.config.Jitter > 0 {
+		d += time.Duration(in.rand.Int63n(int64(in.config.Jitter)))
+	}
+	if d > 0 {
+		in.stats.Delayed++
+	}
+	in.mu.Unlock()
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// roll reports whether a fault with the rate returned by rate happens,
+// counting it with count if so
+func (in *Injector) roll(rate func(Config) float64, count func(*Stats)) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	r := rate(in.config)
+	if r <= 0 || in.rand.Float64() >= r {
+		return false
+	}
+	count(&in.stats)
+	return true
+}
+
+// skew returns the configured clock skew, counting it if there is one
+func (in *Injector) skew() time.Duration {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.config.ClockSkew != 0 {
+		in.stats.Skewed++
+	}
+	return in.config.ClockSkew
+}
@@ -0,0 +1,87 @@
+package chaostest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestMisses(t *testing.T) {
+	faults := New(Config{MissRate: 0.5, Seed: 1})
+	c := gocache.New(0, faults.Option())
+	c.Set("key", "value")
+
+	misses := 0
+	for i := 0; i < 1000; i++ {
+		if _, found := c.GetString("key"); !found {
+			misses++
+		}
+	}
+	if misses < 400 || misses > 600 {
+		t.Fatalf("Expected about half the reads to miss, got %d", misses)
+	}
+	if got := faults.Stats().Misses; got != misses {
+		t.Fatalf("Expected %d misses to be counted, got %d", misses, got)
+	}
+
+	faults.SetConfig(Config{})
+	if _, found := c.GetString("key"); !found {
+		t.Fatalf("Expected the healed cache to serve the value")
+	}
+}
+
+func TestSerializationFailures(t *testing.T) {
+	faults := New(Config{DecodeFailureRate: 1, EncodeFailureRate: 1})
+	c := gocache.New(0, faults.Option())
+
+	if err := c.Set("key", 42); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected the write to fail, got %v", err)
+	}
+
+	faults.SetConfig(Config{DecodeFailureRate: 1})
+	c.Set("key", 42)
+	var n int
+	if _, err := c.Get("key", &n); err == nil {
+		t.Fatalf("Expected the read to fail to decode, got %d", n)
+	}
+}
+
+func TestLatencyAndClockSkew(t *testing.T) {
+	faults := New(Config{Latency: 10 * time.Millisecond, ClockSkew: -time.Hour})
+	c := gocache.New(0, faults.Option())
+
+	start := time.Now()
+	c.SetWithExpiration("key", "value", 2*time.Hour)
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("Expected the write to be delayed, took %v", elapsed)
+	}
+	if ttl, _ := c.TTL("key"); ttl > time.Hour {
+		t.Fatalf("Expected the expiration to be skewed by an hour, got %v", ttl)
+	}
+	if stats := faults.Stats(); stats.Delayed != 1 || stats.Skewed != 1 {
+		t.Fatalf("Expected one delayed and skewed write, got %+v", stats)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	faults := New(Config{EncodeFailureRate: 1})
+	cacher := Wrap(gocache.New(0), faults)
+
+	if err := cacher.Set("key", "value"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected the write to fail, got %v", err)
+	}
+
+	faults.SetConfig(Config{DecodeFailureRate: 1})
+	cacher.Set("key", "value")
+	var s string
+	if _, err := cacher.Get("key", &s); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected the read to fail, got %v", err)
+	}
+
+	faults.SetConfig(Config{})
+	if found, err := cacher.Get("key", &s); !found || err != nil || s != "value" {
+		t.Fatalf("Expected the healed cacher to serve the value, got %q (found=%v, err=%v)", s, found, err)
+	}
+}
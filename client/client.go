@@ -0,0 +1,320 @@
+// Package client talks to a cache served by httpserver, keeping a small
+// local cache in front of it so repeated reads skip the round trip:
+//
+//	c := client.New("http://cache:8080/cache")
+//	defer c.Close()
+//	go c.Watch(ctx, "") // drop local copies as soon as the server changes
+//	value, found, err := c.Get(ctx, "greeting")
+//
+// Found values are kept for PositiveTTL and misses for NegativeTTL. While
+// Watch runs, changes made on the server evict the local copies right away;
+// the TTLs bound staleness when it isn't running or is reconnecting.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// DefaultPositiveTTL is how long found values are kept locally by default
+const DefaultPositiveTTL = 5 * time.Second
+
+// DefaultNegativeTTL is how long misses are kept locally by default
+const DefaultNegativeTTL = time.Second
+
+// ErrGone is returned by Watch when the server no longer retains the changes
+// since its cursor. Watch handles it by dropping the local cache and
+// starting over; it's only returned to OnError.
+var ErrGone = errors.New("client: watch cursor expired")
+
+// errMiss is returned by the loader for keys the server doesn't hold
+var errMiss = errors.New("client: not found")
+
+// Client reads and writes the keys of a remote cache
+type Client struct {
+	// BaseURL is the URL the httpserver.Handler is mounted at
+	BaseURL string
+
+	// HTTPClient sends the requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+
+	// Token, if set, is sent as a bearer token
+	Token string
+
+	// PositiveTTL is how long found values are kept locally, capped by their
+	// remaining TTL on the server. 0 disables local caching of values.
+	PositiveTTL time.Duration
+
+	// NegativeTTL is how long misses are kept locally. 0 disables local
+	// caching of misses.
+	NegativeTTL time.Duration
+
+	// RetryInterval is how long Watch waits before reconnecting, 1s if 0
+	RetryInterval time.Duration
+
+	// OnError, if set, is called when the watch stream fails
+	OnError func(err error)
+
+	local  *gocache.Cache
+	misses *gocache.Cache
+}
+
+// New returns a client for the cache served at baseURL. Close it to stop
+// the janitors of its local cache.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+		PositiveTTL: DefaultPositiveTTL,
+		NegativeTTL: DefaultNegativeTTL,
+		local:       gocache.New(time.Minute),
+		misses:      gocache.New(time.Minute),
+	}
+}
+
+// Get returns the value stored under key, from the local cache if it holds
+// it. Concurrent misses for the same key share one request.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if _, missed := c.misses.GetBytes(key); missed {
+		return nil, false, nil
+	}
+	var value string
+	err := c.local.GetOrLoad(ctx, key, &value, c.load)
+	if errors.Is(err, errMiss) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+// GetJSON decodes the JSON value stored under key into target, reporting
+// whether it was found
+func (c *Client) GetJSON(ctx context.Context, key string, target interface{}) (bool, error) {
+	value, found, err := c.Get(ctx, key)
+	if !found || err != nil {
+		return found, err
+	}
+	return true, json.Unmarshal(value, target)
+}
+
+// Set stores value under key on the server, with no expiration if ttl is 0,
+// and keeps it locally
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	target := c.keyURL(key)
+	if ttl > 0 {
+		target += "?ttl=" + ttl.String()
+	}
+	resp, err := c.do(ctx, http.MethodPut, target, value)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	c.misses.Delete(key)
+	if c.PositiveTTL > 0 {
+		localTTL := c.PositiveTTL
+		if ttl > 0 {
+			localTTL = min(localTTL, ttl)
+		}
+		// The caller keeps value, so the local copy must not alias it
+		c.local.SetRaw(key, bytes.Clone(value), localTTL)
+	}
+	return nil
+}
+
+// Close drops the local cache and stops its janitors. The Client must not be
+// used afterwards; the server is unaffected.
+func (c *Client) Close() error {
+	c.local.Close()
+	c.misses.Close()
+	return nil
+}
+
+// Delete removes key from the server and the local cache
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.keyURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	c.Invalidate(key)
+	return nil
+}
+
+// Invalidate drops the local copy of key, or the record of its absence
+func (c *Client) Invalidate(key string) {
+	c.local.Delete(key)
+	c.misses.Delete(key)
+}
+
+// Watch keeps the local cache in line with the changes to keys starting with
+// prefix on the server, reconnecting when the stream drops, until ctx is
+// done. Everything cached locally is dropped whenever changes may have been
+// missed. It returns ctx's error.
+func (c *Client) Watch(ctx context.Context, prefix string) error {
+	retry := c.RetryInterval
+	if retry <= 0 {
+		retry = time.Second
+	}
+	cursor := ""
+	for {
+		err := c.stream(ctx, prefix, &cursor)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrGone) {
+			cursor = ""
+		}
+		if c.OnError != nil {
+			c.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// stream reads one watch connection, updating cursor as events arrive
+func (c *Client) stream(ctx context.Context, prefix string, cursor *string) error {
+	target := c.BaseURL + "/?watch=" + url.QueryEscape(prefix)
+	if *cursor != "" {
+		target += "&since=" + *cursor
+	}
+	resp, err := c.do(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var id, event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if event != "" {
+				c.apply(event, data, *cursor == "")
+				*cursor = id
+			}
+			event, data = "", ""
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			data = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// apply handles a watch event. A fresh stream starts without a cursor,
+// after changes may have been missed.
+func (c *Client) apply(event, data string, fresh bool) {
+	switch event {
+	case "ready":
+		if fresh {
+			c.local.Flush()
+			c.misses.Flush()
+		}
+	case "set", "delete":
+		var key string
+		if json.Unmarshal([]byte(data), &key) == nil {
+			c.Invalidate(key)
+		}
+	case "flush":
+		c.local.Flush()
+		c.misses.Flush()
+	}
+}
+
+// load is the Loader fetching a key missing from the local cache
+func (c *Client) load(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.keyURL(key), nil)
+	if errors.Is(err, errMiss) {
+		if c.NegativeTTL > 0 {
+			c.misses.SetRaw(key, nil, c.NegativeTTL)
+		}
+		return nil, 0, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := c.PositiveTTL
+	if maxAge, ok := strings.CutPrefix(resp.Header.Get("Cache-Control"), "max-age="); ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && seconds > 0 {
+			ttl = min(ttl, time.Duration(seconds)*time.Second)
+		}
+	}
+	if ttl <= 0 {
+		// Caching disabled, expire right away. The value still reaches the
+		// callers waiting on this load.
+		ttl = time.Nanosecond
+	}
+	return value, ttl, nil
+}
+
+// keyURL returns the URL of key
+func (c *Client) keyURL(key string) string {
+	return c.BaseURL + "/" + url.PathEscape(key)
+}
+
+// do sends a request and returns the response if it succeeded. A 404 is
+// reported as errMiss and a 410 as ErrGone.
+func (c *Client) do(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, errMiss
+	case http.StatusGone:
+		return nil, ErrGone
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("client: %s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+}
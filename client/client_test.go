@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/httpserver"
+)
+
+// newServer serves c, counting the key requests
+func newServer(t *testing.T, c *gocache.Cache) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	h := httpserver.NewHandler(c)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("watch") {
+			requests.Add(1)
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClientCachesLocally(t *testing.T) {
+	ctx := context.Background()
+	remote := gocache.New(0)
+	server, requests := newServer(t, remote)
+	c := New(server.URL)
+	defer c.Close()
+
+	if err := c.Set(ctx, "greeting", []byte("hello"), time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if v, _ := remote.GetString("greeting"); v != "hello" {
+		t.Fatalf("Expected the server to store hello, got %q", v)
+	}
+	for i := 0; i < 3; i++ {
+		value, found, err := c.Get(ctx, "greeting")
+		if err != nil || !found || string(value) != "hello" {
+			t.Fatalf("Expected hello, got %q, %v, %v", value, found, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, found, err := c.Get(ctx, "missing"); found || err != nil {
+			t.Fatalf("Expected a miss, got %v, %v", found, err)
+		}
+	}
+	// One PUT and one GET for the miss
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("Expected 2 requests, got %d", n)
+	}
+
+	remote.Set("user", `{"name":"ada"}`)
+	c.Invalidate("user")
+	var user struct{ Name string }
+	if found, err := c.GetJSON(ctx, "user", &user); !found || err != nil || user.Name != "ada" {
+		t.Fatalf("Expected ada, got %+v, %v, %v", user, found, err)
+	}
+
+	if err := c.Delete(ctx, "greeting"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, found, _ := c.Get(ctx, "greeting"); found {
+		t.Fatalf("Expected greeting to be deleted")
+	}
+}
+
+func TestClientWatchInvalidates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote := gocache.New(0, gocache.WithChangeLog(64))
+	server, _ := newServer(t, remote)
+	c := New(server.URL)
+	c.PositiveTTL = time.Hour
+	c.NegativeTTL = time.Hour
+	go c.Watch(ctx, "")
+
+	remote.Set("k", "v1")
+	waitFor(t, "the change to invalidate the local copy", func() bool {
+		remote.Set("k", "v2")
+		value, _, _ := c.Get(ctx, "k")
+		return string(value) == "v2"
+	})
+
+	remote.Set("k", "v3")
+	waitFor(t, "v3", func() bool {
+		value, _, _ := c.Get(ctx, "k")
+		return string(value) == "v3"
+	})
+
+	// Cached misses are dropped too
+	if _, found, _ := c.Get(ctx, "new"); found {
+		t.Fatalf("Expected a miss")
+	}
+	remote.Set("new", "here")
+	waitFor(t, "the new key", func() bool {
+		_, found, _ := c.Get(ctx, "new")
+		return found
+	})
+}
+
+func TestClientSetCopiesValue(t *testing.T) {
+	ctx := context.Background()
+	server, requests := newServer(t, gocache.New(0))
+	c := New(server.URL)
+
+	value := []byte("hello")
+	if err := c.Set(ctx, "greeting", value, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	copy(value, "HELLO")
+	if got, _, _ := c.Get(ctx, "greeting"); string(got) != "hello" {
+		t.Fatalf("Expected the local copy to keep hello, got %q", got)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("Expected the Get to be served locally, got %d requests", n)
+	}
+
+	c.Close()
+	c.Close()
+}
@@ -0,0 +1,299 @@
+// Package cluster tracks the peers of a fleet of caches, so nodes find each
+// other as instances come and go instead of relying on a static peer list:
+//
+//	members := cluster.NewMembership(cluster.Config{
+//		Self:       os.Getenv("POD_IP") + ":8080",
+//		Discoverer: cluster.DNS{Name: "cache.default.svc.cluster.local", Port: 8080},
+//		OnChange:   func(peers []string) { ... },
+//	})
+//	defer members.Stop()
+//
+// Alternatively, Gossip spreads membership and key invalidations between the
+// nodes themselves, tolerating churn without a central broker.
+//
+// Peers are identified by their "host:port" address.
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Discoverer finds the current peers of the cluster
+type Discoverer interface {
+	// Peers returns the addresses of the peers, possibly including this node
+	Peers(ctx context.Context) ([]string, error)
+}
+
+// Watcher is a Discoverer that can push peer changes as they happen.
+// Membership prefers Watch over polling Peers when available.
+type Watcher interface {
+	Discoverer
+
+	// Watch calls update with the full peer list whenever it changes,
+	// until ctx is done or the watch fails
+	Watch(ctx context.Context, update func(peers []string)) error
+}
+
+// Static is a fixed list of peers
+type Static []string
+
+func (s Static) Peers(ctx context.Context) ([]string, error) {
+	return s, nil
+}
+
+// DNS discovers peers by resolving a name to every address behind it, such
+// as a Kubernetes headless service, whose DNS name resolves to the IPs of its
+// ready pods
+type DNS struct {
+	// Name is the name to resolve, e.g. cache.default.svc.cluster.local
+	Name string
+
+	// Port is the port of every peer. If 0, Name is looked up as an SRV
+	// record instead, e.g. _http._tcp.cache.default.svc.cluster.local, which
+	// carries the ports.
+	Port int
+
+	// Resolver looks up the name, net.DefaultResolver if nil
+	Resolver *net.Resolver
+}
+
+func (d DNS) Peers(ctx context.Context) ([]string, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	var peers []string
+	if d.Port == 0 {
+		_, records, err := resolver.LookupSRV(ctx, "", "", d.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			peers = append(peers, net.JoinHostPort(strings.TrimSuffix(r.Target, "."), strconv.Itoa(int(r.Port))))
+		}
+	} else {
+		addrs, err := resolver.LookupHost(ctx, d.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			peers = append(peers, net.JoinHostPort(addr, strconv.Itoa(d.Port)))
+		}
+	}
+	sort.Strings(peers)
+	return peers, nil
+}
+
+// serviceAccountDir holds the credentials Kubernetes mounts into pods
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// Endpoints discovers peers from the Endpoints object of a Kubernetes
+// service, using the pod's service account. Unlike DNS, it implements
+// Watcher, so pods joining or leaving are seen immediately rather than at
+// the next poll. The service account needs get, list and watch permissions
+// on endpoints.
+type Endpoints struct {
+	// Service is the name of the service
+	Service string
+
+	// Namespace of the service, the pod's own namespace if empty
+	Namespace string
+
+	// Port is the name of the service port peers listen on. If empty, the
+	// first port is used.
+	Port string
+
+	// APIServer is the URL of the Kubernetes API, https://kubernetes.default.svc
+	// if empty
+	APIServer string
+
+	// TokenFile and CAFile default to the service account's token and CA
+	// certificate. The token is read for every request, since Kubernetes
+	// rotates it.
+	TokenFile string
+	CAFile    string
+
+	// Client sends the requests. If nil, a client trusting CAFile is used.
+	Client *http.Client
+
+	clientOnce sync.Once
+	client     *http.Client
+	clientErr  error
+}
+
+// endpointsObject is the part of a Kubernetes Endpoints object read by
+// Endpoints
+type endpointsObject struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+func (e *Endpoints) Peers(ctx context.Context) ([]string, error) {
+	resp, err := e.get(ctx, "/endpoints/"+url.PathEscape(e.Service))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var obj endpointsObject
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("cluster: decoding endpoints: %w", err)
+	}
+	return e.addresses(obj), nil
+}
+
+func (e *Endpoints) Watch(ctx context.Context, update func(peers []string)) error {
+	query := url.Values{"watch": {"true"}, "fieldSelector": {"metadata.name=" + e.Service}}
+	resp, err := e.get(ctx, "/endpoints?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var event struct {
+			Type   string          `json:"type"`
+			Object endpointsObject `json:"object"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("cluster: decoding endpoints event: %w", err)
+		}
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			update(e.addresses(event.Object))
+		case "DELETED":
+			update(nil)
+		case "ERROR":
+			return errors.New("cluster: endpoints watch expired")
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// addresses returns the ready addresses of obj with the configured port
+func (e *Endpoints) addresses(obj endpointsObject) []string {
+	var peers []string
+	for _, subset := range obj.Subsets {
+		port := 0
+		for _, p := range subset.Ports {
+			if e.Port == "" || p.Name == e.Port {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, addr := range subset.Addresses {
+			peers = append(peers, net.JoinHostPort(addr.IP, strconv.Itoa(port)))
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// get sends an authenticated GET for path under the namespace's API and
+// returns the response if it succeeded
+func (e *Endpoints) get(ctx context.Context, path string) (*http.Response, error) {
+	client, err := e.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	namespace := e.Namespace
+	if namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "namespace")
+		if err != nil {
+			return nil, fmt.Errorf("cluster: reading pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	api := e.APIServer
+	if api == "" {
+		api = "https://kubernetes.default.svc"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(api, "/")+"/api/v1/namespaces/"+url.PathEscape(namespace)+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenFile := e.TokenFile
+	if tokenFile == "" {
+		tokenFile = serviceAccountDir + "token"
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: reading service account token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("cluster: GET %s: %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// httpClient returns Client, or a client trusting CAFile
+func (e *Endpoints) httpClient() (*http.Client, error) {
+	if e.Client != nil {
+		return e.Client, nil
+	}
+	e.clientOnce.Do(func() {
+		caFile := e.CAFile
+		if caFile == "" {
+			caFile = serviceAccountDir + "ca.crt"
+		}
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			e.clientErr = fmt.Errorf("cluster: reading API server CA: %w", err)
+			return
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			e.clientErr = errors.New("cluster: no certificates in " + caFile)
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		e.client = &http.Client{Transport: transport}
+	})
+	return e.client, e.clientErr
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDNS(t *testing.T) {
+	peers, err := DNS{Name: "localhost", Port: 8080}.Peers(context.Background())
+	if err != nil {
+		t.Skipf("localhost doesn't resolve: %v", err)
+	}
+	if !slices.Contains(peers, "127.0.0.1:8080") && !slices.Contains(peers, "[::1]:8080") {
+		t.Fatalf("Expected localhost with port 8080, got %v", peers)
+	}
+}
+
+// endpointsJSON is an Endpoints object with the given ready IPs
+func endpointsJSON(ips ...string) string {
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = fmt.Sprintf(`{"ip":%q}`, ip)
+	}
+	return `{"subsets":[{"addresses":[` + strings.Join(addrs, ",") + `],` +
+		`"ports":[{"name":"metrics","port":9090},{"name":"cache","port":8080}]}]}`
+}
+
+func newEndpoints(t *testing.T, handler http.HandlerFunc) *Endpoints {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("token\n"), 0o600)
+	return &Endpoints{
+		Service:   "cache",
+		Namespace: "prod",
+		Port:      "cache",
+		APIServer: server.URL,
+		TokenFile: tokenFile,
+		Client:    server.Client(),
+	}
+}
+
+func TestEndpointsPeers(t *testing.T) {
+	e := newEndpoints(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/prod/endpoints/cache" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, endpointsJSON("10.0.0.2", "10.0.0.1"))
+	})
+
+	peers, err := e.Peers(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(peers, []string{"10.0.0.1:8080", "10.0.0.2:8080"}) {
+		t.Fatalf("Expected both pods on the cache port, got %v", peers)
+	}
+
+	e.Service = "missing"
+	if _, err := e.Peers(context.Background()); err == nil {
+		t.Fatalf("Expected an error for a missing service")
+	}
+}
+
+func TestEndpointsWatch(t *testing.T) {
+	e := newEndpoints(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") != "true" || r.URL.Query().Get("fieldSelector") != "metadata.name=cache" {
+			http.Error(w, "bad watch", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"type":"ADDED","object":%s}`+"\n", endpointsJSON("10.0.0.1"))
+		fmt.Fprintf(w, `{"type":"MODIFIED","object":%s}`+"\n", endpointsJSON("10.0.0.1", "10.0.0.3"))
+		fmt.Fprint(w, `{"type":"DELETED","object":{}}`+"\n")
+	})
+
+	var updates [][]string
+	err := e.Watch(context.Background(), func(peers []string) {
+		updates = append(updates, peers)
+	})
+	if err == nil {
+		t.Fatalf("Expected an error when the watch ends")
+	}
+	if len(updates) != 3 || len(updates[0]) != 1 || len(updates[1]) != 2 || len(updates[2]) != 0 {
+		t.Fatalf("Expected 1, 2 then 0 peers, got %v", updates)
+	}
+}
@@ -0,0 +1,513 @@
+package cluster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	mathrand "math/rand/v2"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// maxPacketSize is the largest gossip datagram sent
+const maxPacketSize = 60 << 10
+
+// maxEventSize bounds the encoded size of an invalidation, leaving the rest
+// of a datagram to the member list. Invalidate splits larger key lists.
+const maxEventSize = maxPacketSize / 2
+
+// seenRetention is how long invalidation IDs are remembered, so a
+// retransmitted event isn't applied and forwarded again
+const seenRetention = time.Minute
+
+// ErrInvalidAdvertise is returned by StartGossip when no address other nodes
+// can reach is known
+var ErrInvalidAdvertise = errors.New("cluster: gossip needs an Advertise address when binding to all interfaces")
+
+// ErrEventTooLarge is reported to OnError for invalidations that can't fit
+// in a datagram, such as a key longer than maxEventSize. They aren't
+// gossiped.
+var ErrEventTooLarge = errors.New("cluster: invalidation too large to gossip")
+
+// GossipConfig configures a Gossip node
+type GossipConfig struct {
+	// Bind is the UDP address to listen on, e.g. ":7946"
+	Bind string
+
+	// Advertise is the address other nodes reach this one at. If empty, the
+	// bound address is used, which must then be a specific IP.
+	Advertise string
+
+	// Seeds finds nodes to join through, e.g. DNS for a headless service.
+	// They are contacted while no other node is known, and now and then
+	// afterwards to heal partitions. May be nil for the first node.
+	Seeds Discoverer
+
+	// Interval is the time between gossip rounds, 200ms if 0
+	Interval time.Duration
+
+	// Fanout is the number of nodes gossiped to per round, 3 if 0
+	Fanout int
+
+	// DeadTimeout is how long a node may go unheard of before it's
+	// considered gone, 5s if 0
+	DeadTimeout time.Duration
+
+	// Key, if set, authenticates gossip with HMAC-SHA256. Every node must
+	// use the same key; packets without a valid MAC are dropped.
+	Key []byte
+
+	// Cache receives the invalidations gossiped by other nodes
+	Cache *gocache.Cache
+
+	// OnChange, if set, is called with the new peer list whenever it
+	// changes, from a single goroutine
+	OnChange func(peers []string)
+
+	// OnError, if set, is called when seed discovery fails, a packet can't
+	// be sent or an invalidation is too large to gossip
+	OnError func(err error)
+}
+
+// Gossip is a node of a cluster whose membership and invalidations spread
+// by gossip over UDP, with no central broker. Every round, a node sends its
+// view of the cluster and its pending invalidations to a few random peers;
+// nodes whose heartbeat stops advancing are dropped after DeadTimeout, and
+// nodes that Stop announce their departure.
+type Gossip struct {
+	config GossipConfig
+	conn   *net.UDPConn
+	self   string
+	stop   chan struct{}
+	halt   sync.Once
+	done   sync.WaitGroup
+
+	mu      sync.Mutex
+	members map[string]*member
+	pending []*pendingEvent
+	seen    map[string]time.Time
+	peers   []string // live peers as last reported to OnChange
+	rounds  int
+}
+
+// member is the state of a node as known locally
+type member struct {
+	heartbeat uint64
+	heard     time.Time // when heartbeat last advanced
+	left      bool
+}
+
+// memberState is a member as gossiped
+type memberState struct {
+	Addr      string `json:"a"`
+	Heartbeat uint64 `json:"h"`
+	Left      bool   `json:"l,omitempty"`
+}
+
+// invalidation is an event deleting keys on every node
+type invalidation struct {
+	ID   string   `json:"id"`
+	Keys []string `json:"k"`
+}
+
+// pendingEvent is an invalidation still being retransmitted
+type pendingEvent struct {
+	event     invalidation
+	size      int // estimated encoded size
+	transmits int
+}
+
+// eventOverhead is the estimated encoded size of an invalidation without
+// its keys
+const eventOverhead = 48
+
+// keySize estimates the space key takes in an encoded invalidation
+func keySize(key string) int {
+	quoted, _ := json.Marshal(key)
+	return len(quoted) + 1
+}
+
+// gossipMessage is the payload of a datagram
+type gossipMessage struct {
+	Members []memberState  `json:"m"`
+	Events  []invalidation `json:"e,omitempty"`
+}
+
+// StartGossip binds the UDP socket and starts gossiping
+func StartGossip(config GossipConfig) (*Gossip, error) {
+	if config.Interval <= 0 {
+		config.Interval = 200 * time.Millisecond
+	}
+	if config.Fanout <= 0 {
+		config.Fanout = 3
+	}
+	if config.DeadTimeout <= 0 {
+		config.DeadTimeout = 5 * time.Second
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", config.Bind)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	self := config.Advertise
+	if self == "" {
+		local := conn.LocalAddr().(*net.UDPAddr)
+		if local.IP.IsUnspecified() {
+			conn.Close()
+			return nil, ErrInvalidAdvertise
+		}
+		self = local.String()
+	}
+
+	g := &Gossip{
+		config: config,
+		conn:   conn,
+		self:   self,
+		stop:   make(chan struct{}),
+		seen:   make(map[string]time.Time),
+		// Starting from the clock keeps a restarted node's heartbeat ahead
+		// of the one the cluster remembers
+		members: map[string]*member{self: {heartbeat: uint64(time.Now().UnixNano()), heard: time.Now()}},
+	}
+	g.done.Add(2)
+	go g.receive()
+	go g.run()
+	return g, nil
+}
+
+// Addr returns the address this node advertises
+func (g *Gossip) Addr() string {
+	return g.self
+}
+
+// Peers returns the addresses of the other live nodes, sorted
+func (g *Gossip) Peers() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.livePeersLocked(time.Now())
+}
+
+// Invalidate deletes keys from the local cache and from every other node's.
+// Key lists too large for one datagram are gossiped as several events; a
+// key that can't fit on its own is only deleted locally and reported to
+// OnError with ErrEventTooLarge.
+func (g *Gossip) Invalidate(keys ...string) {
+	g.apply(keys)
+
+	var events []invalidation
+	var tooLarge []string
+	size := 0
+	for _, key := range keys {
+		n := keySize(key)
+		if eventOverhead+n > maxEventSize {
+			tooLarge = append(tooLarge, key)
+			continue
+		}
+		if len(events) == 0 || size+n > maxEventSize {
+			id := make([]byte, 16)
+			rand.Read(id)
+			events = append(events, invalidation{ID: hex.EncodeToString(id)})
+			size = eventOverhead
+		}
+		last := &events[len(events)-1]
+		last.Keys = append(last.Keys, key)
+		size += n
+	}
+
+	g.mu.Lock()
+	now := time.Now()
+	for _, event := range events {
+		g.seen[event.ID] = now
+		g.enqueueLocked(event)
+	}
+	g.mu.Unlock()
+
+	for _, key := range tooLarge {
+		if len(key) > 64 {
+			key = key[:64] + "..."
+		}
+		g.report(fmt.Errorf("%w: %q", ErrEventTooLarge, key))
+	}
+}
+
+// Stop announces that this node is leaving and stops gossiping. Calls after
+// the first do nothing.
+func (g *Gossip) Stop() {
+	g.halt.Do(func() {
+		g.mu.Lock()
+		me := g.members[g.self]
+		me.heartbeat++
+		me.left = true
+		targets := g.pickLocked(g.config.Fanout * 2)
+		msg, _ := g.messageLocked()
+		g.mu.Unlock()
+
+		g.send(targets, msg)
+		close(g.stop)
+		g.conn.Close()
+		g.done.Wait()
+	})
+}
+
+func (g *Gossip) run() {
+	defer g.done.Done()
+	ticker := time.NewTicker(g.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.round()
+		}
+	}
+}
+
+// round gossips to a few peers, and to a seed while alone or every so often
+func (g *Gossip) round() {
+	now := time.Now()
+
+	g.mu.Lock()
+	g.members[g.self].heartbeat++
+	g.members[g.self].heard = now
+	g.rounds++
+	targets := g.pickLocked(g.config.Fanout)
+	askSeeds := g.config.Seeds != nil && (len(targets) == 0 || g.rounds%50 == 0)
+	msg, dropped := g.messageLocked()
+	g.forgetLocked(now)
+	peers, changed := g.peersChangedLocked(now)
+	g.mu.Unlock()
+
+	if dropped > 0 {
+		g.report(fmt.Errorf("%w: dropped %d events that don't fit beside the member list", ErrEventTooLarge, dropped))
+	}
+
+	if askSeeds {
+		ctx, cancel := context.WithTimeout(context.Background(), g.config.Interval)
+		seeds, err := g.config.Seeds.Peers(ctx)
+		cancel()
+		if err != nil {
+			g.report(err)
+		}
+		for _, seed := range seeds {
+			if seed != g.self && !slices.Contains(targets, seed) {
+				targets = append(targets, seed)
+			}
+		}
+	}
+	g.send(targets, msg)
+
+	if changed && g.config.OnChange != nil {
+		g.config.OnChange(peers)
+	}
+}
+
+// pickLocked returns up to n random live peers. The caller must hold g.mu.
+func (g *Gossip) pickLocked(n int) []string {
+	peers := g.livePeersLocked(time.Now())
+	mathrand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	return peers[:min(n, len(peers))]
+}
+
+// livePeersLocked returns the other nodes heard of within DeadTimeout that
+// haven't left, sorted. The caller must hold g.mu.
+func (g *Gossip) livePeersLocked(now time.Time) []string {
+	var peers []string
+	for addr, m := range g.members {
+		if addr != g.self && !m.left && now.Sub(m.heard) < g.config.DeadTimeout {
+			peers = append(peers, addr)
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// peersChangedLocked returns the live peers and whether they changed since
+// the last call. The caller must hold g.mu.
+func (g *Gossip) peersChangedLocked(now time.Time) ([]string, bool) {
+	peers := g.livePeersLocked(now)
+	if slices.Equal(peers, g.peers) {
+		return nil, false
+	}
+	g.peers = peers
+	return slices.Clone(peers), true
+}
+
+// messageLocked builds the next datagram, taking one transmission off each
+// pending invalidation it carries. Events that wouldn't fit even alone are
+// dropped rather than kept pending forever, and counted in dropped. The
+// caller must hold g.mu.
+func (g *Gossip) messageLocked() (data []byte, dropped int) {
+	var msg gossipMessage
+	size := 16
+	for addr, m := range g.members {
+		msg.Members = append(msg.Members, memberState{Addr: addr, Heartbeat: m.heartbeat, Left: m.left})
+		size += len(addr) + 40
+	}
+	pending := g.pending[:0]
+	base := size
+	for _, p := range g.pending {
+		switch {
+		case size+p.size <= maxPacketSize:
+			msg.Events = append(msg.Events, p.event)
+			size += p.size
+			p.transmits--
+		case base+p.size > maxPacketSize:
+			p.transmits = 0
+			dropped++
+		}
+		if p.transmits > 0 {
+			pending = append(pending, p)
+		}
+	}
+	g.pending = pending
+
+	data, _ = json.Marshal(msg)
+	if len(g.config.Key) > 0 {
+		mac := hmac.New(sha256.New, g.config.Key)
+		mac.Write(data)
+		data = mac.Sum(data)
+	}
+	return data, dropped
+}
+
+// enqueueLocked schedules event for retransmission, more often in larger
+// clusters so it reaches every node with high probability. The caller must
+// hold g.mu.
+func (g *Gossip) enqueueLocked(event invalidation) {
+	transmits := 3 * bits.Len(uint(len(g.members)+1))
+	size := eventOverhead
+	for _, key := range event.Keys {
+		size += keySize(key)
+	}
+	g.pending = append(g.pending, &pendingEvent{event: event, size: size, transmits: transmits})
+}
+
+// forgetLocked drops members gone for a while and expired invalidation IDs.
+// Dead members are kept for a few timeouts so stale gossip doesn't revive
+// them. The caller must hold g.mu.
+func (g *Gossip) forgetLocked(now time.Time) {
+	for addr, m := range g.members {
+		if addr != g.self && now.Sub(m.heard) > 10*g.config.DeadTimeout {
+			delete(g.members, addr)
+		}
+	}
+	for id, at := range g.seen {
+		if now.Sub(at) > seenRetention {
+			delete(g.seen, id)
+		}
+	}
+}
+
+// send writes msg to each target
+func (g *Gossip) send(targets []string, msg []byte) {
+	for _, target := range targets {
+		addr, err := net.ResolveUDPAddr("udp", target)
+		if err == nil {
+			_, err = g.conn.WriteToUDP(msg, addr)
+		}
+		if err != nil {
+			g.report(err)
+		}
+	}
+}
+
+func (g *Gossip) receive() {
+	defer g.done.Done()
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := g.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-g.stop:
+				return
+			default:
+				continue
+			}
+		}
+		g.handle(buf[:n])
+	}
+}
+
+// handle merges a received datagram
+func (g *Gossip) handle(data []byte) {
+	if len(g.config.Key) > 0 {
+		if len(data) < sha256.Size {
+			return
+		}
+		sum := data[len(data)-sha256.Size:]
+		data = data[:len(data)-sha256.Size]
+		mac := hmac.New(sha256.New, g.config.Key)
+		mac.Write(data)
+		if !hmac.Equal(mac.Sum(nil), sum) {
+			return
+		}
+	}
+
+	var msg gossipMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return
+	}
+
+	now := time.Now()
+	var fresh [][]string
+	g.mu.Lock()
+	for _, s := range msg.Members {
+		if s.Addr == g.self {
+			continue
+		}
+		m, known := g.members[s.Addr]
+		if !known {
+			g.members[s.Addr] = &member{heartbeat: s.Heartbeat, heard: now, left: s.Left}
+		} else if s.Heartbeat > m.heartbeat {
+			m.heartbeat, m.heard, m.left = s.Heartbeat, now, s.Left
+		}
+	}
+	for _, event := range msg.Events {
+		if _, seen := g.seen[event.ID]; seen {
+			continue
+		}
+		g.seen[event.ID] = now
+		g.enqueueLocked(event)
+		fresh = append(fresh, event.Keys)
+	}
+	g.mu.Unlock()
+
+	for _, keys := range fresh {
+		g.apply(keys)
+	}
+}
+
+// apply deletes keys from the local cache
+func (g *Gossip) apply(keys []string) {
+	if g.config.Cache == nil {
+		return
+	}
+	for _, key := range keys {
+		g.config.Cache.Delete(key)
+	}
+}
+
+// report passes err to OnError if set
+func (g *Gossip) report(err error) {
+	if g.config.OnError != nil {
+		g.config.OnError(err)
+	}
+}
@@ -0,0 +1,158 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func startGossip(t *testing.T, seeds Discoverer, key []byte) (*Gossip, *gocache.Cache) {
+	t.Helper()
+	c := gocache.New(0)
+	g, err := StartGossip(GossipConfig{
+		Bind:        "127.0.0.1:0",
+		Seeds:       seeds,
+		Interval:    5 * time.Millisecond,
+		DeadTimeout: 200 * time.Millisecond,
+		Key:         key,
+		Cache:       c,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return g, c
+}
+
+func TestGossipMembershipAndInvalidation(t *testing.T) {
+	key := []byte("cluster secret")
+	a, cacheA := startGossip(t, nil, key)
+	defer a.Stop()
+	b, cacheB := startGossip(t, Static{a.Addr()}, key)
+	defer b.Stop()
+	c, cacheC := startGossip(t, Static{a.Addr()}, key)
+
+	waitFor(t, "every node to see the others", func() bool {
+		return len(a.Peers()) == 2 && len(b.Peers()) == 2 && len(c.Peers()) == 2
+	})
+	if !slices.Contains(b.Peers(), c.Addr()) {
+		t.Fatalf("Expected b to learn about c through a, got %v", b.Peers())
+	}
+
+	for _, cache := range []*gocache.Cache{cacheA, cacheB, cacheC} {
+		cache.Set("user:1", "cached")
+		cache.Set("user:2", "cached")
+	}
+	b.Invalidate("user:1")
+	waitFor(t, "the invalidation to spread", func() bool {
+		return !cacheA.Exists("user:1") && !cacheB.Exists("user:1") && !cacheC.Exists("user:1")
+	})
+	if !cacheC.Exists("user:2") {
+		t.Fatalf("Expected user:2 to be kept")
+	}
+
+	// A node that stops is dropped without waiting for the dead timeout
+	c.Stop()
+	waitFor(t, "c to leave", func() bool {
+		return len(a.Peers()) == 1 && len(b.Peers()) == 1
+	})
+}
+
+func TestGossipRejectsWrongKey(t *testing.T) {
+	a, _ := startGossip(t, nil, []byte("right"))
+	defer a.Stop()
+	b, _ := startGossip(t, Static{a.Addr()}, []byte("wrong"))
+	defer b.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if len(a.Peers()) != 0 || len(b.Peers()) != 0 {
+		t.Fatalf("Expected nodes with different keys not to join, got %v and %v", a.Peers(), b.Peers())
+	}
+}
+
+func TestGossipDeadNode(t *testing.T) {
+	a, _ := startGossip(t, nil, nil)
+	defer a.Stop()
+	b, _ := startGossip(t, Static{a.Addr()}, nil)
+	waitFor(t, "b to join", func() bool {
+		return len(a.Peers()) == 1
+	})
+
+	// Stop gossiping without announcing the departure
+	close(b.stop)
+	b.conn.Close()
+	b.done.Wait()
+	waitFor(t, "b to be declared dead", func() bool {
+		return len(a.Peers()) == 0
+	})
+}
+
+func TestGossipNeedsAdvertise(t *testing.T) {
+	if _, err := StartGossip(GossipConfig{Bind: ":0"}); err != ErrInvalidAdvertise {
+		t.Fatalf("Expected ErrInvalidAdvertise, got %v", err)
+	}
+}
+
+func TestGossipLargeInvalidation(t *testing.T) {
+	a, cacheA := startGossip(t, nil, nil)
+	defer a.Stop()
+	b, cacheB := startGossip(t, Static{a.Addr()}, nil)
+	defer b.Stop()
+	waitFor(t, "b to join", func() bool {
+		return len(a.Peers()) == 1
+	})
+
+	// Far more keys than one datagram holds
+	keys := make([]string, 5000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("session:%040d", i)
+		cacheA.Set(keys[i], "cached")
+		cacheB.Set(keys[i], "cached")
+	}
+	a.Invalidate(keys...)
+	waitFor(t, "every key to be invalidated", func() bool {
+		return cacheB.Count() == 0
+	})
+	if n := cacheA.Count(); n != 0 {
+		t.Fatalf("Expected the local cache to be empty, got %d items", n)
+	}
+	waitFor(t, "the events to stop being retransmitted", func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return len(a.pending) == 0
+	})
+}
+
+func TestGossipKeyTooLarge(t *testing.T) {
+	var reported atomic.Value
+	g, err := StartGossip(GossipConfig{
+		Bind:     "127.0.0.1:0",
+		Interval: 5 * time.Millisecond,
+		OnError:  func(err error) { reported.Store(err) },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer g.Stop()
+
+	g.Invalidate(strings.Repeat("x", maxEventSize), "small")
+	if err, _ := reported.Load().(error); !errors.Is(err, ErrEventTooLarge) {
+		t.Fatalf("Expected ErrEventTooLarge, got %v", err)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.pending) != 1 || !slices.Equal(g.pending[0].event.Keys, []string{"small"}) {
+		t.Fatalf("Expected only the small key to be gossiped")
+	}
+}
+
+func TestGossipStopTwice(t *testing.T) {
+	g, _ := startGossip(t, nil, nil)
+	g.Stop()
+	g.Stop()
+}
@@ -0,0 +1,266 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// DefaultBasePath is the path under which Groups serve their peers
+const DefaultBasePath = "/_gocache/"
+
+// DefaultHotTTL is how long a value fetched from its owner is kept locally
+const DefaultHotTTL = time.Minute
+
+// PeerLister provides the current peers, like Membership and Gossip
+type PeerLister interface {
+	Peers() []string
+}
+
+// Group fills a cache across a fleet the way groupcache does: every key has
+// a single owner, chosen by consistent hashing over the peers, and only the
+// owner calls the Loader. Other nodes fetch the value from the owner over
+// HTTP and keep a copy for HotTTL, so hot keys are served locally while the
+// origin sees one load per key for the whole fleet. If the owner can't be
+// reached, the node loads the key itself; if the owner's load fails, the
+// caller gets an *OwnerError instead.
+//
+// Each node serves its peers with the Group's handler:
+//
+//	group := &cluster.Group{Name: "users", Cache: cache, Loader: loadUser, Self: addr, Peers: members}
+//	http.Handle(group.Path(), group)
+//
+// The handler should only be reachable by peers.
+type Group struct {
+	// Name identifies the group in peer requests
+	Name string
+
+	// Cache holds the owned keys and the copies of hot keys
+	Cache *gocache.Cache
+
+	// Loader loads the keys this node owns
+	Loader gocache.Loader
+
+	// Self is this node's address, as it appears in the peer lists of the
+	// other nodes
+	Self string
+
+	// Peers provides the other nodes. A nil Peers makes this node own
+	// every key.
+	Peers PeerLister
+
+	// HTTPPort, if set, replaces the port of peer addresses when contacting
+	// them, for peer lists carrying another port such as Gossip's
+	HTTPPort int
+
+	// HotTTL is how long values fetched from their owner are kept,
+	// DefaultHotTTL if 0. Copies never outlive the owner's item.
+	HotTTL time.Duration
+
+	// Replicas is the number of points each node has on the hash ring,
+	// 50 if 0. More points spread keys more evenly.
+	Replicas int
+
+	// BasePath prefixes the peer request paths, DefaultBasePath if empty
+	BasePath string
+
+	// Client sends the peer requests, http.DefaultClient if nil
+	Client *http.Client
+
+	mu        sync.Mutex
+	ring      []ringPoint
+	ringNodes []string // nodes the ring was built from
+}
+
+// ringPoint is a point of a node on the hash ring
+type ringPoint struct {
+	hash uint32
+	node string
+}
+
+// Get decodes the value of key into target like GetOrLoad, loading it on
+// this node if it owns key and fetching it from its owner otherwise
+func (g *Group) Get(ctx context.Context, key string, target interface{}, opts ...gocache.LoadOption) error {
+	return g.Cache.GetOrLoad(ctx, key, target, g.fill, opts...)
+}
+
+// Owner returns the address of the node owning key
+func (g *Group) Owner(key string) string {
+	nodes := []string{g.Self}
+	if g.Peers != nil {
+		nodes = append(nodes, g.Peers.Peers()...)
+	}
+	sort.Strings(nodes)
+	nodes = slices.Compact(nodes)
+
+	g.mu.Lock()
+	if !slices.Equal(nodes, g.ringNodes) {
+		g.ring = g.buildRing(nodes)
+		g.ringNodes = nodes
+	}
+	ring := g.ring
+	g.mu.Unlock()
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(ring), func(i int) bool {
+		return ring[i].hash >= hash
+	})
+	if i == len(ring) {
+		i = 0
+	}
+	return ring[i].node
+}
+
+// buildRing places Replicas points per node on the ring
+func (g *Group) buildRing(nodes []string) []ringPoint {
+	replicas := g.Replicas
+	if replicas <= 0 {
+		replicas = 50
+	}
+	ring := make([]ringPoint, 0, len(nodes)*replicas)
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			ring = append(ring, ringPoint{hash: crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + node)), node: node})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	return ring
+}
+
+// Path returns the path to serve the Group's handler on
+func (g *Group) Path() string {
+	return g.basePath() + url.PathEscape(g.Name) + "/"
+}
+
+// ServeHTTP serves the value of the key named by the request path to a peer,
+// loading it if needed. The value is loaded here whether or not this node
+// thinks it owns the key, so nodes with diverging peer lists don't bounce
+// requests between each other.
+func (g *Group) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	escaped, ok := strings.CutPrefix(r.URL.EscapedPath(), g.Path())
+	key, err := url.PathUnescape(escaped)
+	if !ok || err != nil || key == "" {
+		http.Error(w, "unknown key", http.StatusNotFound)
+		return
+	}
+
+	value, found := g.Cache.GetBytes(key)
+	if !found {
+		if err := g.Cache.GetOrLoad(r.Context(), key, nil, g.Loader); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if value, found = g.Cache.GetBytes(key); !found {
+			http.Error(w, "value not cached", http.StatusBadGateway)
+			return
+		}
+	}
+	if ttl, err := g.Cache.TTL(key); err == nil && ttl > 0 {
+		// Round up, as max-age=0 would read as no expiration
+		w.Header().Set("Cache-Control", "max-age="+strconv.FormatInt(int64((ttl+time.Second-1)/time.Second), 10))
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+// OwnerError is returned when a key's owner answered a fetch with an error,
+// typically because its Loader failed
+type OwnerError struct {
+	Owner   string
+	Key     string
+	Status  int
+	Message string // the body of the owner's response
+}
+
+func (e *OwnerError) Error() string {
+	return fmt.Sprintf("cluster: fetching %q from %s: %d %s: %s", e.Key, e.Owner, e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// fill is the Loader passed to GetOrLoad
+func (g *Group) fill(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	owner := g.Owner(key)
+	if owner == g.Self {
+		return g.Loader(ctx, key)
+	}
+	value, ttl, err := g.fetch(ctx, owner, key)
+	var ownerErr *OwnerError
+	switch {
+	case err == nil:
+		return value, ttl, nil
+	case errors.As(err, &ownerErr) || ctx.Err() != nil:
+		// Loading here would repeat the owner's failure, or outlive the caller
+		return nil, 0, err
+	}
+	// The owner is down, unreachable or timed out, load the key here rather
+	// than fail
+	return g.Loader(ctx, key)
+}
+
+// fetch gets key from its owner, returning it with the TTL to keep it for
+func (g *Group) fetch(ctx context.Context, owner, key string) ([]byte, time.Duration, error) {
+	host := owner
+	if g.HTTPPort > 0 {
+		if h, _, err := net.SplitHostPort(owner); err == nil {
+			host = net.JoinHostPort(h, strconv.Itoa(g.HTTPPort))
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+g.Path()+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, &OwnerError{Owner: owner, Key: key, Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := g.HotTTL
+	if ttl <= 0 {
+		ttl = DefaultHotTTL
+	}
+	if maxAge, ok := strings.CutPrefix(resp.Header.Get("Cache-Control"), "max-age="); ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil {
+			ttl = min(ttl, time.Duration(seconds)*time.Second)
+		}
+	}
+	return value, ttl, nil
+}
+
+func (g *Group) basePath() string {
+	if g.BasePath == "" {
+		return DefaultBasePath
+	}
+	return g.BasePath
+}
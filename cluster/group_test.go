@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// newFleet starts n nodes sharing a loader, returning their groups and
+// servers
+func newFleet(t *testing.T, n int, loader gocache.Loader) ([]*Group, []*httptest.Server) {
+	groups := make([]*Group, n)
+	servers := make([]*httptest.Server, n)
+	var addrs Static
+	for i := range groups {
+		groups[i] = &Group{Name: "users", Cache: gocache.New(0), Loader: loader}
+		servers[i] = httptest.NewServer(groups[i])
+		t.Cleanup(servers[i].Close)
+		addrs = append(addrs, strings.TrimPrefix(servers[i].URL, "http://"))
+	}
+	for i, g := range groups {
+		g.Self = addrs[i]
+		g.Peers = staticLister(addrs)
+	}
+	return groups, servers
+}
+
+// staticLister lists a fixed set of peers
+type staticLister []string
+
+func (s staticLister) Peers() []string {
+	return s
+}
+
+func TestGroupLoadsOncePerFleet(t *testing.T) {
+	var loads atomic.Int32
+	groups, _ := newFleet(t, 3, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		loads.Add(1)
+		return map[string]string{"id": key}, time.Hour, nil
+	})
+
+	owner := groups[0].Owner("user:1")
+	for _, g := range groups {
+		if o := g.Owner("user:1"); o != owner {
+			t.Fatalf("Expected every node to agree on the owner, got %s and %s", owner, o)
+		}
+		var user map[string]string
+		if err := g.Get(context.Background(), "user:1", &user); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if user["id"] != "user:1" {
+			t.Fatalf("Expected user:1, got %v", user)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("Expected 1 origin load, got %d", n)
+	}
+
+	// Non-owners keep a copy for HotTTL
+	for _, g := range groups {
+		ttl, err := g.Cache.TTL("user:1")
+		if err != nil {
+			t.Fatalf("Expected every node to hold user:1, got %v", err)
+		}
+		if g.Self != owner && ttl > DefaultHotTTL {
+			t.Fatalf("Expected the copy to expire within %v, got %v", DefaultHotTTL, ttl)
+		}
+	}
+}
+
+func TestGroupOwnerDown(t *testing.T) {
+	var loads atomic.Int32
+	groups, servers := newFleet(t, 2, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		loads.Add(1)
+		return "value", 0, nil
+	})
+
+	// Find a key owned by the second node, then take it down
+	key := ""
+	for i := 0; key == ""; i++ {
+		if k := "k" + strings.Repeat("x", i); groups[0].Owner(k) == groups[1].Self {
+			key = k
+		}
+	}
+	servers[1].Close()
+
+	var value string
+	if err := groups[0].Get(context.Background(), key, &value); err != nil || value != "value" {
+		t.Fatalf("Expected the key to be loaded locally, got %q, %v", value, err)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("Expected 1 origin load, got %d", n)
+	}
+}
+
+func TestGroupOwnerLoadFails(t *testing.T) {
+	var loads atomic.Int32
+	groups, _ := newFleet(t, 2, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		loads.Add(1)
+		return nil, 0, errors.New("origin down")
+	})
+
+	key := ""
+	for i := 0; key == ""; i++ {
+		if k := "k" + strings.Repeat("x", i); groups[0].Owner(k) == groups[1].Self {
+			key = k
+		}
+	}
+
+	var value string
+	err := groups[0].Get(context.Background(), key, &value)
+	var ownerErr *OwnerError
+	if !errors.As(err, &ownerErr) {
+		t.Fatalf("Expected an OwnerError, got %v", err)
+	}
+	if ownerErr.Status != http.StatusBadGateway || !strings.Contains(ownerErr.Message, "origin down") {
+		t.Fatalf("Expected the owner's 502 with its load error, got %d %q", ownerErr.Status, ownerErr.Message)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("Expected only the owner to load, got %d loads", n)
+	}
+}
+
+func TestGroupSpreadsKeys(t *testing.T) {
+	g := &Group{Self: "a:1", Peers: staticLister{"b:1", "c:1"}}
+	owned := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		owned[g.Owner("key"+strconv.Itoa(i))]++
+	}
+	for _, node := range []string{"a:1", "b:1", "c:1"} {
+		if owned[node] < 500 {
+			t.Fatalf("Expected keys to spread over every node, got %v", owned)
+		}
+	}
+}
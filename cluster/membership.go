@@ -0,0 +1,137 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how often peers are polled by default
+const DefaultRefreshInterval = 10 * time.Second
+
+// Config configures a Membership
+type Config struct {
+	// Self is this node's address, left out of the peer list
+	Self string
+
+	// Discoverer finds the peers
+	Discoverer Discoverer
+
+	// RefreshInterval is how often the Discoverer is polled, or how long to
+	// wait before restarting a failed watch. DefaultRefreshInterval if 0.
+	RefreshInterval time.Duration
+
+	// OnChange, if set, is called with the new peer list whenever it
+	// changes, from a single goroutine
+	OnChange func(peers []string)
+
+	// OnError, if set, is called when discovery fails. The last known peers
+	// are kept until discovery succeeds again.
+	OnError func(err error)
+}
+
+// Membership keeps the peer list of a cluster up to date
+type Membership struct {
+	config Config
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	peers []string
+}
+
+// NewMembership discovers the current peers and keeps watching for changes
+// until Stop is called. The first discovery runs before it returns, bounded
+// by the refresh interval, so Peers is populated right away when the
+// Discoverer answers.
+func NewMembership(config Config) *Membership {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Membership{config: config, cancel: cancel, done: make(chan struct{})}
+
+	m.refresh(ctx)
+	go m.run(ctx)
+	return m
+}
+
+// Peers returns the addresses of the other nodes, sorted
+func (m *Membership) Peers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.peers)
+}
+
+// Stop stops watching for changes
+func (m *Membership) Stop() {
+	m.cancel()
+	<-m.done
+}
+
+func (m *Membership) run(ctx context.Context) {
+	defer close(m.done)
+
+	watcher, watches := m.config.Discoverer.(Watcher)
+	ticker := time.NewTicker(m.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		if watches {
+			if err := watcher.Watch(ctx, m.update); err != nil && ctx.Err() == nil {
+				m.report(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// A restarted watch may have missed changes, so poll as well
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh polls the Discoverer
+func (m *Membership) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.config.RefreshInterval)
+	defer cancel()
+
+	peers, err := m.config.Discoverer.Peers(ctx)
+	if err != nil {
+		if ctx.Err() == nil || ctx.Err() == context.DeadlineExceeded {
+			m.report(err)
+		}
+		return
+	}
+	m.update(peers)
+}
+
+// update replaces the peer list, calling OnChange if it changed
+func (m *Membership) update(peers []string) {
+	next := make([]string, 0, len(peers))
+	for _, p := range peers {
+		if p != m.config.Self {
+			next = append(next, p)
+		}
+	}
+	sort.Strings(next)
+	next = slices.Compact(next)
+
+	m.mu.Lock()
+	changed := !slices.Equal(m.peers, next)
+	m.peers = next
+	m.mu.Unlock()
+
+	if changed && m.config.OnChange != nil {
+		m.config.OnChange(slices.Clone(next))
+	}
+}
+
+// report passes err to OnError if set
+func (m *Membership) report(err error) {
+	if m.config.OnError != nil {
+		m.config.OnError(err)
+	}
+}
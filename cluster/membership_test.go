@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDiscoverer returns a peer list the test can change
+type fakeDiscoverer struct {
+	mu    sync.Mutex
+	peers []string
+	err   error
+}
+
+func (f *fakeDiscoverer) set(peers []string, err error) {
+	f.mu.Lock()
+	f.peers, f.err = peers, err
+	f.mu.Unlock()
+}
+
+func (f *fakeDiscoverer) Peers(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.peers, f.err
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMembership(t *testing.T) {
+	d := &fakeDiscoverer{peers: []string{"b:1", "self:1", "a:1"}}
+	var mu sync.Mutex
+	var changes [][]string
+	var errs []error
+	m := NewMembership(Config{
+		Self:            "self:1",
+		Discoverer:      d,
+		RefreshInterval: 5 * time.Millisecond,
+		OnChange: func(peers []string) {
+			mu.Lock()
+			changes = append(changes, peers)
+			mu.Unlock()
+		},
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	defer m.Stop()
+
+	if peers := m.Peers(); !slices.Equal(peers, []string{"a:1", "b:1"}) {
+		t.Fatalf("Expected [a:1 b:1] right away, got %v", peers)
+	}
+
+	// Failures keep the last known peers
+	d.set(nil, errors.New("dns down"))
+	waitFor(t, "the error to be reported", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	})
+	if peers := m.Peers(); len(peers) != 2 {
+		t.Fatalf("Expected the peers to be kept, got %v", peers)
+	}
+
+	d.set([]string{"a:1", "c:1", "self:1"}, nil)
+	waitFor(t, "c:1 to join", func() bool {
+		return slices.Equal(m.Peers(), []string{"a:1", "c:1"})
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %v", changes)
+	}
+}
+
+// fakeWatcher pushes peer lists sent on its channel
+type fakeWatcher struct {
+	fakeDiscoverer
+	updates chan []string
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, update func([]string)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case peers := <-f.updates:
+			update(peers)
+		}
+	}
+}
+
+func TestMembershipWatch(t *testing.T) {
+	w := &fakeWatcher{updates: make(chan []string)}
+	m := NewMembership(Config{Discoverer: w, RefreshInterval: time.Hour})
+	defer m.Stop()
+
+	w.updates <- []string{"a:1"}
+	waitFor(t, "the watched peer", func() bool {
+		return slices.Equal(m.Peers(), []string{"a:1"})
+	})
+}
@@ -0,0 +1,83 @@
+// Command gocachectl works with gocache snapshots offline.
+//
+// Usage:
+//
+//	gocachectl convert [-o output] [input]
+//
+// convert reads a snapshot written by any version of SaveSnapshot and
+// rewrites it in the current format, so snapshots can be migrated before a
+// fleet is upgraded. It reads standard input and writes standard output
+// unless files are given.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "gocachectl:", err)
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned for invalid command lines
+var errUsage = errors.New("usage: gocachectl convert [-o output] [input]")
+
+// run executes the command line args
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "convert":
+		return convert(args[1:], stdin, stdout)
+	}
+	return errUsage
+}
+
+// convert rewrites a snapshot in the current format
+func convert(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	output := flags.String("o", "", "write to this file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errUsage
+	}
+
+	in := stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	entries, err := gocache.ReadSnapshot(in)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return gocache.WriteSnapshot(stdout, entries)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := gocache.WriteSnapshot(f, entries); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestConvert(t *testing.T) {
+	// A version 0 snapshot, a gob stream
+	var v0 bytes.Buffer
+	gob.NewEncoder(&v0).Encode(gocache.SnapshotEntry{Key: "key", Value: []byte("value"), Format: gocache.FormatString})
+
+	var out bytes.Buffer
+	if err := run([]string{"convert"}, &v0, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("GCSNAP")) {
+		t.Fatalf("Expected a current snapshot, got %q", out.Bytes())
+	}
+
+	c := gocache.New(0)
+	if n, err := c.LoadSnapshot(bytes.NewReader(out.Bytes())); err != nil || n != 1 {
+		t.Fatalf("Expected the converted snapshot to load, got %d entries (err=%v)", n, err)
+	}
+	if value, _ := c.GetString("key"); value != "value" {
+		t.Fatalf("Expected the converted value, got %q", value)
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.snap"), filepath.Join(dir, "out.snap")
+
+	c := gocache.New(0)
+	c.Set("key", "value")
+	var buf bytes.Buffer
+	c.SaveSnapshot(&buf)
+	os.WriteFile(in, buf.Bytes(), 0o600)
+
+	if err := run([]string{"convert", "-o", out, in}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	converted, _ := os.ReadFile(out)
+	if !bytes.Equal(converted, buf.Bytes()) {
+		t.Fatalf("Expected a current snapshot to be rewritten unchanged")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if err := run(nil, nil, nil); err != errUsage {
+		t.Fatalf("Expected the usage error, got %v", err)
+	}
+	if err := run([]string{"frobnicate"}, nil, nil); err != errUsage {
+		t.Fatalf("Expected the usage error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+type xmlDoc struct {
+	Name string `xml:"name"`
+	Age  int    `xml:"age"`
+}
+
+func TestGetIntoXML(t *testing.T) {
+	c := New(0)
+
+	c.SetWithOptions("doc", xmlDoc{Name: "John", Age: 30}, WithCodec(XMLCodec))
+
+	var doc xmlDoc
+	found, err := c.GetInto("doc", &doc, XMLCodec)
+	if !found || err != nil {
+		t.Fatalf("Expected to decode XML, found=%v err=%v", found, err)
+	}
+	if doc.Name != "John" || doc.Age != 30 {
+		t.Fatalf("Retrieved doc doesn't match: %+v", doc)
+	}
+}
+
+func TestGetIntoDetect(t *testing.T) {
+	c := New(0)
+
+	c.SetRaw("xml", []byte("  <xmlDoc><name>Jane</name><age>25</age></xmlDoc>"), 0)
+	c.Set("json", xmlDoc{Name: "John", Age: 30})
+
+	var fromXML, fromJSON xmlDoc
+	if _, err := c.GetInto("xml", &fromXML, nil); err != nil || fromXML.Name != "Jane" {
+		t.Fatalf("Expected XML to be detected, got %+v (err=%v)", fromXML, err)
+	}
+	if _, err := c.GetInto("json", &fromJSON, nil); err != nil || fromJSON.Name != "John" {
+		t.Fatalf("Expected JSON to be detected, got %+v (err=%v)", fromJSON, err)
+	}
+
+	if found, _ := c.GetInto("missing", &fromJSON, nil); found {
+		t.Fatal("Expected missing key not to be found")
+	}
+}
+
+type gobInner struct {
+	Score float64
+	When  *time.Time
+}
+
+type gobOuter struct {
+	Created time.Time
+	Inner   *gobInner
+	Counts  map[string]int64
+	Nested  map[string]*gobInner
+	Any     interface{}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	RegisterGobTypes(gobInner{})
+	c := New(0)
+
+	when := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.FixedZone("X", 3600))
+	in := gobOuter{
+		Created: when,
+		Inner:   &gobInner{Score: 0.1 + 0.2, When: &when},
+		Counts:  map[string]int64{"big": 1<<62 + 1},
+		Nested:  map[string]*gobInner{"a": {Score: 1.5}},
+		Any:     gobInner{Score: 2},
+	}
+	if err := c.SetWithOptions("gob", in, WithCodec(GobCodec)); err != nil {
+		t.Fatalf("Error setting value: %v", err)
+	}
+
+	var out gobOuter
+	if _, err := c.GetInto("gob", &out, GobCodec); err != nil {
+		t.Fatalf("Error getting value: %v", err)
+	}
+
+	if !out.Created.Equal(when) || out.Created.Nanosecond() != when.Nanosecond() {
+		t.Fatalf("time.Time lost precision: %v", out.Created)
+	}
+	if _, offset := out.Created.Zone(); offset != 3600 {
+		t.Fatalf("time.Time lost its zone offset: %v", out.Created)
+	}
+	if out.Inner == nil || out.Inner.Score != in.Inner.Score || !out.Inner.When.Equal(when) {
+		t.Fatalf("Nested pointer doesn't match: %+v", out.Inner)
+	}
+	if out.Counts["big"] != 1<<62+1 {
+		t.Fatalf("Large integer lost precision: %d", out.Counts["big"])
+	}
+	if out.Nested["a"] == nil || out.Nested["a"].Score != 1.5 {
+		t.Fatalf("Map of pointers doesn't match: %+v", out.Nested)
+	}
+	if any, ok := out.Any.(gobInner); !ok || any.Score != 2 {
+		t.Fatalf("Interface field doesn't match: %#v", out.Any)
+	}
+}
@@ -0,0 +1,292 @@
+package gocache
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Compression headers prefixed to every value written by a CompressedStore
+const (
+	compressionNone  byte = 0
+	compressionFlate byte = 1
+)
+
+// compressionWindow is the number of compressed values after which a
+// namespace's recent totals are halved, so its ratio follows changing data
+const compressionWindow = 64
+
+// errCompressedValue is returned for values not written by a CompressedStore
+var errCompressedValue = errors.New("gocache: invalid compressed value")
+
+// CompressionConfig configures a CompressedStore
+type CompressionConfig struct {
+	// Level is the flate compression level, flate.DefaultCompression if 0
+	Level int
+
+	// MinSize is the smallest value compressed, 256 bytes if 0. Smaller
+	// values rarely shrink enough to pay for the CPU.
+	MinSize int
+
+	// Adaptive stops compressing the namespaces, the key prefix up to the
+	// first ':', whose values compress poorly, such as images or encrypted
+	// data, once MinSamples values were compressed. One in SampleEvery of
+	// their values is still compressed, so a namespace whose data becomes
+	// compressible again resumes compression.
+	Adaptive bool
+
+	// MaxRatio is the compressed to original size ratio above which
+	// Adaptive stops compressing a namespace, 0.9 if 0
+	MaxRatio float64
+
+	// MinSamples is the number of values compressed before Adaptive judges
+	// a namespace, 20 if 0
+	MinSamples int
+
+	// SampleEvery is the rate at which values of a skipped namespace are
+	// still compressed, 100 if 0
+	SampleEvery int
+}
+
+// CompressionStats reports how well the values of a namespace compress
+type CompressionStats struct {
+	Namespace   string // key prefix up to the first ':', empty for keys without one
+	Compressed  int64  // values compressed
+	Skipped     int64  // values stored as is because the namespace compresses poorly
+	InputBytes  int64  // size of the compressed values before compression
+	OutputBytes int64  // size of the compressed values after compression
+	Active      bool   // whether values are currently compressed
+}
+
+// Ratio returns OutputBytes divided by InputBytes, 1 if nothing was compressed
+func (s CompressionStats) Ratio() float64 {
+	if s.InputBytes == 0 {
+		return 1
+	}
+	return float64(s.OutputBytes) / float64(s.InputBytes)
+}
+
+// namespaceCompression tracks the compression of a namespace
+type namespaceCompression struct {
+	stats      CompressionStats
+	recentIn   int64 // decayed totals, see compressionWindow
+	recentOut  int64
+	recentSeen int
+	skipped    int // values skipped since the last sample
+}
+
+// CompressedStore is an OverflowStore compressing the values it passes to
+// another store, saving memory, disk or bandwidth on the overflow tier
+type CompressedStore struct {
+	store  OverflowStore
+	config CompressionConfig
+
+	mu         sync.Mutex
+	namespaces map[string]*namespaceCompression
+}
+
+// NewCompressedStore wraps store so that values are compressed with flate
+// before being stored and decompressed when loaded
+func NewCompressedStore(store OverflowStore, config CompressionConfig) *CompressedStore {
+	if config.Level == 0 {
+		config.Level = flate.DefaultCompression
+	}
+	if config.MinSize == 0 {
+		config.MinSize = 256
+	}
+	if config.MaxRatio == 0 {
+		config.MaxRatio = 0.9
+	}
+	if config.MinSamples == 0 {
+		config.MinSamples = 20
+	}
+	if config.SampleEvery == 0 {
+		config.SampleEvery = 100
+	}
+	return &CompressedStore{store: store, config: config, namespaces: make(map[string]*namespaceCompression)}
+}
+
+// Store compresses value unless it's too small or its namespace compresses
+// poorly, and keeps whichever encoding is smaller
+func (s *CompressedStore) Store(key string, value []byte, expiration int64) error {
+	ns := compressionNamespace(key)
+	if len(value) < s.config.MinSize || !s.shouldCompress(ns) {
+		return s.store.Store(key, append([]byte{compressionNone}, value...), expiration)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(compressionFlate)
+	w, err := flate.NewWriter(&buf, s.config.Level)
+	if err != nil {
+		return err
+	}
+	w.Write(value)
+	if err := w.Close(); err != nil {
+		return err
+	}
+	s.observe(ns, len(value), buf.Len()-1)
+
+	if buf.Len()-1 >= len(value) {
+		return s.store.Store(key, append([]byte{compressionNone}, value...), expiration)
+	}
+	return s.store.Store(key, buf.Bytes(), expiration)
+}
+
+func (s *CompressedStore) Load(key string) ([]byte, int64, bool, error) {
+	value, expiration, found, err := s.store.Load(key)
+	if err != nil || !found {
+		return nil, 0, found, err
+	}
+	value, err = decompress(value)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return value, expiration, true, nil
+}
+
+// LoadMany loads keys with the wrapped store's LoadMany if it's a BatchStore,
+// so a CompressedStore can be pipelined with NewPipelinedStore
+func (s *CompressedStore) LoadMany(keys []string) ([]StoredValue, error) {
+	batch, ok := s.store.(BatchStore)
+	if !ok {
+		values := make([]StoredValue, len(keys))
+		for i, key := range keys {
+			value, expiration, found, err := s.Load(key)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = StoredValue{Value: value, Expiration: expiration, Found: found}
+		}
+		return values, nil
+	}
+
+	values, err := batch.LoadMany(keys)
+	if err != nil {
+		return nil, err
+	}
+	for i := range values {
+		if !values[i].Found {
+			continue
+		}
+		if values[i].Value, err = decompress(values[i].Value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (s *CompressedStore) Delete(key string) error {
+	return s.store.Delete(key)
+}
+
+// Health reports the health of the wrapped store if it implements
+// HealthChecker
+func (s *CompressedStore) Health() error {
+	if checker, ok := s.store.(HealthChecker); ok {
+		return checker.Health()
+	}
+	return nil
+}
+
+// Stats returns the compression statistics of every namespace seen, sorted
+// by namespace
+func (s *CompressedStore) Stats() []CompressionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]CompressionStats, 0, len(s.namespaces))
+	for _, n := range s.namespaces {
+		st := n.stats
+		st.Active = s.activeLocked(n)
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Namespace < stats[j].Namespace
+	})
+	return stats
+}
+
+// shouldCompress reports whether the next value of namespace ns is
+// compressed, counting it as skipped if not
+func (s *CompressedStore) shouldCompress(ns string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.namespaceLocked(ns)
+	if s.activeLocked(n) {
+		return true
+	}
+	if n.skipped++; n.skipped >= s.config.SampleEvery {
+		n.skipped = 0
+		return true
+	}
+	n.stats.Skipped++
+	return false
+}
+
+// activeLocked reports whether values of n are compressed. The caller must
+// hold s.mu.
+func (s *CompressedStore) activeLocked(n *namespaceCompression) bool {
+	if !s.config.Adaptive || n.stats.Compressed < int64(s.config.MinSamples) || n.recentIn == 0 {
+		return true
+	}
+	return float64(n.recentOut)/float64(n.recentIn) <= s.config.MaxRatio
+}
+
+// observe records the compression of a value of namespace ns
+func (s *CompressedStore) observe(ns string, in, out int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.namespaceLocked(ns)
+	n.stats.Compressed++
+	n.stats.InputBytes += int64(in)
+	n.stats.OutputBytes += int64(out)
+	n.recentIn += int64(in)
+	n.recentOut += int64(out)
+	if n.recentSeen++; n.recentSeen >= compressionWindow {
+		n.recentIn /= 2
+		n.recentOut /= 2
+		n.recentSeen /= 2
+	}
+}
+
+// namespaceLocked returns the tracking of namespace ns, creating it if
+// needed. The caller must hold s.mu.
+func (s *CompressedStore) namespaceLocked(ns string) *namespaceCompression {
+	n, ok := s.namespaces[ns]
+	if !ok {
+		n = &namespaceCompression{stats: CompressionStats{Namespace: ns}}
+		s.namespaces[ns] = n
+	}
+	return n
+}
+
+// compressionNamespace returns the key prefix up to the first ':'
+func compressionNamespace(key string) string {
+	prefix, _, found := strings.Cut(key, ":")
+	if !found {
+		return ""
+	}
+	return prefix
+}
+
+// decompress decodes a value written by CompressedStore.Store
+func decompress(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, errCompressedValue
+	}
+	switch value[0] {
+	case compressionNone:
+		return value[1:], nil
+	case compressionFlate:
+		r := flate.NewReader(bytes.NewReader(value[1:]))
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	return nil, errCompressedValue
+}
@@ -0,0 +1,66 @@
+package gocache
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompressedStore(t *testing.T) {
+	backend := newMapBatchStore()
+	store := NewCompressedStore(backend, CompressionConfig{})
+
+	text := []byte(strings.Repeat("the quick brown fox ", 100))
+	store.Store("page:1", text, 42)
+	store.Store("tiny", []byte("abc"), 0)
+
+	if stored := backend.items["page:1"].Value; len(stored) >= len(text)/2 {
+		t.Fatalf("Expected the page to be compressed, got %d bytes", len(stored))
+	}
+	value, expiration, found, err := store.Load("page:1")
+	if err != nil || !found || !bytes.Equal(value, text) || expiration != 42 {
+		t.Fatalf("Expected the page to round trip, got %d bytes, expiration %d (found=%v, err=%v)", len(value), expiration, found, err)
+	}
+	if value, _, _, _ := store.Load("tiny"); string(value) != "abc" {
+		t.Fatalf("Expected the small value to round trip, got %q", value)
+	}
+	if _, _, found, err := store.Load("missing"); found || err != nil {
+		t.Fatalf("Expected a miss, got found=%v err=%v", found, err)
+	}
+
+	values, err := store.LoadMany([]string{"tiny", "page:1", "missing"})
+	if err != nil || string(values[0].Value) != "abc" || !bytes.Equal(values[1].Value, text) || values[2].Found {
+		t.Fatalf("Expected LoadMany to decompress, got %v (err=%v)", values, err)
+	}
+}
+
+func TestCompressedStoreAdaptive(t *testing.T) {
+	backend := newMapBatchStore()
+	store := NewCompressedStore(backend, CompressionConfig{Adaptive: true, MinSamples: 5, SampleEvery: 10})
+
+	text := []byte(strings.Repeat("the quick brown fox ", 100))
+	for i := 0; i < 50; i++ {
+		noise := make([]byte, 1000)
+		rand.Read(noise)
+		store.Store(fmt.Sprintf("img:%d", i), noise, 0)
+		store.Store(fmt.Sprintf("page:%d", i), text, 0)
+
+		if value, _, _, _ := store.Load(fmt.Sprintf("img:%d", i)); !bytes.Equal(value, noise) {
+			t.Fatalf("Expected img:%d to round trip", i)
+		}
+	}
+
+	stats := store.Stats()
+	if len(stats) != 2 || stats[0].Namespace != "img" || stats[1].Namespace != "page" {
+		t.Fatalf("Expected stats for img and page, got %+v", stats)
+	}
+	img, page := stats[0], stats[1]
+	if img.Active || img.Skipped < 40 || img.Compressed > 10 || img.Ratio() < 0.9 {
+		t.Fatalf("Expected random images to stop being compressed, got %+v", img)
+	}
+	if !page.Active || page.Compressed != 50 || page.Skipped != 0 || page.Ratio() > 0.1 {
+		t.Fatalf("Expected pages to keep being compressed, got %+v", page)
+	}
+}
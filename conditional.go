@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"bytes"
+	"time"
+)
+
+// DeleteIfEquals deletes the item stored under key only if its value still
+// equals expected, as returned by GetBytes, and reports whether it did. This
+// lets a lock holder release a lock by its token without deleting a newer
+// entry written after its own expired.
+func (c *Cache) DeleteIfEquals(key string, expected []byte) bool {
+	return c.deleteIf(key, func(item Item) bool {
+		return bytes.Equal(item.Value, expected)
+	})
+}
+
+// DeleteIfVersion deletes the item stored under key only if it wasn't
+// written since Version returned version, and reports whether it did
+func (c *Cache) DeleteIfVersion(key string, version uint64) bool {
+	return c.deleteIf(key, func(item Item) bool {
+		return item.version == version
+	})
+}
+
+// Version returns the version of the item stored under key. Versions change
+// on every write, so they identify one particular value of a key.
+func (c *Cache) Version(key string) (uint64, bool) {
+	if c.overflow != nil {
+		c.lookup(key) // fault the item in if it was spilled
+	}
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	version := c.liveVersionLocked(key, time.Now().UnixNano())
+	return version, version != 0
+}
+
+// GetIfChanged returns the raw bytes and version of the item stored under
+// key if its version differs from lastVersion, as returned by an earlier
+// call or Version, 0 for none. Pollers such as config consumers can then
+// skip decoding values they already have. A missing key is reported as a
+// change to version 0, unless lastVersion is 0 already.
+func (c *Cache) GetIfChanged(key string, lastVersion uint64) (value []byte, version uint64, changed bool) {
+	item, found := c.get(key)
+	if !found {
+		return nil, 0, lastVersion != 0
+	}
+	if item.version == lastVersion {
+		return nil, lastVersion, false
+	}
+	return item.Value, item.version, true
+}
+
+// deleteIf deletes the live item stored under key if match reports true for
+// it, atomically with respect to other writes
+func (c *Cache) deleteIf(key string, match func(Item) bool) bool {
+	if c.overflow != nil {
+		c.lookup(key) // fault the item in if it was spilled
+	}
+	key = c.mapKey(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, found := c.items[key]
+	if !found || c.staleLocked(item, time.Now().UnixNano()) || !match(item) {
+		return false
+	}
+	c.deleteLocked(key, EventDelete)
+	return true
+}
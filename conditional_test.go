@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteIfEquals(t *testing.T) {
+	c := New(0)
+	c.SetWithExpiration("lock", []byte("token-1"), time.Minute)
+
+	// Another holder took over the lock
+	c.SetWithExpiration("lock", []byte("token-2"), time.Minute)
+	if c.DeleteIfEquals("lock", []byte("token-1")) {
+		t.Fatal("Expected a stale token not to release the lock")
+	}
+	if !c.Exists("lock") {
+		t.Fatal("Expected the newer lock to stay")
+	}
+	if !c.DeleteIfEquals("lock", []byte("token-2")) || c.Exists("lock") {
+		t.Fatal("Expected the current token to release the lock")
+	}
+	if c.DeleteIfEquals("lock", []byte("token-2")) {
+		t.Fatal("Expected nothing to delete")
+	}
+}
+
+func TestDeleteIfVersion(t *testing.T) {
+	c := New(0)
+	c.Set("key", "a")
+	version, found := c.Version("key")
+	if !found || version == 0 {
+		t.Fatal("Expected a version for key")
+	}
+
+	c.Set("key", "a")
+	if c.DeleteIfVersion("key", version) {
+		t.Fatal("Expected a rewritten item not to be deleted")
+	}
+	version, _ = c.Version("key")
+	if !c.DeleteIfVersion("key", version) || c.Exists("key") {
+		t.Fatal("Expected the item to be deleted")
+	}
+	if _, found := c.Version("key"); found {
+		t.Fatal("Expected no version for a missing key")
+	}
+}
+
+func TestGetIfChanged(t *testing.T) {
+	c := New(0)
+	if _, version, changed := c.GetIfChanged("config", 0); changed || version != 0 {
+		t.Fatalf("Expected no change for a missing key, got version %d (changed=%v)", version, changed)
+	}
+
+	c.Set("config", "v1")
+	value, version, changed := c.GetIfChanged("config", 0)
+	if !changed || string(value) != "v1" || version == 0 {
+		t.Fatalf("Expected the new value, got %q version %d (changed=%v)", value, version, changed)
+	}
+	if value, again, changed := c.GetIfChanged("config", version); changed || value != nil || again != version {
+		t.Fatalf("Expected no change, got %q version %d (changed=%v)", value, again, changed)
+	}
+
+	c.Set("config", "v2")
+	value, next, changed := c.GetIfChanged("config", version)
+	if !changed || string(value) != "v2" || next == version {
+		t.Fatalf("Expected the updated value, got %q version %d (changed=%v)", value, next, changed)
+	}
+
+	c.Delete("config")
+	if value, gone, changed := c.GetIfChanged("config", next); !changed || value != nil || gone != 0 {
+		t.Fatalf("Expected the deletion to be reported, got %q version %d (changed=%v)", value, gone, changed)
+	}
+}
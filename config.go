@@ -0,0 +1,212 @@
+package gocache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration read from configuration files as a string
+// such as "90s" or "5m"
+type Duration time.Duration
+
+// UnmarshalText parses a duration in time.ParseDuration syntax
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration like time.Duration.String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// NamespaceConfig is the configuration of a NamespacePolicy
+type NamespaceConfig struct {
+	Prefix  string   `json:"prefix" yaml:"prefix"`
+	MinTTL  Duration `json:"min_ttl" yaml:"min_ttl"`
+	MaxTTL  Duration `json:"max_ttl" yaml:"max_ttl"`
+	NoStore []string `json:"no_store" yaml:"no_store"`
+}
+
+// Config holds the tunables of a cache, so operators can adjust them in a
+// configuration file or environment variables instead of code
+type Config struct {
+	CleanupInterval Duration          `json:"cleanup_interval" yaml:"cleanup_interval"`
+	DefaultTTL      Duration          `json:"default_ttl" yaml:"default_ttl"`
+	MaxTTL          Duration          `json:"max_ttl" yaml:"max_ttl"`
+	MaxIdle         Duration          `json:"max_idle" yaml:"max_idle"`
+	MaxBytes        int64             `json:"max_bytes" yaml:"max_bytes"`
+	Namespaces      []NamespaceConfig `json:"namespaces" yaml:"namespaces"`
+}
+
+// Options returns the options configuring a cache as described by cfg
+func (cfg Config) Options() []Option {
+	var opts []Option
+	if cfg.DefaultTTL > 0 {
+		opts = append(opts, WithDefaultTTL(time.Duration(cfg.DefaultTTL)))
+	}
+	if cfg.MaxTTL > 0 {
+		opts = append(opts, WithMaxTTL(time.Duration(cfg.MaxTTL)))
+	}
+	if cfg.MaxIdle > 0 {
+		opts = append(opts, WithMaxIdle(time.Duration(cfg.MaxIdle)))
+	}
+	if cfg.MaxBytes > 0 {
+		opts = append(opts, WithMaxBytes(cfg.MaxBytes))
+	}
+	for _, ns := range cfg.namespaces() {
+		opts = append(opts, WithNamespacePolicy(ns.prefix, ns.policy))
+	}
+	return opts
+}
+
+// namespaces returns the configured namespace policies
+func (cfg Config) namespaces() []namespace {
+	namespaces := make([]namespace, len(cfg.Namespaces))
+	for i, ns := range cfg.Namespaces {
+		namespaces[i] = namespace{prefix: ns.Prefix, policy: NamespacePolicy{
+			MinTTL:  time.Duration(ns.MinTTL),
+			MaxTTL:  time.Duration(ns.MaxTTL),
+			NoStore: ns.NoStore,
+		}}
+	}
+	return namespaces
+}
+
+// NewFromConfig creates a cache configured by cfg. opts are applied after
+// the configuration, for settings that only make sense in code such as
+// WithOverflow.
+func NewFromConfig(cfg Config, opts ...Option) *Cache {
+	return New(time.Duration(cfg.CleanupInterval), append(cfg.Options(), opts...)...)
+}
+
+// LoadConfig reads a JSON configuration file. Use yamlcodec.LoadConfig for
+// YAML files.
+//
+//	{"cleanup_interval": "1m", "default_ttl": "10m", "max_bytes": 67108864,
+//	 "namespaces": [{"prefix": "session:", "max_ttl": "30m"}]}
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		return cfg, fmt.Errorf("gocache: %s: use yamlcodec.LoadConfig for YAML", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("gocache: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides cfg with the environment variables prefix_CLEANUP_INTERVAL,
+// prefix_DEFAULT_TTL, prefix_MAX_TTL, prefix_MAX_IDLE and prefix_MAX_BYTES that
+// are set, e.g. CACHE_DEFAULT_TTL=5m with the prefix "CACHE"
+func (cfg *Config) ApplyEnv(prefix string) error {
+	durations := map[string]*Duration{
+		"CLEANUP_INTERVAL": &cfg.CleanupInterval,
+		"DEFAULT_TTL":      &cfg.DefaultTTL,
+		"MAX_TTL":          &cfg.MaxTTL,
+		"MAX_IDLE":         &cfg.MaxIdle,
+	}
+	for name, d := range durations {
+		if v, ok := os.LookupEnv(prefix + "_" + name); ok {
+			if err := d.UnmarshalText([]byte(v)); err != nil {
+				return fmt.Errorf("gocache: %s_%s: %w", prefix, name, err)
+			}
+		}
+	}
+	if v, ok := os.LookupEnv(prefix + "_MAX_BYTES"); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("gocache: %s_MAX_BYTES: %w", prefix, err)
+		}
+		cfg.MaxBytes = n
+	}
+	return nil
+}
+
+// shrinkBatch is the number of items evicted per lock acquisition when
+// ApplyConfig lowers the size limit
+const shrinkBatch = 256
+
+// ApplyConfig reconfigures a running cache: the cleanup interval, default
+// TTL, maximum TTL, maximum idle time, size limit and namespace policies are
+// replaced by those in cfg, e.g. from a config file watcher. Items already
+// stored keep their expiration. Reads only count towards MaxIdle if the cache
+// was created with access tracking, see WithMaxIdle. Lowering MaxBytes evicts
+// the excess in small batches, releasing the lock in between, so readers
+// aren't stalled for the whole shrink.
+func (c *Cache) ApplyConfig(cfg Config) {
+	c.defaultTTL.Store(int64(cfg.DefaultTTL))
+
+	namespaces := cfg.namespaces()
+	sortNamespaces(namespaces)
+
+	c.namespaces.Store(&namespaces)
+
+	c.mu.Lock()
+	c.maxTTL = time.Duration(cfg.MaxTTL)
+	c.maxIdle = int64(cfg.MaxIdle)
+	c.maxBytes = cfg.MaxBytes
+	c.mu.Unlock()
+
+	c.shrink()
+
+	c.setCleanupInterval(time.Duration(cfg.CleanupInterval))
+}
+
+// shrinkCandidate is an item in the eviction order computed by shrink
+type shrinkCandidate struct {
+	key     string
+	version uint64
+}
+
+// shrink evicts items until the cache fits within its limits, shrinkBatch at
+// a time, releasing the lock in between. The eviction order is computed once
+// and walked batch by batch: items changed meanwhile are skipped, and the
+// order is only recomputed if it runs out while the cache is still over.
+func (c *Cache) shrink() {
+	var order []shrinkCandidate
+	for {
+		c.mu.Lock()
+		if !c.overBudgetLocked() {
+			c.mu.Unlock()
+			return
+		}
+		if c.evictionSamples > 0 {
+			evicted := c.evictSampledLocked(shrinkBatch)
+			c.mu.Unlock()
+			if evicted == 0 {
+				return // only pinned items are left
+			}
+			continue
+		}
+		if len(order) == 0 {
+			for _, key := range c.evictionOrderLocked() {
+				order = append(order, shrinkCandidate{key: key, version: c.items[key].version})
+			}
+			if len(order) == 0 {
+				c.mu.Unlock()
+				return // only pinned items are left
+			}
+		}
+		for i := 0; i < shrinkBatch && len(order) > 0 && c.overBudgetLocked(); i++ {
+			cand := order[0]
+			order = order[1:]
+			if item, found := c.items[cand.key]; found && item.version == cand.version && evictable(item) {
+				c.evictKeyLocked(cand.key)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
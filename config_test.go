@@ -0,0 +1,127 @@
+package gocache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	os.WriteFile(path, []byte(`{
+		"default_ttl": "10m",
+		"max_ttl": "1h",
+		"namespaces": [{"prefix": "session:", "max_ttl": "30m", "no_store": ["session:*:card"]}]
+	}`), 0o600)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewFromConfig(cfg)
+
+	c.Set("plain", "v")
+	if ttl, _ := c.TTL("plain"); ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Fatalf("Expected the default TTL of 10m, got %v", ttl)
+	}
+	c.SetWithExpiration("session:1", "v", 2*time.Hour)
+	if ttl, _ := c.TTL("session:1"); ttl > 30*time.Minute {
+		t.Fatalf("Expected the namespace cap of 30m, got %v", ttl)
+	}
+	if err := c.Set("session:1:card", "v"); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Expected ErrNoStore, got %v", err)
+	}
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	t.Setenv("CACHE_DEFAULT_TTL", "5m")
+	t.Setenv("CACHE_MAX_BYTES", "2048")
+	t.Setenv("CACHE_MAX_IDLE", "15m")
+
+	cfg := Config{DefaultTTL: Duration(time.Minute), MaxTTL: Duration(time.Hour)}
+	if err := cfg.ApplyEnv("CACHE"); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.DefaultTTL) != 5*time.Minute || cfg.MaxBytes != 2048 || time.Duration(cfg.MaxTTL) != time.Hour ||
+		time.Duration(cfg.MaxIdle) != 15*time.Minute {
+		t.Fatalf("Expected env overrides on top of the file, got %+v", cfg)
+	}
+
+	t.Setenv("CACHE_MAX_TTL", "soon")
+	if err := cfg.ApplyEnv("CACHE"); err == nil {
+		t.Fatal("Expected an error for an invalid duration")
+	}
+}
+
+func TestLoadConfigRejectsYAML(t *testing.T) {
+	if _, err := LoadConfig("cache.yaml"); err == nil {
+		t.Fatal("Expected LoadConfig to point YAML files to yamlcodec")
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	c := New(0)
+	defer c.StopJanitor()
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), "0123456789")
+	}
+	size := c.Size()
+
+	c.ApplyConfig(Config{
+		CleanupInterval: Duration(time.Millisecond),
+		DefaultTTL:      Duration(time.Millisecond),
+		MaxBytes:        size / 2,
+		Namespaces:      []NamespaceConfig{{Prefix: "tmp:", NoStore: []string{"tmp:*"}}},
+	})
+
+	if c.Size() > size/2 {
+		t.Fatalf("Expected the cache to shrink to %d bytes, got %d", size/2, c.Size())
+	}
+	if !c.Exists("999") || c.Exists("0") {
+		t.Fatal("Expected the oldest items to be evicted first")
+	}
+	if err := c.Set("tmp:x", "v"); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Expected the new namespace policy to apply, got %v", err)
+	}
+
+	c.Set("short", "v")
+	time.Sleep(20 * time.Millisecond)
+	c.mu.RLock()
+	_, found := c.items["short"]
+	c.mu.RUnlock()
+	if found {
+		t.Fatal("Expected the restarted janitor to remove the item expired by the new default TTL")
+	}
+
+	c.ApplyConfig(Config{})
+	c.Set("forever", "v")
+	if ttl, _ := c.TTL("forever"); ttl != -1 {
+		t.Fatalf("Expected the default TTL to be cleared, got %v", ttl)
+	}
+}
+
+func TestApplyConfigShrink(t *testing.T) {
+	c := New(0, WithSampledEviction(5))
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), "0123456789")
+	}
+	size := c.Size()
+	c.ApplyConfig(Config{MaxBytes: size / 4})
+	if c.Size() > size/4 {
+		t.Fatalf("Expected sampled eviction to shrink the cache to %d bytes, got %d", size/4, c.Size())
+	}
+
+	// Shrinking stops once only pinned items are left
+	c = New(0)
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), "0123456789")
+		c.Pin(strconv.Itoa(i))
+	}
+	c.ApplyConfig(Config{MaxBytes: 1})
+	if c.Count() != 10 {
+		t.Fatalf("Expected pinned items to be kept, got %d items", c.Count())
+	}
+}
@@ -0,0 +1,202 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/chaostest"
+	"github.com/babashankar/go-cache/httpserver"
+)
+
+// cacheStore adapts a *gocache.Cache
+type cacheStore struct {
+	c *gocache.Cache
+}
+
+// FromCache returns a Store for c, implementing CompareAndSwapper with
+// Watch and Flusher
+func FromCache(c *gocache.Cache) Store {
+	return cacheStore{c}
+}
+
+func (s cacheStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, found := s.c.GetBytes(key)
+	return value, found, nil
+}
+
+func (s cacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.c.SetRaw(key, bytes.Clone(value), ttl)
+}
+
+func (s cacheStore) Delete(ctx context.Context, key string) error {
+	s.c.Delete(key)
+	return nil
+}
+
+func (s cacheStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	w := s.c.Watch(key)
+	current, found := s.c.GetBytes(key)
+	if found != (old != nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+	err := w.Exec(gocache.SetOp(key, bytes.Clone(value), ttl))
+	if errors.Is(err, gocache.ErrConflict) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s cacheStore) Flush(ctx context.Context) error {
+	s.c.Flush()
+	return nil
+}
+
+// cacherStore adapts a chaostest.Cacher
+type cacherStore struct {
+	c chaostest.Cacher
+}
+
+// FromCacher returns a Store for any Cacher. Values are stored as strings,
+// which every Cacher stores verbatim.
+func FromCacher(c chaostest.Cacher) Store {
+	return cacherStore{c}
+}
+
+func (s cacherStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value string
+	found, err := s.c.Get(key, &value)
+	if !found || err != nil {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+func (s cacherStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.c.SetWithExpiration(key, string(value), ttl)
+}
+
+func (s cacherStore) Delete(ctx context.Context, key string) error {
+	s.c.Delete(key)
+	return nil
+}
+
+// httpStore talks to a cache served by httpserver
+type httpStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+// FromHTTP returns a Store for the cache served by an httpserver.Handler at
+// baseURL, or any server speaking the same API. It implements
+// CompareAndSwapper with If-Match and If-None-Match, and Flusher with
+// DELETE /. client sends the requests, http.DefaultClient if nil.
+func FromHTTP(baseURL string, client *http.Client) Store {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpStore{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (s httpStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, "", nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		value, err := io.ReadAll(resp.Body)
+		return value, err == nil, err
+	case http.StatusNotFound:
+		return nil, false, nil
+	}
+	return nil, false, statusError(resp)
+}
+
+func (s httpStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := s.put(ctx, key, value, ttl, nil)
+	return err
+}
+
+func (s httpStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return statusError(resp)
+	}
+	return nil
+}
+
+func (s httpStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	header := http.Header{}
+	if old == nil {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", httpserver.ETag(old))
+	}
+	return s.put(ctx, key, value, ttl, header)
+}
+
+func (s httpStore) Flush(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodDelete, "", "", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// put stores value, reporting false if a precondition in header failed
+func (s httpStore) put(ctx context.Context, key string, value []byte, ttl time.Duration, header http.Header) (bool, error) {
+	query := ""
+	if ttl > 0 {
+		query = "ttl=" + ttl.String()
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, query, header, value)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusPreconditionFailed:
+		return false, nil
+	}
+	return false, statusError(resp)
+}
+
+func (s httpStore) do(ctx context.Context, method, key, query string, header http.Header, body []byte) (*http.Response, error) {
+	target := s.baseURL + "/" + url.PathEscape(key)
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return s.client.Do(req)
+}
+
+// statusError describes an unexpected response
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("conformance: %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, bytes.TrimSpace(msg))
+}
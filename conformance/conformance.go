@@ -0,0 +1,314 @@
+// Package conformance is a black-box test suite for caches, so adapters and
+// server modes are held to the same behavior: reads see writes, TTLs expire,
+// large and binary values round-trip, compare-and-swap is atomic under
+// contention and flushes remove everything. Run it from a test:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, conformance.Config{
+//			New: func(t *testing.T) conformance.Store {
+//				return conformance.FromHTTP(startServer(t), nil)
+//			},
+//		})
+//	}
+//
+// Adapters are provided for *gocache.Cache, any chaostest.Cacher and the
+// HTTP API of httpserver.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Store is the byte-oriented view of a cache exercised by the suite
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key, with no expiration if ttl is 0
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// CompareAndSwapper is a Store with atomic conditional writes. Stores
+// implementing it are also tested for CAS under contention.
+type CompareAndSwapper interface {
+	Store
+
+	// CompareAndSwap stores value under key if key currently holds old, or
+	// is missing when old is nil, and reports whether it did
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error)
+}
+
+// Flusher is a Store that can remove every key. Stores implementing it are
+// also tested for flush semantics.
+type Flusher interface {
+	Store
+
+	Flush(ctx context.Context) error
+}
+
+// Config configures a Run
+type Config struct {
+	// New returns an empty store. It's called once per test, so tests don't
+	// see each other's keys.
+	New func(t *testing.T) Store
+
+	// TTL is the short TTL used to check expiration, 100ms if 0. Stores with
+	// coarse expiration, e.g. in whole seconds, need a larger value.
+	TTL time.Duration
+
+	// MaxValueSize is the largest value the store must accept, 1 MiB if 0
+	MaxValueSize int
+
+	// Workers and Increments shape the CAS contention test: every worker
+	// increments a shared counter Increments times. 8 and 50 if 0.
+	Workers    int
+	Increments int
+}
+
+// Run runs the suite as subtests of t. The CompareAndSwap and ConcurrentCAS
+// checks are skipped for stores that don't implement CompareAndSwapper, and
+// Flush for stores that don't implement Flusher. Of the provided adapters,
+// FromCache and FromHTTP run every check; FromCacher skips CompareAndSwap,
+// ConcurrentCAS and Flush, as Cacher has no conditional write or flush.
+func Run(t *testing.T, config Config) {
+	if config.TTL <= 0 {
+		config.TTL = 100 * time.Millisecond
+	}
+	if config.MaxValueSize <= 0 {
+		config.MaxValueSize = 1 << 20
+	}
+	if config.Workers <= 0 {
+		config.Workers = 8
+	}
+	if config.Increments <= 0 {
+		config.Increments = 50
+	}
+
+	tests := []struct {
+		name string
+		fn   func(*testing.T, Config, Store)
+	}{
+		{"SetGet", testSetGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"TTL", testTTL},
+		{"LargeValues", testLargeValues},
+		{"BinaryValues", testBinaryValues},
+		{"CompareAndSwap", testCompareAndSwap},
+		{"ConcurrentCAS", testConcurrentCAS},
+		{"Flush", testFlush},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, config, config.New(t))
+		})
+	}
+}
+
+// mustGet returns the value of key, failing the test on error
+func mustGet(t *testing.T, s Store, key string) ([]byte, bool) {
+	t.Helper()
+	value, found, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): expected no error, got %v", key, err)
+	}
+	return value, found
+}
+
+// mustSet stores value under key, failing the test on error
+func mustSet(t *testing.T, s Store, key string, value []byte, ttl time.Duration) {
+	t.Helper()
+	if err := s.Set(context.Background(), key, value, ttl); err != nil {
+		t.Fatalf("Set(%q): expected no error, got %v", key, err)
+	}
+}
+
+func testSetGet(t *testing.T, config Config, s Store) {
+	if _, found := mustGet(t, s, "conformance:missing"); found {
+		t.Fatalf("Expected a missing key not to be found")
+	}
+	mustSet(t, s, "conformance:k", []byte("v"), 0)
+	if value, found := mustGet(t, s, "conformance:k"); !found || string(value) != "v" {
+		t.Fatalf("Expected v, got %q, %v", value, found)
+	}
+	mustSet(t, s, "conformance:empty", []byte{}, 0)
+	if value, found := mustGet(t, s, "conformance:empty"); !found || len(value) != 0 {
+		t.Fatalf("Expected an empty value to be found, got %q, %v", value, found)
+	}
+}
+
+func testOverwrite(t *testing.T, config Config, s Store) {
+	mustSet(t, s, "conformance:k", []byte("first"), 0)
+	mustSet(t, s, "conformance:k", []byte("second"), 0)
+	if value, _ := mustGet(t, s, "conformance:k"); string(value) != "second" {
+		t.Fatalf("Expected second, got %q", value)
+	}
+}
+
+func testDelete(t *testing.T, config Config, s Store) {
+	ctx := context.Background()
+	mustSet(t, s, "conformance:k", []byte("v"), 0)
+	mustSet(t, s, "conformance:other", []byte("v"), 0)
+	if err := s.Delete(ctx, "conformance:k"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, found := mustGet(t, s, "conformance:k"); found {
+		t.Fatalf("Expected the deleted key not to be found")
+	}
+	if _, found := mustGet(t, s, "conformance:other"); !found {
+		t.Fatalf("Expected other keys to be kept")
+	}
+	if err := s.Delete(ctx, "conformance:missing"); err != nil {
+		t.Fatalf("Expected deleting a missing key to succeed, got %v", err)
+	}
+}
+
+func testTTL(t *testing.T, config Config, s Store) {
+	mustSet(t, s, "conformance:short", []byte("v"), config.TTL)
+	mustSet(t, s, "conformance:forever", []byte("v"), 0)
+	if _, found := mustGet(t, s, "conformance:short"); !found {
+		t.Fatalf("Expected the key to be found before its TTL")
+	}
+
+	// Allow generous slack for clock granularity and lazy expiration
+	deadline := time.Now().Add(10*config.TTL + time.Second)
+	for {
+		if _, found := mustGet(t, s, "conformance:short"); !found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the key to expire after %v", config.TTL)
+		}
+		time.Sleep(config.TTL / 10)
+	}
+	if _, found := mustGet(t, s, "conformance:forever"); !found {
+		t.Fatalf("Expected a key without TTL to be kept")
+	}
+
+	// Overwriting without a TTL clears the old one
+	mustSet(t, s, "conformance:renewed", []byte("v"), config.TTL)
+	mustSet(t, s, "conformance:renewed", []byte("v"), 0)
+	time.Sleep(2 * config.TTL)
+	if _, found := mustGet(t, s, "conformance:renewed"); !found {
+		t.Fatalf("Expected overwriting to clear the TTL")
+	}
+}
+
+func testLargeValues(t *testing.T, config Config, s Store) {
+	for _, size := range []int{config.MaxValueSize / 4, config.MaxValueSize} {
+		value := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+		key := fmt.Sprintf("conformance:large:%d", size)
+		mustSet(t, s, key, value, 0)
+		if got, found := mustGet(t, s, key); !found || !bytes.Equal(got, value) {
+			t.Fatalf("Expected %d bytes back, got %d, %v", size, len(got), found)
+		}
+	}
+}
+
+func testBinaryValues(t *testing.T, config Config, s Store) {
+	value := make([]byte, 512)
+	for i := range value {
+		value[i] = byte(i)
+	}
+	mustSet(t, s, "conformance:binary", value, 0)
+	if got, _ := mustGet(t, s, "conformance:binary"); !bytes.Equal(got, value) {
+		t.Fatalf("Expected every byte value to round-trip, got %v", got)
+	}
+}
+
+func testCompareAndSwap(t *testing.T, config Config, s Store) {
+	cas, ok := s.(CompareAndSwapper)
+	if !ok {
+		t.Skip("store doesn't implement CompareAndSwapper")
+	}
+	ctx := context.Background()
+	key := "conformance:cas"
+
+	if swapped, err := cas.CompareAndSwap(ctx, key, nil, []byte("1"), 0); err != nil || !swapped {
+		t.Fatalf("Expected creating a missing key to succeed, got %v, %v", swapped, err)
+	}
+	if swapped, err := cas.CompareAndSwap(ctx, key, nil, []byte("x"), 0); err != nil || swapped {
+		t.Fatalf("Expected creating an existing key to fail, got %v, %v", swapped, err)
+	}
+	if swapped, err := cas.CompareAndSwap(ctx, key, []byte("0"), []byte("x"), 0); err != nil || swapped {
+		t.Fatalf("Expected a stale swap to fail, got %v, %v", swapped, err)
+	}
+	if swapped, err := cas.CompareAndSwap(ctx, key, []byte("1"), []byte("2"), 0); err != nil || !swapped {
+		t.Fatalf("Expected a current swap to succeed, got %v, %v", swapped, err)
+	}
+	if value, _ := mustGet(t, s, key); string(value) != "2" {
+		t.Fatalf("Expected 2, got %q", value)
+	}
+}
+
+func testConcurrentCAS(t *testing.T, config Config, s Store) {
+	cas, ok := s.(CompareAndSwapper)
+	if !ok {
+		t.Skip("store doesn't implement CompareAndSwapper")
+	}
+	ctx := context.Background()
+	key := "conformance:counter"
+	mustSet(t, s, key, []byte("0"), 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, config.Workers)
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < config.Increments; {
+				old, _, err := s.Get(ctx, key)
+				if err != nil {
+					errs <- err
+					return
+				}
+				var n int
+				fmt.Sscan(string(old), &n)
+				swapped, err := cas.CompareAndSwap(ctx, key, old, []byte(fmt.Sprint(n+1)), 0)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if swapped {
+					i++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := fmt.Sprint(config.Workers * config.Increments)
+	if value, _ := mustGet(t, s, key); string(value) != expected {
+		t.Fatalf("Expected every increment to be kept, %s, got %s", expected, value)
+	}
+}
+
+func testFlush(t *testing.T, config Config, s Store) {
+	f, ok := s.(Flusher)
+	if !ok {
+		t.Skip("store doesn't implement Flusher")
+	}
+	for i := 0; i < 10; i++ {
+		mustSet(t, s, fmt.Sprintf("conformance:flush:%d", i), []byte("v"), 0)
+	}
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, found := mustGet(t, s, fmt.Sprintf("conformance:flush:%d", i)); found {
+			t.Fatalf("Expected every key to be flushed, found %d", i)
+		}
+	}
+	mustSet(t, s, "conformance:after", []byte("v"), 0)
+	if _, found := mustGet(t, s, "conformance:after"); !found {
+		t.Fatalf("Expected writes after a flush to be kept")
+	}
+}
@@ -0,0 +1,38 @@
+package conformance
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/chaostest"
+	"github.com/babashankar/go-cache/httpserver"
+)
+
+func TestCache(t *testing.T) {
+	Run(t, Config{
+		New: func(t *testing.T) Store {
+			return FromCache(gocache.New(0))
+		},
+	})
+}
+
+func TestCacher(t *testing.T) {
+	Run(t, Config{
+		New: func(t *testing.T) Store {
+			// A fault-free injector still goes through the Cacher wrapper
+			return FromCacher(chaostest.Wrap(gocache.New(0), chaostest.New(chaostest.Config{})))
+		},
+	})
+}
+
+func TestHTTPServer(t *testing.T) {
+	Run(t, Config{
+		New: func(t *testing.T) Store {
+			server := httptest.NewServer(httpserver.NewHandler(gocache.New(0)))
+			t.Cleanup(server.Close)
+			return FromHTTP(server.URL, nil)
+		},
+		MaxValueSize: httpserver.DefaultMaxValueSize,
+	})
+}
@@ -0,0 +1,256 @@
+// Package dataset keeps a large, read-mostly dataset such as a GeoIP
+// database or a product catalog in a gocache.Cache and reloads it on a
+// schedule:
+//
+//	ds := &dataset.Dataset{
+//		Cache:    c,
+//		Name:     "countries",
+//		Source:   dataset.File("countries.csv"),
+//		Parse:    dataset.CSV(0),
+//		Interval: time.Hour,
+//	}
+//	if err := ds.Start(ctx); err != nil { ... }
+//	var row []string
+//	found, err := ds.Get("DE", &row)
+//
+// Each version is loaded in full next to the current one and then swapped in
+// atomically, so lookups never see a partial or empty dataset.
+package dataset
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// ErrNotLoaded is returned by lookups before the first load succeeded
+var ErrNotLoaded = errors.New("dataset: not loaded")
+
+// Source fetches the raw dataset. It returns a version string, such as a
+// modification time or an ETag, and a nil reader if the dataset hasn't
+// changed since the given version.
+type Source func(ctx context.Context, version string) (r io.ReadCloser, newVersion string, err error)
+
+// Parser reads a dataset from r, calling add for every record
+type Parser func(r io.Reader, add func(key string, value interface{}) error) error
+
+// Dataset is a dataset held in a cache
+type Dataset struct {
+	Cache  *gocache.Cache
+	Name   string
+	Source Source
+	Parse  Parser
+
+	// Interval is how often the dataset is reloaded after Start, 0 for
+	// never
+	Interval time.Duration
+
+	// OnError, if set, is called with the errors of scheduled reloads. The
+	// current version stays in use when a reload fails.
+	OnError func(error)
+
+	mu         sync.Mutex // serializes loads
+	generation uint64     // guarded by mu
+	version    string     // source version of the current generation
+	current    atomic.Pointer[string]
+	stop       chan struct{}
+}
+
+// Start loads the dataset and reloads it every Interval until Stop is
+// called or ctx is done
+func (d *Dataset) Start(ctx context.Context) error {
+	if err := d.Reload(ctx); err != nil {
+		return err
+	}
+	if d.Interval <= 0 {
+		return nil
+	}
+
+	d.stop = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		ticker := time.NewTicker(d.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.Reload(ctx); err != nil && d.OnError != nil {
+					d.OnError(err)
+				}
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(d.stop)
+	return nil
+}
+
+// Stop stops the scheduled reloads. The loaded dataset stays available.
+func (d *Dataset) Stop() {
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
+
+// Reload loads the dataset unless the source reports it unchanged, swaps it
+// in and removes the previous version
+func (d *Dataset) Reload(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, version, err := d.Source(ctx, d.version)
+	if err != nil {
+		return fmt.Errorf("dataset %s: %w", d.Name, err)
+	}
+	if r == nil {
+		return nil // unchanged
+	}
+	defer r.Close()
+
+	prefix := d.prefix(d.generation + 1)
+	tag := prefix
+	err = d.Parse(r, func(key string, value interface{}) error {
+		return d.Cache.SetWithOptions(prefix+key, value, gocache.WithTags(tag), gocache.WithPriority(gocache.PriorityPinned))
+	})
+	if err != nil {
+		d.Cache.InvalidateTag(tag)
+		return fmt.Errorf("dataset %s: %w", d.Name, err)
+	}
+
+	old := d.current.Load()
+	d.generation++
+	d.version = version
+	d.current.Store(&prefix)
+	if old != nil {
+		d.Cache.InvalidateTag(*old)
+	}
+	return nil
+}
+
+// Get decodes the record stored under key into target
+func (d *Dataset) Get(key string, target interface{}) (bool, error) {
+	prefix := d.current.Load()
+	if prefix == nil {
+		return false, ErrNotLoaded
+	}
+	return d.Cache.Get(*prefix+key, target)
+}
+
+// GetBytes returns the raw record stored under key
+func (d *Dataset) GetBytes(key string) ([]byte, bool) {
+	prefix := d.current.Load()
+	if prefix == nil {
+		return nil, false
+	}
+	return d.Cache.GetBytes(*prefix + key)
+}
+
+// Version returns the source version of the loaded dataset
+func (d *Dataset) Version() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.version
+}
+
+// prefix returns the key prefix of a generation, which doubles as its tag
+func (d *Dataset) prefix(generation uint64) string {
+	return "dataset:" + d.Name + ":" + strconv.FormatUint(generation, 10) + ":"
+}
+
+// File returns a Source reading the file at path, versioned by its
+// modification time and size
+func File(path string) Source {
+	return func(ctx context.Context, version string) (io.ReadCloser, string, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, "", err
+		}
+		newVersion := info.ModTime().UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(info.Size(), 10)
+		if newVersion == version {
+			return nil, version, nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return f, newVersion, nil
+	}
+}
+
+// URL returns a Source downloading url with client, http.DefaultClient if
+// nil, versioned by the response's ETag or Last-Modified header
+func URL(client *http.Client, url string) Source {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, version string) (io.ReadCloser, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		if etag, ok := strings.CutPrefix(version, "etag:"); ok {
+			req.Header.Set("If-None-Match", etag)
+		} else if modified, ok := strings.CutPrefix(version, "modified:"); ok {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		switch resp.StatusCode {
+		case http.StatusNotModified:
+			resp.Body.Close()
+			return nil, version, nil
+		case http.StatusOK:
+		default:
+			resp.Body.Close()
+			return nil, "", fmt.Errorf("fetching %s: %s", url, resp.Status)
+		}
+
+		newVersion := ""
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			newVersion = "etag:" + etag
+		} else if modified := resp.Header.Get("Last-Modified"); modified != "" {
+			newVersion = "modified:" + modified
+		}
+		return resp.Body, newVersion, nil
+	}
+}
+
+// CSV returns a Parser storing each CSV record as a []string under the
+// value of its keyColumn. Records may have varying numbers of fields.
+func CSV(keyColumn int) Parser {
+	return func(r io.Reader, add func(key string, value interface{}) error) error {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if keyColumn >= len(record) {
+				return fmt.Errorf("record %v has no column %d", record, keyColumn)
+			}
+			if err := add(record[keyColumn], record); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestDatasetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.csv")
+	os.WriteFile(path, []byte("DE,Germany\nFR,France\n"), 0o644)
+
+	c := gocache.New(0)
+	ds := &Dataset{Cache: c, Name: "countries", Source: File(path), Parse: CSV(0)}
+	ctx := context.Background()
+
+	var row []string
+	if _, err := ds.Get("DE", &row); err != ErrNotLoaded {
+		t.Fatalf("Expected ErrNotLoaded, got %v", err)
+	}
+	if err := ds.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := ds.Get("DE", &row); !found || err != nil || !reflect.DeepEqual(row, []string{"DE", "Germany"}) {
+		t.Fatalf("Expected the DE record, got %v (found=%v err=%v)", row, found, err)
+	}
+
+	// An unchanged file isn't reloaded
+	version := ds.Version()
+	ds.Reload(ctx)
+	if ds.Version() != version || c.Count() != 2 {
+		t.Fatalf("Expected no reload, got version %q and %d items", ds.Version(), c.Count())
+	}
+
+	os.WriteFile(path, []byte("DE,Deutschland\nIT,Italy\nES,Spain\n"), 0o644)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	if err := ds.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := ds.Get("FR", &row); found {
+		t.Fatal("Expected records of the old version to be gone")
+	}
+	if ds.Get("DE", &row); row[1] != "Deutschland" {
+		t.Fatalf("Expected the new version, got %v", row)
+	}
+	if c.Count() != 3 {
+		t.Fatalf("Expected only the new version to be cached, got %d items", c.Count())
+	}
+}
+
+func TestDatasetKeepsVersionOnError(t *testing.T) {
+	fail := false
+	source := func(ctx context.Context, version string) (io.ReadCloser, string, error) {
+		if fail {
+			return io.NopCloser(strings.NewReader("A,1\n\"broken\n")), "2", nil
+		}
+		return io.NopCloser(strings.NewReader("A,1\n")), "1", nil
+	}
+	c := gocache.New(0)
+	ds := &Dataset{Cache: c, Name: "ds", Source: source, Parse: CSV(0)}
+	ctx := context.Background()
+	ds.Start(ctx)
+
+	fail = true
+	if err := ds.Reload(ctx); err == nil {
+		t.Fatal("Expected the parse error")
+	}
+	if _, found := ds.GetBytes("A"); !found || ds.Version() != "1" || c.Count() != 1 {
+		t.Fatal("Expected the previous version to stay in use and the partial load to be removed")
+	}
+}
+
+func TestDatasetURLScheduledReload(t *testing.T) {
+	var requests atomic.Int32
+	var body atomic.Value
+	body.Store("a,1\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		etag := `"` + body.Load().(string) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, body.Load().(string))
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 10)
+	ds := &Dataset{
+		Cache:    gocache.New(0),
+		Name:     "remote",
+		Source:   URL(nil, srv.URL),
+		Parse:    CSV(0),
+		Interval: time.Millisecond,
+		OnError:  func(err error) { errs <- err },
+	}
+	if err := ds.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Stop()
+
+	body.Store("b,2\n")
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, found := ds.GetBytes("b"); found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the scheduled reload to pick up the new version")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	failing := &Dataset{Cache: gocache.New(0), Name: "x", Source: URL(nil, missing.URL), Parse: CSV(0)}
+	if err := failing.Start(context.Background()); err == nil {
+		t.Fatal("Expected the fetch error")
+	}
+	if _, err := failing.Get("a", new([]string)); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("Expected ErrNotLoaded, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package gocache
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// recentEvictionsLimit is the number of evictions kept for the debug page
+const recentEvictionsLimit = 64
+
+// debugTopKeys is the number of largest keys listed on the debug page
+const debugTopKeys = 20
+
+// debugValueLimit truncates values shown on the debug page
+const debugValueLimit = 1024
+
+// Eviction is an item recently removed to free memory or after expiring
+type Eviction struct {
+	Key   string // as shown by DisplayKey, see UnsafeKey
+	Event Event
+
+	rawKey string
+}
+
+// recordEvictionLocked remembers an eviction or expiration for the debug
+// page. The caller must hold the write lock.
+func (c *Cache) recordEvictionLocked(key string, op EventOp, item Item) {
+	e := Eviction{Key: c.DisplayKey(key), rawKey: key, Event: Event{Op: op, Time: time.Now(), Size: len(item.Value), Version: item.version}}
+	if len(c.evictions) < recentEvictionsLimit {
+		c.evictions = append(c.evictions, e)
+		return
+	}
+	copy(c.evictions, c.evictions[1:])
+	c.evictions[len(c.evictions)-1] = e
+}
+
+// RecentEvictions returns the most recently evicted and expired items,
+// oldest first
+func (c *Cache) RecentEvictions() []Eviction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Eviction(nil), c.evictions...)
+}
+
+// debugKeySize is a key listed on the debug page
+type debugKeySize struct {
+	Key  string
+	Size int64
+}
+
+// debugNamespace aggregates the keys sharing a prefix
+type debugNamespace struct {
+	Prefix string
+	Count  int
+	Bytes  int64
+}
+
+// debugItem describes a single inspected key
+type debugItem struct {
+	Key       string
+	Found     bool
+	Value     string
+	Truncated bool
+	Size      int
+	Format    Format
+	TTL       string
+	Version   uint64
+	Created   time.Time
+	Priority  Priority
+	Pinned    bool
+	Weak      bool
+	Tags      []string
+	Deps      []string
+	History   []Event
+}
+
+// debugPage is the data rendered by the debug handler
+type debugPage struct {
+	Entries    int
+	Expired    int
+	Size       int64
+	Estimated  int64
+	Cost       int64
+	Generation uint64
+	TopKeys    []debugKeySize
+	Namespaces []debugNamespace
+	Evictions  []Eviction
+	Query      string
+	Item       *debugItem
+	Private    bool // keys are hidden, see WithKeyHashing
+}
+
+// DebugHandler returns an http.Handler serving a human-readable page with
+// the cache's stats, largest keys, a breakdown by namespace (the key prefix
+// up to the first ':'), recent evictions and a form to inspect a single key.
+// Mount it like net/http/pprof, e.g. on /debug/gocache, and don't expose it
+// publicly: it shows cached values. Keys are shown as DisplayKey returns
+// them; hidden keys can still be inspected by entering the raw key.
+func (c *Cache) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := c.debugPage(r.URL.Query().Get("key"))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, page)
+	})
+}
+
+// debugPage gathers the data shown by the debug handler
+func (c *Cache) debugPage(query string) debugPage {
+	page := debugPage{Query: query, Private: c.keyPrivacy != nil}
+	if page.Private {
+		page.Query = ""
+	}
+	namespaces := make(map[string]*debugNamespace)
+	now := time.Now().UnixNano()
+
+	c.mu.RLock()
+	page.Entries = len(c.items)
+	page.Size = c.size
+	page.Cost = c.cost
+	page.Generation = c.generation
+	page.Evictions = append([]Eviction(nil), c.evictions...)
+	for k, v := range c.items {
+		size := v.estimatedSize(k)
+		page.Estimated += size
+		if c.staleLocked(v, now) {
+			page.Expired++
+		}
+		page.TopKeys = append(page.TopKeys, debugKeySize{Key: c.DisplayKey(k), Size: size})
+
+		prefix, _, found := strings.Cut(k, ":")
+		if !found {
+			prefix = ""
+		}
+		ns, ok := namespaces[prefix]
+		if !ok {
+			ns = &debugNamespace{Prefix: prefix}
+			namespaces[prefix] = ns
+		}
+		ns.Count++
+		ns.Bytes += size
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(page.TopKeys, func(i, j int) bool {
+		return page.TopKeys[i].Size > page.TopKeys[j].Size
+	})
+	if len(page.TopKeys) > debugTopKeys {
+		page.TopKeys = page.TopKeys[:debugTopKeys]
+	}
+
+	for _, ns := range namespaces {
+		page.Namespaces = append(page.Namespaces, *ns)
+	}
+	sort.Slice(page.Namespaces, func(i, j int) bool {
+		return page.Namespaces[i].Bytes > page.Namespaces[j].Bytes
+	})
+
+	if query != "" {
+		page.Item = c.debugItem(query)
+	}
+	return page
+}
+
+// debugItem describes the item stored under key
+func (c *Cache) debugItem(key string) *debugItem {
+	d := &debugItem{Key: c.DisplayKey(key), History: c.History(key)}
+	mapped := c.mapKey(key)
+
+	c.mu.RLock()
+	item, found := c.items[mapped]
+	if found && !c.staleLocked(item, time.Now().UnixNano()) {
+		d.Found = true
+		d.Size = len(item.Value)
+		d.Format = item.format
+		d.Version = item.version
+		d.Created = time.Unix(0, item.Created)
+		d.Priority = item.priority
+		d.Pinned = item.pinned
+		d.Weak = item.weak
+		d.Tags = item.tags
+		for _, dep := range item.deps {
+			d.Deps = append(d.Deps, c.DisplayKey(dep))
+		}
+
+		value := item.Value
+		if len(value) > debugValueLimit {
+			value, d.Truncated = value[:debugValueLimit], true
+		}
+		if utf8.Valid(value) {
+			d.Value = string(value)
+		} else {
+			d.Value = strings.ToValidUTF8(string(value), "�")
+		}
+	}
+	c.mu.RUnlock()
+
+	if d.Found {
+		if ttl, err := c.TTL(key); err == nil {
+			if ttl < 0 {
+				d.TTL = "none"
+			} else {
+				d.TTL = ttl.Round(time.Millisecond).String()
+			}
+		}
+	}
+	return d
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>gocache</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+pre { background: #f4f4f4; padding: 8px; white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>gocache</h1>
+
+<h2>Stats</h2>
+<table>
+<tr><th>Entries</th><td>{{.Entries}}</td></tr>
+<tr><th>Expired, not yet removed</th><td>{{.Expired}}</td></tr>
+<tr><th>Key and value bytes</th><td>{{.Size}}</td></tr>
+<tr><th>Estimated memory</th><td>{{.Estimated}}</td></tr>
+<tr><th>Cost</th><td>{{.Cost}}</td></tr>
+<tr><th>Generation</th><td>{{.Generation}}</td></tr>
+</table>
+
+<h2>Inspect key</h2>
+<form method="get">
+<input type="text" name="key" value="{{.Query}}" size="60">
+<input type="submit" value="Inspect">
+</form>
+{{with .Item}}
+{{if .Found}}
+<table>
+<tr><th>Key</th><td>{{.Key}}</td></tr>
+<tr><th>Size</th><td>{{.Size}}</td></tr>
+<tr><th>Format</th><td>{{.Format}}</td></tr>
+<tr><th>TTL</th><td>{{.TTL}}</td></tr>
+<tr><th>Created</th><td>{{.Created}}</td></tr>
+<tr><th>Version</th><td>{{.Version}}</td></tr>
+<tr><th>Priority</th><td>{{.Priority}}</td></tr>
+<tr><th>Pinned</th><td>{{.Pinned}}</td></tr>
+<tr><th>Weak</th><td>{{.Weak}}</td></tr>
+<tr><th>Tags</th><td>{{range .Tags}}{{.}} {{end}}</td></tr>
+<tr><th>Depends on</th><td>{{range .Deps}}{{.}} {{end}}</td></tr>
+</table>
+<pre>{{.Value}}{{if .Truncated}}...{{end}}</pre>
+{{else}}
+<p>Key {{printf "%q" .Key}} not found.</p>
+{{end}}
+{{if .History}}
+<table>
+<tr><th>Time</th><th>Operation</th><th>Size</th><th>Version</th></tr>
+{{range .History}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Op}}</td><td>{{.Size}}</td><td>{{.Version}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+
+<h2>Largest keys</h2>
+<table>
+<tr><th>Key</th><th>Bytes</th></tr>
+{{range .TopKeys}}<tr><td>{{if $.Private}}{{.Key}}{{else}}<a href="?key={{.Key}}">{{.Key}}</a>{{end}}</td><td>{{.Size}}</td></tr>
+{{end}}</table>
+
+<h2>Namespaces</h2>
+<table>
+<tr><th>Prefix</th><th>Entries</th><th>Bytes</th></tr>
+{{range .Namespaces}}<tr><td>{{if .Prefix}}{{.Prefix}}:{{else}}(none){{end}}</td><td>{{.Count}}</td><td>{{.Bytes}}</td></tr>
+{{end}}</table>
+
+<h2>Recent evictions</h2>
+<table>
+<tr><th>Time</th><th>Key</th><th>Reason</th><th>Size</th></tr>
+{{range .Evictions}}<tr><td>{{.Event.Time.Format "15:04:05.000"}}</td><td>{{.Key}}</td><td>{{.Event.Op}}</td><td>{{.Event.Size}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
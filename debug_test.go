@@ -0,0 +1,52 @@
+package gocache
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugHandler(t *testing.T) {
+	c := New(time.Hour)
+	c.SetWithExpiration("user:1", "<b>alice</b>", time.Minute)
+	c.SetWithExpiration("user:2", "bob", time.Minute)
+	c.SetWithExpiration("session:abc", "token", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+
+	evictions := c.RecentEvictions()
+	if len(evictions) != 1 || evictions[0].Key != "session:abc" || evictions[0].Event.Op != EventExpire {
+		t.Fatalf("Expected session:abc to be recorded as expired, got %v", evictions)
+	}
+
+	srv := httptest.NewServer(c.DebugHandler())
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "?key=user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	page := string(body)
+
+	for _, want := range []string{"user:", "session:abc", "expire", "&lt;b&gt;alice&lt;/b&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("Expected debug page to contain %q, got %s", want, page)
+		}
+	}
+	if strings.Contains(page, "<b>alice</b>") {
+		t.Fatal("Expected values to be escaped")
+	}
+}
+
+func TestDebugHandlerMissingKey(t *testing.T) {
+	c := New(time.Hour)
+	rec := httptest.NewRecorder()
+	c.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/gocache?key=nope", nil))
+	if !strings.Contains(rec.Body.String(), "not found") {
+		t.Fatalf("Expected not found message, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package gocache
+
+import (
+	"bytes"
+	"hash/maphash"
+	"unique"
+)
+
+// WithKeyInterning canonicalizes keys with the unique package, so that the
+// key strings held by the item map, dependency graph and tag index share one
+// copy per distinct key
+func WithKeyInterning() Option {
+	return func(c *Cache) {
+		c.internKeys = true
+	}
+}
+
+// WithValueDedup makes items with identical value bytes share a single
+// backing slice, reference counted and released once the last item using it
+// is removed. It pays off when the same value, such as a rendered fragment,
+// is stored under many keys, at the cost of hashing every value on Set.
+func WithValueDedup() Option {
+	return func(c *Cache) {
+		c.dedup = newDedupTable()
+	}
+}
+
+// internKey returns the canonical copy of key
+func internKey(key string) string {
+	return unique.Make(key).Value()
+}
+
+// sharedValue is a value referenced by one or more items
+type sharedValue struct {
+	value []byte
+	refs  int
+}
+
+// dedupTable tracks shared values by content hash. It is guarded by the
+// cache's lock.
+type dedupTable struct {
+	seed   maphash.Seed
+	values map[uint64]*sharedValue
+}
+
+func newDedupTable() *dedupTable {
+	return &dedupTable{
+		seed:   maphash.MakeSeed(),
+		values: make(map[uint64]*sharedValue),
+	}
+}
+
+// acquire returns the shared copy of value, registering value as the shared
+// copy if none exists yet. The boolean reports whether the result is tracked
+// by the table and must be released.
+func (t *dedupTable) acquire(value []byte) ([]byte, bool) {
+	h := maphash.Bytes(t.seed, value)
+	shared, ok := t.values[h]
+	if !ok {
+		t.values[h] = &sharedValue{value: value, refs: 1}
+		return value, true
+	}
+	if !bytes.Equal(shared.value, value) {
+		// Hash collision, keep the value private
+		return value, false
+	}
+	shared.refs++
+	return shared.value, true
+}
+
+// release drops one reference to a shared value
+func (t *dedupTable) release(value []byte) {
+	h := maphash.Bytes(t.seed, value)
+	shared, ok := t.values[h]
+	if !ok {
+		return
+	}
+	shared.refs--
+	if shared.refs <= 0 {
+		delete(t.values, h)
+	}
+}
+
+// SharedValues returns the number of distinct values tracked by value
+// deduplication, or 0 if it is disabled
+func (c *Cache) SharedValues() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.dedup == nil {
+		return 0
+	}
+	return len(c.dedup.values)
+}
@@ -0,0 +1,53 @@
+package gocache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValueDedup(t *testing.T) {
+	c := New(0, WithValueDedup())
+	fragment := strings.Repeat("<li>item</li>", 100)
+
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("page:%d", i), fragment)
+	}
+	c.Set("other", "different")
+
+	if n := c.SharedValues(); n != 2 {
+		t.Fatalf("Expected 2 distinct values, got %d", n)
+	}
+
+	a, _ := c.GetBytes("page:0")
+	b, _ := c.GetBytes("page:9")
+	if &a[0] != &b[0] {
+		t.Fatal("Identical values should share the same backing slice")
+	}
+
+	// The shared value is released once its last reference goes
+	for i := 0; i < 9; i++ {
+		c.Delete(fmt.Sprintf("page:%d", i))
+	}
+	if n := c.SharedValues(); n != 2 {
+		t.Fatalf("Expected 2 distinct values while page:9 remains, got %d", n)
+	}
+	c.Set("page:9", "changed")
+	if n := c.SharedValues(); n != 2 {
+		t.Fatalf("Expected the fragment to be released, got %d values", n)
+	}
+
+	c.Flush()
+	if n := c.SharedValues(); n != 0 {
+		t.Fatalf("Expected no shared values after flush, got %d", n)
+	}
+}
+
+func TestKeyInterning(t *testing.T) {
+	c := New(0, WithKeyInterning())
+
+	c.Set(strings.Repeat("k", 2), "value")
+	if val, found := c.GetString("kk"); !found || val != "value" {
+		t.Fatalf("Expected interned key to be found, got '%s' (found=%v)", val, found)
+	}
+}
@@ -0,0 +1,122 @@
+package gocache
+
+import "time"
+
+// SetWithDeps adds an item to the cache that depends on the given keys.
+// Deleting, updating or expiring any of the dependencies invalidates the item,
+// so derived entries never outlive their inputs. Dependencies don't need to
+// exist yet; setting one later still invalidates the dependent item.
+func (c *Cache) SetWithDeps(key string, value interface{}, deps ...string) error {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := encode(value)
+	if err != nil {
+		return err
+	}
+	deps = c.mapKeys(deps)
+
+	own := valueExpiration(value)
+	item := Item{
+		Value:      bytes,
+		Expiration: own,
+		own:        own,
+		deps:       append([]string(nil), deps...),
+		format:     formatOf(value),
+		typeID:     c.fingerprint(value),
+	}
+	store := func(item Item) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		var ok bool
+		if item.Expiration, ok = c.depsExpirationLocked(item.Expiration, deps); !ok {
+			// An input is already gone, so there's nothing valid to cache
+			c.deleteLocked(key, EventInvalidate)
+			return nil
+		}
+		c.setLocked(key, item)
+		return nil
+	}
+	if c.middleware != nil {
+		return c.storeThrough(key, item, store)
+	}
+	return store(item)
+}
+
+// depsExpirationLocked caps expiration so the item doesn't outlive the earliest
+// expiring dependency. It returns false if a dependency has already expired.
+// The caller must hold the lock.
+func (c *Cache) depsExpirationLocked(expiration int64, deps []string) (int64, bool) {
+	now := time.Now().UnixNano()
+	for _, dep := range deps {
+		item, found := c.items[dep]
+		if !found || item.Expiration == 0 {
+			continue
+		}
+		if c.staleLocked(item, now) {
+			return 0, false
+		}
+		if expiration == 0 || item.Expiration < expiration {
+			expiration = item.Expiration
+		}
+	}
+	return expiration, true
+}
+
+// Dependents returns the keys that directly depend on the given key
+func (c *Cache) Dependents(key string) []string {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.dependents[key]))
+	for k := range c.dependents[key] {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// linkDepsLocked records key as a dependent of each of deps.
+// The caller must hold the write lock.
+func (c *Cache) linkDepsLocked(key string, deps []string) {
+	for _, dep := range deps {
+		set, ok := c.dependents[dep]
+		if !ok {
+			set = make(map[string]struct{})
+			c.dependents[dep] = set
+		}
+		set[key] = struct{}{}
+	}
+}
+
+// unlinkDepsLocked removes key from the dependent sets of deps.
+// The caller must hold the write lock.
+func (c *Cache) unlinkDepsLocked(key string, deps []string) {
+	for _, dep := range deps {
+		set := c.dependents[dep]
+		delete(set, key)
+		if len(set) == 0 {
+			delete(c.dependents, dep)
+		}
+	}
+}
+
+// invalidateDependentsLocked deletes every item that depends on key, recursively.
+// The caller must hold the write lock.
+func (c *Cache) invalidateDependentsLocked(key string) {
+	set, ok := c.dependents[key]
+	if !ok {
+		return
+	}
+	delete(c.dependents, key)
+
+	for dependent := range set {
+		// deleteLocked unlinks the dependent and cascades further; cycles
+		// terminate because each key's dependent set is removed before recursing
+		c.deleteLocked(dependent, EventInvalidate)
+	}
+}
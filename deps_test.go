@@ -0,0 +1,75 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDepsCascade(t *testing.T) {
+	c := New(time.Minute)
+	defer c.StopJanitor()
+
+	c.Set("price", "10")
+	c.Set("qty", "3")
+	c.SetWithDeps("total", "30", "price", "qty")
+	c.SetWithDeps("report", "total is 30", "total")
+
+	// Updating a dependency invalidates everything derived from it
+	c.Set("price", "11")
+
+	if c.Exists("total") {
+		t.Fatal("total should be invalidated when price changes")
+	}
+	if c.Exists("report") {
+		t.Fatal("report should be invalidated transitively")
+	}
+	if !c.Exists("qty") {
+		t.Fatal("qty should not be affected")
+	}
+
+	// Deleting a dependency also cascades
+	c.SetWithDeps("total", "33", "price", "qty")
+	c.Delete("qty")
+	if c.Exists("total") {
+		t.Fatal("total should be invalidated when qty is deleted")
+	}
+	if len(c.Dependents("price")) != 0 {
+		t.Fatalf("price should have no dependents left, has %v", c.Dependents("price"))
+	}
+}
+
+func TestDepsExpiration(t *testing.T) {
+	c := New(0)
+
+	c.SetWithExpiration("input", "value", 100*time.Millisecond)
+	c.SetWithDeps("derived", "derived value", "input")
+
+	ttl, err := c.TTL("derived")
+	if err != nil {
+		t.Fatalf("Error getting TTL: %v", err)
+	}
+	if ttl <= 0 || ttl > 100*time.Millisecond {
+		t.Fatalf("derived TTL should be capped by its input, got %v", ttl)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if c.Exists("derived") {
+		t.Fatal("derived should expire with its input")
+	}
+}
+
+func TestDepsMissingInput(t *testing.T) {
+	c := New(0)
+
+	// Depending on a key that doesn't exist yet is allowed
+	c.SetWithDeps("a", "1", "b")
+	if !c.Exists("a") {
+		t.Fatal("a should be stored")
+	}
+
+	// Setting the input later invalidates the dependent
+	c.Set("b", "2")
+	if c.Exists("a") {
+		t.Fatal("a should be invalidated when b is set")
+	}
+}
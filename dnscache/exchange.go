@@ -0,0 +1,138 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// errTruncated is returned by query for answers that didn't fit a UDP packet
+var errTruncated = errors.New("dnscache: truncated answer")
+
+// answer is the result of a single query
+type answer struct {
+	addrs    []string
+	ttl      time.Duration // smallest TTL of the addresses
+	notFound bool
+	err      error
+}
+
+// exchange resolves host by querying Server for A and AAAA records
+func (r *Resolver) exchange(ctx context.Context, host string) ([]string, time.Duration, error) {
+	name, err := dnsmessage.NewName(strings.ToLower(strings.TrimSuffix(host, ".")) + ".")
+	if err != nil {
+		return nil, 0, &net.DNSError{Err: err.Error(), Name: host}
+	}
+
+	answers := make(chan answer, 2)
+	for _, typ := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		go func(typ dnsmessage.Type) {
+			answers <- r.query(ctx, name, typ)
+		}(typ)
+	}
+
+	var addrs []string
+	var ttl time.Duration
+	notFound := false
+	for i := 0; i < 2; i++ {
+		a := <-answers
+		switch {
+		case errors.Is(a.err, errTruncated):
+			// Let the system resolver retry over TCP
+			addrs, err := r.resolver().LookupHost(ctx, host)
+			return addrs, r.ttl(), err
+		case a.err != nil:
+			return nil, 0, &net.DNSError{Err: a.err.Error(), Name: host, Server: r.Server}
+		}
+		notFound = notFound || a.notFound
+		if len(a.addrs) > 0 && (len(addrs) == 0 || a.ttl < ttl) {
+			ttl = a.ttl
+		}
+		addrs = append(addrs, a.addrs...)
+	}
+
+	if notFound || len(addrs) == 0 {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: r.Server, IsNotFound: true}
+	}
+	return addrs, ttl, nil
+}
+
+// query sends a single question to Server over UDP
+func (r *Resolver) query(ctx context.Context, name dnsmessage.Name, typ dnsmessage.Type) answer {
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: typ, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := msg.Pack()
+	if err != nil {
+		return answer{err: err}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", r.Server)
+	if err != nil {
+		return answer{err: err}
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(packet); err != nil {
+		return answer{err: err}
+	}
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return answer{err: err}
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err != nil || resp.ID != id || !resp.Response {
+			continue // not the answer to our question
+		}
+		return parseAnswer(resp, typ)
+	}
+}
+
+// parseAnswer extracts the addresses of type typ from a response
+func parseAnswer(resp dnsmessage.Message, typ dnsmessage.Type) answer {
+	switch {
+	case resp.Truncated:
+		return answer{err: errTruncated}
+	case resp.RCode == dnsmessage.RCodeNameError:
+		return answer{notFound: true}
+	case resp.RCode != dnsmessage.RCodeSuccess:
+		return answer{err: errors.New("server returned " + resp.RCode.String())}
+	}
+
+	var a answer
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != typ {
+			continue // e.g. the CNAME chain leading to the addresses
+		}
+		var addr netip.Addr
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			addr = netip.AddrFrom4(body.A)
+		case *dnsmessage.AAAAResource:
+			addr = netip.AddrFrom16(body.AAAA)
+		default:
+			continue
+		}
+		a.addrs = append(a.addrs, addr.String())
+		if ttl := time.Duration(rr.Header.TTL) * time.Second; len(a.addrs) == 1 || ttl < a.ttl {
+			a.ttl = ttl
+		}
+	}
+	return a
+}
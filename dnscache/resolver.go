@@ -0,0 +1,214 @@
+// Package dnscache provides a caching DNS resolver backed by a
+// gocache.Cache, for services resolving the same names at high volume:
+//
+//	r := &dnscache.Resolver{Cache: c, Server: "10.0.0.2:53"}
+//	transport := &http.Transport{DialContext: r.DialContext(&net.Dialer{})}
+//
+// Resolver has the lookup methods of net.Resolver. With Server set, address
+// lookups query that server directly so answers are cached for exactly as
+// long as their TTLs allow; otherwise they go through net.Resolver and are
+// cached for TTL. Names that don't exist are cached for NegativeTTL.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// keyPrefix namespaces the keys written by Resolver
+const keyPrefix = "dns:"
+
+// Resolver looks up names through a cache
+type Resolver struct {
+	Cache *gocache.Cache
+
+	// Resolver performs lookups, net.DefaultResolver if nil
+	Resolver *net.Resolver
+
+	// Server is the address of a DNS server, such as "10.0.0.2:53", to
+	// query directly for addresses so their TTLs can be honored. If empty,
+	// addresses are looked up with Resolver and cached for TTL.
+	Server string
+
+	// TTL is how long answers without a known TTL are cached, one minute
+	// if 0
+	TTL time.Duration
+
+	// MinTTL and MaxTTL bound the TTLs taken from DNS answers. 0 leaves
+	// them unbounded.
+	MinTTL, MaxTTL time.Duration
+
+	// NegativeTTL is how long nonexistent names are cached, 0 for not at
+	// all
+	NegativeTTL time.Duration
+}
+
+// entry is a cached answer
+type entry struct {
+	Values   []string `json:"v,omitempty"`
+	NotFound bool     `json:"nx,omitempty"`
+}
+
+// LookupHost looks up the addresses of host
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+	return r.lookup(ctx, "ip:", host, r.lookupAddrs)
+}
+
+// LookupIPAddr looks up the IP addresses of host
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IPAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		ip, zone, _ := strings.Cut(addr, "%")
+		ips = append(ips, net.IPAddr{IP: net.ParseIP(ip), Zone: zone})
+	}
+	return ips, nil
+}
+
+// LookupIP looks up the IP addresses of host for network, which must be
+// "ip", "ip4" or "ip6"
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	switch network {
+	case "ip", "ip4", "ip6":
+	default:
+		return nil, net.UnknownNetworkError(network)
+	}
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		is4 := addr.IP.To4() != nil
+		if (network == "ip4" && !is4) || (network == "ip6" && is4) {
+			continue
+		}
+		ips = append(ips, addr.IP)
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+// LookupCNAME looks up the canonical name of host, cached for TTL
+func (r *Resolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	values, err := r.lookup(ctx, "cname:", host, func(ctx context.Context, host string) ([]string, time.Duration, error) {
+		cname, err := r.resolver().LookupCNAME(ctx, host)
+		return []string{cname}, r.ttl(), err
+	})
+	if err != nil {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// LookupTXT looks up the TXT records of name, cached for TTL
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.lookup(ctx, "txt:", name, func(ctx context.Context, name string) ([]string, time.Duration, error) {
+		txt, err := r.resolver().LookupTXT(ctx, name)
+		return txt, r.ttl(), err
+	})
+}
+
+// DialContext returns a dial function for http.Transport and similar that
+// resolves host names through r and dials the addresses in turn with dialer
+func (r *Resolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var firstErr error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return nil, firstErr
+	}
+}
+
+// lookup returns the cached answer for name, calling fn on a miss
+func (r *Resolver) lookup(ctx context.Context, kind, name string, fn func(context.Context, string) ([]string, time.Duration, error)) ([]string, error) {
+	key := keyPrefix + kind + strings.ToLower(strings.TrimSuffix(name, "."))
+
+	var e entry
+	if found, err := r.Cache.Get(key, &e); found && err == nil {
+		if e.NotFound {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return e.Values, nil
+	}
+
+	values, ttl, err := fn(ctx, name)
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		if r.NegativeTTL > 0 {
+			r.Cache.SetWithExpiration(key, entry{NotFound: true}, r.NegativeTTL)
+		}
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	if ttl = r.clamp(ttl); ttl > 0 {
+		r.Cache.SetWithExpiration(key, entry{Values: values}, ttl)
+	}
+	return values, nil
+}
+
+// lookupAddrs resolves host to addresses along with their TTL
+func (r *Resolver) lookupAddrs(ctx context.Context, host string) ([]string, time.Duration, error) {
+	if r.Server != "" {
+		return r.exchange(ctx, host)
+	}
+	addrs, err := r.resolver().LookupHost(ctx, host)
+	return addrs, r.ttl(), err
+}
+
+// clamp applies MinTTL and MaxTTL to a TTL from a DNS answer
+func (r *Resolver) clamp(ttl time.Duration) time.Duration {
+	if r.MinTTL > 0 && ttl < r.MinTTL {
+		ttl = r.MinTTL
+	}
+	if r.MaxTTL > 0 && ttl > r.MaxTTL {
+		ttl = r.MaxTTL
+	}
+	return ttl
+}
+
+func (r *Resolver) ttl() time.Duration {
+	if r.TTL > 0 {
+		return r.TTL
+	}
+	return time.Minute
+}
+
+func (r *Resolver) resolver() *net.Resolver {
+	if r.Resolver != nil {
+		return r.Resolver
+	}
+	return net.DefaultResolver
+}
@@ -0,0 +1,154 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeServer answers A queries for example.test. and NXDOMAIN for anything
+// else, counting queries
+type fakeServer struct {
+	conn    net.PacketConn
+	queries atomic.Int32
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{conn: conn}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		s.queries.Add(1)
+
+		q := req.Questions[0]
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: req.ID, Response: true},
+			Questions: req.Questions,
+		}
+		switch {
+		case q.Name.String() != "example.test.":
+			resp.RCode = dnsmessage.RCodeNameError
+		case q.Type == dnsmessage.TypeA:
+			for i, ttl := range []uint32{300, 2} {
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+					Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, byte(i + 1)}},
+				})
+			}
+		}
+		packet, _ := resp.Pack()
+		s.conn.WriteTo(packet, addr)
+	}
+}
+
+func TestResolverServer(t *testing.T) {
+	srv := newFakeServer(t)
+	c := gocache.New(0)
+	r := &Resolver{Cache: c, Server: srv.conn.LocalAddr().String(), NegativeTTL: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		addrs, err := r.LookupHost(ctx, "Example.test")
+		if err != nil || len(addrs) != 2 || addrs[0] != "192.0.2.1" {
+			t.Fatalf("Expected both addresses, got %v (%v)", addrs, err)
+		}
+	}
+	if n := srv.queries.Load(); n != 2 {
+		t.Fatalf("Expected one A and one AAAA query, got %d", n)
+	}
+	if ttl, _ := c.TTL(keyPrefix + "ip:example.test"); ttl > 2*time.Second || ttl < time.Second {
+		t.Fatalf("Expected the smallest answer TTL, got %v", ttl)
+	}
+
+	ips, err := r.LookupIP(ctx, "ip6", "example.test")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound || ips != nil {
+		t.Fatalf("Expected no IPv6 addresses, got %v (%v)", ips, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := r.LookupHost(ctx, "missing.test")
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("Expected a not found error, got %v", err)
+		}
+	}
+	if n := srv.queries.Load(); n != 4 {
+		t.Fatalf("Expected NXDOMAIN to be cached, got %d queries", n)
+	}
+}
+
+func TestResolverClampsTTL(t *testing.T) {
+	srv := newFakeServer(t)
+	c := gocache.New(0)
+	r := &Resolver{Cache: c, Server: srv.conn.LocalAddr().String(), MinTTL: time.Minute}
+	r.LookupHost(context.Background(), "example.test")
+	if ttl, _ := c.TTL(keyPrefix + "ip:example.test"); ttl < 59*time.Second {
+		t.Fatalf("Expected MinTTL to apply, got %v", ttl)
+	}
+}
+
+func TestResolverSystem(t *testing.T) {
+	r := &Resolver{Cache: gocache.New(0)}
+	ctx := context.Background()
+
+	if addrs, err := r.LookupHost(ctx, "127.0.0.1"); err != nil || len(addrs) != 1 {
+		t.Fatalf("Expected IP literals to be returned as is, got %v (%v)", addrs, err)
+	}
+	addrs, err := r.LookupHost(ctx, "localhost")
+	if err != nil || len(addrs) == 0 {
+		t.Skipf("localhost doesn't resolve here: %v", err)
+	}
+	if !r.Cache.Exists(keyPrefix + "ip:localhost") {
+		t.Fatal("Expected the answer to be cached")
+	}
+	if _, err := r.LookupIP(ctx, "tcp", "localhost"); err == nil {
+		t.Fatal("Expected an unknown network to be rejected")
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	c := gocache.New(0)
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	r := &Resolver{Cache: c}
+	c.Set(keyPrefix+"ip:service.test", entry{Values: []string{"127.0.0.1"}})
+
+	conn, err := r.DialContext(&net.Dialer{})(context.Background(), "tcp", net.JoinHostPort("service.test", port))
+	if err != nil {
+		t.Fatalf("Expected to dial the cached address, got %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,35 @@
+// Package echocache adapts httpcache.ResponseCache to echo:
+//
+//	rc := &httpcache.ResponseCache{Cache: c, TTL: time.Minute}
+//	e.GET("/reports/:id", reportHandler, echocache.Middleware(rc))
+package echocache
+
+import (
+	"github.com/babashankar/go-cache/httpcache"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns an echo middleware serving GET requests from rc and
+// caching the responses of the handler it wraps. Cache hits skip the
+// handler.
+func Middleware(rc *httpcache.ResponseCache) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+			if rc.ServeCached(res, c.Request()) {
+				return nil
+			}
+
+			rec := rc.NewRecorder(res.Writer)
+			res.Writer = rec
+			defer func() { res.Writer = rec.Unwrap() }()
+
+			if err := next(c); err != nil {
+				// Let echo's error handler write the error response uncached
+				return err
+			}
+			rc.Store(c.Request(), rec)
+			return nil
+		}
+	}
+}
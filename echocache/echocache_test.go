@@ -0,0 +1,48 @@
+package echocache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/httpcache"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	rc := &httpcache.ResponseCache{Cache: gocache.New(0), TTL: time.Minute}
+	calls := 0
+
+	e := echo.New()
+	e.GET("/users/:id", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+	}, Middleware(rc))
+	e.GET("/fail", func(c echo.Context) error {
+		calls++
+		return errors.New("boom")
+	}, Middleware(rc))
+
+	for _, path := range []string{"/users/1", "/users/1", "/fail", "/fail"} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/users/1" && w.Body.String() != "{\"id\":\"1\"}\n" {
+			t.Fatalf("Expected the handler's response, got %q", w.Body.String())
+		}
+		if path == "/fail" && w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected the error to be handled by echo, got %d", w.Code)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("Expected the second request to be cached and errors not to be, got %d calls", calls)
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if w.Header().Get(httpcache.XFromCache) != "1" || w.Code != http.StatusOK {
+		t.Fatalf("Expected the cached response, got %d %v", w.Code, w.Header())
+	}
+}
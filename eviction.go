@@ -0,0 +1,205 @@
+package gocache
+
+import (
+	"iter"
+	"maps"
+	"sort"
+	"time"
+)
+
+// Priority controls the order in which items are evicted when the cache is full
+type Priority int
+
+const (
+	// PriorityLow items are evicted before any other priority
+	PriorityLow Priority = iota - 1
+	// PriorityNormal is the default priority
+	PriorityNormal
+	// PriorityHigh items are evicted only once no lower priority items remain
+	PriorityHigh
+	// PriorityPinned items are never evicted, though they still expire
+	PriorityPinned
+)
+
+// WithMaxBytes bounds the total size of keys and values held by the cache.
+// When a Set pushes the cache past the limit, items are evicted until it fits
+// again: weak items first, then by ascending priority, oldest first within a
+// priority (least recently used first with WithAccessTracking). Pinned items
+// are never evicted, so the limit may be exceeded if they alone don't fit.
+func WithMaxBytes(maxBytes int64) Option {
+	return func(c *Cache) {
+		c.maxBytes = maxBytes
+	}
+}
+
+// WithMaxCost bounds the total cost of the items held by the cache, where
+// the cost of each item is computed by costFn when it is set. The cost can
+// model any resource, e.g. the decoded size of a value or the upstream
+// compute time it represents, like Ristretto's cost model. Items are evicted
+// in the same order as with WithMaxBytes, and both limits can be combined.
+func WithMaxCost(maxCost int64, costFn func(key string, value []byte) int64) Option {
+	return func(c *Cache) {
+		c.maxCost = maxCost
+		c.costFn = costFn
+	}
+}
+
+// Cost returns the total cost of the items held by the cache, or 0 if no
+// cost function is configured
+func (c *Cache) Cost() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cost
+}
+
+// overBudgetLocked reports whether the cache exceeds its size or cost limit.
+// The caller must hold the lock.
+func (c *Cache) overBudgetLocked() bool {
+	return (c.maxBytes > 0 && c.size > c.maxBytes) ||
+		(c.maxCost > 0 && c.cost > c.maxCost)
+}
+
+// Size returns the total number of key and value bytes held by the cache
+func (c *Cache) Size() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.size
+}
+
+// WithSampledEviction replaces the exact eviction order with a Redis-style
+// approximation: each eviction samples the given number of items and evicts
+// the most evictable of them. This avoids sorting every item whenever the
+// cache is full, at the cost of sometimes evicting an item that isn't the
+// globally best candidate. Larger samples approximate the exact order better;
+// Redis uses 5 by default.
+func WithSampledEviction(samples int) Option {
+	return func(c *Cache) {
+		c.evictionSamples = samples
+	}
+}
+
+// evictLocked evicts items until the cache fits within its limits, or until
+// the WithEvictionLimit budget of the write is spent. The caller must hold
+// the write lock.
+func (c *Cache) evictLocked() {
+	if c.evictLimit == nil {
+		c.evictUpToLocked(-1)
+		return
+	}
+	budget := c.evictLimit.allow(time.Now().UnixNano())
+	c.evictLimit.spend(c.evictUpToLocked(budget))
+}
+
+// evictUpToLocked evicts at most budget items, or any number if budget is
+// negative, until the cache fits within its limits, and returns the number
+// of items evicted. The caller must hold the write lock.
+func (c *Cache) evictUpToLocked(budget int) int {
+	if budget == 0 || !c.overBudgetLocked() {
+		return 0
+	}
+	if c.evictionSamples > 0 {
+		return c.evictSampledLocked(budget)
+	}
+
+	evicted := 0
+	for _, key := range c.evictionOrderLocked() {
+		if !c.overBudgetLocked() || evicted == budget {
+			break
+		}
+		c.evictKeyLocked(key)
+		evicted++
+	}
+	return evicted
+}
+
+// evictKeyLocked removes an item to free memory, spilling it to the overflow
+// store if one is configured. The caller must hold the write lock.
+func (c *Cache) evictKeyLocked(key string) {
+	item, found := c.items[key]
+	c.deleteLocked(key, EventEvict)
+	if found && c.overflow != nil {
+		c.spillLocked(key, item)
+	}
+}
+
+// evictSampledLocked evicts the best of a few sampled items at a time until the
+// cache fits within its limits or budget items were evicted, and returns the
+// number of items evicted. The caller must hold the write lock.
+func (c *Cache) evictSampledLocked(budget int) int {
+	evicted := 0
+	for c.overBudgetLocked() && evicted != budget {
+		var victim string
+		var best Item
+		found := false
+		sampled := 0
+
+		// Map iteration starts at a random position, which makes the first
+		// few items a cheap random sample
+		for k, v := range c.items {
+			if !evictable(v) {
+				continue
+			}
+			if !found || evictsBefore(v, best) {
+				victim, best, found = k, v, true
+			}
+			if sampled++; sampled >= c.evictionSamples {
+				break
+			}
+		}
+
+		if !found {
+			break // Only pinned items are left
+		}
+		c.evictKeyLocked(victim)
+		evicted++
+	}
+	return evicted
+}
+
+// evictable reports whether an item may be evicted at all
+func evictable(item Item) bool {
+	return item.priority < PriorityPinned && !item.pinned
+}
+
+// evictsBefore reports whether a should be evicted before b
+func evictsBefore(a, b Item) bool {
+	if a.weak != b.weak {
+		return a.weak
+	}
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	return a.lastUsed() < b.lastUsed()
+}
+
+// evictionOrderLocked returns the keys of all evictable items, most evictable
+// first. The caller must hold the lock.
+func (c *Cache) evictionOrderLocked() []string {
+	return c.evictionOrderOfLocked(maps.Keys(c.items))
+}
+
+// evictionOrderOfLocked returns the given keys of evictable items, most
+// evictable first. The caller must hold the lock.
+func (c *Cache) evictionOrderOfLocked(keys iter.Seq[string]) []string {
+	type candidate struct {
+		key  string
+		item Item
+	}
+
+	var candidates []candidate
+	for k := range keys {
+		if v, found := c.items[k]; found && evictable(v) {
+			candidates = append(candidates, candidate{key: k, item: v})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return evictsBefore(candidates[i].item, candidates[j].item)
+	})
+
+	order := make([]string, len(candidates))
+	for i, cand := range candidates {
+		order[i] = cand.key
+	}
+	return order
+}
@@ -0,0 +1,104 @@
+package gocache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEvictionPriority(t *testing.T) {
+	// Each item below accounts for 10 bytes (key + value)
+	c := New(0, WithMaxBytes(30))
+	value := strings.Repeat("v", 8)
+
+	c.SetWithOptions("c1", value, WithPriority(PriorityPinned))
+	c.SetWithOptions("h1", value, WithPriority(PriorityHigh))
+	c.SetWithOptions("n1", value)
+	if c.Size() != 30 {
+		t.Fatalf("Expected size 30, got %d", c.Size())
+	}
+
+	// Low priority items are evicted before older normal ones
+	c.SetWithOptions("l1", value, WithPriority(PriorityLow))
+	if c.Exists("l1") {
+		t.Fatal("Low priority item should be evicted first")
+	}
+
+	// Normal items go before high ones
+	c.SetWithOptions("n2", value)
+	if c.Exists("n1") || !c.Exists("n2") || !c.Exists("h1") {
+		t.Fatal("Oldest normal item should have been evicted")
+	}
+
+	// Pinned items are never evicted, even by higher priority writes
+	c.SetWithOptions("h2", value, WithPriority(PriorityHigh))
+	c.SetWithOptions("h3", value, WithPriority(PriorityHigh))
+	if !c.Exists("c1") {
+		t.Fatal("Pinned item must never be evicted")
+	}
+	if c.Size() > 30 {
+		t.Fatalf("Cache should fit within 30 bytes, has %d", c.Size())
+	}
+}
+
+func TestSizeAccounting(t *testing.T) {
+	c := New(0)
+
+	c.Set("key", "value")
+	c.Set("key", "longer value")
+	c.Set("other", "x")
+	c.Delete("other")
+
+	if c.Size() != int64(len("key")+len("longer value")) {
+		t.Fatalf("Unexpected size %d", c.Size())
+	}
+
+	c.Flush()
+	if c.Size() != 0 {
+		t.Fatalf("Expected size 0 after flush, got %d", c.Size())
+	}
+}
+
+func TestMaxCost(t *testing.T) {
+	// Cost each item by the number it stores, e.g. its upstream compute time
+	cost := func(key string, value []byte) int64 {
+		return int64(len(value)) * 10
+	}
+	c := New(0, WithMaxCost(100, cost))
+
+	c.Set("a", "1234")  // cost 40
+	c.Set("b", "123")   // cost 30
+	c.Set("c", "12345") // cost 50, evicts a
+
+	if c.Cost() != 80 {
+		t.Fatalf("Expected cost 80, got %d", c.Cost())
+	}
+	if c.Exists("a") || !c.Exists("b") || !c.Exists("c") {
+		t.Fatal("Oldest item should have been evicted to fit the cost budget")
+	}
+
+	c.Delete("b")
+	if c.Cost() != 50 {
+		t.Fatalf("Expected cost 50 after delete, got %d", c.Cost())
+	}
+}
+
+func TestSampledEviction(t *testing.T) {
+	c := New(0, WithMaxBytes(100), WithSampledEviction(5))
+	value := strings.Repeat("v", 8)
+
+	c.SetWithOptions("pinned", value, WithPriority(PriorityPinned))
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprintf("k%02d", i), value[:7])
+	}
+
+	if c.Size() > 100 {
+		t.Fatalf("Cache should fit within 100 bytes, has %d", c.Size())
+	}
+	if !c.Exists("pinned") {
+		t.Fatal("Pinned item must never be evicted")
+	}
+	if !c.Exists("k49") {
+		t.Fatal("The newest item should survive sampled eviction")
+	}
+}
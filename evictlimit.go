@@ -0,0 +1,75 @@
+package gocache
+
+import "time"
+
+// evictDeferredBatch is the number of items evicted per lock acquisition by
+// the janitor when catching up on deferred evictions
+const evictDeferredBatch = 1000
+
+// WithEvictionLimit bounds how many items a write evicts to make room: at
+// most perOp per write, and at most perSecond across all writes. 0 means no
+// limit. A write that reaches the limit leaves the cache over WithMaxBytes or
+// WithMaxCost, and the janitor evicts the rest on its next pass, so a burst
+// of large Sets doesn't hold the lock for a long eviction loop inside a
+// request. The limits can therefore be exceeded by what is written between
+// two janitor passes; without a janitor, they're only enforced as the budget
+// of later writes allows.
+func WithEvictionLimit(perOp, perSecond int) Option {
+	return func(c *Cache) {
+		if perOp <= 0 && perSecond <= 0 {
+			c.evictLimit = nil
+			return
+		}
+		c.evictLimit = &evictionLimiter{perOp: perOp, perSecond: perSecond, tokens: float64(perSecond)}
+	}
+}
+
+// evictionLimiter is a token bucket of evictions. It is guarded by the
+// cache's lock.
+type evictionLimiter struct {
+	perOp     int
+	perSecond int
+	tokens    float64
+	last      int64 // when tokens were last refilled, in UnixNano
+}
+
+// allow returns how many evictions the current write may perform, -1 for
+// any number
+func (l *evictionLimiter) allow(now int64) int {
+	budget := -1
+	if l.perOp > 0 {
+		budget = l.perOp
+	}
+	if l.perSecond > 0 {
+		if l.last > 0 {
+			elapsed := time.Duration(now - l.last).Seconds()
+			l.tokens = min(float64(l.perSecond), l.tokens+elapsed*float64(l.perSecond))
+		}
+		l.last = now
+		if budget < 0 || int(l.tokens) < budget {
+			budget = int(l.tokens)
+		}
+	}
+	return budget
+}
+
+// spend takes n evictions from the bucket
+func (l *evictionLimiter) spend(n int) {
+	if l.perSecond > 0 {
+		l.tokens -= float64(n)
+	}
+}
+
+// evictDeferred evicts the items writes left over their budget, in batches
+// so writes aren't blocked for the whole pass
+func (c *Cache) evictDeferred() {
+	for {
+		c.mu.Lock()
+		evicted := c.evictUpToLocked(evictDeferredBatch)
+		c.mu.Unlock()
+
+		if evicted < evictDeferredBatch {
+			return
+		}
+	}
+}
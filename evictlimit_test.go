@@ -0,0 +1,51 @@
+package gocache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEvictionLimitPerOp(t *testing.T) {
+	c := New(0, WithMaxBytes(1000), WithEvictionLimit(2, 0))
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key:%d", i), strings.Repeat("x", 80))
+	}
+	if c.Size() > 1000 {
+		t.Fatalf("Expected the cache to fit its limit before the burst, got %d bytes", c.Size())
+	}
+
+	// An item larger than the rest needs many evictions, only 2 are allowed
+	c.Set("big", strings.Repeat("x", 600))
+	if got := c.Count(); got != 9 {
+		t.Fatalf("Expected 2 evictions for the write, got %d items", got)
+	}
+	if c.Size() <= 1000 {
+		t.Fatalf("Expected the cache to stay over its limit, got %d bytes", c.Size())
+	}
+
+	c.DeleteExpired()
+	if c.Size() > 1000 {
+		t.Fatalf("Expected the janitor to evict the rest, got %d bytes", c.Size())
+	}
+	if !c.Exists("big") {
+		t.Fatalf("Expected the newest item to survive")
+	}
+}
+
+func TestEvictionLimitPerSecond(t *testing.T) {
+	c := New(0, WithMaxBytes(100), WithEvictionLimit(0, 3))
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key:%d", i), strings.Repeat("x", 40))
+	}
+
+	// The first 2 fit, the bucket of 3 evictions is spent by the next 3 Sets
+	if got := c.Count(); got != 7 {
+		t.Fatalf("Expected 3 evictions within the second, got %d items", got)
+	}
+
+	c.DeleteExpired()
+	if c.Size() > 100 {
+		t.Fatalf("Expected the janitor to evict the rest, got %d bytes", c.Size())
+	}
+}
@@ -0,0 +1,35 @@
+package gocache
+
+import (
+	"strings"
+	"time"
+)
+
+// ExpireByPrefix resets the expiration of every live item whose key starts
+// with prefix to ttl from now (0 means no expiration), in a single pass under
+// the write lock, and returns the number of items updated. Keys are matched
+// as stored, after any key policy was applied, and items spilled to the
+// overflow store aren't updated. Namespace policies, WithMaxTTL, the value's
+// own expiration and the expiration of an item's dependencies still cap the
+// new expiration.
+func (c *Cache) ExpireByPrefix(prefix string, ttl time.Duration) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	expiration := expirationFor(ttl)
+	updated := 0
+	for key, item := range c.items {
+		if !strings.HasPrefix(key, prefix) || c.staleLocked(item, now.UnixNano()) {
+			continue
+		}
+		item.Expiration = expiration
+		item.Expiration = c.capExpirationLocked(key, item, now)
+		c.items[key] = item
+		c.indexReadLocked(key, item)
+		c.indexExpirationLocked(key, item)
+		c.publishLocked(Change{Kind: ChangeSet, Key: key, Value: item.Value, Expiration: item.Expiration, Format: item.format})
+		updated++
+	}
+	return updated
+}
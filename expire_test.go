@@ -0,0 +1,41 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireByPrefix(t *testing.T) {
+	c := New(0)
+	c.SetWithExpiration("session:1", "a", time.Minute)
+	c.Set("session:2", "b")
+	c.SetWithExpiration("user:1", "c", time.Minute)
+
+	if n := c.ExpireByPrefix("session:", time.Hour); n != 2 {
+		t.Fatalf("Expected 2 items updated, got %d", n)
+	}
+	for _, key := range []string{"session:1", "session:2"} {
+		if ttl, _ := c.TTL(key); ttl <= 59*time.Minute || ttl > time.Hour {
+			t.Fatalf("Expected %s to expire in an hour, got %v", key, ttl)
+		}
+	}
+	if ttl, _ := c.TTL("user:1"); ttl > time.Minute {
+		t.Fatalf("Expected other keys to keep their TTL, got %v", ttl)
+	}
+
+	if n := c.ExpireByPrefix("session:", 0); n != 2 {
+		t.Fatalf("Expected 2 items updated, got %d", n)
+	}
+	if ttl, _ := c.TTL("session:1"); ttl != -1 {
+		t.Fatalf("Expected no expiration, got %v", ttl)
+	}
+}
+
+func TestExpireByPrefixCaps(t *testing.T) {
+	c := New(0, WithMaxTTL(time.Minute))
+	c.Set("session:1", "a")
+	c.ExpireByPrefix("session:", time.Hour)
+	if ttl, _ := c.TTL("session:1"); ttl > time.Minute {
+		t.Fatalf("Expected the maximum TTL to apply, got %v", ttl)
+	}
+}
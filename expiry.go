@@ -0,0 +1,122 @@
+package gocache
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// WithExpiryIndex keeps the items that expire ordered by expiration, so
+// ExpiringWithin only visits the items it returns instead of scanning the
+// whole cache. It costs a heap insertion per write of an item with a TTL.
+func WithExpiryIndex() Option {
+	return func(c *Cache) {
+		c.expiries = &expiryIndex{}
+	}
+}
+
+// expiryEntry is an item's expiration in the expiry index
+type expiryEntry struct {
+	expiration int64
+	version    uint64
+	key        string
+}
+
+// expiryIndex is a min-heap of expirations. Entries aren't removed when their
+// item is deleted or rewritten; they're recognized as outdated by the item's
+// version and expiration, and dropped once they reach the top or on
+// compaction.
+type expiryIndex struct {
+	entries []expiryEntry
+}
+
+func (x *expiryIndex) Len() int           { return len(x.entries) }
+func (x *expiryIndex) Less(i, j int) bool { return x.entries[i].expiration < x.entries[j].expiration }
+func (x *expiryIndex) Swap(i, j int)      { x.entries[i], x.entries[j] = x.entries[j], x.entries[i] }
+func (x *expiryIndex) Push(e any)         { x.entries = append(x.entries, e.(expiryEntry)) }
+func (x *expiryIndex) Pop() any {
+	e := x.entries[len(x.entries)-1]
+	x.entries = x.entries[:len(x.entries)-1]
+	return e
+}
+
+// indexExpirationLocked adds the expiration of the item stored under key to
+// the expiry index and drops outdated entries. The caller must hold the
+// write lock.
+func (c *Cache) indexExpirationLocked(key string, item Item) {
+	x := c.expiries
+	if x == nil || item.Expiration == 0 {
+		return
+	}
+	heap.Push(x, expiryEntry{expiration: item.Expiration, version: item.version, key: key})
+
+	for x.Len() > 0 && !c.currentExpiryLocked(x.entries[0]) {
+		heap.Pop(x)
+	}
+	if x.Len() > 2*len(c.items)+64 {
+		live := x.entries[:0]
+		for _, e := range x.entries {
+			if c.currentExpiryLocked(e) {
+				live = append(live, e)
+			}
+		}
+		clear(x.entries[len(live):])
+		x.entries = live
+		heap.Init(x)
+	}
+}
+
+// currentExpiryLocked reports whether e still describes the item stored under
+// its key. The caller must hold the lock.
+func (c *Cache) currentExpiryLocked(e expiryEntry) bool {
+	item, found := c.items[e.key]
+	return found && item.version == e.version && item.Expiration == e.expiration
+}
+
+// ExpiringWithin returns the keys of the live items that expire within d,
+// soonest first, so a background refresher can rebuild them before they
+// expire. Keys are returned as stored, after any key policy was applied.
+// Without WithExpiryIndex it scans the whole cache.
+func (c *Cache) ExpiringWithin(d time.Duration) []string {
+	c.mu.RLock()
+	now := time.Now().UnixNano()
+	limit := now + int64(d)
+
+	var entries []expiryEntry
+	add := func(key string, item Item) {
+		if item.Expiration > 0 && item.Expiration <= limit && !c.staleLocked(item, now) {
+			entries = append(entries, expiryEntry{expiration: item.Expiration, key: key})
+		}
+	}
+
+	if x := c.expiries; x != nil {
+		// Only descend into subtrees whose root expires within the limit;
+		// the heap property guarantees everything below expires later
+		stack := []int{0}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if i >= x.Len() || x.entries[i].expiration > limit {
+				continue
+			}
+			if e := x.entries[i]; c.currentExpiryLocked(e) {
+				add(e.key, c.items[e.key])
+			}
+			stack = append(stack, 2*i+1, 2*i+2)
+		}
+	} else {
+		for key, item := range c.items {
+			add(key, item)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].expiration < entries[j].expiration
+	})
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
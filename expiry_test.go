@@ -0,0 +1,50 @@
+package gocache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExpiringWithin(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		var opts []Option
+		if indexed {
+			opts = append(opts, WithExpiryIndex())
+		}
+		c := New(0, opts...)
+		c.SetWithExpiration("c", "v", 3*time.Minute)
+		c.SetWithExpiration("a", "v", time.Minute)
+		c.SetWithExpiration("b", "v", 2*time.Minute)
+		c.SetWithExpiration("later", "v", time.Hour)
+		c.Set("forever", "v")
+		c.SetWithExpiration("expired", "v", time.Nanosecond)
+		time.Sleep(time.Millisecond)
+
+		if got := c.ExpiringWithin(5 * time.Minute); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+			t.Fatalf("Expected [a b c] (indexed=%v), got %v", indexed, got)
+		}
+
+		// Rewrites and deletes are reflected
+		c.SetWithExpiration("a", "v", 2*time.Hour)
+		c.Delete("b")
+		c.ExpireByPrefix("later", time.Second)
+		if got := c.ExpiringWithin(5 * time.Minute); !reflect.DeepEqual(got, []string{"later", "c"}) {
+			t.Fatalf("Expected [later c] (indexed=%v), got %v", indexed, got)
+		}
+	}
+}
+
+func TestExpiryIndexCompaction(t *testing.T) {
+	c := New(0, WithExpiryIndex())
+	for i := 0; i < 10000; i++ {
+		c.SetWithExpiration(strconv.Itoa(i%10), i, time.Hour+time.Duration(i))
+	}
+	if n := c.expiries.Len(); n > 2*10+64 {
+		t.Fatalf("Expected outdated entries to be dropped, got %d entries", n)
+	}
+	if got := c.ExpiringWithin(2 * time.Hour); len(got) != 10 {
+		t.Fatalf("Expected 10 keys, got %v", got)
+	}
+}
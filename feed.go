@@ -0,0 +1,207 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrCursorExpired is returned by WatchPrefix for a cursor older than the
+// changes retained by WithChangeLog, or not issued by this cache. The
+// consumer has to reload the state it tracks and resume from Cursor().
+var ErrCursorExpired = errors.New("gocache: cursor expired")
+
+// ErrWatcherLagged is reported by Watcher.Err when the consumer fell so far
+// behind that changes were dropped. It can resume from Watcher.Cursor.
+var ErrWatcherLagged = errors.New("gocache: watcher fell behind")
+
+// errWatcherStopped is the cancellation cause used by Watcher.Stop
+var errWatcherStopped = errors.New("watcher stopped")
+
+// watcherBuffer is the number of changes queued for a slow watcher
+const watcherBuffer = 1024
+
+// Cursor is a position in a cache's change stream. Cursors increase
+// monotonically with every Set, Delete and Flush.
+type Cursor uint64
+
+// WithChangeLog retains the last n changes, so watchers can resume from a
+// cursor after disconnecting
+func WithChangeLog(n int) Option {
+	return func(c *Cache) {
+		c.changeLog = newChangeRing(n)
+	}
+}
+
+// changeRing is a fixed-size ring of the most recent changes
+type changeRing struct {
+	changes []Change
+	next    int
+	full    bool
+}
+
+func newChangeRing(n int) *changeRing {
+	return &changeRing{changes: make([]Change, n)}
+}
+
+func (r *changeRing) add(change Change) {
+	if len(r.changes) == 0 {
+		return
+	}
+	r.changes[r.next] = change
+	r.next = (r.next + 1) % len(r.changes)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// since returns the retained changes after seq, oldest first, and whether
+// every such change is still retained
+func (r *changeRing) since(seq, latest uint64) ([]Change, bool) {
+	var ordered []Change
+	if r.full {
+		ordered = append(ordered, r.changes[r.next:]...)
+	}
+	ordered = append(ordered, r.changes[:r.next]...)
+
+	if latest == seq {
+		return nil, true
+	}
+	if len(ordered) == 0 || ordered[0].Seq > seq+1 {
+		return nil, false
+	}
+	i := len(ordered) - int(latest-seq)
+	return ordered[i:], true
+}
+
+// Cursor returns the position of the last change, to pass to WatchPrefix
+// after reading the state a watcher tracks
+func (c *Cache) Cursor() Cursor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Cursor(c.changeSeq)
+}
+
+// Watcher streams the changes to keys with a prefix, see WatchPrefix
+type Watcher struct {
+	// C receives the changes in order. It's closed when the watcher stops.
+	C <-chan Change
+
+	cache  *Cache
+	sub    *subscriber
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	cursor Cursor
+	err    error
+}
+
+// WatchPrefix streams the changes to keys starting with prefix made after
+// since, along with every Flush (BumpGeneration is reported as a flush).
+// Changes retained by WithChangeLog are replayed first, so a consumer that
+// remembers the Cursor of the last change it handled can resume after a
+// disconnect without missing or repeating changes. Passing Cursor() starts
+// from the current state.
+//
+// The watcher stops when ctx is done, when Stop is called, or when the
+// consumer falls more than watcherBuffer changes behind.
+func (c *Cache) WatchPrefix(ctx context.Context, prefix string, since Cursor) (*Watcher, error) {
+	sub := &subscriber{ch: make(chan Change, watcherBuffer)}
+
+	c.mu.Lock()
+	latest := c.changeSeq
+	var backlog []Change
+	ok := uint64(since) == latest
+	if uint64(since) < latest && c.changeLog != nil {
+		backlog, ok = c.changeLog.since(uint64(since), latest)
+	}
+	if !ok {
+		c.mu.Unlock()
+		return nil, ErrCursorExpired
+	}
+	c.subscribers = append(c.subscribers, sub)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	out := make(chan Change)
+	w := &Watcher{C: out, cache: c, sub: sub, cancel: cancel, done: make(chan struct{}), cursor: since}
+	go w.run(ctx, prefix, backlog, out)
+	return w, nil
+}
+
+// Cursor returns the position of the last change received from C
+func (w *Watcher) Cursor() Cursor {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cursor
+}
+
+// Err returns why the watcher stopped: the context's error, ErrWatcherLagged
+// or nil after Stop
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Stop stops the watcher and closes C
+func (w *Watcher) Stop() {
+	w.cancel(errWatcherStopped)
+	<-w.done
+}
+
+func (w *Watcher) run(ctx context.Context, prefix string, backlog []Change, out chan<- Change) {
+	defer close(w.done)
+	defer close(out)
+	defer w.cache.unsubscribe(w.sub)
+
+	send := func(change Change) bool {
+		if change.Kind != ChangeFlush && !strings.HasPrefix(change.Key, prefix) {
+			return true
+		}
+		select {
+		case out <- change:
+			w.mu.Lock()
+			w.cursor = Cursor(change.Seq)
+			w.mu.Unlock()
+			return true
+		case <-ctx.Done():
+			w.stop(ctx)
+			return false
+		}
+	}
+
+	for _, change := range backlog {
+		if !send(change) {
+			return
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			w.stop(ctx)
+			return
+		case change := <-w.sub.ch:
+			if w.sub.overflow.Load() {
+				w.mu.Lock()
+				w.err = ErrWatcherLagged
+				w.mu.Unlock()
+				return
+			}
+			if !send(change) {
+				return
+			}
+		}
+	}
+}
+
+// stop records why the watcher's context ended
+func (w *Watcher) stop(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := context.Cause(ctx); err != errWatcherStopped {
+		w.err = err
+	}
+}
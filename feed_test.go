@@ -0,0 +1,103 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// next receives a change from w or fails the test
+func next(t *testing.T, w *Watcher) Change {
+	t.Helper()
+	select {
+	case change, ok := <-w.C:
+		if !ok {
+			t.Fatalf("Expected a change, watcher stopped: %v", w.Err())
+		}
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+	}
+	return Change{}
+}
+
+func TestWatchPrefix(t *testing.T) {
+	c := New(0)
+	w, err := c.WatchPrefix(context.Background(), "config:", c.Cursor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	c.Set("other", "x")
+	c.Set("config:a", "1")
+	c.Delete("config:a")
+	c.Flush()
+
+	if change := next(t, w); change.Kind != ChangeSet || change.Key != "config:a" || string(change.Value) != "1" {
+		t.Fatalf("Expected set of config:a, got %+v", change)
+	}
+	if change := next(t, w); change.Kind != ChangeDelete || change.Key != "config:a" {
+		t.Fatalf("Expected delete of config:a, got %+v", change)
+	}
+	if change := next(t, w); change.Kind != ChangeFlush {
+		t.Fatalf("Expected flush, got %+v", change)
+	}
+	if w.Cursor() != c.Cursor() {
+		t.Fatalf("Expected the watcher cursor %d to match the cache cursor %d", w.Cursor(), c.Cursor())
+	}
+}
+
+func TestWatchPrefixResume(t *testing.T) {
+	c := New(0, WithChangeLog(16))
+	c.Set("k:1", "1")
+	cursor := c.Cursor()
+	c.Set("k:2", "2")
+	c.Set("k:3", "3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := c.WatchPrefix(ctx, "k:", cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := next(t, w); change.Key != "k:2" {
+		t.Fatalf("Expected to resume at k:2, got %+v", change)
+	}
+	cancel()
+	for range w.C {
+	}
+	if !errors.Is(w.Err(), context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", w.Err())
+	}
+
+	w, err = c.WatchPrefix(context.Background(), "k:", w.Cursor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if change := next(t, w); change.Key != "k:3" {
+		t.Fatalf("Expected to resume at k:3, got %+v", change)
+	}
+}
+
+func TestWatchPrefixCursorExpired(t *testing.T) {
+	c := New(0, WithChangeLog(2))
+	for _, k := range []string{"a", "b", "c", "d"} {
+		c.Set(k, k)
+	}
+	if _, err := c.WatchPrefix(context.Background(), "", 1); err != ErrCursorExpired {
+		t.Fatalf("Expected ErrCursorExpired for a dropped cursor, got %v", err)
+	}
+	if _, err := c.WatchPrefix(context.Background(), "", c.Cursor()+1); err != ErrCursorExpired {
+		t.Fatalf("Expected ErrCursorExpired for a future cursor, got %v", err)
+	}
+	w, err := c.WatchPrefix(context.Background(), "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if change := next(t, w); change.Key != "c" {
+		t.Fatalf("Expected c, got %+v", change)
+	}
+}
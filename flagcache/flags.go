@@ -0,0 +1,256 @@
+// Package flagcache caches feature-flag evaluations so request paths keep
+// working, with the last known or default values, while the flag service
+// is slow or down.
+package flagcache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// keyPrefix namespaces the keys written by Flags
+const keyPrefix = "flag:"
+
+// DefaultTTL is used for flags without a TTL
+const DefaultTTL = 30 * time.Second
+
+// Source evaluates a flag, typically by calling a flag service SDK. subject
+// identifies who the flag is evaluated for, such as a user or tenant ID, and
+// is empty for global flags. Values should be bools, strings or numbers.
+type Source func(ctx context.Context, flag, subject string) (interface{}, error)
+
+// Flags caches flag evaluations per flag and subject
+type Flags struct {
+	Cache  *gocache.Cache
+	Source Source
+
+	// TTL is how long an evaluation is used before the Source is asked
+	// again. 0 means DefaultTTL.
+	TTL time.Duration
+
+	// FlagTTLs overrides TTL for individual flags
+	FlagTTLs map[string]time.Duration
+
+	// StaleTTL is how long past its TTL an evaluation is still served when
+	// the Source fails, before falling back to the caller's default.
+	// 0 disables stale-if-error.
+	StaleTTL time.Duration
+
+	// Timeout bounds each Source call, 0 for no limit
+	Timeout time.Duration
+
+	// OnError, if set, is called when the Source fails or returns a value
+	// of the wrong type for the getter
+	OnError func(flag string, err error)
+
+	mu    sync.Mutex
+	calls map[string]*evalCall // in-flight Source calls by key
+}
+
+// entry is a cached evaluation
+type entry struct {
+	Value     interface{} `json:"value"`
+	Evaluated time.Time   `json:"evaluated"`
+}
+
+// evalCall is an in-flight Source call shared by concurrent callers
+type evalCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Bool returns the value of a boolean flag, or def if it can't be evaluated.
+// String values such as "true" are accepted.
+func (f *Flags) Bool(ctx context.Context, flag, subject string, def bool) bool {
+	value, err := f.Value(ctx, flag, subject)
+	if err != nil {
+		return def
+	}
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	f.report(flag, typeError(flag, value, "bool"))
+	return def
+}
+
+// String returns the value of a string flag, or def if it can't be evaluated
+func (f *Flags) String(ctx context.Context, flag, subject, def string) string {
+	value, err := f.Value(ctx, flag, subject)
+	if err != nil {
+		return def
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	f.report(flag, typeError(flag, value, "string"))
+	return def
+}
+
+// Float64 returns the value of a numeric flag, or def if it can't be
+// evaluated. Numeric strings are accepted.
+func (f *Flags) Float64(ctx context.Context, flag, subject string, def float64) float64 {
+	value, err := f.Value(ctx, flag, subject)
+	if err != nil {
+		return def
+	}
+	if n, ok := number(value); ok {
+		return n
+	}
+	f.report(flag, typeError(flag, value, "number"))
+	return def
+}
+
+// Int returns the value of a numeric flag truncated to an int, or def if it
+// can't be evaluated
+func (f *Flags) Int(ctx context.Context, flag, subject string, def int) int {
+	value, err := f.Value(ctx, flag, subject)
+	if err != nil {
+		return def
+	}
+	if n, ok := number(value); ok {
+		return int(n)
+	}
+	f.report(flag, typeError(flag, value, "number"))
+	return def
+}
+
+// Value returns the evaluation of flag for subject. A cached evaluation is
+// used until its TTL passes; after that the Source is asked again, and if it
+// fails the old evaluation is served for up to StaleTTL more. The error is
+// only returned when there's no evaluation to serve.
+func (f *Flags) Value(ctx context.Context, flag, subject string) (interface{}, error) {
+	key := keyPrefix + url.QueryEscape(flag) + ":" + subject
+	ttl := f.ttl(flag)
+
+	var cached entry
+	found, err := f.Cache.Get(key, &cached)
+	found = found && err == nil
+	if found && time.Since(cached.Evaluated) < ttl {
+		return cached.Value, nil
+	}
+
+	value, err := f.evaluate(ctx, key, flag, subject, ttl)
+	if err != nil {
+		f.report(flag, err)
+		if found {
+			// The item only outlives its TTL by StaleTTL, so this is within bounds
+			return cached.Value, nil
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// Invalidate drops the cached evaluation of flag for subject, for example
+// when the flag service announces a change
+func (f *Flags) Invalidate(flag, subject string) {
+	f.Cache.Delete(keyPrefix + url.QueryEscape(flag) + ":" + subject)
+}
+
+// evaluate calls the Source, sharing the call with concurrent callers for the
+// same key, and caches the result
+func (f *Flags) evaluate(ctx context.Context, key, flag, subject string, ttl time.Duration) (interface{}, error) {
+	f.mu.Lock()
+	call, running := f.calls[key]
+	if !running {
+		call = &evalCall{done: make(chan struct{})}
+		if f.calls == nil {
+			f.calls = make(map[string]*evalCall)
+		}
+		f.calls[key] = call
+	}
+	f.mu.Unlock()
+
+	if !running {
+		// Like GetOrLoad, the call is detached from the first caller's
+		// cancellation since others may be waiting on it
+		go func() {
+			defer func() {
+				f.mu.Lock()
+				delete(f.calls, key)
+				f.mu.Unlock()
+				close(call.done)
+			}()
+
+			callCtx := context.WithoutCancel(ctx)
+			if f.Timeout > 0 {
+				var cancel context.CancelFunc
+				callCtx, cancel = context.WithTimeout(callCtx, f.Timeout)
+				defer cancel()
+			}
+			call.value, call.err = f.Source(callCtx, flag, subject)
+			if call.err == nil {
+				f.Cache.SetWithExpiration(key, entry{Value: call.value, Evaluated: time.Now()}, ttl+f.StaleTTL)
+			}
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ttl returns the TTL of flag
+func (f *Flags) ttl(flag string) time.Duration {
+	if ttl, ok := f.FlagTTLs[flag]; ok && ttl > 0 {
+		return ttl
+	}
+	if f.TTL > 0 {
+		return f.TTL
+	}
+	return DefaultTTL
+}
+
+// report passes err to OnError if set
+func (f *Flags) report(flag string, err error) {
+	if f.OnError != nil {
+		f.OnError(flag, err)
+	}
+}
+
+// typeError describes a flag value that doesn't have the wanted type
+func typeError(flag string, value interface{}, want string) error {
+	return fmt.Errorf("flagcache: flag %q is %T, not a %s", flag, value, want)
+}
+
+// number converts the numeric types a Source or the JSON decoding of a
+// cached entry may produce to a float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
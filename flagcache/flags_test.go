@@ -0,0 +1,127 @@
+package flagcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestFlagsTypedGetters(t *testing.T) {
+	values := map[string]interface{}{"enabled": true, "theme": "dark", "limit": 25, "ratio": "0.5"}
+	var calls atomic.Int32
+	var errs []error
+	f := &Flags{
+		Cache: gocache.New(0),
+		Source: func(ctx context.Context, flag, subject string) (interface{}, error) {
+			calls.Add(1)
+			return values[flag], nil
+		},
+		OnError: func(flag string, err error) { errs = append(errs, err) },
+	}
+	ctx := context.Background()
+
+	if !f.Bool(ctx, "enabled", "u1", false) {
+		t.Fatal("Expected enabled to be true")
+	}
+	if theme := f.String(ctx, "theme", "u1", "light"); theme != "dark" {
+		t.Fatalf("Expected dark, got %s", theme)
+	}
+	if limit := f.Int(ctx, "limit", "u1", 10); limit != 25 {
+		t.Fatalf("Expected 25, got %d", limit)
+	}
+	if ratio := f.Float64(ctx, "ratio", "u1", 1); ratio != 0.5 {
+		t.Fatalf("Expected 0.5, got %v", ratio)
+	}
+
+	// Cached evaluations decode from JSON
+	if limit := f.Int(ctx, "limit", "u1", 10); limit != 25 || calls.Load() != 4 {
+		t.Fatalf("Expected the cached 25 without another call, got %d after %d calls", limit, calls.Load())
+	}
+
+	if f.Bool(ctx, "theme", "u1", true) != true || len(errs) != 1 {
+		t.Fatalf("Expected the default and a type error, got %v", errs)
+	}
+}
+
+func TestFlagsStaleIfError(t *testing.T) {
+	var mu sync.Mutex
+	fail := false
+	f := &Flags{
+		Cache: gocache.New(0),
+		Source: func(ctx context.Context, flag, subject string) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				return nil, errors.New("flag service unavailable")
+			}
+			return true, nil
+		},
+		TTL:      10 * time.Millisecond,
+		StaleTTL: 50 * time.Millisecond,
+	}
+	ctx := context.Background()
+
+	if !f.Bool(ctx, "beta", "", false) {
+		t.Fatal("Expected beta to be true")
+	}
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	if !f.Bool(ctx, "beta", "", false) {
+		t.Fatal("Expected the stale value while the source fails")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if f.Bool(ctx, "beta", "", false) {
+		t.Fatal("Expected the default once the stale window passed")
+	}
+}
+
+func TestFlagsSharedCallAndTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	f := &Flags{
+		Cache: gocache.New(0),
+		Source: func(ctx context.Context, flag, subject string) (interface{}, error) {
+			calls.Add(1)
+			select {
+			case <-release:
+				return "v2", nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+		FlagTTLs: map[string]time.Duration{"slow": time.Minute},
+		Timeout:  20 * time.Millisecond,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v := f.String(context.Background(), "slow", "", "v1"); v != "v1" {
+				t.Errorf("Expected the default after the timeout, got %s", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("Expected one shared source call, got %d", calls.Load())
+	}
+
+	close(release)
+	if v := f.String(context.Background(), "slow", "", "v1"); v != "v2" {
+		t.Fatalf("Expected v2, got %s", v)
+	}
+	f.Invalidate("slow", "")
+	if f.String(context.Background(), "slow", "", "v1") != "v2" || calls.Load() != 3 {
+		t.Fatalf("Expected a new call after Invalidate, got %d calls", calls.Load())
+	}
+}
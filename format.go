@@ -0,0 +1,108 @@
+package gocache
+
+import "errors"
+
+// ErrFormatMismatch is returned when decoding an item stored in one format
+// as another, e.g. reading a JSON encoded struct into a *string or decoding
+// a gob encoded value with XMLCodec
+var ErrFormatMismatch = errors.New("gocache: value stored in a different format")
+
+// Format identifies how an item's value was encoded. It's recorded with
+// every item so reads can pick the matching codec.
+type Format uint8
+
+const (
+	// FormatRaw is a byte slice whose encoding the cache doesn't know, e.g.
+	// values stored with SetRaw or faulted in from an overflow store
+	FormatRaw Format = iota
+	// FormatString is a string stored as its bytes
+	FormatString
+	// FormatJSON is a value encoded with JSONCodec, the default codec
+	FormatJSON
+	// FormatXML is a value encoded with XMLCodec
+	FormatXML
+	// FormatGob is a value encoded with GobCodec
+	FormatGob
+	// FormatCodec is a value encoded with a custom Codec
+	FormatCodec
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatRaw:
+		return "raw"
+	case FormatString:
+		return "string"
+	case FormatJSON:
+		return "json"
+	case FormatXML:
+		return "xml"
+	case FormatGob:
+		return "gob"
+	case FormatCodec:
+		return "codec"
+	}
+	return "unknown"
+}
+
+// formatOf returns the format encode produces for value
+func formatOf(value interface{}) Format {
+	switch value.(type) {
+	case []byte:
+		return FormatRaw
+	case string:
+		return FormatString
+	}
+	return FormatJSON
+}
+
+// codecFormat returns the format produced by codec
+func codecFormat(codec Codec) Format {
+	switch codec {
+	case JSONCodec:
+		return FormatJSON
+	case XMLCodec:
+		return FormatXML
+	case GobCodec:
+		return FormatGob
+	}
+	return FormatCodec
+}
+
+// codec returns the codec decoding f, or nil if it isn't known
+func (f Format) codec() Codec {
+	switch f {
+	case FormatJSON:
+		return JSONCodec
+	case FormatXML:
+		return XMLCodec
+	case FormatGob:
+		return GobCodec
+	}
+	return nil
+}
+
+// structured reports whether f is produced by a codec rather than being
+// text or raw bytes
+func (f Format) structured() bool {
+	return f >= FormatJSON
+}
+
+// Format returns the format the item stored under key was encoded in
+func (c *Cache) Format(key string) (Format, bool) {
+	item, found := c.lookup(key)
+	return item.format, found
+}
+
+// decodeFormat decodes a value stored in the given format into target,
+// using the codec that encoded it. Structured values can't be read into a
+// *string; use GetBytes to see their encoding.
+func decodeFormat(data []byte, format Format, target interface{}) error {
+	if _, ok := target.(*string); ok && format.structured() {
+		return ErrFormatMismatch
+	}
+	if codec := format.codec(); codec != nil && target != nil {
+		return codec.Unmarshal(data, target)
+	}
+	return decode(data, target)
+}
@@ -0,0 +1,83 @@
+package gocache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	c := New(0)
+	c.Set("s", "text")
+	c.Set("b", []byte("raw"))
+	c.Set("j", testStruct{Name: "a"})
+	c.SetWithOptions("g", testStruct{Name: "a"}, WithCodec(GobCodec))
+
+	for key, want := range map[string]Format{"s": FormatString, "b": FormatRaw, "j": FormatJSON, "g": FormatGob} {
+		if got, _ := c.Format(key); got != want {
+			t.Errorf("Expected %s to be stored as %s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestGetDecodesStoredCodec(t *testing.T) {
+	c := New(0)
+	in := testStruct{Name: "Alice", Age: 30}
+	c.SetWithOptions("gob", in, WithCodec(GobCodec))
+	c.SetWithOptions("xml", in, WithCodec(XMLCodec))
+
+	for _, key := range []string{"gob", "xml"} {
+		var out testStruct
+		if _, err := c.Get(key, &out); err != nil || out != in {
+			t.Fatalf("Expected %s to decode with its codec, got %+v %v", key, out, err)
+		}
+		out = testStruct{}
+		if _, err := c.GetInto(key, &out, nil); err != nil || out != in {
+			t.Fatalf("Expected GetInto to pick the stored codec for %s, got %+v %v", key, out, err)
+		}
+	}
+
+	var out testStruct
+	if _, err := c.GetInto("gob", &out, JSONCodec); err != ErrFormatMismatch {
+		t.Fatalf("Expected ErrFormatMismatch decoding gob as JSON, got %v", err)
+	}
+}
+
+func TestGetStringRejectsStructured(t *testing.T) {
+	c := New(0)
+	c.Set("j", testStruct{Name: "a"})
+	c.Set("s", "text")
+
+	var s string
+	if _, err := c.Get("j", &s); err != ErrFormatMismatch {
+		t.Fatalf("Expected ErrFormatMismatch, got %v", err)
+	}
+	if _, err := c.Get("s", &s); err != nil || s != "text" {
+		t.Fatalf("Expected text, got %q %v", s, err)
+	}
+}
+
+func TestFormatSurvivesLoadsAndReplication(t *testing.T) {
+	c := New(0)
+	var out testStruct
+	c.GetOrLoad(context.Background(), "k", &out, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return testStruct{Name: "b"}, 0, nil
+	})
+	if f, _ := c.Format("k"); f != FormatJSON {
+		t.Fatalf("Expected loaded values to be stored as JSON, got %s", f)
+	}
+
+	replica := New(0)
+	replica.Apply([]Change{{Kind: ChangeSet, Key: "g", Value: mustGob(t, testStruct{Name: "c"}), Format: FormatGob}})
+	if _, err := replica.Get("g", &out); err != nil || out.Name != "c" {
+		t.Fatalf("Expected the replicated format to be kept, got %+v %v", out, err)
+	}
+}
+
+func mustGob(t *testing.T, v interface{}) []byte {
+	data, err := GobCodec.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,119 @@
+package gocache
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf8"
+)
+
+type fuzzValue struct {
+	Name  string `xml:"name"`
+	Count int64  `xml:"count"`
+	Flag  bool   `xml:"flag"`
+	Data  []byte `xml:"-"`
+}
+
+func FuzzCodecRoundTrip(f *testing.F) {
+	f.Add("John", int64(30), true, []byte{0, 1, 2})
+	f.Add("", int64(-1), false, []byte(nil))
+	f.Add("<a & \"b\">\r\n", int64(1<<62), true, []byte("\xff"))
+
+	f.Fuzz(func(t *testing.T, name string, count int64, flag bool, data []byte) {
+		codecs := map[string]Codec{"gob": GobCodec}
+		if utf8.ValidString(name) {
+			codecs["json"] = JSONCodec
+		}
+		if xmlSafe(name) {
+			codecs["xml"] = XMLCodec
+		}
+
+		c := New(0)
+		for codecName, codec := range codecs {
+			in := fuzzValue{Name: name, Count: count, Flag: flag, Data: data}
+			if codec == XMLCodec {
+				in.Data = nil
+			}
+			if err := c.SetWithOptions(codecName, in, WithCodec(codec)); err != nil {
+				t.Fatalf("%s: Expected to encode %+v, got %v", codecName, in, err)
+			}
+
+			var out fuzzValue
+			found, err := c.GetInto(codecName, &out, codec)
+			if !found || err != nil {
+				t.Fatalf("%s: Expected to decode, found=%v err=%v", codecName, found, err)
+			}
+			if out.Name != in.Name || out.Count != in.Count || out.Flag != in.Flag || !bytes.Equal(out.Data, in.Data) {
+				t.Fatalf("%s: Round trip changed %+v into %+v", codecName, in, out)
+			}
+		}
+	})
+}
+
+func FuzzBytesRoundTrip(f *testing.F) {
+	f.Add("key", []byte("value"), 8)
+	f.Add("", []byte{}, 0)
+	f.Add("a long key that gets hashed", []byte{0xff, 0}, 16)
+
+	f.Fuzz(func(t *testing.T, key string, value []byte, maxLength int) {
+		if maxLength < 0 || maxLength > 1024 {
+			return
+		}
+		c := New(0, WithKeyPolicy(KeyPolicy{MaxLength: maxLength, HashLongKeys: true}))
+		if err := c.SetWithExpiration(key, value, 0); err != nil {
+			t.Fatalf("Expected to set %q, got %v", key, err)
+		}
+
+		got, found := c.GetBytes(key)
+		if !found || !bytes.Equal(got, value) {
+			t.Fatalf("Expected %q under %q, got %q (found=%v)", value, key, got, found)
+		}
+		if c.Size() != int64(len(c.mapKey(key))+len(value)) {
+			t.Fatalf("Expected size to account for the item, got %d", c.Size())
+		}
+
+		c.Delete(key)
+		if c.Exists(key) || c.Count() != 0 || c.Size() != 0 {
+			t.Fatal("Expected Delete to remove the item and its size")
+		}
+	})
+}
+
+// xmlSafe reports whether s only contains characters XML 1.0 can represent
+func xmlSafe(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+		case r < 0x20, r >= 0xD800 && r <= 0xDFFF, r == 0xFFFE, r == 0xFFFF:
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzReadSnapshot(f *testing.F) {
+	var valid bytes.Buffer
+	WriteSnapshot(&valid, []SnapshotEntry{{Key: "k", Value: []byte(`"v"`), Expiration: 1, Tags: []string{"t"}}})
+	f.Add(valid.Bytes())
+	f.Add([]byte("GCSNAP\x01\x80\x80\x80\x80\x80\x80\x80\x80\x40")) // a 1<<62 byte record
+	f.Add([]byte("GCSNAP\x01\x05ab"))
+	f.Add([]byte("not a snapshot"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entries, err := ReadSnapshot(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// Whatever was read writes and reads back the same
+		var buf bytes.Buffer
+		if err := WriteSnapshot(&buf, entries); err != nil {
+			t.Fatalf("Expected to write the entries back, got %v", err)
+		}
+		again, err := ReadSnapshot(&buf)
+		if err != nil || len(again) != len(entries) {
+			t.Fatalf("Expected %d entries back, got %d (%v)", len(entries), len(again), err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package gocache
+
+// BumpGeneration logically invalidates every item currently in the cache in
+// O(1). Invalidated items are no longer returned and are reclaimed by the
+// janitor or DeleteExpired, which avoids holding the lock for the map swap
+// Flush performs on very large caches. Like expired items, they are still
+// counted by Count and Size until reclaimed.
+// It returns the new generation number.
+func (c *Cache) BumpGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushedAt = c.version
+	c.clearReadsLocked()
+	c.generation++
+	c.publishLocked(Change{Kind: ChangeFlush})
+	return c.generation
+}
+
+// Generation returns the number of times BumpGeneration has been called
+func (c *Cache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
@@ -0,0 +1,52 @@
+package gocache
+
+import "testing"
+
+func TestBumpGeneration(t *testing.T) {
+	c := New(0)
+
+	c.Set("old", "value")
+	c.Set("pinned", "value")
+	c.Pin("pinned")
+
+	if gen := c.BumpGeneration(); gen != 1 {
+		t.Fatalf("Expected generation 1, got %d", gen)
+	}
+	if c.Exists("old") || c.Exists("pinned") {
+		t.Fatal("Items from the previous generation must be invalid")
+	}
+	if _, err := c.TTL("old"); err == nil {
+		t.Fatal("TTL should report invalidated keys as missing")
+	}
+
+	c.Set("new", "value")
+	if !c.Exists("new") {
+		t.Fatal("Items set after the bump must be valid")
+	}
+
+	// Stale items are reclaimed by DeleteExpired
+	if c.Count() != 3 {
+		t.Fatalf("Expected 3 items before cleanup, have %d", c.Count())
+	}
+	c.DeleteExpired()
+	if c.Count() != 1 {
+		t.Fatalf("Expected 1 item after cleanup, have %d", c.Count())
+	}
+}
+
+func TestBumpGenerationOverflow(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	c := New(0, WithMaxBytes(10), WithOverflow(store))
+
+	c.Set("k1", "value-01")
+	c.Set("k2", "value-02") // spills k1
+	c.BumpGeneration()
+
+	if c.Exists("k1") {
+		t.Fatal("Spilled items from the previous generation must be invalid")
+	}
+	c.DeleteExpired()
+	if _, _, found, _ := store.Load("k1"); found {
+		t.Fatal("DeleteExpired should reclaim stale spilled items")
+	}
+}
@@ -0,0 +1,117 @@
+package gocache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// WithGhostList remembers the keys and sizes, but not the values, of the
+// last size items evicted to free memory. Reads that miss on one of those
+// keys are counted by GhostStats, which estimates how much a larger capacity
+// would raise the hit ratio.
+func WithGhostList(size int) Option {
+	return func(c *Cache) {
+		c.ghosts = &ghostList{size: size, entries: list.New(), index: make(map[string]*list.Element)}
+	}
+}
+
+// GhostStats reports reads of recently evicted keys, see WithGhostList
+type GhostStats struct {
+	Reads     uint64 // Get, GetBytes and GetString calls
+	Misses    uint64 // reads that found nothing
+	GhostHits uint64 // misses for keys in the ghost list
+	Keys      int    // keys in the ghost list
+	Bytes     int64  // size of the ghost list's items when they were evicted
+}
+
+// HitRatioGain estimates how much the hit ratio would have risen with Bytes
+// more capacity: the share of reads that missed only because of an eviction
+func (s GhostStats) HitRatioGain() float64 {
+	if s.Reads == 0 {
+		return 0
+	}
+	return float64(s.GhostHits) / float64(s.Reads)
+}
+
+// ghostEntry is an evicted key in the ghost list
+type ghostEntry struct {
+	key  string
+	size int64
+}
+
+// ghostList holds recently evicted keys, most recent first. It has its own
+// lock since reads only hold the cache's read lock.
+type ghostList struct {
+	mu      sync.Mutex
+	size    int
+	entries *list.List
+	index   map[string]*list.Element
+	bytes   int64
+
+	reads, misses, hits atomic.Uint64
+}
+
+// add records an evicted key, forgetting the oldest if the list is full
+func (g *ghostList) add(key string, size int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(key)
+	g.index[key] = g.entries.PushFront(ghostEntry{key: key, size: size})
+	g.bytes += size
+	for g.entries.Len() > g.size {
+		g.removeLocked(g.entries.Back().Value.(ghostEntry).key)
+	}
+}
+
+// remove forgets key, once it's stored again
+func (g *ghostList) remove(key string) {
+	g.mu.Lock()
+	g.removeLocked(key)
+	g.mu.Unlock()
+}
+
+// removeLocked forgets key. The caller must hold g.mu.
+func (g *ghostList) removeLocked(key string) {
+	if e, ok := g.index[key]; ok {
+		g.bytes -= e.Value.(ghostEntry).size
+		g.entries.Remove(e)
+		delete(g.index, key)
+	}
+}
+
+// observe counts a read of key
+func (g *ghostList) observe(key string, found bool) {
+	g.reads.Add(1)
+	if found {
+		return
+	}
+	g.misses.Add(1)
+
+	g.mu.Lock()
+	_, ghost := g.index[key]
+	g.mu.Unlock()
+	if ghost {
+		g.hits.Add(1)
+	}
+}
+
+// GhostStats returns the reads of recently evicted keys since the cache was
+// created. It's the zero value without WithGhostList.
+func (c *Cache) GhostStats() GhostStats {
+	g := c.ghosts
+	if g == nil {
+		return GhostStats{}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return GhostStats{
+		Reads:     g.reads.Load(),
+		Misses:    g.misses.Load(),
+		GhostHits: g.hits.Load(),
+		Keys:      g.entries.Len(),
+		Bytes:     g.bytes,
+	}
+}
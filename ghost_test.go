@@ -0,0 +1,42 @@
+package gocache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGhostList(t *testing.T) {
+	// Room for 10 items of 11 bytes
+	c := New(0, WithMaxBytes(110), WithGhostList(5))
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("key:%02d", i), "01234")
+	}
+
+	stats := c.GhostStats()
+	if stats.Keys != 5 || stats.Bytes != 55 {
+		t.Fatalf("Expected 5 ghost keys of 11 bytes, got %+v", stats)
+	}
+
+	c.GetString("key:19")  // hit
+	c.GetString("key:07")  // evicted recently, a ghost hit
+	c.GetString("key:00")  // evicted too long ago
+	c.GetString("missing") // never stored
+	stats = c.GhostStats()
+	if stats.Reads != 4 || stats.Misses != 3 || stats.GhostHits != 1 {
+		t.Fatalf("Expected 4 reads, 3 misses and 1 ghost hit, got %+v", stats)
+	}
+	if gain := stats.HitRatioGain(); gain != 0.25 {
+		t.Fatalf("Expected a gain of 0.25, got %v", gain)
+	}
+
+	// Storing a key again removes it from the ghost list
+	c.Set("key:07", "01234")
+	if stats = c.GhostStats(); stats.Keys != 5 {
+		t.Fatalf("Expected the readmitted key to be replaced by the next eviction, got %+v", stats)
+	}
+	c.Delete("key:07")
+	c.GetString("key:07")
+	if c.GhostStats().GhostHits != 1 {
+		t.Fatal("Expected a deleted key not to count as a ghost hit")
+	}
+}
@@ -0,0 +1,48 @@
+// Package gincache adapts httpcache.ResponseCache to gin:
+//
+//	rc := &httpcache.ResponseCache{Cache: c, TTL: time.Minute}
+//	router.GET("/reports/:id", gincache.Middleware(rc), reportHandler)
+package gincache
+
+import (
+	"github.com/babashankar/go-cache/httpcache"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns a gin middleware serving GET requests from rc and
+// caching the responses of the handlers after it. Cache hits abort the
+// chain, so later handlers don't run.
+func Middleware(rc *httpcache.ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rc.ServeCached(c.Writer, c.Request) {
+			c.Abort()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer}
+		w.rec = rc.NewRecorder(c.Writer)
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+		rc.Store(c.Request, w.rec)
+	}
+}
+
+// writer records the response written through gin's ResponseWriter
+type writer struct {
+	gin.ResponseWriter
+	rec *httpcache.Recorder
+}
+
+func (w *writer) WriteHeader(status int) {
+	w.rec.WriteHeader(status)
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.rec.Write(p)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.rec.Write([]byte(s))
+}
@@ -0,0 +1,49 @@
+package gincache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/httpcache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rc := &httpcache.ResponseCache{Cache: gocache.New(0), TTL: time.Minute}
+	calls := 0
+
+	router := gin.New()
+	router.GET("/users/:id", Middleware(rc), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	router.GET("/fail", Middleware(rc), func(c *gin.Context) {
+		calls++
+		c.String(http.StatusInternalServerError, "boom")
+	})
+
+	var last *httptest.ResponseRecorder
+	for _, path := range []string{"/users/1", "/users/1", "/fail", "/fail"} {
+		last = httptest.NewRecorder()
+		router.ServeHTTP(last, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/users/1" && last.Body.String() != `{"id":"1"}` {
+			t.Fatalf("Expected the handler's response, got %q", last.Body.String())
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("Expected the second request to be cached and errors not to be, got %d calls", calls)
+	}
+	if last.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the error status, got %d", last.Code)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if w.Header().Get(httpcache.XFromCache) != "1" || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("Expected the cached response with its headers, got %v", w.Header())
+	}
+}
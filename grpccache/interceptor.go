@@ -0,0 +1,111 @@
+// Package grpccache provides a gRPC client interceptor that caches the
+// responses of idempotent unary RPCs in a gocache.Cache:
+//
+//	c := gocache.New(time.Minute)
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithUnaryInterceptor(grpccache.UnaryClientInterceptor(grpccache.Config{
+//			Cache: c,
+//			Methods: map[string]time.Duration{
+//				"/users.v1.Users/GetUser": time.Minute,
+//			},
+//		})),
+//	)
+//
+// Responses are keyed by the full method name and a hash of the
+// deterministically marshaled request, and stored with protocodec.
+package grpccache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/protocodec"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// keyPrefix namespaces the keys written by the interceptor
+const keyPrefix = "grpc:"
+
+// Config configures UnaryClientInterceptor
+type Config struct {
+	Cache *gocache.Cache
+
+	// Methods maps the full names of the methods to cache, such as
+	// "/users.v1.Users/GetUser", to the TTL of their responses. Only list
+	// idempotent methods; calls to any other method pass through.
+	Methods map[string]time.Duration
+
+	// Vary, if set, returns a string that becomes part of the key, so
+	// responses aren't shared between callers that may see different data,
+	// e.g. the caller's identity from the outgoing metadata
+	Vary func(ctx context.Context) string
+}
+
+// noCache is the CallOption returned by NoCache
+type noCache struct {
+	grpc.EmptyCallOption
+}
+
+// NoCache makes a call bypass the cache, both for reading and for storing
+// its response
+func NoCache() grpc.CallOption {
+	return noCache{}
+}
+
+// UnaryClientInterceptor returns an interceptor serving the responses of the
+// configured methods from the cache. Only successful responses are cached.
+func UnaryClientInterceptor(cfg Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ttl, cacheable := cfg.Methods[method]
+		reqMsg, reqOK := req.(proto.Message)
+		replyMsg, replyOK := reply.(proto.Message)
+		if !cacheable || !reqOK || !replyOK || bypass(opts) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		key, err := cacheKey(ctx, cfg, method, reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if found, err := protocodec.Get(cfg.Cache, key, replyMsg); found && err == nil {
+			return nil
+		}
+
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		// A failure to cache shouldn't fail the call
+		protocodec.Set(cfg.Cache, key, replyMsg, ttl)
+		return nil
+	}
+}
+
+// cacheKey returns the key of the response to req
+func cacheKey(ctx context.Context, cfg Config, method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(data)
+	if cfg.Vary != nil {
+		h.Write([]byte{0})
+		h.Write([]byte(cfg.Vary(ctx)))
+	}
+	return keyPrefix + method + ":" + hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// bypass reports whether opts contain NoCache
+func bypass(opts []grpc.CallOption) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(noCache); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package grpccache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const getUser = "/users.v1.Users/GetUser"
+
+// fakeInvoker answers with "user <id>" and counts calls
+type fakeInvoker struct {
+	calls int
+	err   error
+}
+
+func (f *fakeInvoker) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	proto.Merge(reply.(proto.Message), wrapperspb.String("user "+req.(*wrapperspb.StringValue).Value))
+	return nil
+}
+
+func call(t *testing.T, interceptor grpc.UnaryClientInterceptor, ctx context.Context, method, id string, f *fakeInvoker, opts ...grpc.CallOption) (string, error) {
+	t.Helper()
+	reply := &wrapperspb.StringValue{}
+	err := interceptor(ctx, method, wrapperspb.String(id), reply, nil, f.invoke, opts...)
+	return reply.Value, err
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := UnaryClientInterceptor(Config{
+		Cache:   gocache.New(0),
+		Methods: map[string]time.Duration{getUser: time.Minute},
+	})
+	ctx := context.Background()
+	f := &fakeInvoker{}
+
+	for i := 0; i < 2; i++ {
+		if reply, err := call(t, interceptor, ctx, getUser, "1", f); err != nil || reply != "user 1" {
+			t.Fatalf("Expected 'user 1', got %q (%v)", reply, err)
+		}
+	}
+	if f.calls != 1 {
+		t.Fatalf("Expected the second call to be served from the cache, got %d calls", f.calls)
+	}
+
+	call(t, interceptor, ctx, getUser, "2", f)
+	call(t, interceptor, ctx, "/users.v1.Users/DeleteUser", "1", f)
+	call(t, interceptor, ctx, "/users.v1.Users/DeleteUser", "1", f)
+	call(t, interceptor, ctx, getUser, "1", f, NoCache())
+	if f.calls != 5 {
+		t.Fatalf("Expected other requests, uncached methods and NoCache to reach the server, got %d calls", f.calls)
+	}
+}
+
+func TestUnaryClientInterceptorErrors(t *testing.T) {
+	interceptor := UnaryClientInterceptor(Config{
+		Cache:   gocache.New(0),
+		Methods: map[string]time.Duration{getUser: time.Minute},
+	})
+	f := &fakeInvoker{err: errors.New("unavailable")}
+
+	if _, err := call(t, interceptor, context.Background(), getUser, "1", f); err == nil {
+		t.Fatal("Expected the error to be returned")
+	}
+	f.err = nil
+	if reply, _ := call(t, interceptor, context.Background(), getUser, "1", f); reply != "user 1" || f.calls != 2 {
+		t.Fatalf("Expected errors not to be cached, got %q after %d calls", reply, f.calls)
+	}
+}
+
+func TestUnaryClientInterceptorVary(t *testing.T) {
+	interceptor := UnaryClientInterceptor(Config{
+		Cache:   gocache.New(0),
+		Methods: map[string]time.Duration{getUser: time.Minute},
+		Vary: func(ctx context.Context) string {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			return md.Get("user")[0]
+		},
+	})
+	f := &fakeInvoker{}
+
+	alice := metadata.AppendToOutgoingContext(context.Background(), "user", "alice")
+	bob := metadata.AppendToOutgoingContext(context.Background(), "user", "bob")
+	call(t, interceptor, alice, getUser, "1", f)
+	call(t, interceptor, bob, getUser, "1", f)
+	call(t, interceptor, alice, getUser, "1", f)
+	if f.calls != 2 {
+		t.Fatalf("Expected one call per caller, got %d", f.calls)
+	}
+}
@@ -0,0 +1,167 @@
+package gocache
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by writes to a cache after Close
+var ErrClosed = errors.New("gocache: cache closed")
+
+// HealthStatus summarizes the state of a cache
+type HealthStatus int
+
+const (
+	// HealthOK means the cache and its dependencies work
+	HealthOK HealthStatus = iota
+	// HealthDegraded means the cache works but a dependency, such as the
+	// overflow store or a remote tier, is failing
+	HealthDegraded
+	// HealthUnavailable means the cache was closed
+	HealthUnavailable
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthOK:
+		return "ok"
+	case HealthDegraded:
+		return "degraded"
+	case HealthUnavailable:
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
+
+// Health is the state of a cache and its dependencies, see Cache.Health
+type Health struct {
+	Status HealthStatus
+
+	// Problems maps failing components, "cache", "overflow" or the name of
+	// a WithHealthCheck, to their error
+	Problems map[string]error
+
+	// DroppedWrites and FailedReads count the errors WithFailOpen turned
+	// into dropped writes and misses
+	DroppedWrites uint64
+	FailedReads   uint64
+}
+
+// Components returns the names of the failing components, sorted
+func (h Health) Components() []string {
+	names := make([]string, 0, len(h.Problems))
+	for name := range h.Problems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HealthChecker is implemented by overflow stores and other dependencies
+// that can report whether they work
+type HealthChecker interface {
+	Health() error
+}
+
+// WithHealthCheck makes Health report the cache as degraded while check
+// returns an error, e.g. for a remote tier the application puts in front of
+// or behind the cache
+func WithHealthCheck(name string, check func() error) Option {
+	return func(c *Cache) {
+		if c.healthChecks == nil {
+			c.healthChecks = make(map[string]func() error)
+		}
+		c.healthChecks[name] = check
+	}
+}
+
+// WithFailOpen makes the cache degrade instead of failing requests: Set,
+// SetWithExpiration, SetWithExpireAt and SetWithOptions drop writes they
+// can't perform and return nil, and Get reports values it can't decode as
+// misses. The errors are counted in Health, so they stay visible in metrics
+// without reaching the request path.
+func WithFailOpen() Option {
+	return func(c *Cache) {
+		c.failOpen = true
+	}
+}
+
+// failOpenStats counts the errors hidden by WithFailOpen
+type failOpenStats struct {
+	droppedWrites atomic.Uint64
+	failedReads   atomic.Uint64
+}
+
+// failOpenWrite drops err if the cache fails open
+func (c *Cache) failOpenWrite(err error) error {
+	if err == nil || !c.failOpen {
+		return err
+	}
+	c.degraded.droppedWrites.Add(1)
+	return nil
+}
+
+// failOpenRead turns a read error into a miss if the cache fails open
+func (c *Cache) failOpenRead(found bool, err error) (bool, error) {
+	if err == nil || !c.failOpen {
+		return found, err
+	}
+	c.degraded.failedReads.Add(1)
+	return false, nil
+}
+
+// Close stops the janitor, waits for the write queue to drain, removes all
+// items and makes further writes fail with ErrClosed, or be dropped with
+// WithFailOpen. Reads then miss. It's safe to call more than once.
+func (c *Cache) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.StopJanitor()
+	c.FlushWrites()
+	c.Flush()
+	return nil
+}
+
+// Health reports whether the cache and its dependencies work: the overflow
+// store's last error, the store's own HealthChecker if it implements one,
+// and the checks added with WithHealthCheck
+func (c *Cache) Health() Health {
+	h := Health{
+		Problems:      make(map[string]error),
+		DroppedWrites: c.degraded.droppedWrites.Load(),
+		FailedReads:   c.degraded.failedReads.Load(),
+	}
+
+	if c.closed.Load() {
+		h.Problems["cache"] = ErrClosed
+	}
+
+	c.mu.RLock()
+	overflowErr := c.overflowErr
+	c.mu.RUnlock()
+	if checker, ok := c.overflow.(HealthChecker); ok {
+		if err := checker.Health(); err != nil {
+			overflowErr = err
+		}
+	}
+	if overflowErr != nil {
+		h.Problems["overflow"] = overflowErr
+	}
+
+	for name, check := range c.healthChecks {
+		if err := check(); err != nil {
+			h.Problems[name] = err
+		}
+	}
+
+	switch {
+	case c.closed.Load():
+		h.Status = HealthUnavailable
+	case len(h.Problems) > 0:
+		h.Status = HealthDegraded
+	}
+	return h
+}
@@ -0,0 +1,84 @@
+package gocache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "overflow")
+	store, err := NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errTier := errors.New("remote tier down")
+	var tierErr error
+	c := New(0, WithMaxBytes(10), WithOverflow(store), WithHealthCheck("redis", func() error { return tierErr }))
+
+	if h := c.Health(); h.Status != HealthOK || len(h.Problems) != 0 {
+		t.Fatalf("Expected a healthy cache, got %+v", h)
+	}
+
+	// Spilling fails once the overflow directory is gone
+	os.RemoveAll(dir)
+	c.Set("a", "0123456789")
+	c.Set("b", "0123456789")
+	tierErr = errTier
+	h := c.Health()
+	if h.Status != HealthDegraded || !reflect.DeepEqual(h.Components(), []string{"overflow", "redis"}) {
+		t.Fatalf("Expected the overflow store and the remote tier to be reported, got %+v", h)
+	}
+	if h.Problems["redis"] != errTier {
+		t.Fatalf("Expected the health check's error, got %v", h.Problems["redis"])
+	}
+
+	c.Close()
+	c.Close()
+	if h := c.Health(); h.Status != HealthUnavailable {
+		t.Fatalf("Expected a closed cache to be unavailable, got %v", h.Status)
+	}
+	if err := c.Set("c", "v"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+	if c.Exists("b") {
+		t.Fatal("Expected a closed cache to be empty")
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	c := New(0, WithFailOpen())
+	c.Set("user", []byte("not json"))
+
+	var u struct{ Name string }
+	if found, err := c.Get("user", &u); found || err != nil {
+		t.Fatalf("Expected an undecodable value to read as a miss, got found=%v err=%v", found, err)
+	}
+
+	c.Close()
+	if err := c.Set("key", "v"); err != nil {
+		t.Fatalf("Expected the write to be dropped, got %v", err)
+	}
+	if err := c.SetWithOptions("key", "v"); err != nil {
+		t.Fatalf("Expected the write to be dropped, got %v", err)
+	}
+	if h := c.Health(); h.DroppedWrites != 2 || h.FailedReads != 1 {
+		t.Fatalf("Expected 2 dropped writes and 1 failed read, got %+v", h)
+	}
+}
+
+func TestBreakerStoreHealth(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	breaker := NewCircuitBreaker(BreakerConfig{MinRequests: 1})
+	c := New(0, WithOverflow(NewBreakerStore(store, breaker)))
+	if h := c.Health(); h.Status != HealthOK {
+		t.Fatalf("Expected a healthy cache, got %+v", h)
+	}
+
+	breaker.Do(func() error { return errors.New("disk full") })
+	if h := c.Health(); h.Problems["overflow"] != ErrCircuitOpen {
+		t.Fatalf("Expected the open breaker to be reported, got %+v", h)
+	}
+}
@@ -0,0 +1,129 @@
+package gocache
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+// sizeBounds are the upper bounds of the value size buckets, in bytes
+var sizeBounds = []int64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, math.MaxInt64}
+
+// ttlBounds are the upper bounds of the remaining TTL buckets
+var ttlBounds = []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour, math.MaxInt64}
+
+// SizeBucket counts the items whose value size is at most Max bytes and
+// larger than the Max of the previous bucket
+type SizeBucket struct {
+	Max   int64 // math.MaxInt64 for the last bucket
+	Items int
+	Bytes int64 // total value bytes of the items
+}
+
+// TTLBucket counts the items whose remaining time to live is at most Max and
+// longer than the Max of the previous bucket
+type TTLBucket struct {
+	Max   time.Duration // math.MaxInt64 for the last bucket
+	Items int
+}
+
+// Histogram describes the distribution of the items in a cache
+type Histogram struct {
+	Sizes []SizeBucket // value sizes of all live items
+	TTLs  []TTLBucket  // remaining TTLs of the live items that expire
+
+	NoExpiration int // live items without an expiration
+	Expired      int // expired items waiting for the janitor
+}
+
+// Histogram returns the distribution of value sizes and remaining TTLs of the
+// items in the cache, to help tune limits and default TTLs to what is
+// actually cached
+func (c *Cache) Histogram() Histogram {
+	h := Histogram{
+		Sizes: make([]SizeBucket, len(sizeBounds)),
+		TTLs:  make([]TTLBucket, len(ttlBounds)),
+	}
+	for i, max := range sizeBounds {
+		h.Sizes[i].Max = max
+	}
+	for i, max := range ttlBounds {
+		h.TTLs[i].Max = max
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	for _, item := range c.items {
+		if c.staleLocked(item, now) {
+			h.Expired++
+			continue
+		}
+
+		size := int64(len(item.Value))
+		b := &h.Sizes[bucketFor(size, sizeBounds)]
+		b.Items++
+		b.Bytes += size
+
+		if item.Expiration == 0 || item.pinned {
+			h.NoExpiration++
+			continue
+		}
+		ttl := time.Duration(item.Expiration - now)
+		h.TTLs[bucketFor(ttl, ttlBounds)].Items++
+	}
+	return h
+}
+
+// bucketFor returns the index of the first bound v doesn't exceed
+func bucketFor[T int64 | time.Duration](v T, bounds []T) int {
+	for i, bound := range bounds {
+		if v <= bound {
+			return i
+		}
+	}
+	return len(bounds) - 1
+}
+
+// WriteTo writes the histogram as a table to w
+func (h Histogram) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "VALUE SIZE\tITEMS\tBYTES\n")
+	for _, b := range h.Sizes {
+		max := "inf"
+		if b.Max != math.MaxInt64 {
+			max = fmt.Sprintf("<= %d", b.Max)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", max, b.Items, b.Bytes)
+	}
+	fmt.Fprintf(tw, "\nREMAINING TTL\tITEMS\n")
+	for _, b := range h.TTLs {
+		max := "inf"
+		if b.Max != math.MaxInt64 {
+			max = "<= " + b.Max.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", max, b.Items)
+	}
+	fmt.Fprintf(tw, "no expiration\t%d\n", h.NoExpiration)
+	fmt.Fprintf(tw, "expired\t%d\n", h.Expired)
+
+	err := tw.Flush()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,43 @@
+package gocache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	c := New(0)
+	c.Set("small", "x")
+	c.SetWithExpiration("medium", strings.Repeat("x", 1000), 30*time.Second)
+	c.SetWithExpiration("large", strings.Repeat("x", 2<<20), 2*time.Hour)
+	c.SetWithExpiration("expired", "x", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	h := c.Histogram()
+	if h.Sizes[0].Items != 1 || h.Sizes[0].Bytes != 1 {
+		t.Fatalf("Expected one item of up to 64 bytes, got %+v", h.Sizes[0])
+	}
+	if h.Sizes[2].Items != 1 || h.Sizes[2].Max != 1<<10 {
+		t.Fatalf("Expected one item of up to 1KiB, got %+v", h.Sizes[2])
+	}
+	if last := h.Sizes[len(h.Sizes)-1]; last.Items != 1 || last.Bytes != 2<<20 {
+		t.Fatalf("Expected one item in the last size bucket, got %+v", last)
+	}
+	if h.TTLs[2].Items != 1 || h.TTLs[5].Items != 1 {
+		t.Fatalf("Expected TTLs in the 1m and 6h buckets, got %+v", h.TTLs)
+	}
+	if h.NoExpiration != 1 || h.Expired != 1 {
+		t.Fatalf("Expected 1 item without expiration and 1 expired, got %d and %d", h.NoExpiration, h.Expired)
+	}
+
+	var buf bytes.Buffer
+	n, err := h.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("Expected WriteTo to report %d bytes, got %d (%v)", buf.Len(), n, err)
+	}
+	if !strings.Contains(buf.String(), "<= 1m0s") || !strings.Contains(buf.String(), "expired") {
+		t.Fatalf("Expected a table of buckets, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,171 @@
+package gocache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MaxHistoryKeys is the number of keys WithHistory tracks. Once exceeded,
+// the histories of the keys least recently operated on are dropped.
+const MaxHistoryKeys = 10000
+
+// EventOp identifies a cache operation
+type EventOp uint8
+
+const (
+	// opReplace removes an item that is being overwritten. It isn't
+	// reported, the following EventSet is.
+	opReplace EventOp = iota
+
+	// EventSet records a write
+	EventSet
+	// EventGet records a successful read
+	EventGet
+	// EventDelete records an explicit delete
+	EventDelete
+	// EventExpire records the janitor removing an expired item
+	EventExpire
+	// EventEvict records an item removed to free memory
+	EventEvict
+	// EventInvalidate records an item removed through a dependency or tag
+	EventInvalidate
+)
+
+func (op EventOp) String() string {
+	switch op {
+	case EventSet:
+		return "set"
+	case EventGet:
+		return "get"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	case EventInvalidate:
+		return "invalidate"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an operation recorded in an item's history
+type Event struct {
+	Op      EventOp
+	Time    time.Time
+	Size    int    // value size in bytes
+	Version uint64 // version of the item the operation applied to
+}
+
+// eventRing holds the most recent events of one key
+type eventRing struct {
+	key    string
+	events []Event
+	next   int
+	elem   *list.Element // in historyLog.order
+}
+
+// add records an event, overwriting the oldest one once the ring holds limit events
+func (r *eventRing) add(e Event, limit int) {
+	if len(r.events) < limit {
+		r.events = append(r.events, e)
+		return
+	}
+	r.events[r.next] = e
+	r.next = (r.next + 1) % limit
+}
+
+// list returns the events oldest first
+func (r *eventRing) list() []Event {
+	events := make([]Event, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// historyLog records the last operations per key, for up to maxKeys keys
+type historyLog struct {
+	mu      sync.Mutex
+	limit   int
+	maxKeys int
+	events  map[string]*eventRing
+	order   *list.List // of *eventRing, most recently recorded first
+}
+
+// resetLocked drops all histories. The caller must hold h.mu.
+func (h *historyLog) resetLocked() {
+	h.events = make(map[string]*eventRing)
+	h.order = list.New()
+}
+
+// WithHistory records the last n operations (sets, reads, deletes,
+// expirations, evictions and invalidations) of every key, retrievable with
+// History. It is meant for debugging issues like "who overwrote my entry".
+// Histories outlive their items so that removals can be inspected; they are
+// dropped by Flush and ClearHistory, and once more than MaxHistoryKeys keys
+// have one, for the keys least recently operated on.
+func WithHistory(n int) Option {
+	return func(c *Cache) {
+		if n > 0 {
+			c.history = &historyLog{limit: n, maxKeys: MaxHistoryKeys}
+			c.history.resetLocked()
+		}
+	}
+}
+
+// History returns the recorded operations on key, oldest first, or nil if
+// history recording isn't enabled
+func (c *Cache) History(key string) []Event {
+	if c.history == nil {
+		return nil
+	}
+	key = c.mapKey(key)
+
+	h := c.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ring, ok := h.events[key]
+	if !ok {
+		return nil
+	}
+	return ring.list()
+}
+
+// ClearHistory drops all recorded operations
+func (c *Cache) ClearHistory() {
+	if c.history == nil {
+		return
+	}
+
+	h := c.history
+	h.mu.Lock()
+	h.resetLocked()
+	h.mu.Unlock()
+}
+
+// record adds an operation on an item to the key's history
+func (c *Cache) record(key string, op EventOp, item Item) {
+	if c.history == nil || op == opReplace {
+		return
+	}
+
+	h := c.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ring, ok := h.events[key]
+	if ok {
+		h.order.MoveToFront(ring.elem)
+	} else {
+		ring = &eventRing{key: key}
+		ring.elem = h.order.PushFront(ring)
+		h.events[key] = ring
+		for h.order.Len() > h.maxKeys {
+			oldest := h.order.Remove(h.order.Back()).(*eventRing)
+			delete(h.events, oldest.key)
+		}
+	}
+	ring.add(Event{Op: op, Time: time.Now(), Size: len(item.Value), Version: item.version}, h.limit)
+}
@@ -0,0 +1,92 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	c := New(0, WithHistory(4))
+
+	c.Set("key", "v1")
+	c.GetString("key")
+	c.Set("key", "v22")
+	c.Delete("key")
+
+	ops := func(events []Event) []EventOp {
+		var out []EventOp
+		for _, e := range events {
+			out = append(out, e.Op)
+		}
+		return out
+	}
+
+	events := c.History("key")
+	want := []EventOp{EventSet, EventGet, EventSet, EventDelete}
+	if got := ops(events); len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if events[i].Op != want[i] {
+			t.Fatalf("Expected %v, got %v", want, ops(events))
+		}
+	}
+	if events[2].Size != 3 || events[2].Version <= events[0].Version {
+		t.Fatalf("Unexpected overwrite event: %+v", events[2])
+	}
+
+	// Only the last 4 operations are kept
+	c.SetWithExpiration("key", "v3", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+
+	events = c.History("key")
+	want = []EventOp{EventSet, EventDelete, EventSet, EventExpire}
+	for i := range want {
+		if events[i].Op != want[i] {
+			t.Fatalf("Expected %v, got %v", want, ops(events))
+		}
+	}
+
+	c.Flush()
+	if c.History("key") != nil {
+		t.Fatal("Flush should clear the history")
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	c := New(0)
+	c.Set("key", "value")
+	if c.History("key") != nil {
+		t.Fatal("History should be nil when not enabled")
+	}
+}
+
+func TestHistoryKeyLimit(t *testing.T) {
+	c := New(0, WithHistory(2))
+	c.history.maxKeys = 3
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, "value")
+	}
+	c.GetString("a") // a is now more recent than b
+	c.Set("d", "value")
+
+	if c.History("b") != nil {
+		t.Fatal("Expected the least recently used history to be dropped")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if c.History(key) == nil {
+			t.Fatalf("Expected %s to keep its history", key)
+		}
+	}
+	if n := len(c.history.events); n != 3 {
+		t.Fatalf("Expected 3 tracked keys, got %d", n)
+	}
+
+	c.ClearHistory()
+	c.Set("e", "value")
+	if c.History("e") == nil || c.history.order.Len() != 1 {
+		t.Fatal("Expected ClearHistory to reset the tracked keys")
+	}
+}
@@ -0,0 +1,182 @@
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// responseKeyPrefix namespaces the keys written by ResponseCache
+const responseKeyPrefix = keyPrefix + "response:"
+
+// ResponseCache caches the responses of a server's handlers, so repeated
+// GET requests are answered without running them:
+//
+//	rc := &httpcache.ResponseCache{Cache: c, TTL: time.Minute}
+//	http.Handle("/reports/", rc.Middleware(reportsHandler))
+//
+// Responses are cached for as long as their Cache-Control or Expires header
+// allows, or for TTL if they have neither. Responses that are private,
+// no-store or no-cache, set cookies, vary by request headers, or have a
+// status that isn't cacheable by default are never stored.
+type ResponseCache struct {
+	Cache *gocache.Cache
+
+	// TTL applies to responses without Cache-Control or Expires headers.
+	// 0 leaves them uncached.
+	TTL time.Duration
+
+	// MaxSize is the largest body stored in bytes, 0 for no limit
+	MaxSize int64
+
+	// Key returns the key a request's response is cached under, the
+	// request URL if nil
+	Key func(r *http.Request) string
+}
+
+// Middleware returns a handler serving GET requests from the cache and
+// caching the responses of next
+func (rc *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rc.ServeCached(w, r) {
+			return
+		}
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := rc.NewRecorder(w)
+		next.ServeHTTP(rec, r)
+		rc.Store(r, rec)
+	})
+}
+
+// ServeCached writes the cached response to a GET request to w and reports
+// whether there was one. Together with NewRecorder and Store it lets
+// routers with their own middleware types reuse ResponseCache.
+func (rc *ResponseCache) ServeCached(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	data, found := rc.Cache.GetBytes(rc.key(r))
+	if !found {
+		return false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), r)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(XFromCache, "1")
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	return true
+}
+
+// Store caches the response recorded by rec if it's cacheable
+func (rc *ResponseCache) Store(r *http.Request, rec *Recorder) {
+	if r.Method != http.MethodGet || rec.tooLarge {
+		return
+	}
+	header := rec.Header().Clone()
+	ttl, ok := rc.ttl(rec.Status(), header)
+	if !ok {
+		return
+	}
+
+	header.Del(XFromCache)
+	header.Set("Content-Length", strconv.Itoa(rec.body.Len()))
+	resp := &http.Response{
+		StatusCode:    rec.Status(),
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		ContentLength: int64(rec.body.Len()),
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return
+	}
+	rc.Cache.SetWithExpiration(rc.key(r), dump, ttl)
+}
+
+// ttl returns how long a response may be cached for
+func (rc *ResponseCache) ttl(status int, header http.Header) (time.Duration, bool) {
+	if !cacheableStatus[status] || header.Get("Set-Cookie") != "" || header.Get("Vary") != "" {
+		return 0, false
+	}
+	if len(header.Values("Cache-Control")) == 0 && header.Get("Expires") == "" {
+		return rc.TTL, rc.TTL > 0
+	}
+	return Freshness(header, true, time.Now())
+}
+
+func (rc *ResponseCache) key(r *http.Request) string {
+	if rc.Key != nil {
+		return responseKeyPrefix + rc.Key(r)
+	}
+	return responseKeyPrefix + r.URL.String()
+}
+
+// Recorder passes a response through to the client while recording it for
+// Store
+type Recorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	maxSize  int64
+	tooLarge bool
+}
+
+// NewRecorder returns a Recorder writing through to w
+func (rc *ResponseCache) NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, maxSize: rc.MaxSize}
+}
+
+// WriteHeader records and sends the status code
+func (rec *Recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records and sends part of the body
+func (rec *Recorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if !rec.tooLarge {
+		if rec.maxSize > 0 && int64(rec.body.Len()+len(p)) > rec.maxSize {
+			rec.tooLarge = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Status returns the recorded status code
+func (rec *Recorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController
+func (rec *Recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
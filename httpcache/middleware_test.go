@@ -0,0 +1,100 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// countingHandler writes "hit <n>" with the given headers and counts calls
+func countingHandler(calls *int, header http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		fmt.Fprintf(w, "hit %d", *calls)
+	})
+}
+
+func serve(h http.Handler, method, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	return w
+}
+
+func TestResponseCache(t *testing.T) {
+	rc := &ResponseCache{Cache: gocache.New(0), TTL: time.Minute}
+	calls := 0
+	h := rc.Middleware(countingHandler(&calls, http.Header{"Content-Type": {"text/plain"}}))
+
+	serve(h, http.MethodGet, "/a")
+	w := serve(h, http.MethodGet, "/a")
+	if w.Body.String() != "hit 1" || w.Header().Get(XFromCache) != "1" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("Expected the cached response, got %q %v", w.Body.String(), w.Header())
+	}
+
+	serve(h, http.MethodGet, "/b")
+	serve(h, http.MethodPost, "/a")
+	serve(h, http.MethodPost, "/a")
+	if calls != 4 {
+		t.Fatalf("Expected other URLs and POSTs to reach the handler, got %d calls", calls)
+	}
+}
+
+func TestResponseCacheHonorsHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		header http.Header
+		ttl    time.Duration
+		cached bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=60"}}, 0, true},
+		{http.Header{"Cache-Control": {"no-store"}}, time.Minute, false},
+		{http.Header{"Cache-Control": {"private, max-age=60"}}, time.Minute, false},
+		{http.Header{"Cache-Control": {"max-age=0"}}, time.Minute, false},
+		{http.Header{"Set-Cookie": {"a=b"}}, time.Minute, false},
+		{http.Header{"Vary": {"Accept"}}, time.Minute, false},
+	} {
+		rc := &ResponseCache{Cache: gocache.New(0), TTL: tt.ttl}
+		calls := 0
+		h := rc.Middleware(countingHandler(&calls, tt.header))
+		serve(h, http.MethodGet, "/")
+		serve(h, http.MethodGet, "/")
+		if cached := calls == 1; cached != tt.cached {
+			t.Fatalf("Expected cached=%v for %v with TTL %v, got %d calls", tt.cached, tt.header, tt.ttl, calls)
+		}
+	}
+}
+
+func TestResponseCacheStatusAndSize(t *testing.T) {
+	rc := &ResponseCache{Cache: gocache.New(0), TTL: time.Minute, MaxSize: 10}
+	calls := 0
+	h := rc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 11)))
+		}
+	}))
+
+	for _, path := range []string{"/error", "/missing", "/large"} {
+		serve(h, http.MethodGet, path)
+		serve(h, http.MethodGet, path)
+	}
+	if calls != 5 {
+		t.Fatalf("Expected only the 404 to be cached, got %d calls", calls)
+	}
+	if w := serve(h, http.MethodGet, "/missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected the cached status, got %d", w.Code)
+	}
+}
@@ -0,0 +1,243 @@
+// Package httpcache provides an http.RoundTripper that caches responses in a
+// gocache.Cache, honoring the origin's Cache-Control and Expires headers like
+// an RFC 9111 cache:
+//
+//	c := gocache.New(time.Minute)
+//	client := &http.Client{Transport: &httpcache.Transport{Cache: c}}
+//
+// Only fresh responses are served from the cache; stale responses are
+// refetched rather than revalidated.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// XFromCache is set on responses served from the cache
+const XFromCache = "X-From-Cache"
+
+// keyPrefix namespaces the keys written by Transport
+const keyPrefix = "httpcache:"
+
+// cacheableStatus are the status codes RFC 9111 allows caching by default
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// Transport is an http.RoundTripper caching GET responses in Cache
+type Transport struct {
+	// Cache stores the responses
+	Cache *gocache.Cache
+
+	// Transport makes the requests, http.DefaultTransport if nil
+	Transport http.RoundTripper
+
+	// Shared makes the cache behave as a shared cache: s-maxage takes
+	// precedence over max-age and private responses aren't stored
+	Shared bool
+
+	// TTL, if set, overrides the freshness lifetime computed from the
+	// response headers. It's called for every response that isn't no-store
+	// with the computed lifetime (0 if the origin didn't give one); a
+	// result <= 0 leaves the response uncached.
+	TTL func(resp *http.Response, ttl time.Duration) time.Duration
+}
+
+// RoundTrip serves req from the cache if a fresh response is stored,
+// otherwise forwards it and stores the response if it's cacheable
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	reqDirectives := parseCacheControl(req.Header)
+	_, noStore := reqDirectives["no-store"]
+	_, noCache := reqDirectives["no-cache"]
+
+	key := keyPrefix + req.URL.String()
+	if !noCache && !noStore {
+		if resp, ok := t.cached(key, req); ok {
+			return resp, nil
+		}
+	}
+
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil || noStore {
+		return resp, err
+	}
+
+	ttl, ok := t.ttl(resp)
+	if !ok {
+		return resp, nil
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	t.Cache.SetWithExpiration(varyKey(key, resp.Header.Values("Vary"), req.Header), dump, ttl)
+	if vary := resp.Header.Values("Vary"); len(vary) > 0 {
+		// Remember which request headers select the variant
+		t.Cache.SetWithExpiration(key, "vary:"+strings.Join(vary, ","), ttl)
+	}
+	return resp, nil
+}
+
+// cached returns the fresh response stored for req
+func (t *Transport) cached(key string, req *http.Request) (*http.Response, bool) {
+	data, found := t.Cache.GetBytes(key)
+	if !found {
+		return nil, false
+	}
+	if vary, ok := bytes.CutPrefix(data, []byte("vary:")); ok {
+		data, found = t.Cache.GetBytes(varyKey(key, []string{string(vary)}, req.Header))
+		if !found {
+			return nil, false
+		}
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, false
+	}
+	resp.Header.Set(XFromCache, "1")
+	return resp, true
+}
+
+// ttl returns how long resp may be cached for
+func (t *Transport) ttl(resp *http.Response) (time.Duration, bool) {
+	ttl, ok := Freshness(resp.Header, t.Shared, time.Now())
+	if !ok && ttl < 0 {
+		return 0, false // no-store
+	}
+	if !cacheableStatus[resp.StatusCode] {
+		return 0, false
+	}
+	for _, v := range resp.Header.Values("Vary") {
+		if strings.TrimSpace(v) == "*" {
+			return 0, false
+		}
+	}
+	if t.TTL != nil {
+		ttl = t.TTL(resp, ttl)
+	}
+	return ttl, ttl > 0
+}
+
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// Freshness returns the freshness lifetime of a response with the given
+// headers, minus its Age: s-maxage (for shared caches), max-age, or Expires
+// relative to Date. ok is false if the response must not be served from a
+// cache without revalidation, with a negative ttl if it must not be stored
+// at all (no-store, or private in a shared cache).
+func Freshness(header http.Header, shared bool, now time.Time) (ttl time.Duration, ok bool) {
+	directives := parseCacheControl(header)
+	if _, found := directives["no-store"]; found {
+		return -1, false
+	}
+	if _, found := directives["private"]; found && shared {
+		return -1, false
+	}
+	if _, found := directives["no-cache"]; found {
+		return 0, false
+	}
+
+	var lifetime time.Duration
+	if v, found := directives["s-maxage"]; found && shared {
+		lifetime, ok = parseSeconds(v)
+	}
+	if v, found := directives["max-age"]; found && !ok {
+		lifetime, ok = parseSeconds(v)
+	}
+	if expires := header.Get("Expires"); expires != "" && !ok {
+		date := now
+		if d, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = d
+		}
+		// An invalid Expires, e.g. "0", means already expired
+		if t, err := http.ParseTime(expires); err == nil {
+			lifetime = t.Sub(date)
+		}
+		ok = true
+	}
+	if !ok {
+		return 0, false
+	}
+
+	if age, found := parseSeconds(header.Get("Age")); found {
+		lifetime -= age
+	}
+	if lifetime <= 0 {
+		return 0, false
+	}
+	return lifetime, true
+}
+
+// parseCacheControl returns the directives of the Cache-Control header,
+// with lowercased names and unquoted values
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// parseSeconds parses a delta-seconds value
+func parseSeconds(v string) (time.Duration, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// varyKey returns the key of the variant selected by the request headers
+// named in vary
+func varyKey(key string, vary []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, line := range vary {
+		for _, name := range strings.Split(line, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			b.WriteString("\x00")
+			b.WriteString(name)
+			b.WriteString("=")
+			b.WriteString(strings.Join(header.Values(name), ","))
+		}
+	}
+	return b.String()
+}
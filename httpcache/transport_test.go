@@ -0,0 +1,114 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// origin serves a counter with the given Cache-Control header
+func origin(t *testing.T, cacheControl string) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprintf(w, "%s %d", r.Header.Get("Accept-Language"), n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func get(t *testing.T, client *http.Client, url, lang string) (string, bool) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept-Language", lang)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), resp.Header.Get(XFromCache) != ""
+}
+
+func TestTransportMaxAge(t *testing.T) {
+	srv, hits := origin(t, "max-age=60")
+	client := &http.Client{Transport: &Transport{Cache: gocache.New(0)}}
+
+	if body, cached := get(t, client, srv.URL, "en"); body != "en 1" || cached {
+		t.Fatalf("Expected a fresh response, got %q (cached %v)", body, cached)
+	}
+	if body, cached := get(t, client, srv.URL, "en"); body != "en 1" || !cached {
+		t.Fatalf("Expected the cached response, got %q (cached %v)", body, cached)
+	}
+	if body, _ := get(t, client, srv.URL, "de"); body != "de 2" {
+		t.Fatalf("Expected Vary to select a new variant, got %q", body)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("Expected 2 origin hits, got %d", hits.Load())
+	}
+}
+
+func TestTransportNoStore(t *testing.T) {
+	srv, hits := origin(t, "no-store, max-age=60")
+	client := &http.Client{Transport: &Transport{Cache: gocache.New(0)}}
+
+	get(t, client, srv.URL, "en")
+	get(t, client, srv.URL, "en")
+	if hits.Load() != 2 {
+		t.Fatalf("Expected no-store responses not to be cached, got %d origin hits", hits.Load())
+	}
+}
+
+func TestTransportTTLOverride(t *testing.T) {
+	srv, hits := origin(t, "")
+	client := &http.Client{Transport: &Transport{
+		Cache: gocache.New(0),
+		TTL: func(resp *http.Response, ttl time.Duration) time.Duration {
+			if ttl == 0 {
+				return time.Minute
+			}
+			return ttl
+		},
+	}}
+
+	get(t, client, srv.URL, "en")
+	if _, cached := get(t, client, srv.URL, "en"); !cached || hits.Load() != 1 {
+		t.Fatalf("Expected the TTL hook to make the response cacheable, got %d origin hits", hits.Load())
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header http.Header
+		shared bool
+		ttl    time.Duration
+		ok     bool
+	}{
+		{http.Header{"Cache-Control": {"max-age=60"}}, false, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=10"}}, false, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=10"}}, true, 10 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}}, false, 40 * time.Second, true},
+		{http.Header{"Cache-Control": {"private, max-age=60"}}, true, -1, false},
+		{http.Header{"Cache-Control": {"no-store"}}, false, -1, false},
+		{http.Header{"Cache-Control": {"no-cache"}}, false, 0, false},
+		{http.Header{"Expires": {now.Add(time.Hour).Format(http.TimeFormat)}}, false, time.Hour, true},
+		{http.Header{"Expires": {"0"}}, false, 0, false},
+		{http.Header{}, false, 0, false},
+	}
+	for _, tt := range tests {
+		ttl, ok := Freshness(tt.header, tt.shared, now)
+		if ttl != tt.ttl || ok != tt.ok {
+			t.Errorf("Freshness(%v, %v) = %v, %v; expected %v, %v", tt.header, tt.shared, ttl, ok, tt.ttl, tt.ok)
+		}
+	}
+}
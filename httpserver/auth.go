@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthenticated is returned by an Authenticator that can't identify
+// the client
+var ErrUnauthenticated = errors.New("httpserver: unauthenticated")
+
+// Authenticator identifies the client making a request, returning the
+// principal that ACL rules are matched against
+type Authenticator func(r *http.Request) (principal string, err error)
+
+// TokenAuth authenticates requests by their "Authorization: Bearer <token>"
+// header. tokens maps each accepted token to its principal.
+func TokenAuth(tokens map[string]string) Authenticator {
+	return func(r *http.Request) (string, error) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return "", ErrUnauthenticated
+		}
+		// Compare against every token so timing doesn't leak which one
+		// shares a prefix with the presented token
+		principal, found := "", false
+		for t, p := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				principal, found = p, true
+			}
+		}
+		if !found {
+			return "", ErrUnauthenticated
+		}
+		return principal, nil
+	}
+}
+
+// ClientCertAuth authenticates requests by their verified TLS client
+// certificate, using its subject common name as the principal. The server's
+// tls.Config must set ClientAuth to tls.RequireAndVerifyClientCert or
+// tls.VerifyClientCertIfGiven.
+func ClientCertAuth() Authenticator {
+	return func(r *http.Request) (string, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return "", ErrUnauthenticated
+		}
+		return r.TLS.VerifiedChains[0][0].Subject.CommonName, nil
+	}
+}
+
+// Rule grants a principal access to the keys starting with Prefix
+type Rule struct {
+	Principal string // "*" matches any principal
+	Prefix    string // "" matches every key
+	Read      bool   // GET and HEAD
+	Write     bool   // PUT and DELETE
+}
+
+// ACL is a list of rules. For each request the rule with the longest prefix
+// matching the key, among those naming the principal or "*", decides access;
+// a rule naming the principal wins over "*" for the same prefix. Without a
+// matching rule access is denied.
+type ACL []Rule
+
+// Allowed reports whether principal may read or write key
+func (acl ACL) Allowed(principal, key string, write bool) bool {
+	var match *Rule
+	for i := range acl {
+		r := &acl[i]
+		if r.Principal != principal && r.Principal != "*" {
+			continue
+		}
+		if !strings.HasPrefix(key, r.Prefix) {
+			continue
+		}
+		if match == nil || len(r.Prefix) > len(match.Prefix) ||
+			(len(r.Prefix) == len(match.Prefix) && match.Principal == "*") {
+			match = r
+		}
+	}
+	if match == nil {
+		return false
+	}
+	if write {
+		return match.Write
+	}
+	return match.Read
+}
+
+// authorize authenticates r and checks the ACL for key, returning the
+// principal. It writes an error response and returns false if the request
+// isn't allowed.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, key string, write bool) (string, bool) {
+	var principal string
+	if h.Authenticate != nil {
+		p, err := h.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return "", false
+		}
+		principal = p
+	}
+	if h.ACL != nil && !h.ACL.Allowed(principal, key, write) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return principal, true
+}
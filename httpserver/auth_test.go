@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestTokenAuth(t *testing.T) {
+	h := NewHandler(gocache.New(0))
+	h.Authenticate = TokenAuth(map[string]string{"s3cret": "api"})
+
+	if rec := do(t, h, "PUT", "/k", "v"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/k", "v", "Authorization", "Bearer wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 with a wrong token, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/k", "v", "Authorization", "Bearer s3cret"); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 with a valid token, got %d", rec.Code)
+	}
+}
+
+func TestACL(t *testing.T) {
+	c := gocache.New(0)
+	c.Set("sessions:1", "s")
+	c.Set("config:db", "c")
+
+	h := NewHandler(c)
+	h.Authenticate = TokenAuth(map[string]string{"a": "api", "w": "worker"})
+	h.ACL = ACL{
+		{Principal: "*", Prefix: "config:", Read: true},
+		{Principal: "api", Prefix: "sessions:", Read: true, Write: true},
+		{Principal: "api", Prefix: "config:", Read: true, Write: true},
+	}
+
+	tests := []struct {
+		token, method, key string
+		code               int
+	}{
+		{"w", "GET", "/config:db", http.StatusOK},
+		{"w", "PUT", "/config:db", http.StatusForbidden},
+		{"w", "GET", "/sessions:1", http.StatusForbidden},
+		{"a", "GET", "/sessions:1", http.StatusOK},
+		{"a", "PUT", "/config:db", http.StatusNoContent},
+		{"a", "GET", "/other", http.StatusForbidden},
+		{"a", "DELETE", "/", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		rec := do(t, h, tt.method, tt.key, "v", "Authorization", "Bearer "+tt.token)
+		if rec.Code != tt.code {
+			t.Errorf("%s %s as %s: expected %d, got %d", tt.method, tt.key, tt.token, tt.code, rec.Code)
+		}
+	}
+}
+
+func TestClientCertAuthWithoutTLS(t *testing.T) {
+	h := NewHandler(gocache.New(0))
+	h.Authenticate = ClientCertAuth()
+	if rec := do(t, h, "GET", "/k", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for plaintext requests, got %d", rec.Code)
+	}
+}
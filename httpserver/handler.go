@@ -0,0 +1,238 @@
+// Package httpserver exposes a gocache.Cache over HTTP, so processes that
+// can't embed the cache can share one node:
+//
+//	c := gocache.New(time.Minute)
+//	http.Handle("/cache/", http.StripPrefix("/cache/", httpserver.NewHandler(c)))
+//
+// GET and HEAD /{key} return the stored bytes, PUT /{key} stores the request
+// body (with an optional ?ttl=30s) and DELETE /{key} removes the key.
+// DELETE / flushes the whole cache; the ACL only allows it to principals
+// that may write the empty prefix.
+//
+// Responses carry a strong ETag computed from the value. GET honors
+// If-None-Match with 304 Not Modified, and PUT and DELETE honor If-Match
+// (and If-None-Match: *) with 412 Precondition Failed, which lets clients
+// poll keys cheaply and update them with optimistic concurrency.
+//
+// GET /?watch={prefix} streams the changes to keys with the prefix as
+// server-sent events, so clients can invalidate what they keep locally.
+//
+// To expose a node on a shared network, set Handler.Authenticate (bearer
+// tokens or TLS client certificates) and Handler.ACL to restrict each
+// principal to its namespaces.
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// DefaultMaxValueSize is the largest request body stored by default
+const DefaultMaxValueSize = 1 << 20
+
+// Handler serves the keys of Cache over HTTP
+type Handler struct {
+	// Cache holds the served keys
+	Cache *gocache.Cache
+
+	// MaxValueSize limits PUT request bodies, DefaultMaxValueSize if 0
+	MaxValueSize int64
+
+	// Authenticate identifies clients. If nil, every request is served as
+	// the anonymous principal "".
+	Authenticate Authenticator
+
+	// ACL restricts which keys each principal may read and write. If nil,
+	// every authenticated request is allowed.
+	ACL ACL
+}
+
+// NewHandler returns a Handler serving c
+func NewHandler(c *gocache.Cache) *Handler {
+	return &Handler{Cache: c}
+}
+
+// ServeHTTP serves a single key, named by the request path without its
+// leading slash
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if key == "" && r.Method == http.MethodGet && r.URL.Query().Has("watch") {
+		prefix := r.URL.Query().Get("watch")
+		if principal, ok := h.authorize(w, r, prefix, false); ok {
+			h.watch(w, r, prefix, principal)
+		}
+		return
+	}
+	if key == "" && r.Method == http.MethodDelete {
+		if _, ok := h.authorize(w, r, "", true); ok {
+			h.Cache.Flush()
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+	if key == "" {
+		http.Error(w, "missing key", http.StatusNotFound)
+		return
+	}
+
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if _, ok := h.authorize(w, r, key, write); !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, key)
+	case http.MethodPut:
+		h.put(w, r, key)
+	case http.MethodDelete:
+		h.delete(w, r, key)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, key string) {
+	value, found := h.Cache.GetBytes(key)
+	if !found {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	etag := ETag(value)
+	w.Header().Set("ETag", etag)
+	if ttl, err := h.Cache.TTL(key); err == nil && ttl > 0 {
+		w.Header().Set("Cache-Control", "max-age="+formatSeconds(ttl))
+	}
+	if matchesAny(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, key string) {
+	var ttl time.Duration
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	limit := h.MaxValueSize
+	if limit <= 0 {
+		limit = DefaultMaxValueSize
+	}
+	value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "value too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apply(r, key, gocache.SetOp(key, value, ttl)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", ETag(value))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, key string) {
+	if err := h.apply(r, key, gocache.DeleteOp(key)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// errPrecondition is returned by apply when If-Match or If-None-Match
+// doesn't hold
+var errPrecondition = errors.New("precondition failed")
+
+// apply runs op if the request's If-Match and If-None-Match preconditions
+// hold for the current value of key, atomically with respect to other writes
+func (h *Handler) apply(r *http.Request, key string, op gocache.Op) error {
+	ifMatch := r.Header.Get("If-Match")
+	ifNoneMatch := r.Header.Get("If-None-Match")
+
+	w := h.Cache.Watch(key)
+	if ifMatch != "" || ifNoneMatch != "" {
+		value, found := h.Cache.GetBytes(key)
+		etag := ""
+		if found {
+			etag = ETag(value)
+		}
+		if ifMatch != "" && !(found && matchesAny(ifMatch, etag, false)) {
+			return errPrecondition
+		}
+		if ifNoneMatch != "" && found && matchesAny(ifNoneMatch, etag, true) {
+			return errPrecondition
+		}
+	}
+	return w.Exec(op)
+}
+
+// writeError maps errors from apply to HTTP statuses
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errPrecondition), errors.Is(err, gocache.ErrConflict):
+		http.Error(w, "precondition failed", http.StatusPreconditionFailed)
+	case errors.Is(err, gocache.ErrNoStore):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		var keyErr *gocache.KeyError
+		if errors.As(err, &keyErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ETag returns the strong entity tag of value
+func ETag(value []byte) string {
+	sum := sha256.Sum256(value)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchesAny reports whether etag is in the comma-separated list of entity
+// tags of an If-Match or If-None-Match header. "*" matches any etag. Weak
+// comparison ignores the W/ prefix, as If-None-Match requires.
+func matchesAny(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSeconds formats d as whole delta-seconds, rounding up
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
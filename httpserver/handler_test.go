@@ -0,0 +1,114 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func do(t *testing.T, h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	c := gocache.New(0)
+	h := NewHandler(c)
+
+	if rec := do(t, h, "PUT", "/greeting?ttl=1m", "hello"); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	rec := do(t, h, "GET", "/greeting", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Fatalf("Expected hello, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("ETag") != ETag([]byte("hello")) {
+		t.Fatalf("Expected ETag %s, got %s", ETag([]byte("hello")), rec.Header().Get("ETag"))
+	}
+	if ttl, _ := c.TTL("greeting"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected a TTL of up to 1m, got %v", ttl)
+	}
+
+	if rec := do(t, h, "DELETE", "/greeting", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/greeting", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", rec.Code)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	c := gocache.New(0)
+	c.Set("a", "1")
+	c.Set("b", "2")
+	h := NewHandler(c)
+
+	if rec := do(t, h, "DELETE", "/", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if n := c.Count(); n != 0 {
+		t.Fatalf("Expected an empty cache, got %d items", n)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	c := gocache.New(0)
+	c.Set("k", "v1")
+	h := NewHandler(c)
+
+	etag := do(t, h, "GET", "/k", "").Header().Get("ETag")
+	if rec := do(t, h, "GET", "/k", "", "If-None-Match", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("Expected 304 with no body, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := do(t, h, "GET", "/k", "", "If-None-Match", "W/"+etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("Expected weak comparison to match, got %d", rec.Code)
+	}
+
+	c.Set("k", "v2")
+	if rec := do(t, h, "GET", "/k", "", "If-None-Match", etag); rec.Code != http.StatusOK || rec.Body.String() != "v2" {
+		t.Fatalf("Expected the new value, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConditionalWrite(t *testing.T) {
+	c := gocache.New(0)
+	c.Set("k", "v1")
+	h := NewHandler(c)
+	etag := ETag([]byte("v1"))
+
+	if rec := do(t, h, "PUT", "/k", "v2", "If-Match", `"stale"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/k", "v2", "If-Match", etag); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if rec := do(t, h, "DELETE", "/k", "", "If-Match", etag); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for the replaced value, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/k", "v3", "If-None-Match", "*"); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for create-only on an existing key, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/new", "v", "If-None-Match", "*"); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for create-only on a missing key, got %d", rec.Code)
+	}
+	if value, _ := c.GetString("k"); value != "v2" {
+		t.Fatalf("Expected v2, got %q", value)
+	}
+}
+
+func TestMaxValueSize(t *testing.T) {
+	h := &Handler{Cache: gocache.New(0), MaxValueSize: 4}
+	if rec := do(t, h, "PUT", "/k", "too large"); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig returns a server tls.Config using the PEM encoded certificate
+// and key in certFile and keyFile. If clientCAFile is set, clients must
+// present a certificate signed by one of the CAs it contains, which
+// ClientCertAuth then maps to a principal.
+//
+//	cfg, err := httpserver.TLSConfig("node.crt", "node.key", "clients.crt")
+//	srv := &http.Server{Addr: ":8443", Handler: h, TLSConfig: cfg}
+//	err = srv.ListenAndServeTLS("", "")
+func TLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("httpserver: %s: %w", clientCAFile, errNoCertificates)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg, nil
+}
+
+var errNoCertificates = errors.New("no PEM certificates found")
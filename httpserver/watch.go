@@ -0,0 +1,100 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// watchPingInterval is how often an idle watch stream sends a comment, so
+// proxies don't close it
+const watchPingInterval = 30 * time.Second
+
+// watch streams the changes to keys starting with prefix as server-sent
+// events. The stream opens with a "ready" event, then sends a "set",
+// "delete" or "flush" event per change, with the JSON-encoded key as data
+// and the change's cursor as id. Values aren't sent: the stream tells
+// clients what to drop, not what to store.
+//
+// A client resumes after a disconnect by passing the last id it saw as
+// ?since= or Last-Event-ID. If the changes since then are no longer retained
+// (see gocache.WithChangeLog), the request fails with 410 Gone and the client
+// has to drop everything it derived from the prefix and watch again without
+// a cursor.
+func (h *Handler) watch(w http.ResponseWriter, r *http.Request, prefix, principal string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	var watcher *gocache.Watcher
+	var err error
+	if since != "" {
+		cursor, parseErr := strconv.ParseUint(since, 10, 64)
+		if parseErr != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		watcher, err = h.Cache.WatchPrefix(r.Context(), prefix, gocache.Cursor(cursor))
+	} else {
+		// A write between reading the cursor and watching moves it on, so
+		// retry a few times rather than failing
+		for i := 0; i < 3; i++ {
+			if watcher, err = h.Cache.WatchPrefix(r.Context(), prefix, h.Cache.Cursor()); err == nil {
+				break
+			}
+		}
+	}
+	if errors.Is(err, gocache.ErrCursorExpired) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer watcher.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, "id: %d\nevent: ready\ndata:\n\n", watcher.Cursor())
+	flusher.Flush()
+
+	ping := time.NewTicker(watchPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case change, ok := <-watcher.C:
+			if !ok {
+				return
+			}
+			event := ""
+			switch change.Kind {
+			case gocache.ChangeSet:
+				event = "set"
+			case gocache.ChangeDelete:
+				event = "delete"
+			case gocache.ChangeFlush:
+				event = "flush"
+			}
+			if change.Kind != gocache.ChangeFlush && h.ACL != nil && !h.ACL.Allowed(principal, change.Key, false) {
+				continue
+			}
+			key, _ := json.Marshal(change.Key)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, event, key)
+		}
+		flusher.Flush()
+	}
+}
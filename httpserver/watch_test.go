@@ -0,0 +1,101 @@
+package httpserver
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// readEvents reads n server-sent events from a watch stream, returning them
+// as "event data" lines
+func readEvents(t *testing.T, resp *http.Response, n int) []string {
+	t.Helper()
+	var events []string
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event != "" {
+				events = append(events, strings.TrimSpace(event+" "+data))
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return events
+}
+
+func TestWatch(t *testing.T) {
+	c := gocache.New(0, gocache.WithChangeLog(16))
+	h := NewHandler(c)
+	h.Authenticate = TokenAuth(map[string]string{"t": "app"})
+	h.ACL = ACL{
+		{Principal: "app", Prefix: "user:", Read: true},
+		{Principal: "app", Prefix: "user:secret", Read: false},
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/?watch=user:", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %v, %v", resp, err)
+	}
+	defer resp.Body.Close()
+	if events := readEvents(t, resp, 1); len(events) != 1 || events[0] != "ready" {
+		t.Fatalf("Expected ready, got %v", events)
+	}
+
+	c.Set("user:1", "a")
+	c.Set("user:secret", "hidden")
+	c.Set("order:1", "ignored")
+	c.Delete("user:1")
+	c.Flush()
+	expected := []string{`set "user:1"`, `delete "user:1"`, `flush ""`}
+	if events := readEvents(t, resp, 3); strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+
+	// Watching outside the granted prefixes is forbidden
+	if rec := do(t, h, "GET", "/?watch=order:", "", "Authorization", "Bearer t"); rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403, got %d", rec.Code)
+	}
+}
+
+func TestWatchResume(t *testing.T) {
+	c := gocache.New(0, gocache.WithChangeLog(2))
+	server := httptest.NewServer(NewHandler(c))
+	defer server.Close()
+
+	since := c.Cursor()
+	c.Set("k", "1")
+	resp, err := http.Get(server.URL + "/?watch=&since=" + strconv.FormatUint(uint64(since), 10))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %v, %v", resp, err)
+	}
+	events := readEvents(t, resp, 2)
+	resp.Body.Close()
+	if len(events) != 2 || events[1] != `set "k"` {
+		t.Fatalf("Expected the missed set to be replayed, got %v", events)
+	}
+
+	c.Set("k", "2")
+	c.Set("k", "3")
+	c.Set("k", "4")
+	resp, err = http.Get(server.URL + "/?watch=&since=" + strconv.FormatUint(uint64(since), 10))
+	if err != nil || resp.StatusCode != http.StatusGone {
+		t.Fatalf("Expected 410 once the changes are no longer retained, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+}
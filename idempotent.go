@@ -0,0 +1,101 @@
+package gocache
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInProgress is returned by Idempotent when another call with the same
+// key is still running
+var ErrInProgress = errors.New("gocache: idempotent call in progress")
+
+// RecordedError is returned by Idempotent when replaying a call that failed
+// permanently. Only the error message is recorded.
+type RecordedError struct {
+	Message string
+}
+
+func (e *RecordedError) Error() string {
+	return e.Message
+}
+
+// idempotencyRecord is the value stored under an idempotency key
+type idempotencyRecord struct {
+	Done  bool            `json:"done"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Err   string          `json:"err,omitempty"`
+}
+
+// Idempotent runs fn once per key within ttl and records its outcome, e.g.
+// for webhook or payment handlers receiving retried deliveries. Later calls
+// with the same key return the recorded value, or a *RecordedError if fn
+// failed, without running fn again. While fn runs, other calls with the key
+// fail with ErrInProgress.
+//
+// Errors marked with Retryable aren't recorded, so the next call runs fn
+// again; other errors are treated as permanent. If fn panics the key is
+// released. The value is recorded as JSON.
+func Idempotent[T any](c *Cache, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	var zero T
+	pending, _ := json.Marshal(idempotencyRecord{})
+
+	for {
+		claimed, err := c.SetNX(key, pending, ttl)
+		if err != nil {
+			return zero, err
+		}
+		if claimed {
+			break
+		}
+
+		data, found := c.GetBytes(key)
+		if !found {
+			continue // the record expired meanwhile, claim it
+		}
+		var record idempotencyRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return zero, err
+		}
+		if !record.Done {
+			return zero, ErrInProgress
+		}
+		if record.Err != "" {
+			return zero, &RecordedError{Message: record.Err}
+		}
+		var value T
+		if err := json.Unmarshal(record.Value, &value); err != nil {
+			return zero, err
+		}
+		return value, nil
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			c.Delete(key)
+		}
+	}()
+
+	value, fnErr := fn()
+	if IsRetryable(fnErr) {
+		return value, fnErr
+	}
+
+	record := idempotencyRecord{Done: true}
+	if fnErr != nil {
+		record.Err = fnErr.Error()
+	} else {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return value, err
+		}
+		record.Value = raw
+	}
+	data, _ := json.Marshal(record)
+	if err := c.SetWithExpiration(key, data, ttl); err != nil {
+		return value, err
+	}
+	completed = true
+	return value, fnErr
+}
@@ -0,0 +1,78 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type receipt struct {
+	ID     string
+	Amount int
+}
+
+func TestIdempotent(t *testing.T) {
+	c := New(0)
+	calls := 0
+	charge := func() (receipt, error) {
+		calls++
+		return receipt{ID: "r1", Amount: 42}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		r, err := Idempotent(c, "idem:pay-1", time.Hour, charge)
+		if err != nil || r.ID != "r1" || r.Amount != 42 {
+			t.Fatalf("Expected the recorded receipt, got %+v %v", r, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("Expected fn to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotentErrors(t *testing.T) {
+	c := New(0)
+	calls := 0
+	fail := func(err error) func() (int, error) {
+		return func() (int, error) {
+			calls++
+			return 0, err
+		}
+	}
+
+	if _, err := Idempotent(c, "a", time.Hour, fail(Retryable(errors.New("timeout")))); err == nil {
+		t.Fatal("Expected the retryable error")
+	}
+	if _, err := Idempotent(c, "a", time.Hour, fail(errors.New("card declined"))); err == nil || err.Error() != "card declined" {
+		t.Fatalf("Expected card declined, got %v", err)
+	}
+	var recorded *RecordedError
+	if _, err := Idempotent(c, "a", time.Hour, fail(nil)); !errors.As(err, &recorded) || recorded.Message != "card declined" {
+		t.Fatalf("Expected the recorded error on replay, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected the retryable failure to be retried once, ran %d times", calls)
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	c := New(0)
+	_, err := Idempotent(c, "k", time.Hour, func() (int, error) {
+		_, err := Idempotent(c, "k", time.Hour, func() (int, error) { return 2, nil })
+		return 1, err
+	})
+	if err != ErrInProgress {
+		t.Fatalf("Expected ErrInProgress for a concurrent call, got %v", err)
+	}
+}
+
+func TestIdempotentPanicReleasesKey(t *testing.T) {
+	c := New(0)
+	func() {
+		defer func() { recover() }()
+		Idempotent(c, "k", time.Hour, func() (int, error) { panic("boom") })
+	}()
+	if v, err := Idempotent(c, "k", time.Hour, func() (int, error) { return 7, nil }); v != 7 || err != nil {
+		t.Fatalf("Expected the key to be released after a panic, got %v %v", v, err)
+	}
+}
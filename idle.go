@@ -0,0 +1,31 @@
+package gocache
+
+import "time"
+
+// WithMaxIdle makes the janitor remove items that weren't read or written
+// within maxIdle, even if their TTL hasn't elapsed, keeping the cache to its
+// working set. It enables access tracking with a resolution of a tenth of
+// maxIdle, unless WithAccessTracking sets one. Pinned items and items with
+// PriorityPinned are never removed for being idle.
+func WithMaxIdle(maxIdle time.Duration) Option {
+	return func(c *Cache) {
+		c.maxIdle = int64(maxIdle)
+		if !c.trackAccess {
+			c.trackAccess = true
+			c.accessResolution = int64(maxIdle / 10)
+		}
+	}
+}
+
+// idleKeyLocked reports whether the item stored under key in memory is idle.
+// The caller must hold the lock.
+func (c *Cache) idleKeyLocked(key string, now int64) bool {
+	item, found := c.items[key]
+	return found && c.idleLocked(item, now)
+}
+
+// idleLocked reports whether item went unused for longer than the MaxIdle
+// window at now. The caller must hold the lock.
+func (c *Cache) idleLocked(item Item, now int64) bool {
+	return c.maxIdle > 0 && evictable(item) && now-item.lastUsed() > c.maxIdle
+}
@@ -0,0 +1,35 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxIdle(t *testing.T) {
+	c := New(0, WithMaxIdle(50*time.Millisecond))
+	c.Set("idle", "value")
+	c.SetWithExpiration("read", "value", time.Hour)
+	c.SetWithOptions("pinned", "value", WithPriority(PriorityPinned))
+
+	for i := 0; i < 8; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if _, found := c.GetString("read"); !found {
+			t.Fatal("Expected the read item to be found")
+		}
+	}
+	c.DeleteExpired()
+
+	if c.Exists("idle") {
+		t.Fatal("Expected the idle item to be removed")
+	}
+	if !c.Exists("read") || !c.Exists("pinned") {
+		t.Fatal("Expected the recently read and pinned items to stay")
+	}
+
+	// Writing counts as use
+	c.Set("idle", "value")
+	c.DeleteExpired()
+	if !c.Exists("idle") {
+		t.Fatal("Expected the rewritten item to stay")
+	}
+}
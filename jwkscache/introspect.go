@@ -0,0 +1,115 @@
+package jwkscache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// introspectKeyPrefix namespaces the keys written by Introspector
+const introspectKeyPrefix = "introspect:"
+
+// Introspection is the result of introspecting a token, following RFC 7662
+type Introspection struct {
+	Active   bool   `json:"active"`
+	Subject  string `json:"sub,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Expiry   int64  `json:"exp,omitempty"` // Unix seconds
+
+	// Checked is when the result was obtained from the authorization server
+	Checked time.Time `json:"checked"`
+}
+
+// Introspector caches token introspection results until the token expires.
+// Tokens are only kept as SHA-256 hashes in the cache.
+type Introspector struct {
+	Cache *gocache.Cache
+
+	// Introspect asks the authorization server about a token
+	Introspect func(ctx context.Context, token string) (Introspection, error)
+
+	// MaxTTL caps how long an active result is cached, so revocations are
+	// picked up eventually. 0 caches until the token's expiry; active
+	// results without an expiry are only cached with a MaxTTL.
+	MaxTTL time.Duration
+
+	// InactiveTTL is how long inactive results are cached, 0 for not at all
+	InactiveTTL time.Duration
+
+	// RefreshAhead revalidates an active result in the background once it
+	// is older than MaxTTL minus RefreshAhead, so frequently used tokens
+	// are never introspected on the request path. 0 disables it.
+	RefreshAhead time.Duration
+
+	refreshing sync.Map // keys with a background refresh running
+}
+
+// Check returns the introspection result for token, asking the
+// authorization server only on a cache miss
+func (in *Introspector) Check(ctx context.Context, token string) (Introspection, error) {
+	key := introspectKeyPrefix + hashToken(token)
+
+	var result Introspection
+	if found, err := in.Cache.Get(key, &result); found && err == nil {
+		if in.RefreshAhead > 0 && in.MaxTTL > 0 && result.Active &&
+			time.Since(result.Checked) > in.MaxTTL-in.RefreshAhead {
+			in.refreshInBackground(key, token)
+		}
+		return result, nil
+	}
+	return in.introspect(ctx, key, token)
+}
+
+// introspect asks the authorization server and caches the result
+func (in *Introspector) introspect(ctx context.Context, key, token string) (Introspection, error) {
+	result, err := in.Introspect(ctx, token)
+	if err != nil {
+		return Introspection{}, err
+	}
+	result.Checked = time.Now()
+
+	if ttl := in.ttl(result); ttl > 0 {
+		in.Cache.SetWithExpiration(key, result, ttl)
+	}
+	return result, nil
+}
+
+// refreshInBackground introspects token again unless that's already running
+func (in *Introspector) refreshInBackground(key, token string) {
+	if _, running := in.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer in.refreshing.Delete(key)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		// On failure the cached result is served until it expires
+		in.introspect(ctx, key, token)
+	}()
+}
+
+// ttl returns how long result may be cached
+func (in *Introspector) ttl(result Introspection) time.Duration {
+	if !result.Active {
+		return in.InactiveTTL
+	}
+	ttl := in.MaxTTL
+	if result.Expiry > 0 {
+		untilExpiry := time.Until(time.Unix(result.Expiry, 0))
+		if ttl == 0 || untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	return ttl
+}
+
+// hashToken returns the key suffix for token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
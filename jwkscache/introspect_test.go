@@ -0,0 +1,85 @@
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestIntrospector(t *testing.T) {
+	var calls atomic.Int32
+	c := gocache.New(0)
+	in := &Introspector{
+		Cache:       c,
+		InactiveTTL: time.Minute,
+		Introspect: func(ctx context.Context, token string) (Introspection, error) {
+			calls.Add(1)
+			switch token {
+			case "good":
+				return Introspection{Active: true, Subject: "john", Expiry: time.Now().Add(time.Hour).Unix()}, nil
+			case "fail":
+				return Introspection{}, errors.New("unavailable")
+			}
+			return Introspection{Active: false}, nil
+		},
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := in.Check(ctx, "good")
+		if err != nil || !result.Active || result.Subject != "john" {
+			t.Fatalf("Expected an active result, got %+v (%v)", result, err)
+		}
+		in.Check(ctx, "revoked")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("Expected active and inactive results to be cached, got %d calls", n)
+	}
+
+	if _, err := in.Check(ctx, "fail"); err == nil {
+		t.Fatal("Expected the error to be returned")
+	}
+	in.Check(ctx, "fail")
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("Expected errors not to be cached, got %d calls", n)
+	}
+
+	key := introspectKeyPrefix + hashToken("good")
+	if ttl, _ := c.TTL(key); ttl < 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("Expected the result to be cached until the token expires, got %v", ttl)
+	}
+	if c.Exists("introspect:good") {
+		t.Fatal("Expected tokens to be stored hashed")
+	}
+}
+
+func TestIntrospectorRefreshAhead(t *testing.T) {
+	var calls atomic.Int32
+	in := &Introspector{
+		Cache:        gocache.New(0),
+		MaxTTL:       time.Hour,
+		RefreshAhead: time.Hour,
+		Introspect: func(ctx context.Context, token string) (Introspection, error) {
+			calls.Add(1)
+			return Introspection{Active: true}, nil
+		},
+	}
+	ctx := context.Background()
+	in.Check(ctx, "token")
+	time.Sleep(time.Millisecond)
+	if result, _ := in.Check(ctx, "token"); !result.Active {
+		t.Fatal("Expected the cached result while refreshing")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a background refresh of the aging result")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,310 @@
+// Package jwkscache caches the JSON Web Key Sets used to verify JWTs and the
+// results of OAuth token introspection in a gocache.Cache.
+//
+// KeySet looks up verification keys by key ID, refreshing the key set in
+// the background shortly before it expires and on demand when a token names
+// an unknown key, so key rotation never stalls the request path:
+//
+//	ks := &jwkscache.KeySet{URL: "https://issuer.example/.well-known/jwks.json", Cache: c}
+//	key, err := ks.Key(ctx, kid) // *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+package jwkscache
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/babashankar/go-cache/httpcache"
+)
+
+// ErrKeyNotFound is returned by KeySet.Key for key IDs missing from the key
+// set even after a refresh
+var ErrKeyNotFound = errors.New("jwkscache: key not found")
+
+// keyPrefix namespaces the keys written by KeySet
+const keyPrefix = "jwks:"
+
+// KeySet is a cached JSON Web Key Set fetched from URL
+type KeySet struct {
+	URL   string
+	Cache *gocache.Cache
+
+	// Client fetches the key set, http.DefaultClient if nil
+	Client *http.Client
+
+	// TTL is how long the key set is cached if the response doesn't say,
+	// one hour if 0
+	TTL time.Duration
+
+	// RefreshAhead starts a background refresh once less than this much of
+	// the key set's lifetime remains, a fifth of TTL if 0
+	RefreshAhead time.Duration
+
+	// MinRefreshInterval limits how often an unknown key ID forces a
+	// refresh, one minute if 0
+	MinRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	raw       []byte                      // key set the parsed keys came from
+	keys      map[string]crypto.PublicKey // parsed keys by key ID
+	lastFetch time.Time                   // guarded by mu
+	fetching  atomic.Bool                 // a background refresh is running
+}
+
+// Key returns the public key with the given key ID. A key ID that isn't in
+// the cached key set triggers a refresh, at most once per
+// MinRefreshInterval, to pick up rotated keys.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	keys, err := ks.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	ks.mu.Lock()
+	recent := time.Since(ks.lastFetch) < ks.minRefreshInterval()
+	ks.mu.Unlock()
+	if recent {
+		return nil, ErrKeyNotFound
+	}
+	if keys, err = ks.fetch(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Keys returns all keys of the key set by key ID, fetching it on a miss
+func (ks *KeySet) Keys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	raw, found := ks.Cache.GetBytes(keyPrefix + ks.URL)
+	if !found {
+		return ks.fetch(ctx)
+	}
+	if ttl, err := ks.Cache.TTL(keyPrefix + ks.URL); err == nil && ttl > 0 && ttl < ks.refreshAhead() {
+		ks.refreshInBackground()
+	}
+	return ks.parsed(raw)
+}
+
+// Refresh fetches the key set now
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	_, err := ks.fetch(ctx)
+	return err
+}
+
+// refreshInBackground fetches the key set unless a refresh is running
+func (ks *KeySet) refreshInBackground() {
+	if !ks.fetching.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer ks.fetching.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ks.fetch(ctx) // the cached key set stays in use on failure
+	}()
+}
+
+// fetch downloads, caches and parses the key set
+func (ks *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	ks.mu.Lock()
+	ks.lastFetch = time.Now()
+	ks.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := ks.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwkscache: fetching %s: %s", ks.URL, resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ks.parsed(raw)
+	if err != nil {
+		return nil, err
+	}
+	ttl, ok := httpcache.Freshness(resp.Header, true, time.Now())
+	if !ok {
+		ttl = ks.ttl()
+	}
+	ks.Cache.SetWithExpiration(keyPrefix+ks.URL, raw, ttl)
+	return keys, nil
+}
+
+// parsed returns the keys of raw, reusing the last parse result if the key
+// set hasn't changed
+func (ks *KeySet) parsed(raw []byte) (map[string]crypto.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.keys != nil && bytes.Equal(raw, ks.raw) {
+		return ks.keys, nil
+	}
+	keys, err := ParseKeySet(raw)
+	if err != nil {
+		return nil, err
+	}
+	ks.raw, ks.keys = raw, keys
+	return keys, nil
+}
+
+func (ks *KeySet) ttl() time.Duration {
+	if ks.TTL > 0 {
+		return ks.TTL
+	}
+	return time.Hour
+}
+
+func (ks *KeySet) refreshAhead() time.Duration {
+	if ks.RefreshAhead > 0 {
+		return ks.RefreshAhead
+	}
+	return ks.ttl() / 5
+}
+
+func (ks *KeySet) minRefreshInterval() time.Duration {
+	if ks.MinRefreshInterval > 0 {
+		return ks.MinRefreshInterval
+	}
+	return time.Minute
+}
+
+// jwk is a JSON Web Key as defined by RFC 7517, limited to public keys
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseKeySet parses the RSA, EC and Ed25519 public keys of a JSON Web Key
+// Set by key ID. Keys of other types and encryption keys are skipped.
+func ParseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwkscache: parsing key set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwkscache: key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key, returning nil for unsupported key types
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// decodeInt decodes a base64url encoded big-endian integer
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,161 @@
+package jwkscache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// issuer serves a JWKS document whose keys can be rotated
+type issuer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches atomic.Int32
+	header  string
+}
+
+func (is *issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	is.fetches.Add(1)
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if is.header != "" {
+		w.Header().Set("Cache-Control", is.header)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": is.keys})
+}
+
+func (is *issuer) set(keys ...map[string]string) {
+	is.mu.Lock()
+	is.keys = keys
+	is.mu.Unlock()
+}
+
+func rsaJWK(t *testing.T, kid string) (map[string]string, *rsa.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &key.PublicKey
+	return map[string]string{
+		"kid": kid, "kty": "RSA", "use": "sig",
+		"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes()),
+	}, pub
+}
+
+func TestKeySet(t *testing.T) {
+	is := &issuer{}
+	rsa1, pub1 := rsaJWK(t, "rsa1")
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ed, _, _ := ed25519.GenerateKey(rand.Reader)
+	is.set(rsa1,
+		map[string]string{"kid": "ec", "kty": "EC", "crv": "P-256", "x": b64(ec.X.FillBytes(make([]byte, 32))), "y": b64(ec.Y.FillBytes(make([]byte, 32)))},
+		map[string]string{"kid": "ed", "kty": "OKP", "crv": "Ed25519", "x": b64(ed)},
+		map[string]string{"kid": "enc", "kty": "RSA", "use": "enc"},
+		map[string]string{"kid": "sym", "kty": "oct"},
+	)
+	srv := httptest.NewServer(is)
+	defer srv.Close()
+
+	ks := &KeySet{URL: srv.URL, Cache: gocache.New(0)}
+	ctx := context.Background()
+
+	key, err := ks.Key(ctx, "rsa1")
+	if err != nil || !pub1.Equal(key) {
+		t.Fatalf("Expected the RSA key, got %v (%v)", key, err)
+	}
+	if key, _ := ks.Key(ctx, "ec"); !ec.PublicKey.Equal(key) {
+		t.Fatalf("Expected the EC key, got %v", key)
+	}
+	if key, _ := ks.Key(ctx, "ed"); !ed.Equal(key) {
+		t.Fatalf("Expected the Ed25519 key, got %v", key)
+	}
+	keys, _ := ks.Keys(ctx)
+	if len(keys) != 3 {
+		t.Fatalf("Expected encryption and symmetric keys to be skipped, got %d keys", len(keys))
+	}
+	if n := is.fetches.Load(); n != 1 {
+		t.Fatalf("Expected the key set to be fetched once, got %d fetches", n)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	is := &issuer{}
+	rsa1, _ := rsaJWK(t, "rsa1")
+	rsa2, pub2 := rsaJWK(t, "rsa2")
+	is.set(rsa1)
+	srv := httptest.NewServer(is)
+	defer srv.Close()
+
+	ks := &KeySet{URL: srv.URL, Cache: gocache.New(0), MinRefreshInterval: time.Hour}
+	ctx := context.Background()
+	ks.Key(ctx, "rsa1")
+
+	// The first lookup already fetched, so an unknown key doesn't refetch yet
+	is.set(rsa1, rsa2)
+	if _, err := ks.Key(ctx, "rsa2"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound within MinRefreshInterval, got %v", err)
+	}
+
+	ks.MinRefreshInterval = time.Nanosecond
+	key, err := ks.Key(ctx, "rsa2")
+	if err != nil || !pub2.Equal(key) {
+		t.Fatalf("Expected the rotated key, got %v (%v)", key, err)
+	}
+	if _, err := ks.Key(ctx, "missing"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestKeySetRefreshAhead(t *testing.T) {
+	is := &issuer{header: "max-age=2"}
+	rsa1, _ := rsaJWK(t, "rsa1")
+	is.set(rsa1)
+	srv := httptest.NewServer(is)
+	defer srv.Close()
+
+	ks := &KeySet{URL: srv.URL, Cache: gocache.New(0), RefreshAhead: 2 * time.Second}
+	ctx := context.Background()
+	ks.Keys(ctx)
+	if _, err := ks.Key(ctx, "rsa1"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for is.fetches.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a background refresh before the key set expires")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestParseKeySetErrors(t *testing.T) {
+	for _, doc := range []string{
+		`not json`,
+		`{"keys":[{"kid":"a","kty":"RSA","n":"!","e":"AQAB"}]}`,
+		`{"keys":[{"kid":"a","kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+		`{"keys":[{"kid":"a","kty":"OKP","crv":"Ed25519","x":"AQ"}]}`,
+	} {
+		if _, err := ParseKeySet([]byte(doc)); err == nil {
+			t.Fatalf("Expected %s to be rejected", doc)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoKeyFields is returned by KeyFor for values that aren't structs with
+// fields tagged cachekey
+var ErrNoKeyFields = errors.New("gocache: no cachekey fields")
+
+// keyField is a struct field included in derived keys
+type keyField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// keyFields caches the cachekey fields of each struct type
+var keyFields sync.Map // reflect.Type -> []keyField
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// KeyFor derives a stable cache key from the fields of a struct tagged
+// cachekey, e.g. for memoizing requests by their parameters:
+//
+//	type SearchRequest struct {
+//		Query  string   `cachekey:"q"`
+//		Page   int      `cachekey:"page,omitempty"`
+//		Tags   []string `cachekey:"tags"`
+//		Cursor string   // not part of the key
+//	}
+//
+// gives keys like "pkg.SearchRequest{page=2,q=go+cache,tags=[a,b]}". Fields
+// are ordered by name, maps by key, and values are escaped so distinct
+// requests can't produce the same key. The omitempty option leaves out zero
+// values, so adding an optional field doesn't change existing keys. Untagged
+// fields are ignored; nested structs use their own tagged fields if they have
+// any and fmt's formatting otherwise.
+func KeyFor(v any) (string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", ErrNoKeyFields
+	}
+	fields := fieldsFor(rv.Type())
+	if len(fields) == 0 {
+		return "", ErrNoKeyFields
+	}
+
+	var b strings.Builder
+	b.WriteString(rv.Type().String())
+	writeStruct(&b, rv, fields)
+	return b.String(), nil
+}
+
+// fieldsFor returns the cachekey fields of t, sorted by name
+func fieldsFor(t reflect.Type) []keyField {
+	if cached, ok := keyFields.Load(t); ok {
+		return cached.([]keyField)
+	}
+
+	var fields []keyField
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup("cachekey")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, keyField{name: name, index: f.Index, omitEmpty: opts == "omitempty"})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+
+	keyFields.Store(t, fields)
+	return fields
+}
+
+// writeStruct writes the key fields of a struct value as {name=value,...}
+func writeStruct(b *strings.Builder, v reflect.Value, fields []keyField) {
+	b.WriteByte('{')
+	first := true
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.WriteString(url.QueryEscape(f.name))
+		b.WriteByte('=')
+		writeValue(b, fv)
+	}
+	b.WriteByte('}')
+}
+
+// writeValue writes a value in an unambiguous textual form
+func writeValue(b *strings.Builder, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		writeValue(b, v.Elem())
+	case reflect.String:
+		b.WriteString(url.QueryEscape(v.String()))
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeValue(b, v.Index(i))
+		}
+		b.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		entries := make([][2]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var k, e strings.Builder
+			writeValue(&k, iter.Key())
+			writeValue(&e, iter.Value())
+			entries = append(entries, [2]string{k.String(), e.String()})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i][0] < entries[j][0]
+		})
+		b.WriteByte('{')
+		for i, entry := range entries {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(entry[0])
+			b.WriteByte(':')
+			b.WriteString(entry[1])
+		}
+		b.WriteByte('}')
+	case reflect.Struct:
+		if v.Type() == timeType {
+			b.WriteString(v.Interface().(time.Time).UTC().Format(time.RFC3339Nano))
+			return
+		}
+		if fields := fieldsFor(v.Type()); len(fields) > 0 {
+			writeStruct(b, v, fields)
+			return
+		}
+		b.WriteString(url.QueryEscape(fmt.Sprintf("%+v", v.Interface())))
+	default:
+		b.WriteString(url.QueryEscape(fmt.Sprintf("%v", v)))
+	}
+}
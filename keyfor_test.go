@@ -0,0 +1,58 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+type searchRequest struct {
+	Query   string            `cachekey:"q"`
+	Page    int               `cachekey:"page,omitempty"`
+	Tags    []string          `cachekey:"tags"`
+	Filters map[string]string `cachekey:"filters,omitempty"`
+	Since   time.Time         `cachekey:"since,omitempty"`
+	Cursor  string
+}
+
+func TestKeyFor(t *testing.T) {
+	key, err := KeyFor(searchRequest{Query: "go cache", Page: 2, Tags: []string{"a", "b"}, Cursor: "ignored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "gocache.searchRequest{page=2,q=go+cache,tags=[a,b]}"
+	if key != want {
+		t.Fatalf("Expected %s, got %s", want, key)
+	}
+
+	ptrKey, _ := KeyFor(&searchRequest{Query: "go cache", Page: 2, Tags: []string{"a", "b"}, Cursor: "other"})
+	if ptrKey != key {
+		t.Fatalf("Expected pointers and untagged fields not to change the key, got %s", ptrKey)
+	}
+}
+
+func TestKeyForStable(t *testing.T) {
+	req := searchRequest{Query: "q", Filters: map[string]string{"b": "2", "a": "1", "c": "3"}}
+	first, _ := KeyFor(req)
+	for i := 0; i < 20; i++ {
+		if key, _ := KeyFor(req); key != first {
+			t.Fatalf("Expected map order not to change the key, got %s and %s", first, key)
+		}
+	}
+}
+
+func TestKeyForEscaping(t *testing.T) {
+	a, _ := KeyFor(searchRequest{Query: "x,page=3"})
+	b, _ := KeyFor(searchRequest{Query: "x", Page: 3})
+	if a == b {
+		t.Fatalf("Expected distinct requests to have distinct keys, both got %s", a)
+	}
+}
+
+func TestKeyForNoFields(t *testing.T) {
+	if _, err := KeyFor(testStruct{Name: "a"}); err != ErrNoKeyFields {
+		t.Fatalf("Expected ErrNoKeyFields, got %v", err)
+	}
+	if _, err := KeyFor("string"); err != ErrNoKeyFields {
+		t.Fatalf("Expected ErrNoKeyFields, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package gocache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrEmptyKey is reported for empty keys when KeyPolicy.RejectEmpty is set
+	ErrEmptyKey = errors.New("empty key")
+	// ErrKeyTooLong is reported for keys longer than KeyPolicy.MaxLength
+	ErrKeyTooLong = errors.New("key too long")
+	// ErrInvalidKeyChar is reported for keys containing a character that
+	// KeyPolicy.AllowedChars rejects
+	ErrInvalidKeyChar = errors.New("invalid character in key")
+)
+
+// KeyError is returned by write operations for keys that violate the cache's
+// KeyPolicy. It wraps one of ErrEmptyKey, ErrKeyTooLong or ErrInvalidKeyChar.
+type KeyError struct {
+	Key string // as shown by DisplayKey, see UnsafeKey
+	Err error
+
+	rawKey string
+}
+
+func (e *KeyError) Error() string {
+	key := e.Key
+	if len(key) > 64 {
+		key = key[:64] + "..."
+	}
+	return fmt.Sprintf("gocache: %v: %q", e.Err, key)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// KeyPolicy constrains the keys accepted by write operations
+type KeyPolicy struct {
+	// MaxLength is the maximum key length in bytes. 0 means unlimited.
+	MaxLength int
+
+	// HashLongKeys replaces keys longer than MaxLength with a fixed-length
+	// SHA-256 based key instead of rejecting them. Reads apply the same
+	// mapping, so long keys keep working transparently. A MaxLength below
+	// MinHashedKeyLength is raised to it, since shorter keys couldn't hold
+	// the whole digest.
+	HashLongKeys bool
+
+	// RejectEmpty rejects the empty key
+	RejectEmpty bool
+
+	// AllowedChars, if set, must return true for every rune of a key
+	AllowedChars func(r rune) bool
+}
+
+// MinHashedKeyLength is the smallest MaxLength used with HashLongKeys: one
+// byte of the original key, a separator and the 64 hex digits of the SHA-256
+const MinHashedKeyLength = 1 + 1 + 2*sha256.Size
+
+// WithKeyPolicy enforces policy on every key written to the cache. Violations
+// are reported as *KeyError.
+func WithKeyPolicy(policy KeyPolicy) Option {
+	return func(c *Cache) {
+		if policy.HashLongKeys && policy.MaxLength > 0 {
+			policy.MaxLength = max(policy.MaxLength, MinHashedKeyLength)
+		}
+		c.keyPolicy = &policy
+	}
+}
+
+// PrintableASCII allows the printable ASCII characters except space, matching
+// what text protocols such as memcached accept in keys
+func PrintableASCII(r rune) bool {
+	return r > ' ' && r <= '~'
+}
+
+// checkKey validates key against the key and namespace policies and returns
+// the key to store the item under
+func (c *Cache) checkKey(key string) (string, error) {
+	if c.closed.Load() {
+		return "", ErrClosed
+	}
+	if ns := c.namespacePolicy(key); ns != nil && !ns.allowStore(key) {
+		return "", ErrNoStore
+	}
+
+	p := c.keyPolicy
+	if p == nil {
+		return key, nil
+	}
+
+	if key == "" && p.RejectEmpty {
+		return "", c.keyError(key, ErrEmptyKey)
+	}
+	if p.AllowedChars != nil {
+		if !utf8.ValidString(key) || strings.IndexFunc(key, func(r rune) bool { return !p.AllowedChars(r) }) >= 0 {
+			return "", c.keyError(key, ErrInvalidKeyChar)
+		}
+	}
+	if p.MaxLength > 0 && len(key) > p.MaxLength {
+		if !p.HashLongKeys {
+			return "", c.keyError(key, ErrKeyTooLong)
+		}
+		return hashKey(key, p.MaxLength), nil
+	}
+	return key, nil
+}
+
+// mapKey returns the key an item would be stored under, without validating it
+func (c *Cache) mapKey(key string) string {
+	p := c.keyPolicy
+	if p == nil || !p.HashLongKeys || p.MaxLength <= 0 || len(key) <= p.MaxLength {
+		return key
+	}
+	return hashKey(key, p.MaxLength)
+}
+
+// mapKeys applies mapKey to each of keys
+func (c *Cache) mapKeys(keys []string) []string {
+	if c.keyPolicy == nil {
+		return keys
+	}
+	mapped := make([]string, len(keys))
+	for i, key := range keys {
+		mapped[i] = c.mapKey(key)
+	}
+	return mapped
+}
+
+// hashKey derives a key of maxLength bytes, at least MinHashedKeyLength,
+// from a longer key. As much of the original key as fits is kept as a
+// prefix, which keeps prefix-based namespaces working, followed by the hex
+// SHA-256 of the whole key.
+func hashKey(key string, maxLength int) string {
+	sum := sha256.Sum256([]byte(key))
+	return key[:maxLength-1-2*sha256.Size] + "#" + hex.EncodeToString(sum[:])
+}
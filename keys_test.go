@@ -0,0 +1,87 @@
+package gocache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	c := New(0, WithKeyPolicy(KeyPolicy{
+		MaxLength:    16,
+		RejectEmpty:  true,
+		AllowedChars: PrintableASCII,
+	}))
+
+	var keyErr *KeyError
+	if err := c.Set("", "value"); !errors.Is(err, ErrEmptyKey) || !errors.As(err, &keyErr) {
+		t.Fatalf("Expected ErrEmptyKey as *KeyError, got %v", err)
+	}
+	if err := c.Set("has space", "value"); !errors.Is(err, ErrInvalidKeyChar) {
+		t.Fatalf("Expected ErrInvalidKeyChar, got %v", err)
+	}
+	if err := c.SetRaw(strings.Repeat("k", 17), []byte("value"), 0); !errors.Is(err, ErrKeyTooLong) {
+		t.Fatalf("Expected ErrKeyTooLong, got %v", err)
+	}
+	if err := c.Watch().Exec(SetOp("bad\nkey", "value", 0)); !errors.Is(err, ErrInvalidKeyChar) {
+		t.Fatalf("Expected Exec to validate keys, got %v", err)
+	}
+	if c.Count() != 0 {
+		t.Fatalf("Invalid keys must not be stored, have %d items", c.Count())
+	}
+
+	if err := c.Set("user:1", "value"); err != nil {
+		t.Fatalf("Valid key rejected: %v", err)
+	}
+}
+
+func TestHashLongKeys(t *testing.T) {
+	c := New(0, WithKeyPolicy(KeyPolicy{MaxLength: 80, HashLongKeys: true}))
+
+	long := "search:" + strings.Repeat("q", 200)
+	if err := c.Set(long, "results"); err != nil {
+		t.Fatalf("Long key should be hashed, got %v", err)
+	}
+
+	if val, found := c.GetString(long); !found || val != "results" {
+		t.Fatalf("Expected to read through the hashed key, got '%s' (found=%v)", val, found)
+	}
+
+	c.Delete(long)
+	if c.Exists(long) {
+		t.Fatal("Delete should apply the same key mapping")
+	}
+
+	if got := hashKey(long, 80); len(got) != 80 || !strings.HasPrefix(got, "search:") {
+		t.Fatalf("Hashed key should keep a prefix and fit the limit, got %q", got)
+	}
+}
+
+func TestHashLongKeysSmallMaxLength(t *testing.T) {
+	c := New(0, WithKeyPolicy(KeyPolicy{MaxLength: 16, HashLongKeys: true}))
+	if c.keyPolicy.MaxLength != MinHashedKeyLength {
+		t.Fatalf("Expected MaxLength to be raised to %d, got %d", MinHashedKeyLength, c.keyPolicy.MaxLength)
+	}
+
+	// Keys differing only past the digest's reach must not collide
+	a := strings.Repeat("k", 100) + "a"
+	b := strings.Repeat("k", 100) + "b"
+	c.Set(a, "A")
+	c.Set(b, "B")
+	if val, _ := c.GetString(a); val != "A" {
+		t.Fatalf("Expected A, got %q", val)
+	}
+	if val, _ := c.GetString(b); val != "B" {
+		t.Fatalf("Expected B, got %q", val)
+	}
+
+	// Keys up to the raised limit are stored as is
+	if short := strings.Repeat("x", 40); c.mapKey(short) != short {
+		t.Fatalf("Expected a %d byte key to be kept, got %q", len(short), c.mapKey(short))
+	}
+
+	got := hashKey(a, MinHashedKeyLength)
+	if len(got) != MinHashedKeyLength || !strings.HasPrefix(got, "k#") {
+		t.Fatalf("Expected the full digest after one byte of the key, got %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+package gocache
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// latencyOp identifies an operation whose latency is tracked
+type latencyOp int
+
+const (
+	opGet    latencyOp = iota // lookups by Get, GetBytes, GetString and friends
+	opSet                     // encoding and storing by Set and friends
+	opDelete                  // Delete, including cascading invalidations
+	opDecode                  // decoding by Get and GetInto
+	opLoad                    // loader calls by GetOrLoad
+	numLatencyOps
+)
+
+var latencyOpNames = [numLatencyOps]string{"get", "set", "delete", "decode", "load"}
+
+// latencyBounds are the upper bounds of the latency buckets: powers of two
+// from 1µs to about 1s, then everything slower
+var latencyBounds = func() []time.Duration {
+	bounds := make([]time.Duration, 0, 22)
+	for d := time.Microsecond; d < 2*time.Second; d *= 2 {
+		bounds = append(bounds, d)
+	}
+	return append(bounds, math.MaxInt64)
+}()
+
+// latencyRecorder holds a lock-free latency histogram per operation
+type latencyRecorder struct {
+	sampleRate uint64
+	ops        [numLatencyOps]latencyHistogram
+}
+
+type latencyHistogram struct {
+	calls   atomic.Uint64 // all calls, to pick the sampled ones
+	count   atomic.Uint64 // sampled calls
+	sum     atomic.Int64  // total sampled latency in nanoseconds
+	buckets []atomic.Uint64
+}
+
+// LatencyStats is the latency histogram of one operation. Only sampled calls
+// are counted, see WithLatencyTracking.
+type LatencyStats struct {
+	Op      string          // "get", "set", "delete", "decode" or "load"
+	Count   uint64          // number of sampled calls
+	Sum     time.Duration   // total latency of the sampled calls
+	Bounds  []time.Duration // upper bound of each bucket, the last is math.MaxInt64
+	Buckets []uint64        // number of sampled calls per bucket
+}
+
+// WithLatencyTracking records latency histograms of Get, Set, Delete,
+// decoding and loader calls, so regressions from serialization or lock
+// contention show up in monitoring. Only one in sampleRate calls is timed to
+// keep the overhead low; a sampleRate of 1 or less times every call. The
+// histograms are read with Latencies or WriteLatencyMetrics.
+func WithLatencyTracking(sampleRate int) Option {
+	return func(c *Cache) {
+		if sampleRate < 1 {
+			sampleRate = 1
+		}
+		c.latency = &latencyRecorder{sampleRate: uint64(sampleRate)}
+		for i := range c.latency.ops {
+			c.latency.ops[i].buckets = make([]atomic.Uint64, len(latencyBounds))
+		}
+	}
+}
+
+// start returns the start time of a call to op if it's sampled, or the zero
+// time otherwise
+func (r *latencyRecorder) start(op latencyOp) time.Time {
+	if r.ops[op].calls.Add(1)%r.sampleRate != 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe records the latency of a sampled call started at start
+func (r *latencyRecorder) observe(op latencyOp, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	elapsed := time.Since(start)
+	h := &r.ops[op]
+	h.count.Add(1)
+	h.sum.Add(int64(elapsed))
+	for i, bound := range latencyBounds {
+		if elapsed <= bound {
+			h.buckets[i].Add(1)
+			return
+		}
+	}
+}
+
+// Latencies returns the latency histogram of each tracked operation, or nil
+// unless WithLatencyTracking is enabled
+func (c *Cache) Latencies() []LatencyStats {
+	if c.latency == nil {
+		return nil
+	}
+
+	stats := make([]LatencyStats, numLatencyOps)
+	for op := range stats {
+		h := &c.latency.ops[op]
+		s := &stats[op]
+		s.Op = latencyOpNames[op]
+		s.Count = h.count.Load()
+		s.Sum = time.Duration(h.sum.Load())
+		s.Bounds = latencyBounds
+		s.Buckets = make([]uint64, len(h.buckets))
+		for i := range h.buckets {
+			s.Buckets[i] = h.buckets[i].Load()
+		}
+	}
+	return stats
+}
+
+// Quantile returns an upper bound of the q-quantile latency, e.g. q=0.99 for
+// the p99, or 0 if no calls were sampled
+func (s LatencyStats) Quantile(q float64) time.Duration {
+	var total uint64
+	for _, n := range s.Buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, n := range s.Buckets {
+		seen += n
+		if seen >= rank && n > 0 {
+			return s.Bounds[i]
+		}
+	}
+	return s.Bounds[len(s.Bounds)-1]
+}
+
+// WriteLatencyMetrics writes the latency histograms in the Prometheus text
+// exposition format as gocache_operation_duration_seconds, labelled with the
+// given cache name and the operation. It writes nothing unless
+// WithLatencyTracking is enabled.
+func (c *Cache) WriteLatencyMetrics(w io.Writer, name string) error {
+	stats := c.Latencies()
+	if stats == nil {
+		return nil
+	}
+
+	const metric = "gocache_operation_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of sampled cache operations.\n", metric)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", metric)
+	for _, s := range stats {
+		labels := fmt.Sprintf("cache=%q,op=%q", name, s.Op)
+		var cumulative uint64
+		for i, n := range s.Buckets {
+			cumulative += n
+			le := "+Inf"
+			if s.Bounds[i] != math.MaxInt64 {
+				le = fmt.Sprint(s.Bounds[i].Seconds())
+			}
+			fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n", metric, labels, le, cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", metric, labels, s.Sum.Seconds())
+		_, err := fmt.Fprintf(w, "%s_count{%s} %d\n", metric, labels, s.Count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
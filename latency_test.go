@@ -0,0 +1,65 @@
+package gocache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracking(t *testing.T) {
+	c := New(0, WithLatencyTracking(1))
+	c.Set("key", testStruct{Name: "John", Age: 30})
+	var s testStruct
+	c.Get("key", &s)
+	c.GetBytes("missing")
+	c.Delete("key")
+	c.GetOrLoad(context.Background(), "loaded", &s, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		time.Sleep(2 * time.Millisecond)
+		return "value", 0, nil
+	})
+
+	counts := make(map[string]uint64)
+	var load LatencyStats
+	for _, stats := range c.Latencies() {
+		counts[stats.Op] = stats.Count
+		if stats.Op == "load" {
+			load = stats
+		}
+	}
+	if counts["get"] != 3 || counts["set"] != 1 || counts["delete"] != 1 || counts["decode"] != 1 || counts["load"] != 1 {
+		t.Fatalf("Expected every call to be timed, got %v", counts)
+	}
+	if p99 := load.Quantile(0.99); p99 < 2*time.Millisecond || p99 > time.Second {
+		t.Fatalf("Expected the load p99 to cover the 2ms loader, got %v", p99)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteLatencyMetrics(&buf, "users"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE gocache_operation_duration_seconds histogram",
+		`gocache_operation_duration_seconds_bucket{cache="users",op="set",le="+Inf"} 1`,
+		`gocache_operation_duration_seconds_count{cache="users",op="get"} 3`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("Expected metrics to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestLatencySampling(t *testing.T) {
+	c := New(0, WithLatencyTracking(10))
+	for i := 0; i < 100; i++ {
+		c.GetBytes("key")
+	}
+	if stats := c.Latencies()[opGet]; stats.Count != 10 {
+		t.Fatalf("Expected 1 in 10 calls to be timed, got %d", stats.Count)
+	}
+
+	if New(0).Latencies() != nil {
+		t.Fatal("Expected no latencies without WithLatencyTracking")
+	}
+}
@@ -0,0 +1,132 @@
+package gocache
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrLeaseHeld is returned by AcquireLease when another owner holds a live
+// lease on the key
+var ErrLeaseHeld = errors.New("gocache: lease held by another owner")
+
+// ErrLeaseLost is returned by Renew and Release when the lease expired or
+// was taken over, so the holder must stop acting on it
+var ErrLeaseLost = errors.New("gocache: lease lost")
+
+// errLeaseTTL is returned for leases that would never expire
+var errLeaseTTL = errors.New("gocache: lease ttl must be positive")
+
+// Lease is a time-boxed exclusive claim on a key, see AcquireLease
+type Lease struct {
+	Key     string
+	Owner   string
+	Token   uint64    // fencing token, increases with every acquisition
+	Expires time.Time // when the lease lapses unless renewed
+
+	cache *Cache
+}
+
+// leaseRecord is the value stored under a leased key
+type leaseRecord struct {
+	Owner string `json:"owner"`
+	Token uint64 `json:"token"`
+}
+
+// AcquireLease claims key for owner for ttl, failing with ErrLeaseHeld if
+// another owner holds a live lease. Re-acquiring a lease the owner already
+// holds issues a new one.
+//
+// A lease is only as good as the clock and the cache holding it: a holder
+// that stalls past Expires may still believe it holds the lease. Pass Token
+// to the resources the lease protects and have them reject tokens lower than
+// the highest seen, so writes from a stale holder are fenced off.
+func (c *Cache) AcquireLease(key, owner string, ttl time.Duration) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, errLeaseTTL
+	}
+	key, err := c.checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.leaseLocked(key); ok && current.Owner != owner {
+		return nil, ErrLeaseHeld
+	}
+
+	// Versions increase with every write to the cache, which makes the next
+	// one a valid fencing token
+	record := leaseRecord{Owner: owner, Token: c.version + 1}
+	value, _ := json.Marshal(record)
+	expiration := expirationFor(ttl)
+	c.setLocked(key, Item{Value: value, Expiration: expiration, priority: PriorityPinned, format: FormatJSON})
+
+	return &Lease{
+		Key:     key,
+		Owner:   owner,
+		Token:   record.Token,
+		Expires: time.Unix(0, c.items[key].Expiration),
+		cache:   c,
+	}, nil
+}
+
+// Renew extends the lease to ttl from now, failing with ErrLeaseLost if it
+// lapsed or was taken over in the meantime. Like AcquireLease, it rejects a
+// ttl that isn't positive.
+func (l *Lease) Renew(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errLeaseTTL
+	}
+	c := l.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := l.heldLocked()
+	if !ok {
+		return ErrLeaseLost
+	}
+	c.setLocked(l.Key, Item{Value: item.Value, Expiration: expirationFor(ttl), priority: PriorityPinned, format: FormatJSON})
+	l.Expires = time.Unix(0, c.items[l.Key].Expiration)
+	return nil
+}
+
+// Release gives up the lease, failing with ErrLeaseLost if it already lapsed
+// or was taken over
+func (l *Lease) Release() error {
+	c := l.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := l.heldLocked(); !ok {
+		return ErrLeaseLost
+	}
+	c.deleteLocked(l.Key, EventDelete)
+	return nil
+}
+
+// heldLocked returns the item storing l if l is still live. The caller must
+// hold the write lock.
+func (l *Lease) heldLocked() (Item, bool) {
+	current, ok := l.cache.leaseLocked(l.Key)
+	if !ok || current.Owner != l.Owner || current.Token != l.Token {
+		return Item{}, false
+	}
+	return l.cache.items[l.Key], true
+}
+
+// leaseLocked decodes the live lease stored under key. Keys holding other
+// values are treated as free. The caller must hold the lock.
+func (c *Cache) leaseLocked(key string) (leaseRecord, bool) {
+	item, found := c.items[key]
+	if !found || c.staleLocked(item, time.Now().UnixNano()) {
+		return leaseRecord{}, false
+	}
+	var record leaseRecord
+	if err := json.Unmarshal(item.Value, &record); err != nil || record.Owner == "" {
+		return leaseRecord{}, false
+	}
+	return record, true
+}
@@ -0,0 +1,136 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetNX(t *testing.T) {
+	c := New(0)
+	if ok, err := c.SetNX("k", "first", 0); !ok || err != nil {
+		t.Fatalf("Expected the first SetNX to succeed, got %v %v", ok, err)
+	}
+	if ok, _ := c.SetNX("k", "second", 0); ok {
+		t.Fatal("Expected SetNX on a live key to fail")
+	}
+	c.SetWithExpiration("expired", "v", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if ok, _ := c.SetNX("expired", "v", 0); !ok {
+		t.Fatal("Expected SetNX on an expired key to succeed")
+	}
+	if v, _ := c.GetString("k"); v != "first" {
+		t.Fatalf("Expected first, got %q", v)
+	}
+}
+
+func TestSetNXSpilled(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+	c := New(0, WithMaxBytes(10), WithOverflow(store))
+	c.Set("a", "value-a")
+	c.Set("b", "value-b") // spills a
+
+	if ok, _ := c.SetNX("a", "claimed", 0); ok {
+		t.Fatal("Expected SetNX on a spilled live key to fail")
+	}
+	if v, _ := c.GetString("a"); v != "value-a" {
+		t.Fatalf("Expected value-a, got %q", v)
+	}
+}
+
+func TestSetNXValueExpiration(t *testing.T) {
+	c := New(0)
+	expires := time.Now().Add(time.Minute)
+	c.SetNX("url", signedURL{Expires: expires}, time.Hour)
+	if ttl, _ := c.TTL("url"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected the value's own expiry to cap the TTL, got %v", ttl)
+	}
+}
+
+func TestLease(t *testing.T) {
+	c := New(0)
+	a, err := c.AcquireLease("lock:job", "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AcquireLease("lock:job", "b", time.Minute); err != ErrLeaseHeld {
+		t.Fatalf("Expected ErrLeaseHeld, got %v", err)
+	}
+
+	expires := a.Expires
+	time.Sleep(time.Millisecond)
+	if err := a.Renew(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Expires.After(expires) {
+		t.Fatal("Expected Renew to extend the lease")
+	}
+
+	if err := a.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Release(); err != ErrLeaseLost {
+		t.Fatalf("Expected ErrLeaseLost after release, got %v", err)
+	}
+
+	b, err := c.AcquireLease("lock:job", "b", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Token <= a.Token {
+		t.Fatalf("Expected fencing tokens to increase, got %d after %d", b.Token, a.Token)
+	}
+}
+
+func TestLeaseExpires(t *testing.T) {
+	c := New(0)
+	a, _ := c.AcquireLease("lock", "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	b, err := c.AcquireLease("lock", "b", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected the lapsed lease to be free, got %v", err)
+	}
+	if err := a.Renew(time.Minute); err != ErrLeaseLost {
+		t.Fatalf("Expected the stale holder to get ErrLeaseLost, got %v", err)
+	}
+	if err := a.Release(); err != ErrLeaseLost {
+		t.Fatalf("Expected the stale holder's release to fail, got %v", err)
+	}
+	if err := b.Renew(time.Minute); err != nil {
+		t.Fatalf("Expected the new holder to keep the lease, got %v", err)
+	}
+}
+
+func TestLeaseRenewRejectsNonPositiveTTL(t *testing.T) {
+	c := New(0)
+	if _, err := c.AcquireLease("lock", "a", 0); err != errLeaseTTL {
+		t.Fatalf("Expected errLeaseTTL from AcquireLease, got %v", err)
+	}
+	l, _ := c.AcquireLease("lock", "a", time.Minute)
+	expires := l.Expires
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if err := l.Renew(ttl); err != errLeaseTTL {
+			t.Fatalf("Expected errLeaseTTL for %v, got %v", ttl, err)
+		}
+	}
+	if !l.Expires.Equal(expires) {
+		t.Fatalf("Expected the lease to keep expiring at %v, got %v", expires, l.Expires)
+	}
+	if ttl, _ := c.TTL("lock"); ttl <= 0 {
+		t.Fatalf("Expected the lease to keep its expiration, got %v", ttl)
+	}
+}
+
+func TestLeaseSurvivesEviction(t *testing.T) {
+	c := New(0, WithMaxBytes(64))
+	l, _ := c.AcquireLease("lock", "a", time.Minute)
+	for i := 0; i < 10; i++ {
+		c.Set(string(rune('a'+i)), "0123456789")
+	}
+	if err := l.Renew(time.Minute); err != nil {
+		t.Fatalf("Expected the lease not to be evicted, got %v", err)
+	}
+}
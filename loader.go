@@ -0,0 +1,294 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLoadTimeout is returned by GetOrLoad when the loader doesn't finish
+// within the LoadTimeout and no fallback value is available
+var ErrLoadTimeout = errors.New("gocache: loader timed out")
+
+// Loader produces the value for a key missing from the cache, along with the
+// TTL to store it with (0 means no expiration)
+type Loader func(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error)
+
+// LoadOption configures a single GetOrLoad call
+type LoadOption func(*loadOptions)
+
+// loadOptions holds the per-call settings collected from LoadOptions
+type loadOptions struct {
+	timeout     time.Duration
+	serveStale  bool
+	fallback    interface{}
+	hasFallback bool
+	breaker     *CircuitBreaker
+	retry       *RetryPolicy
+	errorTTL    func(error) time.Duration
+	softTTL     time.Duration
+}
+
+// loadError is a loader error cached by LoadErrors. They're kept apart from
+// the items so they don't show up in counts, snapshots or change feeds.
+type loadError struct {
+	message    string
+	expiration int64
+}
+
+// LoadTimeout bounds how long GetOrLoad waits for the loader. The loader
+// keeps running after the deadline and its result is still cached, so a slow
+// origin only delays the callers that arrived before it answered.
+func LoadTimeout(timeout time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.timeout = timeout
+	}
+}
+
+// ServeStale makes GetOrLoad return the last known good value, an expired
+// item not yet removed by the janitor, when the loader times out or fails
+func ServeStale() LoadOption {
+	return func(o *loadOptions) {
+		o.serveStale = true
+	}
+}
+
+// LoadFallback makes GetOrLoad decode value into the target when the loader
+// times out or fails and no stale value is available
+func LoadFallback(value interface{}) LoadOption {
+	return func(o *loadOptions) {
+		o.fallback = value
+		o.hasFallback = true
+	}
+}
+
+// LoadErrors caches loader errors for the duration policy returns for them,
+// so an error storm calls a failing origin once per duration instead of once
+// per request, while failures still clear up long before the value's TTL
+// would. Errors with a duration of 0 aren't cached, nor is the caller giving
+// up on a slow loader. A cached error is returned as *RecordedError, after
+// ServeStale and LoadFallback had a chance to answer instead.
+func LoadErrors(policy func(err error) time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.errorTTL = policy
+	}
+}
+
+// TransientErrors returns a LoadErrors policy caching every error for ttl
+// except context.Canceled, which says nothing about the origin
+func TransientErrors(ttl time.Duration) func(error) time.Duration {
+	return func(err error) time.Duration {
+		if errors.Is(err, context.Canceled) {
+			return 0
+		}
+		return ttl
+	}
+}
+
+// loadCall is an in-flight loader execution shared by concurrent callers
+type loadCall struct {
+	done chan struct{}
+	item Item // the item stored by the loader
+	err  error
+}
+
+// GetOrLoad decodes the item stored under key into target like Get. On a miss
+// it calls loader, stores the result and decodes it into target. Concurrent
+// misses for the same key share a single loader call.
+//
+// The loader runs detached from ctx's cancellation, so one caller giving up
+// doesn't abort a load other callers are waiting on; ctx's values are still
+// passed through. Loaders should bound their own origin calls. Callers stop
+// waiting when ctx is done or LoadTimeout expires, in which case ServeStale
+// and LoadFallback provide a degraded answer.
+//
+// Items past their soft TTL, see WithSoftTTL and LoadSoftTTL, are served
+// while the loader refreshes them in the background.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, target interface{}, loader Loader, opts ...LoadOption) error {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.breaker != nil {
+		loader = o.breaker.guard(loader)
+	}
+	if o.retry != nil {
+		loader = o.retry.wrap(loader)
+	}
+
+	if item, found := c.get(key); found {
+		err := decodeItem(item, target)
+		if err == nil || !c.repairItem(key, item, err) {
+			if err == nil && item.softExpired(time.Now().UnixNano()) {
+				c.startLoad(ctx, key, loader, o.softTTL)
+			}
+			return err
+		}
+		// The corrupt entry is gone, load it again
+	}
+
+	waitCtx := ctx
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	var err error
+	if msg, found := c.recordedError(key, o); found {
+		err = &RecordedError{Message: msg}
+	} else {
+		call := c.startLoad(ctx, key, loader, o.softTTL)
+		select {
+		case <-call.done:
+			if call.err == nil {
+				return decodeItem(call.item, target)
+			}
+			err = call.err
+			c.recordError(key, o, err)
+		case <-waitCtx.Done():
+			err = waitCtx.Err()
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				err = ErrLoadTimeout
+			}
+		}
+	}
+
+	if o.serveStale {
+		if item, found := c.staleItem(key); found {
+			return decodeItem(item, target)
+		}
+	}
+	if o.hasFallback {
+		bytes, encErr := encode(o.fallback)
+		if encErr != nil {
+			return encErr
+		}
+		return decode(bytes, target)
+	}
+	return err
+}
+
+// recordedError returns the loader error cached for key, see LoadErrors
+func (c *Cache) recordedError(key string, o loadOptions) (string, bool) {
+	if o.errorTTL == nil {
+		return "", false
+	}
+
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	e, found := c.loadErrors[key]
+	if !found {
+		return "", false
+	}
+	if time.Now().UnixNano() > e.expiration {
+		delete(c.loadErrors, key)
+		return "", false
+	}
+	return e.message, true
+}
+
+// recordError caches a loader error for key if the LoadErrors policy says so
+func (c *Cache) recordError(key string, o loadOptions, err error) {
+	if o.errorTTL == nil {
+		return
+	}
+	ttl := o.errorTTL(err)
+	if ttl <= 0 {
+		return
+	}
+
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	if c.loadErrors == nil {
+		c.loadErrors = make(map[string]loadError)
+	}
+	c.loadErrors[key] = loadError{message: err.Error(), expiration: time.Now().Add(ttl).UnixNano()}
+}
+
+// purgeLoadErrors drops the cached loader errors that expired before now
+func (c *Cache) purgeLoadErrors(now int64) {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	for key, e := range c.loadErrors {
+		if now > e.expiration {
+			delete(c.loadErrors, key)
+		}
+	}
+}
+
+// startLoad returns the in-flight load for key, starting one if needed. The
+// loaded item becomes stale after softTTL, 0 for never.
+func (c *Cache) startLoad(ctx context.Context, key string, loader Loader, softTTL time.Duration) *loadCall {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	if call, ok := c.loads[key]; ok {
+		return call
+	}
+	if c.loads == nil {
+		c.loads = make(map[string]*loadCall)
+	}
+
+	call := &loadCall{done: make(chan struct{})}
+	c.loads[key] = call
+
+	go func() {
+		call.item, call.err = c.runLoader(context.WithoutCancel(ctx), key, loader, softTTL)
+
+		c.loadMu.Lock()
+		delete(c.loads, key)
+		c.loadMu.Unlock()
+		close(call.done)
+	}()
+
+	return call
+}
+
+// runLoader calls the loader and stores its result
+func (c *Cache) runLoader(ctx context.Context, key string, loader Loader, softTTL time.Duration) (Item, error) {
+	var start time.Time
+	if c.latency != nil {
+		start = c.latency.start(opLoad)
+	}
+	value, ttl, err := loader(ctx, key)
+	if c.latency != nil {
+		c.latency.observe(opLoad, start)
+	}
+	if err != nil {
+		return Item{}, err
+	}
+
+	bytes, err := encode(value)
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{Value: bytes, Expiration: capToValue(value, expirationFor(ttl)), own: valueExpiration(value), softExpiration: expirationFor(softTTL), format: formatOf(value), typeID: c.fingerprint(value)}
+	// The caller decodes the item as loaded, not as middleware stored it
+	if c.middleware != nil {
+		err = c.storeThrough(c.mapKey(key), item, func(item Item) error {
+			return c.storeItem(key, item)
+		})
+	} else {
+		err = c.storeItem(key, item)
+	}
+	if err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// staleItem returns an expired item that hasn't been removed yet
+func (c *Cache) staleItem(key string) (Item, bool) {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, found := c.items[key]
+	if !found || item.version <= c.flushedAt {
+		return Item{}, false
+	}
+	return item, true
+}
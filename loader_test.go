@@ -0,0 +1,166 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := New(0)
+	var calls int32
+
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return testStruct{Name: key, Age: 30}, time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var item testStruct
+			if err := c.GetOrLoad(context.Background(), "john", &item, loader); err != nil {
+				t.Errorf("Error loading: %v", err)
+			}
+			if item.Name != "john" {
+				t.Errorf("Unexpected item: %+v", item)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Concurrent misses should share one loader call, got %d", calls)
+	}
+	if ttl, _ := c.TTL("john"); ttl <= 0 {
+		t.Fatalf("Loaded value should be cached with the loader's TTL, got %v", ttl)
+	}
+
+	// Hits don't call the loader
+	var item testStruct
+	c.GetOrLoad(context.Background(), "john", &item, loader)
+	if calls != 1 {
+		t.Fatalf("Cached value should be served without loading, got %d calls", calls)
+	}
+}
+
+func TestGetOrLoadTimeout(t *testing.T) {
+	c := New(0)
+	release := make(chan struct{})
+
+	slow := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		<-release
+		return "fresh", 0, nil
+	}
+
+	var val string
+	err := c.GetOrLoad(context.Background(), "key", &val, slow, LoadTimeout(10*time.Millisecond))
+	if !errors.Is(err, ErrLoadTimeout) {
+		t.Fatalf("Expected ErrLoadTimeout, got %v", err)
+	}
+
+	err = c.GetOrLoad(context.Background(), "key", &val, slow,
+		LoadTimeout(10*time.Millisecond), LoadFallback("default"))
+	if err != nil || val != "default" {
+		t.Fatalf("Expected fallback value, got '%s' (err=%v)", val, err)
+	}
+
+	// The slow load still completes in the background and gets cached
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for !c.Exists("key") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if v, _ := c.GetString("key"); v != "fresh" {
+		t.Fatalf("Late loader result should be cached, got '%s'", v)
+	}
+}
+
+func TestGetOrLoadServeStale(t *testing.T) {
+	c := New(0)
+	c.SetWithExpiration("key", "last known good", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	failing := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return nil, 0, errors.New("origin down")
+	}
+
+	var val string
+	err := c.GetOrLoad(context.Background(), "key", &val, failing, ServeStale(), LoadFallback("default"))
+	if err != nil || val != "last known good" {
+		t.Fatalf("Expected stale value, got '%s' (err=%v)", val, err)
+	}
+
+	err = c.GetOrLoad(context.Background(), "other", &val, failing)
+	if err == nil || err.Error() != "origin down" {
+		t.Fatalf("Expected the loader error, got %v", err)
+	}
+}
+
+func TestGetOrLoadErrors(t *testing.T) {
+	c := New(0)
+	var calls atomic.Int32
+	errOrigin := errors.New("origin down")
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		if calls.Add(1) == 1 {
+			return nil, 0, context.Canceled
+		}
+		return nil, 0, errOrigin
+	}
+	policy := LoadErrors(TransientErrors(20 * time.Millisecond))
+	var target string
+
+	// Cancellation isn't cached
+	if err := c.GetOrLoad(context.Background(), "key", &target, loader, policy); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if err := c.GetOrLoad(context.Background(), "key", &target, loader, policy); !errors.Is(err, errOrigin) {
+		t.Fatalf("Expected the origin error, got %v", err)
+	}
+
+	// The origin error is served from the cache until it expires
+	var recorded *RecordedError
+	err := c.GetOrLoad(context.Background(), "key", &target, loader, policy)
+	if !errors.As(err, &recorded) || recorded.Message != "origin down" || calls.Load() != 2 {
+		t.Fatalf("Expected the cached error without a loader call, got %v after %d calls", err, calls.Load())
+	}
+	if err := c.GetOrLoad(context.Background(), "key", &target, loader, policy, LoadFallback("default")); err != nil || target != "default" {
+		t.Fatalf("Expected the fallback for a cached error, got %q (%v)", target, err)
+	}
+
+	// The cached error isn't an item
+	if n := c.Count(); n != 0 {
+		t.Fatalf("Expected no items, got %d", n)
+	}
+	c.Set("key#err", "user value")
+	if v, _ := c.GetString("key#err"); v != "user value" {
+		t.Fatalf("Expected the user's key#err to be kept, got %q", v)
+	}
+	err = c.GetOrLoad(context.Background(), "key", &target, loader, policy)
+	if !errors.As(err, &recorded) || recorded.Message != "origin down" || calls.Load() != 2 {
+		t.Fatalf("Expected the cached error despite key#err, got %v after %d calls", err, calls.Load())
+	}
+	c.Delete("key#err")
+
+	time.Sleep(30 * time.Millisecond)
+	c.GetOrLoad(context.Background(), "key", &target, loader, policy)
+	if calls.Load() != 3 {
+		t.Fatalf("Expected the loader to be called once the error expired, got %d calls", calls.Load())
+	}
+
+	// Expired errors are purged by the janitor
+	time.Sleep(30 * time.Millisecond)
+	c.DeleteExpired()
+	c.loadMu.Lock()
+	n := len(c.loadErrors)
+	c.loadMu.Unlock()
+	if n != 0 {
+		t.Fatalf("Expected the expired error to be purged, got %d", n)
+	}
+}
@@ -0,0 +1,184 @@
+// Package loadtest simulates production traffic against a gocache.Cache, so
+// TTL and size settings can be validated before they meet real load:
+//
+//	res, err := loadtest.Run(ctx, loadtest.Config{
+//		Cache:         gocache.New(time.Minute, gocache.WithMaxBytes(64<<20)),
+//		Workers:       64,
+//		Duration:      10 * time.Second,
+//		Keys:          100000,
+//		TTL:           30 * time.Second,
+//		OriginLatency: 20 * time.Millisecond,
+//	})
+//	fmt.Println(res)
+//
+// Each worker requests keys drawn from a Zipf distribution, as popular keys
+// dominate most real workloads, reading through the cache with GetOrLoad so
+// concurrent misses of a key share one origin call.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// Config describes a load test scenario
+type Config struct {
+	// Cache is the cache under test, configured as in production
+	Cache *gocache.Cache
+
+	// Workers is the number of concurrent clients, 1 if 0
+	Workers int
+
+	// Duration bounds the run. Requests bounds the total number of requests
+	// across all workers. At least one of them must be set; the run stops at
+	// whichever limit is reached first, or when the context is done.
+	Duration time.Duration
+	Requests int
+
+	// Keys is the number of distinct keys
+	Keys uint64
+
+	// Skew is the Zipf exponent, which must be greater than 1 if set. Larger
+	// values concentrate traffic on fewer keys. 1.1 if 0.
+	Skew float64
+
+	// TTL is the expiration of the values loaded from the origin, 0 for none
+	TTL time.Duration
+
+	// OriginLatency is how long each simulated origin call takes
+	OriginLatency time.Duration
+
+	// ValueSize is the size of the values returned by the origin in bytes
+	ValueSize int
+
+	// Seed makes the key sequence reproducible
+	Seed int64
+}
+
+// Result summarizes a load test run
+type Result struct {
+	Requests    uint64
+	Hits        uint64
+	OriginCalls uint64
+	Errors      uint64
+	Elapsed     time.Duration
+
+	HitRatio  float64 // Hits / Requests
+	OriginQPS float64 // origin calls per second
+
+	P50, P99, Max time.Duration // request latency, including origin calls
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d requests in %v: hit ratio %.2f%%, origin %.1f qps, p50 %v, p99 %v, max %v, %d errors",
+		r.Requests, r.Elapsed.Round(time.Millisecond), 100*r.HitRatio, r.OriginQPS, r.P50, r.P99, r.Max, r.Errors)
+}
+
+// Run runs the scenario described by cfg and reports how the cache held up
+func Run(ctx context.Context, cfg Config) (Result, error) {
+	if cfg.Cache == nil {
+		return Result{}, errors.New("loadtest: no cache")
+	}
+	if cfg.Duration <= 0 && cfg.Requests <= 0 {
+		return Result{}, errors.New("loadtest: neither Duration nor Requests is set")
+	}
+	if cfg.Keys == 0 {
+		return Result{}, errors.New("loadtest: no keys")
+	}
+	if cfg.Skew == 0 {
+		cfg.Skew = 1.1
+	}
+	if cfg.Skew <= 1 {
+		return Result{}, errors.New("loadtest: Skew must be greater than 1")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	// Start the clock before the deadline so Elapsed covers the whole Duration
+	start := time.Now()
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var originCalls, errorCount atomic.Uint64
+	value := make([]byte, cfg.ValueSize)
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		originCalls.Add(1)
+		time.Sleep(cfg.OriginLatency)
+		return value, cfg.TTL, nil
+	}
+
+	var issued atomic.Int64
+	latencies := make([][]time.Duration, cfg.Workers)
+	hits := make([]uint64, cfg.Workers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(cfg.Seed + int64(w)))
+			zipf := rand.NewZipf(rng, cfg.Skew, 1, cfg.Keys-1)
+			var target string
+
+			for ctx.Err() == nil {
+				if cfg.Requests > 0 && issued.Add(1) > int64(cfg.Requests) {
+					return
+				}
+				key := "loadtest:" + strconv.FormatUint(zipf.Uint64(), 10)
+
+				begin := time.Now()
+				if _, found := cfg.Cache.GetBytes(key); found {
+					hits[w]++
+				} else if err := cfg.Cache.GetOrLoad(ctx, key, &target, loader); err != nil {
+					errorCount.Add(1)
+				}
+				latencies[w] = append(latencies[w], time.Since(begin))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	res := Result{
+		OriginCalls: originCalls.Load(),
+		Errors:      errorCount.Load(),
+		Elapsed:     time.Since(start),
+	}
+	var all []time.Duration
+	for w := range latencies {
+		all = append(all, latencies[w]...)
+		res.Hits += hits[w]
+	}
+	res.Requests = uint64(len(all))
+	if res.Requests > 0 {
+		res.HitRatio = float64(res.Hits) / float64(res.Requests)
+		sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+		res.P50 = percentile(all, 0.50)
+		res.P99 = percentile(all, 0.99)
+		res.Max = all[len(all)-1]
+	}
+	if res.Elapsed > 0 {
+		res.OriginQPS = float64(res.OriginCalls) / res.Elapsed.Seconds()
+	}
+	return res, nil
+}
+
+// percentile returns the q-quantile of sorted latencies
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
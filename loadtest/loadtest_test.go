@@ -0,0 +1,73 @@
+package loadtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func TestRun(t *testing.T) {
+	res, err := Run(context.Background(), Config{
+		Cache:         gocache.New(0),
+		Workers:       8,
+		Requests:      5000,
+		Keys:          100,
+		OriginLatency: 100 * time.Microsecond,
+		ValueSize:     16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Requests != 5000 || res.Errors != 0 {
+		t.Fatalf("Expected 5000 successful requests, got %+v", res)
+	}
+	if res.OriginCalls == 0 || res.OriginCalls > 100 {
+		t.Fatalf("Expected at most one origin call per key without a TTL, got %d", res.OriginCalls)
+	}
+	if res.HitRatio < 0.9 {
+		t.Fatalf("Expected a high hit ratio with a skewed workload, got %v", res.HitRatio)
+	}
+	if res.P99 < res.P50 || res.Max < res.P99 || res.P99 < 100*time.Microsecond {
+		t.Fatalf("Expected the p99 to include origin calls, got %v", res)
+	}
+	if !strings.Contains(res.String(), "5000 requests") {
+		t.Fatalf("Expected a summary, got %q", res.String())
+	}
+}
+
+func TestRunDuration(t *testing.T) {
+	c := gocache.New(0, gocache.WithMaxBytes(2048))
+	res, err := Run(context.Background(), Config{
+		Cache:     c,
+		Workers:   4,
+		Duration:  50 * time.Millisecond,
+		Keys:      1000,
+		ValueSize: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Requests == 0 || res.Elapsed < 50*time.Millisecond {
+		t.Fatalf("Expected the run to last 50ms, got %+v", res)
+	}
+	if c.Size() > 2048 {
+		t.Fatalf("Expected the cache to stay within its limit, got %d", c.Size())
+	}
+}
+
+func TestRunValidatesConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Keys: 10, Requests: 1},
+		{Cache: gocache.New(0), Keys: 10},
+		{Cache: gocache.New(0), Requests: 1},
+		{Cache: gocache.New(0), Keys: 10, Requests: 1, Skew: 0.5},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Fatalf("Expected %+v to be rejected", cfg)
+		}
+	}
+}
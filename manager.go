@@ -0,0 +1,135 @@
+package gocache
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCacheExists is returned by Manager when a name is already taken
+var ErrCacheExists = errors.New("gocache: cache name already registered")
+
+// Manager tracks the named caches of a process, giving them a common
+// lifecycle and a single place to look them up and report on them
+type Manager struct {
+	mu     sync.RWMutex
+	caches map[string]*Cache
+}
+
+// CacheStats summarizes the contents of one cache
+type CacheStats struct {
+	Items int   // items held, including expired ones not yet removed
+	Bytes int64 // size of keys and values, see Size
+	Cost  int64 // see Cost
+}
+
+// NewManager creates an empty Manager
+func NewManager() *Manager {
+	return &Manager{caches: make(map[string]*Cache)}
+}
+
+// New creates a cache like New and registers it under name
+func (m *Manager) New(name string, cleanupInterval time.Duration, opts ...Option) (*Cache, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.caches[name]; exists {
+		return nil, ErrCacheExists
+	}
+	c := New(cleanupInterval, opts...)
+	m.caches[name] = c
+	return c, nil
+}
+
+// NewFromConfig creates a cache like NewFromConfig and registers it under name
+func (m *Manager) NewFromConfig(name string, cfg Config, opts ...Option) (*Cache, error) {
+	return m.New(name, time.Duration(cfg.CleanupInterval), append(cfg.Options(), opts...)...)
+}
+
+// Register adds an existing cache under name
+func (m *Manager) Register(name string, c *Cache) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.caches[name]; exists {
+		return ErrCacheExists
+	}
+	m.caches[name] = c
+	return nil
+}
+
+// Get returns the cache registered under name
+func (m *Manager) Get(name string) (*Cache, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, found := m.caches[name]
+	return c, found
+}
+
+// Remove stops the janitor of the cache registered under name and forgets
+// it. It reports whether the name was registered.
+func (m *Manager) Remove(name string) bool {
+	m.mu.Lock()
+	c, found := m.caches[name]
+	delete(m.caches, name)
+	m.mu.Unlock()
+
+	if found {
+		c.StopJanitor()
+	}
+	return found
+}
+
+// Names returns the names of the registered caches, sorted
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// StopAll stops the janitors of all registered caches, e.g. on shutdown.
+// The caches stay registered and usable.
+func (m *Manager) StopAll() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.caches {
+		c.StopJanitor()
+	}
+}
+
+// Stats returns the stats of each registered cache by name
+func (m *Manager) Stats() map[string]CacheStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]CacheStats, len(m.caches))
+	for name, c := range m.caches {
+		stats[name] = c.stats()
+	}
+	return stats
+}
+
+// Total returns the stats of all registered caches added up
+func (m *Manager) Total() CacheStats {
+	var total CacheStats
+	for _, s := range m.Stats() {
+		total.Items += s.Items
+		total.Bytes += s.Bytes
+		total.Cost += s.Cost
+	}
+	return total
+}
+
+// stats returns a consistent summary of the cache
+func (c *Cache) stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return CacheStats{Items: len(c.items), Bytes: c.size, Cost: c.cost}
+}
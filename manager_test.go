@@ -0,0 +1,49 @@
+package gocache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	sessions, err := m.New("sessions", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.New("sessions", 0); err != ErrCacheExists {
+		t.Fatalf("Expected ErrCacheExists, got %v", err)
+	}
+	users, _ := m.NewFromConfig("users", Config{DefaultTTL: Duration(time.Hour)})
+	if err := m.Register("other", New(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, found := m.Get("sessions"); !found || c != sessions {
+		t.Fatal("Expected to look up the sessions cache by name")
+	}
+	if names := m.Names(); !reflect.DeepEqual(names, []string{"other", "sessions", "users"}) {
+		t.Fatalf("Expected sorted names, got %v", names)
+	}
+
+	sessions.Set("a", "12345")
+	users.Set("b", "123")
+	users.Set("c", "123")
+	stats := m.Stats()
+	if stats["sessions"].Items != 1 || stats["users"].Items != 2 {
+		t.Fatalf("Expected per-cache stats, got %+v", stats)
+	}
+	if total := m.Total(); total.Items != 3 || total.Bytes != sessions.Size()+users.Size() {
+		t.Fatalf("Expected aggregated stats, got %+v", total)
+	}
+
+	m.StopAll()
+	m.StopAll() // idempotent
+	if !m.Remove("users") || m.Remove("users") {
+		t.Fatal("Expected Remove to report whether the cache was registered")
+	}
+	if _, found := m.Get("users"); found {
+		t.Fatal("Expected users to be removed")
+	}
+}
@@ -0,0 +1,81 @@
+package gocache
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MemoizeOption configures Memoize
+type MemoizeOption func(*memoizeOptions)
+
+// memoizeOptions holds the settings collected from MemoizeOptions
+type memoizeOptions struct {
+	ttl  time.Duration
+	load []LoadOption
+}
+
+// MemoizeTTL sets how long results are cached. 0, the default, means they
+// don't expire.
+func MemoizeTTL(ttl time.Duration) MemoizeOption {
+	return func(o *memoizeOptions) {
+		o.ttl = ttl
+	}
+}
+
+// MemoizeErrors caches errors for the duration policy returns for them, so a
+// failing origin isn't called again by every request. Errors with a duration
+// of 0 aren't cached, which is the default for all errors. Cached errors are
+// returned as *RecordedError. It's LoadErrors for the underlying GetOrLoad.
+func MemoizeErrors(policy func(err error) time.Duration) MemoizeOption {
+	return MemoizeLoadOptions(LoadErrors(policy))
+}
+
+// MemoizeLoadOptions passes options such as LoadTimeout or LoadRetry to the
+// underlying GetOrLoad calls
+func MemoizeLoadOptions(opts ...LoadOption) MemoizeOption {
+	return func(o *memoizeOptions) {
+		o.load = append(o.load, opts...)
+	}
+}
+
+// Memoize wraps fn with a read-through cache: calls with the same argument
+// return the cached result, and concurrent calls for an uncached argument
+// share a single call to fn. Results are keyed by name and the argument,
+// using KeyFor for structs with cachekey tags and the argument's value
+// otherwise, so name must be unique per function. Functions taking several
+// arguments can take them as a struct.
+//
+//	getUser := gocache.Memoize(c, "user", db.GetUser, gocache.MemoizeTTL(time.Minute))
+//	user, err := getUser(ctx, 42)
+func Memoize[A, T any](c *Cache, name string, fn func(context.Context, A) (T, error), opts ...MemoizeOption) func(context.Context, A) (T, error) {
+	var o memoizeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, arg A) (T, error) {
+		var result T
+		key := name + ":" + argKey(arg)
+		err := c.GetOrLoad(ctx, key, &result, func(ctx context.Context, _ string) (interface{}, time.Duration, error) {
+			value, err := fn(ctx, arg)
+			return value, o.ttl, err
+		}, o.load...)
+		return result, err
+	}
+}
+
+// argKey derives the key of a memoized function's argument
+func argKey(arg any) string {
+	if key, err := KeyFor(arg); err == nil {
+		return key
+	}
+	var b strings.Builder
+	if v := reflect.ValueOf(arg); v.IsValid() {
+		writeValue(&b, v)
+	} else {
+		b.WriteString("nil")
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoize(t *testing.T) {
+	c := New(0)
+	var calls atomic.Int32
+	square := Memoize(c, "square", func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return n * n, nil
+	}, MemoizeTTL(time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := square(context.Background(), 3); v != 9 || err != nil {
+				t.Errorf("Expected 9, got %d %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, _ := square(context.Background(), 4); v != 16 {
+		t.Fatalf("Expected 16, got %d", v)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("Expected one call per argument, got %d", calls.Load())
+	}
+	if ttl, _ := c.TTL("square:3"); ttl <= 0 {
+		t.Fatalf("Expected the result to be cached with a TTL, got %v", ttl)
+	}
+}
+
+func TestMemoizeStructArgs(t *testing.T) {
+	c := New(0)
+	calls := 0
+	search := Memoize(c, "search", func(ctx context.Context, req searchRequest) ([]string, error) {
+		calls++
+		return []string{req.Query}, nil
+	})
+
+	search(context.Background(), searchRequest{Query: "a", Cursor: "1"})
+	res, _ := search(context.Background(), searchRequest{Query: "a", Cursor: "2"})
+	if calls != 1 || len(res) != 1 || res[0] != "a" {
+		t.Fatalf("Expected untagged fields not to affect the key, got %v after %d calls", res, calls)
+	}
+}
+
+func TestMemoizeErrors(t *testing.T) {
+	c := New(0)
+	calls := 0
+	fetch := Memoize(c, "fetch", func(ctx context.Context, id string) (string, error) {
+		calls++
+		if id == "canceled" {
+			return "", context.Canceled
+		}
+		return "", errors.New("origin down")
+	}, MemoizeErrors(func(err error) time.Duration {
+		if errors.Is(err, context.Canceled) {
+			return 0
+		}
+		return time.Minute
+	}))
+
+	fetch(context.Background(), "a")
+	_, err := fetch(context.Background(), "a")
+	var recorded *RecordedError
+	if !errors.As(err, &recorded) || recorded.Message != "origin down" {
+		t.Fatalf("Expected the cached error, got %v", err)
+	}
+
+	fetch(context.Background(), "canceled")
+	fetch(context.Background(), "canceled")
+	if calls != 3 {
+		t.Fatalf("Expected uncached errors to be retried, got %d calls", calls)
+	}
+}
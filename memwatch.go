@@ -0,0 +1,111 @@
+package gocache
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// MemoryWatcherConfig configures a MemoryWatcher
+type MemoryWatcherConfig struct {
+	// Interval between heap samples. Defaults to 1 second.
+	Interval time.Duration
+
+	// HeapThreshold is the heap size in bytes (runtime.MemStats.HeapAlloc)
+	// above which the watcher starts evicting.
+	HeapThreshold uint64
+
+	// EvictFraction is the fraction of items (0-1] evicted each time the
+	// threshold is crossed. Defaults to 0.1.
+	EvictFraction float64
+
+	// OnPressure, if set, is called after each eviction round with the
+	// sampled heap size and the number of items evicted.
+	OnPressure func(heapAlloc uint64, evicted int)
+}
+
+// MemoryWatcher periodically samples heap usage and evicts part of a cache
+// when it grows past a threshold
+type MemoryWatcher struct {
+	cache *Cache
+	cfg   MemoryWatcherConfig
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// StartMemoryWatcher starts a goroutine that evicts items from the cache
+// whenever the heap grows past cfg.HeapThreshold. Items are evicted in the
+// same order as size-based eviction. Call Stop on the returned watcher when done.
+func (c *Cache) StartMemoryWatcher(cfg MemoryWatcherConfig) *MemoryWatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.EvictFraction <= 0 || cfg.EvictFraction > 1 {
+		cfg.EvictFraction = 0.1
+	}
+
+	w := &MemoryWatcher{
+		cache: c,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Stop stops the watcher. It is safe to call more than once.
+func (w *MemoryWatcher) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+}
+
+// run samples the heap until the watcher is stopped
+func (w *MemoryWatcher) run() {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	var stats runtime.MemStats
+	for {
+		select {
+		case <-ticker.C:
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc < w.cfg.HeapThreshold {
+				continue
+			}
+			evicted := w.cache.EvictFraction(w.cfg.EvictFraction)
+			if w.cfg.OnPressure != nil {
+				w.cfg.OnPressure(stats.HeapAlloc, evicted)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// EvictFraction removes the given fraction (0-1] of items from the cache in
+// eviction order (see WithMaxBytes). It returns the number of items evicted.
+func (c *Cache) EvictFraction(fraction float64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := int(float64(len(c.items)) * fraction)
+	if n == 0 && fraction > 0 && len(c.items) > 0 {
+		n = 1
+	}
+	if n == 0 {
+		return 0
+	}
+
+	keys := c.evictionOrderLocked()
+
+	before := len(c.items)
+	for _, key := range keys {
+		if before-len(c.items) >= n {
+			break
+		}
+		c.evictKeyLocked(key)
+	}
+
+	return before - len(c.items)
+}
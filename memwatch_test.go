@@ -0,0 +1,54 @@
+package gocache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvictFraction(t *testing.T) {
+	c := New(0)
+
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key%d", i), "value")
+	}
+	c.SetWeak("weak", "value", 0)
+
+	evicted := c.EvictFraction(0.5)
+	if evicted != 5 {
+		t.Fatalf("Expected 5 items evicted, got %d", evicted)
+	}
+	if c.Exists("weak") {
+		t.Fatal("Weak item should be evicted first")
+	}
+	if c.Count() != 6 {
+		t.Fatalf("Expected 6 items left, have %d", c.Count())
+	}
+}
+
+func TestMemoryWatcher(t *testing.T) {
+	c := New(0)
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key%d", i), "value")
+	}
+
+	pressure := make(chan int, 10)
+	w := c.StartMemoryWatcher(MemoryWatcherConfig{
+		Interval:      10 * time.Millisecond,
+		HeapThreshold: 1, // always under pressure
+		EvictFraction: 0.2,
+		OnPressure: func(heapAlloc uint64, evicted int) {
+			pressure <- evicted
+		},
+	})
+	defer w.Stop()
+
+	select {
+	case evicted := <-pressure:
+		if evicted != 2 {
+			t.Fatalf("Expected 2 items evicted, got %d", evicted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watcher never reported memory pressure")
+	}
+}
@@ -0,0 +1,124 @@
+package gocache
+
+import "time"
+
+// Timestamp orders writes across caches for last-write-wins merging. It's a
+// hybrid logical clock: Wall follows the wall clock in UnixNano but never
+// goes backwards and moves past every timestamp merged from other nodes, and
+// Node breaks ties between nodes.
+type Timestamp struct {
+	Wall int64
+	Node string
+}
+
+// After reports whether t is later than u
+func (t Timestamp) After(u Timestamp) bool {
+	if t.Wall != u.Wall {
+		return t.Wall > u.Wall
+	}
+	return t.Node > u.Node
+}
+
+// VersionedItem is an item or tombstone exchanged between caches by Merge
+type VersionedItem struct {
+	Key        string
+	Value      []byte
+	Expiration int64
+	Format     Format
+	Deleted    bool // a tombstone: the key was deleted at Stamp
+	Stamp      Timestamp
+}
+
+// ItemBatch is a set of writes exchanged between caches
+type ItemBatch []VersionedItem
+
+// WithLWW enables last-write-wins merging with other caches for
+// active-active setups. node identifies this cache and must be unique among
+// the merging caches. Deletes are remembered as tombstones for tombstoneTTL
+// (1h if 0), so that a delete isn't undone by merging an older write; caches
+// must exchange their changes more often than that.
+func WithLWW(node string, tombstoneTTL time.Duration) Option {
+	return func(c *Cache) {
+		if tombstoneTTL <= 0 {
+			tombstoneTTL = time.Hour
+		}
+		c.node = node
+		c.tombstones = make(map[string]Timestamp)
+		c.tombstoneTTL = tombstoneTTL
+	}
+}
+
+// tickLocked returns the timestamp of a local write. The caller must hold
+// the write lock.
+func (c *Cache) tickLocked() Timestamp {
+	c.clock = max(time.Now().UnixNano(), c.clock+1)
+	return Timestamp{Wall: c.clock, Node: c.node}
+}
+
+// ChangesSince returns the live items and tombstones written after the
+// given wall time, to be merged into another cache. Pass 0 for everything,
+// or the Wall of the latest timestamp received from this cache for a delta.
+func (c *Cache) ChangesSince(wall int64) ItemBatch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var batch ItemBatch
+	now := time.Now().UnixNano()
+	for k, v := range c.items {
+		if v.stamp.Wall > wall && !c.staleLocked(v, now) {
+			batch = append(batch, VersionedItem{Key: k, Value: v.Value, Expiration: v.Expiration, Format: v.format, Stamp: v.stamp})
+		}
+	}
+	for k, stamp := range c.tombstones {
+		if stamp.Wall > wall {
+			batch = append(batch, VersionedItem{Key: k, Deleted: true, Stamp: stamp})
+		}
+	}
+	return batch
+}
+
+// Merge applies the writes in remote that are newer than this cache's write
+// or delete of the same key, keeping the remote timestamps, and returns the
+// number of writes applied. Merging is commutative and idempotent, so caches
+// exchanging batches in any order converge on the same state.
+func (c *Cache) Merge(remote ItemBatch) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	applied := 0
+	for _, r := range remote {
+		c.clock = max(c.clock, r.Stamp.Wall)
+
+		key := c.mapKey(r.Key)
+		local, found := c.items[key]
+		localStamp := local.stamp
+		if !found {
+			localStamp = c.tombstones[key]
+		}
+		if !r.Stamp.After(localStamp) {
+			continue
+		}
+
+		applied++
+		if r.Deleted {
+			c.deleteLocked(key, EventDelete)
+			if c.tombstones != nil {
+				c.tombstones[key] = r.Stamp
+			}
+			continue
+		}
+		c.setLocked(key, Item{Value: r.Value, Expiration: r.Expiration, format: r.Format, stamp: r.Stamp})
+	}
+	return applied
+}
+
+// purgeTombstonesLocked forgets tombstones older than the tombstone TTL.
+// The caller must hold the write lock.
+func (c *Cache) purgeTombstonesLocked(now int64) {
+	cutoff := now - int64(c.tombstoneTTL)
+	for k, stamp := range c.tombstones {
+		if stamp.Wall < cutoff {
+			delete(c.tombstones, k)
+		}
+	}
+}
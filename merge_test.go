@@ -0,0 +1,85 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeLastWriteWins(t *testing.T) {
+	a := New(0, WithLWW("a", 0))
+	b := New(0, WithLWW("b", 0))
+
+	a.Set("x", "from a")
+	a.Set("y", "from a")
+	time.Sleep(time.Millisecond)
+	b.Set("x", "from b") // later, wins
+	a.Delete("y")
+	b.Set("z", "from b")
+
+	batchA, batchB := a.ChangesSince(0), b.ChangesSince(0)
+	b.Merge(batchA)
+	a.Merge(batchB)
+
+	for _, c := range []*Cache{a, b} {
+		if v, _ := c.GetString("x"); v != "from b" {
+			t.Fatalf("Expected the later write to win, got %q", v)
+		}
+		if c.Exists("y") {
+			t.Fatal("Expected the delete to be merged")
+		}
+		if v, _ := c.GetString("z"); v != "from b" {
+			t.Fatalf("Expected z to be merged, got %q", v)
+		}
+	}
+
+	// Merging again is a no-op
+	if n := a.Merge(batchB); n != 0 {
+		t.Fatalf("Expected a repeated merge to apply nothing, applied %d", n)
+	}
+}
+
+func TestMergeTombstoneBeatsOlderWrite(t *testing.T) {
+	a := New(0, WithLWW("a", 0))
+	b := New(0, WithLWW("b", 0))
+
+	b.Set("k", "old")
+	old := b.ChangesSince(0)
+	a.Merge(old)
+	a.Delete("k")
+
+	// A stale replay of the older write doesn't resurrect the key
+	if n := a.Merge(old); n != 0 || a.Exists("k") {
+		t.Fatalf("Expected the tombstone to win, applied %d", n)
+	}
+
+	b.Merge(a.ChangesSince(0))
+	if b.Exists("k") {
+		t.Fatal("Expected the delete to reach b")
+	}
+}
+
+func TestMergeClockAdvances(t *testing.T) {
+	a := New(0, WithLWW("a", 0))
+	b := New(0, WithLWW("b", 0))
+
+	// b's clock runs far ahead; a's next write must still win after merging
+	future := time.Now().Add(time.Hour).UnixNano()
+	a.Merge(ItemBatch{{Key: "k", Value: []byte("remote"), Stamp: Timestamp{Wall: future, Node: "b"}}})
+	a.Set("k", "local")
+
+	b.Merge(a.ChangesSince(0))
+	if v, _ := b.GetString("k"); v != "local" {
+		t.Fatalf("Expected the later local write to win, got %q", v)
+	}
+}
+
+func TestTombstonePurge(t *testing.T) {
+	c := New(0, WithLWW("a", time.Millisecond))
+	c.Set("k", "v")
+	c.Delete("k")
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+	if len(c.ChangesSince(0)) != 0 {
+		t.Fatal("Expected the tombstone to be purged")
+	}
+}
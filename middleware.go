@@ -0,0 +1,124 @@
+package gocache
+
+// OperationKind identifies the cache operation passed through middleware
+type OperationKind uint8
+
+const (
+	// OperationGet reads an item
+	OperationGet OperationKind = iota
+	// OperationSet writes an item
+	OperationSet
+	// OperationDelete deletes an item
+	OperationDelete
+)
+
+func (k OperationKind) String() string {
+	switch k {
+	case OperationGet:
+		return "get"
+	case OperationSet:
+		return "set"
+	case OperationDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// Operation is a cache operation passed through middleware
+type Operation struct {
+	Kind OperationKind
+	// Key is the key as stored, after any key policy was applied.
+	// Middleware must not change it.
+	Key string
+	// Value is the encoded value to write for a Set, and the value read
+	// for a Get once the next handler returned. Middleware may replace it,
+	// e.g. to encrypt values.
+	Value []byte
+	// Expiration is the expiration of a Set, 0 for none
+	Expiration int64
+	// Found reports whether a Get found the item, once the next handler
+	// returned
+	Found bool
+}
+
+// Handler performs an operation
+type Handler func(op *Operation) error
+
+// Middleware wraps the handler performing an operation, to observe it,
+// change it or fail it without calling next
+type Middleware func(next Handler) Handler
+
+// WithMiddleware runs the cache's operations through a chain of middleware,
+// the first one outermost, so auth, metrics, logging or fault injection can
+// be added in one place, including behind the HTTP and gRPC servers. Reads
+// made by Get and its variants are covered, including GetOrLoad hits, as are
+// writes made by Set, SetWithExpiration, SetWithExpireAt, SetWithOptions,
+// SetNX, SetWithDeps, SetWeak, SetRaw, SetRawMulti, GetOrLoad loads and
+// Overlay.Commit, and Delete. A Get that fails reads as a miss. Merge,
+// Rename, Copy, LoadSnapshot and replication move values as stored, so
+// they're neither transformed again nor passed through the chain; leases
+// are stored as is for the Lease methods to read.
+//
+// Watch.Exec applies its operations atomically, so it runs them through the
+// middleware one by one before applying any, and doesn't apply them if one
+// fails. Middleware must not use the cache from within an Exec.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Cache) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// handle runs op through the middleware chain around final
+func (c *Cache) handle(op *Operation, final Handler) error {
+	h := final
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h(op)
+}
+
+// admit runs op through the middleware chain without performing it, for
+// operations applied later as part of a transaction
+func (c *Cache) admit(op *Operation) error {
+	return c.handle(op, func(*Operation) error { return nil })
+}
+
+// lookupThrough is lookup run through the middleware chain
+func (c *Cache) lookupThrough(key string) (Item, bool) {
+	var item Item
+	op := &Operation{Kind: OperationGet, Key: c.mapKey(key)}
+	err := c.handle(op, func(op *Operation) error {
+		item, op.Found = c.lookup(key)
+		op.Value = item.Value
+		return nil
+	})
+	if err != nil || !op.Found {
+		return Item{}, false
+	}
+	item.Value = op.Value
+	return item, true
+}
+
+// storeThrough runs a write of item through the middleware chain around
+// store, which performs the write with the item as middleware left it
+func (c *Cache) storeThrough(key string, item Item, store func(Item) error) error {
+	op := &Operation{Kind: OperationSet, Key: key, Value: item.Value, Expiration: item.Expiration}
+	return c.handle(op, func(op *Operation) error {
+		item.Value, item.Expiration = op.Value, op.Expiration
+		return store(item)
+	})
+}
+
+// admitOp runs an operation of Watch.Exec through the middleware chain and
+// returns it as middleware left it
+func (c *Cache) admitOp(key string, op Op) (Op, error) {
+	if op.delete {
+		return op, c.admit(&Operation{Kind: OperationDelete, Key: key})
+	}
+	o := &Operation{Kind: OperationSet, Key: key, Value: op.value, Expiration: op.expiration()}
+	if err := c.admit(o); err != nil {
+		return op, err
+	}
+	op.value, op.admittedExpiration, op.admitted = o.Value, o.Expiration, true
+	return op, nil
+}
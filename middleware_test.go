@@ -0,0 +1,172 @@
+package gocache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareOrderAndLogging(t *testing.T) {
+	var log []string
+	logger := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(op *Operation) error {
+				log = append(log, name+" "+op.Kind.String()+" "+op.Key)
+				return next(op)
+			}
+		}
+	}
+	c := New(0, WithMiddleware(logger("outer"), logger("inner")))
+
+	c.Set("a", "1")
+	c.GetString("a")
+	c.Delete("a")
+
+	want := "outer set a,inner set a,outer get a,inner get a,outer delete a,inner delete a"
+	if got := strings.Join(log, ","); got != want {
+		t.Fatalf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestMiddlewareTransformsValues(t *testing.T) {
+	// Stores values reversed and reverses them back on reads
+	reverse := func(b []byte) []byte {
+		r := bytes.Clone(b)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return r
+	}
+	c := New(0, WithMiddleware(func(next Handler) Handler {
+		return func(op *Operation) error {
+			if op.Kind == OperationSet {
+				op.Value = reverse(op.Value)
+			}
+			err := next(op)
+			if op.Kind == OperationGet && op.Found {
+				op.Value = reverse(op.Value)
+			}
+			return err
+		}
+	}))
+
+	c.SetWithOptions("key", "abc", WithTTL(time.Minute))
+	if got, _ := c.GetString("key"); got != "abc" {
+		t.Fatalf("Expected the value to round trip, got %q", got)
+	}
+
+	c.mu.RLock()
+	stored := string(c.items["key"].Value)
+	c.mu.RUnlock()
+	if stored != "cba" {
+		t.Fatalf("Expected the value to be stored transformed, got %q", stored)
+	}
+}
+
+func TestMiddlewareRejects(t *testing.T) {
+	errDenied := errors.New("denied")
+	c := New(0, WithMiddleware(func(next Handler) Handler {
+		return func(op *Operation) error {
+			if strings.HasPrefix(op.Key, "admin:") {
+				return errDenied
+			}
+			return next(op)
+		}
+	}))
+	c.mu.Lock()
+	c.setLocked("admin:x", Item{Value: []byte("stored before")})
+	c.mu.Unlock()
+
+	if err := c.Set("admin:y", "value"); !errors.Is(err, errDenied) {
+		t.Fatalf("Expected the Set to be denied, got %v", err)
+	}
+	if _, found := c.GetBytes("admin:x"); found {
+		t.Fatalf("Expected a denied Get to read as a miss")
+	}
+
+	w := c.Watch("user:1")
+	if err := w.Exec(SetOp("user:1", "value", 0), DeleteOp("admin:x")); !errors.Is(err, errDenied) {
+		t.Fatalf("Expected Exec to be denied, got %v", err)
+	}
+	if c.Count() != 1 {
+		t.Fatalf("Expected nothing to be applied, got %d items", c.Count())
+	}
+
+	if err := c.SetRawMulti(map[string][]byte{"admin:z": []byte("raw")}, 0); !errors.Is(err, errDenied) {
+		t.Fatalf("Expected SetRawMulti to be denied, got %v", err)
+	}
+	if err := c.SetRawMulti(map[string][]byte{"user:2": []byte("raw")}, 0); err != nil {
+		t.Fatalf("Expected SetRawMulti of other keys to pass, got %v", err)
+	}
+	if c.Count() != 2 {
+		t.Fatalf("Expected only user:2 to be stored, got %d items", c.Count())
+	}
+}
+
+func TestMiddlewareTransformsAllWrites(t *testing.T) {
+	xor := func(b []byte) []byte {
+		r := bytes.Clone(b)
+		for i := range r {
+			r[i] ^= 0x5a
+		}
+		return r
+	}
+	c := New(0, WithMiddleware(func(next Handler) Handler {
+		return func(op *Operation) error {
+			if op.Kind == OperationSet {
+				op.Value = xor(op.Value)
+			}
+			err := next(op)
+			if op.Kind == OperationGet && op.Found {
+				op.Value = xor(op.Value)
+			}
+			return err
+		}
+	}))
+	ctx := context.Background()
+
+	writes := map[string]func(key string) error{
+		"GetOrLoad": func(key string) error {
+			var v string
+			return c.GetOrLoad(ctx, key, &v, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+				return "value", 0, nil
+			})
+		},
+		"SetNX": func(key string) error {
+			_, err := c.SetNX(key, "value", 0)
+			return err
+		},
+		"SetWithDeps": func(key string) error {
+			c.Set("input", "x")
+			return c.SetWithDeps(key, "value", "input")
+		},
+		"SetWeak": func(key string) error {
+			return c.SetWeak(key, "value", 0)
+		},
+		"Overlay": func(key string) error {
+			o := c.Overlay()
+			o.Set(key, "value")
+			return o.Commit()
+		},
+		"SetRawMulti": func(key string) error {
+			return c.SetRawMulti(map[string][]byte{key: []byte("value")}, 0)
+		},
+	}
+	for name, write := range writes {
+		if err := write(name); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if got, _ := c.GetString(name); got != "value" {
+			t.Errorf("%s: expected the value to round trip, got %q", name, got)
+		}
+		c.mu.RLock()
+		stored := string(c.items[name].Value)
+		c.mu.RUnlock()
+		if stored == "value" {
+			t.Errorf("%s: expected the value to be stored transformed", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gocache
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoStore is returned by write operations for keys that a namespace
+// policy forbids caching
+var ErrNoStore = errors.New("gocache: key matches a no-store policy")
+
+// NamespacePolicy constrains the items stored under a key prefix
+type NamespacePolicy struct {
+	// MinTTL raises shorter expirations to MinTTL, but never past the
+	// expiration a value sets for itself or that of the item's
+	// dependencies. Items without an expiration are not affected.
+	MinTTL time.Duration
+
+	// MaxTTL caps expirations at MaxTTL, including items set without one
+	MaxTTL time.Duration
+
+	// NoStore lists path.Match patterns, matched against the whole key,
+	// of keys that must not be cached at all
+	NoStore []string
+}
+
+// namespace is a policy bound to its key prefix
+type namespace struct {
+	prefix string
+	policy NamespacePolicy
+}
+
+// WithNamespacePolicy applies policy to every key starting with prefix, so
+// a platform team can enforce caching hygiene for the many callers sharing
+// one cache. When prefixes overlap, the longest matching prefix wins. The
+// cache-wide WithMaxTTL still applies on top of namespace policies.
+func WithNamespacePolicy(prefix string, policy NamespacePolicy) Option {
+	return func(c *Cache) {
+		var namespaces []namespace
+		if current := c.namespaces.Load(); current != nil {
+			namespaces = append(namespaces, *current...)
+		}
+		namespaces = append(namespaces, namespace{prefix: prefix, policy: policy})
+		sortNamespaces(namespaces)
+		c.namespaces.Store(&namespaces)
+	}
+}
+
+// sortNamespaces orders namespaces longest prefix first, so the first match
+// is the most specific
+func sortNamespaces(namespaces []namespace) {
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return len(namespaces[i].prefix) > len(namespaces[j].prefix)
+	})
+}
+
+// namespacePolicy returns the policy governing key, or nil if there is none.
+// The policies are swapped atomically by ApplyConfig and never modified in
+// place, so no lock is needed.
+func (c *Cache) namespacePolicy(key string) *NamespacePolicy {
+	current := c.namespaces.Load()
+	if current == nil {
+		return nil
+	}
+	namespaces := *current
+	for i := range namespaces {
+		if strings.HasPrefix(key, namespaces[i].prefix) {
+			return &namespaces[i].policy
+		}
+	}
+	return nil
+}
+
+// allowStore reports whether a namespace policy lets key be cached
+func (p *NamespacePolicy) allowStore(key string) bool {
+	for _, pattern := range p.NoStore {
+		if matched, _ := path.Match(pattern, key); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// clampExpiration applies the policy's TTL bounds to an expiration timestamp
+func (p *NamespacePolicy) clampExpiration(expiration int64, now time.Time) int64 {
+	if p.MinTTL > 0 && expiration > 0 {
+		if min := now.Add(p.MinTTL).UnixNano(); expiration < min {
+			expiration = min
+		}
+	}
+	if p.MaxTTL > 0 {
+		expiration = clampExpiration(expiration, now.Add(p.MaxTTL).UnixNano())
+	}
+	return expiration
+}
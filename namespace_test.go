@@ -0,0 +1,96 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNamespacePolicy(t *testing.T) {
+	c := New(0,
+		WithNamespacePolicy("session:", NamespacePolicy{
+			MinTTL: time.Minute,
+			MaxTTL: time.Hour,
+		}),
+		WithNamespacePolicy("session:admin:", NamespacePolicy{
+			NoStore: []string{"session:admin:*"},
+		}),
+		WithNamespacePolicy("user:", NamespacePolicy{
+			NoStore: []string{"user:*:password"},
+		}),
+	)
+
+	// MinTTL raises short expirations
+	c.SetWithExpiration("session:1", "data", time.Second)
+	if ttl, _ := c.TTL("session:1"); ttl <= 59*time.Second {
+		t.Fatalf("TTL should be raised to a minute, got %v", ttl)
+	}
+
+	// MaxTTL caps long and missing expirations
+	c.Set("session:2", "data")
+	if ttl, _ := c.TTL("session:2"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("TTL should be capped at an hour, got %v", ttl)
+	}
+
+	// The longest prefix wins
+	if err := c.Set("session:admin:1", "data"); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Expected ErrNoStore for admin sessions, got %v", err)
+	}
+
+	if err := c.Set("user:1:password", "hunter2"); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Expected ErrNoStore, got %v", err)
+	}
+	if c.Exists("user:1:password") {
+		t.Fatal("No-store keys must not be cached")
+	}
+	if err := c.Set("user:1:name", "John"); err != nil {
+		t.Fatalf("Other user keys should be stored, got %v", err)
+	}
+
+	// Keys outside any namespace are unaffected
+	c.Set("other", "data")
+	if ttl, _ := c.TTL("other"); ttl != -1 {
+		t.Fatalf("Expected infinite TTL outside namespaces, got %v", ttl)
+	}
+}
+
+func TestNamespaceMinTTLKeepsCaps(t *testing.T) {
+	c := New(0, WithNamespacePolicy("session:", NamespacePolicy{MinTTL: time.Minute}))
+	c.SetWithExpiration("input", "data", 5*time.Second)
+
+	tests := []struct {
+		name string
+		set  func(key string) error
+		max  time.Duration
+	}{
+		{"TTLProvider", func(key string) error {
+			return c.SetWithExpiration(key, apiResponse{Body: "ok", MaxAge: 5}, time.Second)
+		}, 5 * time.Second},
+		{"ExpiryProvider", func(key string) error {
+			return c.SetWithExpiration(key, signedURL{URL: "u", Expires: time.Now().Add(5 * time.Second)}, time.Second)
+		}, 5 * time.Second},
+		{"SetWithDeps", func(key string) error {
+			return c.SetWithDeps(key, "derived", "input")
+		}, 5 * time.Second},
+		{"WithDeps", func(key string) error {
+			return c.SetWithOptions(key, "derived", WithTTL(time.Second), WithDeps("input"))
+		}, 5 * time.Second},
+		// The floor still applies below a longer value expiration
+		{"LongTTLProvider", func(key string) error {
+			return c.SetWithExpiration(key, apiResponse{Body: "ok", MaxAge: 3600}, time.Second)
+		}, time.Minute},
+	}
+	for _, tt := range tests {
+		key := "session:" + tt.name
+		if err := tt.set(key); err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+		ttl, err := c.TTL(key)
+		if err != nil || ttl <= 0 || ttl > tt.max {
+			t.Fatalf("%s: expected a TTL of up to %v, got %v (%v)", tt.name, tt.max, ttl, err)
+		}
+		if tt.max == time.Minute && ttl <= 59*time.Second {
+			t.Fatalf("%s: expected MinTTL to raise the TTL to a minute, got %v", tt.name, ttl)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package gocache
+
+import "time"
+
+// Option configures a Cache created with New
+type Option func(*Cache)
+
+// WithMaxTTL caps the expiration of every item at maxTTL from the time it is
+// set. Items set without an expiration expire after maxTTL as well, so callers
+// can't create effectively permanent entries.
+func WithMaxTTL(maxTTL time.Duration) Option {
+	return func(c *Cache) {
+		c.maxTTL = maxTTL
+	}
+}
+
+// WithDefaultTTL makes items stored with Set expire after ttl. Calls that
+// pass an expiration, such as SetWithExpiration, are not affected.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.defaultTTL.Store(int64(ttl))
+	}
+}
+
+// SetOption configures a single SetWithOptions call
+type SetOption func(*setOptions)
+
+// setOptions holds the per-call settings collected from SetOptions
+type setOptions struct {
+	duration time.Duration
+	expireAt time.Time
+	codec    Codec
+	noCopy   bool
+	weak     bool
+	deps     []string
+	tags     []string
+	priority Priority
+	softTTL  time.Duration
+}
+
+// WithTTL sets the time to live of the item. 0 means no expiration.
+func WithTTL(duration time.Duration) SetOption {
+	return func(o *setOptions) {
+		o.duration = duration
+	}
+}
+
+// WithExpireAt makes the item expire at the given wall clock time, taking
+// precedence over WithTTL
+func WithExpireAt(at time.Time) SetOption {
+	return func(o *setOptions) {
+		o.expireAt = at
+	}
+}
+
+// WithCodec encodes the value with codec instead of the default JSON encoding.
+// Strings and byte slices are passed through the codec as well. Read the value
+// back with GetInto and the same codec.
+func WithCodec(codec Codec) SetOption {
+	return func(o *setOptions) {
+		o.codec = codec
+	}
+}
+
+// WithNoCopy stores a []byte value as is instead of copying it. The caller
+// must not modify the slice afterwards.
+func WithNoCopy() SetOption {
+	return func(o *setOptions) {
+		o.noCopy = true
+	}
+}
+
+// WithWeak marks the item as weak, see SetWeak
+func WithWeak() SetOption {
+	return func(o *setOptions) {
+		o.weak = true
+	}
+}
+
+// WithDeps makes the item depend on the given keys, see SetWithDeps
+func WithDeps(deps ...string) SetOption {
+	return func(o *setOptions) {
+		o.deps = append(o.deps, deps...)
+	}
+}
+
+// WithTags attaches tags to the item so that groups of items can be removed
+// together with InvalidateTag
+func WithTags(tags ...string) SetOption {
+	return func(o *setOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithPriority sets the eviction priority of the item
+func WithPriority(priority Priority) SetOption {
+	return func(o *setOptions) {
+		o.priority = priority
+	}
+}
+
+// SetWithOptions adds an item to the cache configured by the given options.
+// Unlike Set, []byte values are copied unless WithNoCopy is given.
+func (c *Cache) SetWithOptions(key string, value interface{}, opts ...SetOption) (err error) {
+	defer func() { err = c.failOpenWrite(err) }()
+
+	key, err = c.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var bytes []byte
+	var format Format
+	switch {
+	case o.codec != nil:
+		bytes, err = o.codec.Marshal(value)
+		format = codecFormat(o.codec)
+	default:
+		if b, ok := value.([]byte); ok && !o.noCopy {
+			value = append([]byte(nil), b...)
+		}
+		bytes, err = encode(value)
+		format = formatOf(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	expiration := expirationFor(o.duration)
+	if !o.expireAt.IsZero() {
+		expiration = expirationAt(o.expireAt)
+	}
+	expiration = capToValue(value, expiration)
+
+	item := Item{
+		Value:          bytes,
+		Expiration:     expiration,
+		own:            valueExpiration(value),
+		weak:           o.weak,
+		tags:           o.tags,
+		priority:       o.priority,
+		softExpiration: expirationFor(o.softTTL),
+		format:         format,
+		typeID:         c.fingerprint(value),
+	}
+
+	if c.middleware != nil {
+		return c.storeThrough(key, item, func(item Item) error {
+			c.setWithDeps(key, item, o.deps)
+			return nil
+		})
+	}
+	c.setWithDeps(key, item, o.deps)
+	return nil
+}
+
+// setWithDeps stores item under key, depending on deps if there are any
+func (c *Cache) setWithDeps(key string, item Item, deps []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(deps) > 0 {
+		deps = c.mapKeys(deps)
+		var ok bool
+		if item.Expiration, ok = c.depsExpirationLocked(item.Expiration, deps); !ok {
+			// An input is already gone, so there's nothing valid to cache
+			c.deleteLocked(key, EventInvalidate)
+			return
+		}
+		item.deps = deps
+	}
+
+	c.setLocked(key, item)
+}
@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	return append([]byte("U:"), b...), err
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data[2:], v)
+}
+
+func TestSetWithOptions(t *testing.T) {
+	c := New(0)
+
+	// []byte values are copied by default
+	raw := []byte("abc")
+	c.SetWithOptions("copied", raw, WithTTL(time.Minute))
+	raw[0] = 'X'
+	if val, _ := c.GetString("copied"); val != "abc" {
+		t.Fatalf("Expected copied value 'abc', got '%s'", val)
+	}
+	if ttl, _ := c.TTL("copied"); ttl <= 0 {
+		t.Fatalf("Expected a positive TTL, got %v", ttl)
+	}
+
+	// ...unless WithNoCopy is given
+	c.SetWithOptions("shared", raw, WithNoCopy())
+	raw[0] = 'Y'
+	if val, _ := c.GetString("shared"); val != "Ybc" {
+		t.Fatalf("Expected shared value 'Ybc', got '%s'", val)
+	}
+
+	// Codec override
+	c.SetWithOptions("coded", testStruct{Name: "John", Age: 30}, WithCodec(upperCodec{}))
+	bytes, _ := c.GetBytes("coded")
+	var item testStruct
+	if err := (upperCodec{}).Unmarshal(bytes, &item); err != nil || item.Name != "John" {
+		t.Fatalf("Expected custom codec round-trip, got %+v (err=%v)", item, err)
+	}
+}
+
+func TestInvalidateTag(t *testing.T) {
+	c := New(0)
+
+	c.SetWithOptions("page:1", "a", WithTags("pages"))
+	c.SetWithOptions("page:2", "b", WithTags("pages", "recent"))
+	c.SetWithOptions("user:1", "c", WithTags("recent"))
+
+	if n := c.InvalidateTag("pages"); n != 2 {
+		t.Fatalf("Expected 2 items invalidated, got %d", n)
+	}
+	if c.Exists("page:1") || c.Exists("page:2") {
+		t.Fatal("Tagged pages should be gone")
+	}
+
+	keys := c.Tagged("recent")
+	if len(keys) != 1 || keys[0] != "user:1" {
+		t.Fatalf("Expected only user:1 tagged recent, got %v", keys)
+	}
+
+	// Overwriting an item drops its old tags
+	c.Set("user:1", "d")
+	if n := c.InvalidateTag("recent"); n != 0 {
+		t.Fatalf("Expected no items left tagged recent, got %d", n)
+	}
+}
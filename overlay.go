@@ -0,0 +1,161 @@
+package gocache
+
+import (
+	"sync"
+	"time"
+)
+
+// Overlay is a lightweight cache layered over a parent Cache. Reads fall
+// through to the parent for keys the overlay hasn't touched, while writes and
+// deletes stay local until Commit applies them to the parent. Overlays suit
+// per-request caching in web handlers and speculative computations.
+type Overlay struct {
+	parent *Cache
+	mu     sync.RWMutex
+	writes map[string]overlayWrite
+}
+
+// overlayWrite is a pending write. A nil value with deleted set records a
+// delete that hides the parent's item.
+type overlayWrite struct {
+	value      []byte
+	expiration int64
+	own        int64 // see Item
+	format     Format
+	typeID     uint64
+	deleted    bool
+}
+
+// Overlay returns a new, empty overlay on top of the cache
+func (c *Cache) Overlay() *Overlay {
+	return &Overlay{
+		parent: c,
+		writes: make(map[string]overlayWrite),
+	}
+}
+
+// Set adds an item to the overlay with no expiration
+func (o *Overlay) Set(key string, value interface{}) error {
+	return o.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration adds an item to the overlay with a specific expiration time
+func (o *Overlay) SetWithExpiration(key string, value interface{}, duration time.Duration) error {
+	key, err := o.parent.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.writes[key] = overlayWrite{value: bytes, expiration: capToValue(value, expirationFor(duration)), own: valueExpiration(value), format: formatOf(value), typeID: o.parent.fingerprint(value)}
+	o.mu.Unlock()
+
+	return nil
+}
+
+// Delete hides an item from the overlay and deletes it from the parent on Commit
+func (o *Overlay) Delete(key string) {
+	key = o.parent.mapKey(key)
+
+	o.mu.Lock()
+	o.writes[key] = overlayWrite{deleted: true}
+	o.mu.Unlock()
+}
+
+// GetBytes retrieves raw byte data from the overlay, falling back to the parent
+func (o *Overlay) GetBytes(key string) ([]byte, bool) {
+	item, found := o.get(key)
+	return item.Value, found
+}
+
+// get returns the overlay's live item for key, falling back to the parent
+func (o *Overlay) get(key string) (Item, bool) {
+	o.mu.RLock()
+	w, local := o.writes[o.parent.mapKey(key)]
+	o.mu.RUnlock()
+
+	if !local {
+		return o.parent.get(key)
+	}
+	if w.deleted || (Item{Expiration: w.expiration}).expired(time.Now().UnixNano()) {
+		return Item{}, false
+	}
+	return Item{Value: w.value, Expiration: w.expiration, format: w.format, typeID: w.typeID}, true
+}
+
+// GetString gets a string value from the overlay
+func (o *Overlay) GetString(key string) (string, bool) {
+	bytes, found := o.GetBytes(key)
+	if !found {
+		return "", false
+	}
+	return string(bytes), true
+}
+
+// Get retrieves and unmarshals an item from the overlay
+func (o *Overlay) Get(key string, target interface{}) (bool, error) {
+	item, found := o.get(key)
+	if !found {
+		return false, nil
+	}
+	return true, decodeItem(item, target)
+}
+
+// Exists checks if a key exists in the overlay or the parent and is not expired
+func (o *Overlay) Exists(key string) bool {
+	_, found := o.GetBytes(key)
+	return found
+}
+
+// Commit atomically applies the overlay's writes and deletes to the parent
+// and empties the overlay. With WithMiddleware, they're run through the
+// chain one by one before any is applied, as by Watch.Exec, and nothing is
+// applied if one fails.
+func (o *Overlay) Commit() error {
+	o.mu.Lock()
+	writes := o.writes
+	o.writes = make(map[string]overlayWrite)
+	o.mu.Unlock()
+
+	c := o.parent
+	if c.middleware != nil {
+		for key, w := range writes {
+			if w.deleted {
+				if err := c.admit(&Operation{Kind: OperationDelete, Key: key}); err != nil {
+					return err
+				}
+				continue
+			}
+			op := &Operation{Kind: OperationSet, Key: key, Value: w.value, Expiration: w.expiration}
+			if err := c.admit(op); err != nil {
+				return err
+			}
+			w.value, w.expiration = op.Value, op.Expiration
+			writes[key] = w
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, w := range writes {
+		if w.deleted {
+			c.deleteLocked(key, EventDelete)
+			continue
+		}
+		c.setLocked(key, Item{Value: w.value, Expiration: w.expiration, own: w.own, format: w.format, typeID: w.typeID})
+	}
+	return nil
+}
+
+// Discard drops the overlay's pending writes and deletes
+func (o *Overlay) Discard() {
+	o.mu.Lock()
+	o.writes = make(map[string]overlayWrite)
+	o.mu.Unlock()
+}
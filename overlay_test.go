@@ -0,0 +1,60 @@
+package gocache
+
+import "testing"
+
+func TestOverlayCommit(t *testing.T) {
+	c := New(0)
+	c.Set("shared", "parent")
+	c.Set("doomed", "parent")
+
+	o := c.Overlay()
+
+	// Reads fall through to the parent
+	if val, _ := o.GetString("shared"); val != "parent" {
+		t.Fatalf("Expected parent value, got '%s'", val)
+	}
+
+	o.Set("shared", "local")
+	o.Set("new", "local")
+	o.Delete("doomed")
+
+	if val, _ := o.GetString("shared"); val != "local" {
+		t.Fatalf("Expected local value in overlay, got '%s'", val)
+	}
+	if o.Exists("doomed") {
+		t.Fatal("Deleted key should be hidden by the overlay")
+	}
+
+	// The parent is untouched until Commit
+	if val, _ := c.GetString("shared"); val != "parent" {
+		t.Fatalf("Parent should be unchanged before commit, got '%s'", val)
+	}
+	if c.Exists("new") || !c.Exists("doomed") {
+		t.Fatal("Parent should be unchanged before commit")
+	}
+
+	o.Commit()
+	if val, _ := c.GetString("shared"); val != "local" {
+		t.Fatalf("Expected committed value, got '%s'", val)
+	}
+	if !c.Exists("new") || c.Exists("doomed") {
+		t.Fatal("Commit should apply writes and deletes")
+	}
+}
+
+func TestOverlayDiscard(t *testing.T) {
+	c := New(0)
+	c.Set("key", "parent")
+
+	o := c.Overlay()
+	o.Set("key", "speculative")
+	o.Discard()
+
+	if val, _ := o.GetString("key"); val != "parent" {
+		t.Fatalf("Discarded overlay should read through again, got '%s'", val)
+	}
+	o.Commit()
+	if val, _ := c.GetString("key"); val != "parent" {
+		t.Fatalf("Discarded writes must not be committed, got '%s'", val)
+	}
+}
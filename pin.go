@@ -0,0 +1,44 @@
+package gocache
+
+import "time"
+
+// Pin exempts an existing item from eviction and expiration until Unpin is
+// called, e.g. while a long-running job iterates over it. An item whose
+// expiration passes while pinned expires as soon as it is unpinned.
+// Pin returns false if the key doesn't exist or has already expired.
+func (c *Cache) Pin(key string) bool {
+	return c.setPinned(key, true)
+}
+
+// Unpin makes a pinned item subject to eviction and expiration again.
+// It returns false if the key doesn't exist.
+func (c *Cache) Unpin(key string) bool {
+	return c.setPinned(key, false)
+}
+
+// Pinned reports whether the item stored under key is pinned
+func (c *Cache) Pinned(key string) bool {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.items[key].pinned
+}
+
+// setPinned updates the pinned flag of an existing item
+func (c *Cache) setPinned(key string, pinned bool) bool {
+	key = c.mapKey(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, found := c.items[key]
+	if !found || c.staleLocked(item, time.Now().UnixNano()) {
+		return false
+	}
+
+	item.pinned = pinned
+	c.items[key] = item
+	c.indexReadLocked(key, item)
+	return true
+}
@@ -0,0 +1,46 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPinExpiration(t *testing.T) {
+	c := New(10 * time.Millisecond)
+	defer c.StopJanitor()
+
+	c.SetWithExpiration("job", "data", 50*time.Millisecond)
+	if !c.Pin("job") {
+		t.Fatal("Expected Pin to succeed on an existing key")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if !c.Exists("job") {
+		t.Fatal("Pinned item must not expire")
+	}
+
+	c.Unpin("job")
+	if c.Exists("job") {
+		t.Fatal("Item should expire once unpinned")
+	}
+
+	if c.Pin("missing") {
+		t.Fatal("Pin should fail for missing keys")
+	}
+}
+
+func TestPinEviction(t *testing.T) {
+	c := New(0, WithMaxBytes(20))
+
+	c.Set("k1", "12345678")
+	c.Pin("k1")
+	c.Set("k2", "12345678")
+	c.Set("k3", "12345678")
+
+	if !c.Exists("k1") {
+		t.Fatal("Pinned item must not be evicted")
+	}
+	if c.Exists("k2") {
+		t.Fatal("Oldest unpinned item should have been evicted")
+	}
+}
@@ -0,0 +1,161 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StoredValue is the result of loading one key from a BatchStore
+type StoredValue struct {
+	Value      []byte
+	Expiration int64 // 0 means no expiration
+	Found      bool
+}
+
+// BatchStore is an OverflowStore that can load many keys in one round trip,
+// such as a remote tier answering Redis MGET or memcached multi-get
+type BatchStore interface {
+	OverflowStore
+	// LoadMany returns the values stored under keys, in the same order
+	LoadMany(keys []string) ([]StoredValue, error)
+}
+
+// pipelinedStore coalesces concurrent Loads into LoadMany calls
+type pipelinedStore struct {
+	store    BatchStore
+	window   time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending *loadBatch // batch collecting keys, nil if none
+}
+
+// loadBatch is a LoadMany call shared by the Loads that joined it
+type loadBatch struct {
+	keys   []string
+	index  map[string]int // position of each key in keys
+	timer  *time.Timer
+	done   chan struct{}
+	values []StoredValue
+	err    error
+}
+
+// NewPipelinedStore wraps store so that Loads issued within window of each
+// other are sent as a single LoadMany, up to maxBatch keys per call (0 means
+// no limit). Concurrent reads of spilled items, such as those of a fan-out
+// handler or GetMany, then cost one round trip instead of one per key, for
+// at most window of added latency. Loads of the same key share a slot in the
+// batch. Store and Delete are passed through.
+func NewPipelinedStore(store BatchStore, window time.Duration, maxBatch int) OverflowStore {
+	return &pipelinedStore{store: store, window: window, maxBatch: maxBatch}
+}
+
+func (s *pipelinedStore) Store(key string, value []byte, expiration int64) error {
+	return s.store.Store(key, value, expiration)
+}
+
+func (s *pipelinedStore) Load(key string) ([]byte, int64, bool, error) {
+	s.mu.Lock()
+	b := s.pending
+	if b == nil {
+		b = &loadBatch{index: make(map[string]int), done: make(chan struct{})}
+		b.timer = time.AfterFunc(s.window, func() { s.flush(b) })
+		s.pending = b
+	}
+	i, ok := b.index[key]
+	if !ok {
+		i = len(b.keys)
+		b.keys = append(b.keys, key)
+		b.index[key] = i
+	}
+	full := s.maxBatch > 0 && len(b.keys) >= s.maxBatch
+	s.mu.Unlock()
+
+	if full {
+		s.flush(b)
+	}
+	<-b.done
+
+	if b.err != nil {
+		return nil, 0, false, b.err
+	}
+	v := b.values[i]
+	return v.Value, v.Expiration, v.Found, nil
+}
+
+func (s *pipelinedStore) Delete(key string) error {
+	return s.store.Delete(key)
+}
+
+// Health reports the health of the wrapped store if it implements
+// HealthChecker
+func (s *pipelinedStore) Health() error {
+	if checker, ok := s.store.(HealthChecker); ok {
+		return checker.Health()
+	}
+	return nil
+}
+
+// flush sends batch b unless it was already sent
+func (s *pipelinedStore) flush(b *loadBatch) {
+	s.mu.Lock()
+	if s.pending != b {
+		s.mu.Unlock()
+		return
+	}
+	s.pending = nil
+	s.mu.Unlock()
+
+	b.timer.Stop()
+	b.values, b.err = s.store.LoadMany(b.keys)
+	if b.err == nil && len(b.values) != len(b.keys) {
+		b.err = fmt.Errorf("gocache: LoadMany returned %d values for %d keys", len(b.values), len(b.keys))
+	}
+	close(b.done)
+}
+
+// GetMany returns the raw bytes of the live items stored under keys. Missing
+// keys are left out of the result. Items spilled to the overflow store are
+// faulted in concurrently, so with NewPipelinedStore they're fetched in a
+// single round trip.
+func (c *Cache) GetMany(keys []string) map[string][]byte {
+	values := make(map[string][]byte, len(keys))
+	var cold []string
+	for _, key := range keys {
+		if c.overflow != nil && c.spilledKey(key) {
+			cold = append(cold, key)
+			continue
+		}
+		if value, found := c.GetBytes(key); found {
+			values[key] = value
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, key := range cold {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, found := c.GetBytes(key); found {
+				mu.Lock()
+				values[key] = value
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return values
+}
+
+// spilledKey reports whether the item stored under key is held by the
+// overflow store
+func (c *Cache) spilledKey(key string) bool {
+	key = c.mapKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, cold := c.spilled[key]
+	return cold
+}
@@ -0,0 +1,119 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapBatchStore is an in-memory BatchStore counting its LoadMany calls
+type mapBatchStore struct {
+	mu      sync.Mutex
+	items   map[string]StoredValue
+	batches [][]string
+}
+
+func newMapBatchStore() *mapBatchStore {
+	return &mapBatchStore{items: make(map[string]StoredValue)}
+}
+
+func (s *mapBatchStore) Store(key string, value []byte, expiration int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = StoredValue{Value: value, Expiration: expiration, Found: true}
+	return nil
+}
+
+func (s *mapBatchStore) Load(key string) ([]byte, int64, bool, error) {
+	values, err := s.LoadMany([]string{key})
+	return values[0].Value, values[0].Expiration, values[0].Found, err
+}
+
+func (s *mapBatchStore) LoadMany(keys []string) ([]StoredValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, keys)
+	values := make([]StoredValue, len(keys))
+	for i, key := range keys {
+		values[i] = s.items[key]
+	}
+	return values, nil
+}
+
+func (s *mapBatchStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func TestPipelinedStoreCoalescesLoads(t *testing.T) {
+	backend := newMapBatchStore()
+	for i := 0; i < 5; i++ {
+		backend.Store(fmt.Sprintf("key:%d", i), []byte(fmt.Sprint(i)), 0)
+	}
+	store := NewPipelinedStore(backend, 50*time.Millisecond, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("key:%d", i%5)
+			value, _, found, err := store.Load(key)
+			if err != nil || !found || string(value) != fmt.Sprint(i%5) {
+				t.Errorf("Expected %s to load %d, got %q (found=%v, err=%v)", key, i%5, value, found, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(backend.batches) != 1 || len(backend.batches[0]) != 5 {
+		t.Fatalf("Expected a single batch of 5 distinct keys, got %v", backend.batches)
+	}
+}
+
+func TestPipelinedStoreMaxBatch(t *testing.T) {
+	backend := newMapBatchStore()
+	store := NewPipelinedStore(backend, time.Hour, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, found, err := store.Load(fmt.Sprintf("key:%d", i)); found || err != nil {
+				t.Errorf("Expected a miss, got found=%v err=%v", found, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(backend.batches) != 2 {
+		t.Fatalf("Expected full batches to be sent without waiting for the window, got %v", backend.batches)
+	}
+}
+
+func TestGetManyPipelinesSpilledItems(t *testing.T) {
+	backend := newMapBatchStore()
+
+	// Room for two 10 byte items in memory
+	c := New(0, WithMaxBytes(20), WithOverflow(NewPipelinedStore(backend, 100*time.Millisecond, 0)))
+	for i := 1; i <= 5; i++ {
+		c.Set(fmt.Sprintf("k%d", i), fmt.Sprintf("value-%02d", i))
+	}
+
+	values := c.GetMany([]string{"k1", "k2", "k3", "k5", "missing"})
+	if len(values) != 4 {
+		t.Fatalf("Expected 4 values, got %d", len(values))
+	}
+	for _, i := range []int{1, 2, 3, 5} {
+		if got := string(values[fmt.Sprintf("k%d", i)]); got != fmt.Sprintf("value-%02d", i) {
+			t.Fatalf("Expected k%d to hold value-%02d, got %s", i, i, got)
+		}
+	}
+	if len(backend.batches) != 1 {
+		t.Fatalf("Expected the spilled items to be loaded in one round trip, got %v", backend.batches)
+	}
+}
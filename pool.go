@@ -0,0 +1,77 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// maxPooledBufferSize keeps unusually large buffers from pinning memory in the pool
+const maxPooledBufferSize = 64 << 10
+
+// jsonEncoder pairs a reusable buffer with an encoder writing into it
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonEncoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// appendJSON appends the JSON encoding of value to dst, as json.Marshal
+// would produce it, using a pooled encoder so that nothing is allocated when
+// dst has room
+func appendJSON(dst []byte, value interface{}) ([]byte, error) {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBufferSize {
+			e.buf.Reset()
+			jsonEncoderPool.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(value); err != nil {
+		return dst, err
+	}
+	// Encode terminates each value with a newline that json.Marshal doesn't
+	return append(dst, bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'})...), nil
+}
+
+// PutJSON stores value like SetWithExpiration, encoding it into buf instead
+// of a newly allocated slice. The cache keeps buf's backing array: the
+// caller must not modify it afterwards, but can carve the buffers of many
+// values out of one preallocated slab, e.g. buf = slab[n:n:n+size], to save
+// an allocation per write. buf grows like append if it's too small.
+func (c *Cache) PutJSON(key string, value interface{}, duration time.Duration, buf []byte) error {
+	var encoded []byte
+	var err error
+	switch v := value.(type) {
+	case []byte:
+		encoded = append(buf[:0], v...)
+	case string:
+		encoded = append(buf[:0], v...)
+	default:
+		encoded, err = appendJSON(buf[:0], value)
+	}
+	if err != nil {
+		return err
+	}
+	return c.failOpenWrite(c.storeEncoded(key, value, encoded, capToValue(value, expirationFor(duration))))
+}
+
+// AppendBytes appends the raw bytes stored under key to dst and returns the
+// extended slice. Reusing dst across calls lets high-throughput readers avoid
+// an allocation per lookup and never aliases the cache's internal storage.
+func (c *Cache) AppendBytes(dst []byte, key string) ([]byte, bool) {
+	bytes, found := c.GetBytes(key)
+	if !found {
+		return dst, false
+	}
+	return append(dst, bytes...), true
+}
@@ -0,0 +1,97 @@
+package gocache
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendJSONMatchesStdlib(t *testing.T) {
+	item := testStruct{Name: "John <admin>", Age: 30}
+	want, _ := json.Marshal(item)
+
+	got, err := appendJSON([]byte("prefix"), item)
+	if err != nil {
+		t.Fatalf("appendJSON: %v", err)
+	}
+	if string(got) != "prefix"+string(want) {
+		t.Fatalf("Expected prefix%s, got %s", want, got)
+	}
+}
+
+func TestPutJSON(t *testing.T) {
+	c := New(0)
+	slab := make([]byte, 0, 256)
+
+	if err := c.PutJSON("struct", testStruct{Name: "John", Age: 30}, 0, slab[0:0:128]); err != nil {
+		t.Fatalf("PutJSON: %v", err)
+	}
+	if err := c.PutJSON("string", "value", 0, slab[128:128:256]); err != nil {
+		t.Fatalf("PutJSON: %v", err)
+	}
+
+	var result testStruct
+	if found, err := c.Get("struct", &result); !found || err != nil || result.Name != "John" || result.Age != 30 {
+		t.Fatalf("Expected {John 30}, got %+v (err=%v)", result, err)
+	}
+	if val, _ := c.GetString("string"); val != "value" {
+		t.Fatalf("Expected 'value', got '%s'", val)
+	}
+
+	// A buffer that's too small grows instead of failing
+	if err := c.PutJSON("small", testStruct{Name: "Jane", Age: 25}, 0, nil); err != nil {
+		t.Fatalf("PutJSON: %v", err)
+	}
+	if found, err := c.Get("small", &result); !found || err != nil || result.Name != "Jane" {
+		t.Fatalf("Expected Jane, got %+v (err=%v)", result, err)
+	}
+}
+
+func TestAppendBytes(t *testing.T) {
+	c := New(0)
+	c.Set("key", "value")
+
+	buf := make([]byte, 0, 16)
+	buf, found := c.AppendBytes(buf[:0], "key")
+	if !found || string(buf) != "value" {
+		t.Fatalf("Expected 'value', got '%s' (found=%v)", buf, found)
+	}
+
+	// Modifying the buffer must not affect the cached value
+	buf[0] = 'V'
+	if val, _ := c.GetString("key"); val != "value" {
+		t.Fatalf("Cached value was modified through the buffer: %s", val)
+	}
+
+	if _, found := c.AppendBytes(buf[:0], "missing"); found {
+		t.Fatal("Expected missing key not to be found")
+	}
+}
+
+func BenchmarkSetStruct(b *testing.B) {
+	c := New(0)
+	item := testStruct{Name: "John", Age: 30}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Set("item", item)
+	}
+}
+
+// BenchmarkPutJSON stores the same value as BenchmarkSetStruct into buffers
+// carved out of one slab, so the encoded bytes cost no allocation of their own
+func BenchmarkPutJSON(b *testing.B) {
+	c := New(0)
+	item := testStruct{Name: "John", Age: 30}
+	const size = 64
+	var slab []byte
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if len(slab) == cap(slab) {
+			slab = make([]byte, 0, 1024*size)
+		}
+		n := len(slab)
+		slab = slab[:n+size]
+		c.PutJSON("item", &item, 0, slab[n:n:n+size])
+	}
+}
@@ -0,0 +1,76 @@
+package gocache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// keyPrivacy controls how keys are shown to humans, see WithKeyHashing
+type keyPrivacy struct {
+	salt   []byte
+	redact bool
+}
+
+// WithKeyHashing makes the cache show keys as salted hashes wherever it
+// reports them for humans: the debug page, RecentEvictions, KeyError and
+// Validate errors. Keys often hold user IDs or emails, which don't belong in
+// logs. The prefix up to the first ':' is kept, so "user:jane@example.com"
+// shows as something like "user:#3f9a1c0e5b7d2a64", and a key always hashes
+// the same within a cache so its occurrences can still be correlated. The
+// salt keeps guessable keys from being recovered by hashing candidates; keep
+// it secret. Raw keys remain available through the UnsafeKey methods.
+func WithKeyHashing(salt []byte) Option {
+	return func(c *Cache) {
+		c.keyPrivacy = &keyPrivacy{salt: salt}
+	}
+}
+
+// WithKeyRedaction is like WithKeyHashing, but shows every key as its prefix
+// followed by "#redacted", for when even correlating keys is too much
+func WithKeyRedaction() Option {
+	return func(c *Cache) {
+		c.keyPrivacy = &keyPrivacy{redact: true}
+	}
+}
+
+// DisplayKey returns key as the cache shows it in debug output and errors,
+// which is key itself unless WithKeyHashing or WithKeyRedaction is set. Use it
+// to log keys consistently with the cache.
+func (c *Cache) DisplayKey(key string) string {
+	p := c.keyPrivacy
+	if p == nil {
+		return key
+	}
+
+	prefix, rest, found := strings.Cut(key, ":")
+	if !found {
+		prefix, rest = "", key
+	} else {
+		prefix += ":"
+	}
+	if p.redact {
+		return prefix + "#redacted"
+	}
+	mac := hmac.New(sha256.New, p.salt)
+	mac.Write([]byte(rest))
+	return prefix + "#" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// UnsafeKey returns the raw key of the evicted item, even if the cache hides
+// keys with WithKeyHashing or WithKeyRedaction
+func (e Eviction) UnsafeKey() string {
+	return e.rawKey
+}
+
+// UnsafeKey returns the raw key that was rejected, even if the cache hides
+// keys with WithKeyHashing or WithKeyRedaction
+func (e *KeyError) UnsafeKey() string {
+	return e.rawKey
+}
+
+// keyError returns a KeyError for key showing it as DisplayKey does
+func (c *Cache) keyError(key string, err error) *KeyError {
+	return &KeyError{Key: c.DisplayKey(key), Err: err, rawKey: key}
+}
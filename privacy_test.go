@@ -0,0 +1,61 @@
+package gocache
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisplayKey(t *testing.T) {
+	if got := New(0).DisplayKey("user:jane@example.com"); got != "user:jane@example.com" {
+		t.Fatalf("Expected raw keys by default, got %q", got)
+	}
+
+	c := New(0, WithKeyHashing([]byte("salt")))
+	hashed := c.DisplayKey("user:jane@example.com")
+	if !strings.HasPrefix(hashed, "user:#") || strings.Contains(hashed, "jane") {
+		t.Fatalf("Expected a hashed key keeping its prefix, got %q", hashed)
+	}
+	if c.DisplayKey("user:jane@example.com") != hashed {
+		t.Fatalf("Expected hashing to be stable")
+	}
+	if other := New(0, WithKeyHashing([]byte("pepper"))).DisplayKey("user:jane@example.com"); other == hashed {
+		t.Fatalf("Expected the salt to change the hash")
+	}
+
+	r := New(0, WithKeyRedaction())
+	if got := r.DisplayKey("jane@example.com"); got != "#redacted" {
+		t.Fatalf("Expected a redacted key, got %q", got)
+	}
+}
+
+func TestKeyHashingHidesKeys(t *testing.T) {
+	c := New(time.Hour, WithKeyHashing([]byte("salt")), WithKeyPolicy(KeyPolicy{MaxLength: 10}))
+	c.SetWithExpiration("s:jane", "token", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+
+	evictions := c.RecentEvictions()
+	if len(evictions) != 1 || evictions[0].Key != c.DisplayKey("s:jane") || evictions[0].UnsafeKey() != "s:jane" {
+		t.Fatalf("Expected the eviction to hide its key, got %+v", evictions)
+	}
+
+	var keyErr *KeyError
+	err := c.Set("user:jane@example.com", "profile")
+	if !errors.As(err, &keyErr) || strings.Contains(err.Error(), "jane") || keyErr.UnsafeKey() != "user:jane@example.com" {
+		t.Fatalf("Expected a KeyError hiding the key, got %v", err)
+	}
+
+	c.Set("u:jane", "profile")
+	rec := httptest.NewRecorder()
+	c.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/gocache?key=u:jane", nil))
+	page := rec.Body.String()
+	if strings.Contains(page, "jane") {
+		t.Fatalf("Expected the debug page to hide keys, got %s", page)
+	}
+	if !strings.Contains(page, c.DisplayKey("u:jane")) || !strings.Contains(page, "profile") {
+		t.Fatalf("Expected raw keys to still be inspectable, got %s", page)
+	}
+}
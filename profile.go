@@ -0,0 +1,32 @@
+package gocache
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiledOps are the operations labelled by WithProfiling
+var profiledOps = []string{"set", "get", "janitor"}
+
+// WithProfiling labels the cache's Set, Get and janitor work with pprof
+// labels ("gocache" set to name, "op" set to the operation) and wraps it in
+// runtime/trace regions, so CPU profiles and execution traces of processes
+// running several caches attribute time to the right one. Disabled by
+// default since labelling costs a few allocations per call.
+func WithProfiling(name string) Option {
+	return func(c *Cache) {
+		c.profileLabels = make(map[string]pprof.LabelSet, len(profiledOps))
+		for _, op := range profiledOps {
+			c.profileLabels[op] = pprof.Labels("gocache", name, "op", op)
+		}
+	}
+}
+
+// profile runs fn with the pprof labels and trace region of op
+func (c *Cache) profile(op string, fn func()) {
+	pprof.Do(context.Background(), c.profileLabels[op], func(ctx context.Context) {
+		defer trace.StartRegion(ctx, "gocache."+op).End()
+		fn()
+	})
+}
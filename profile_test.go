@@ -0,0 +1,44 @@
+package gocache
+
+import (
+	"bytes"
+	"runtime/trace"
+	"testing"
+	"time"
+)
+
+func TestProfiling(t *testing.T) {
+	c := New(0, WithProfiling("sessions"))
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("tracing unavailable: ", err)
+	}
+	if err := c.SetWithExpiration("a", "1", time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("b", "2")
+	time.Sleep(time.Millisecond)
+	c.DeleteExpired()
+	value, found := c.GetBytes("b")
+	trace.Stop()
+
+	if !found || string(value) != "2" {
+		t.Fatalf("Expected 2, got %q", value)
+	}
+	if _, found := c.GetBytes("a"); found {
+		t.Fatal("Expected a to be expired")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("gocache.set")) {
+		t.Fatal("Expected the trace to contain a gocache.set region")
+	}
+}
+
+func BenchmarkGetProfiling(b *testing.B) {
+	c := New(0, WithProfiling("bench"))
+	c.Set("key", "value")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.GetBytes("key")
+	}
+}
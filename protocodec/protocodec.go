@@ -0,0 +1,48 @@
+// Package protocodec provides a gocache.Codec for protocol buffer messages.
+//
+// Protobuf encoding is faster than the default JSON encoding and preserves
+// field semantics that JSON loses, such as oneofs, enums and bytes fields.
+//
+//	protocodec.Set(c, "user:1", user, time.Minute)
+//	found, err := protocodec.Get(c, "user:1", &pb.User{})
+package protocodec
+
+import (
+	"fmt"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes proto.Message values with protobuf wire format.
+// Marshaling or unmarshaling any other type returns an error.
+var Codec gocache.Codec = codec{}
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protocodec: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protocodec: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// Set stores a message in the cache with the given expiration
+func Set(c *gocache.Cache, key string, m proto.Message, duration time.Duration) error {
+	return c.SetWithOptions(key, m, gocache.WithCodec(Codec), gocache.WithTTL(duration))
+}
+
+// Get decodes the message stored under key into m
+func Get(c *gocache.Cache, key string, m proto.Message) (bool, error) {
+	return c.GetInto(key, m, Codec)
+}
@@ -0,0 +1,56 @@
+package protocodec
+
+import (
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := gocache.New(0)
+
+	// structpb.Value is a oneof, which JSON can't round-trip into the same message
+	in, err := structpb.NewValue(map[string]interface{}{
+		"name":  "John",
+		"admin": true,
+		"tags":  []interface{}{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("Error building message: %v", err)
+	}
+	if err := Set(c, "value", in, time.Minute); err != nil {
+		t.Fatalf("Error setting message: %v", err)
+	}
+
+	out := &structpb.Value{}
+	found, err := Get(c, "value", out)
+	if !found || err != nil {
+		t.Fatalf("Expected to decode message, found=%v err=%v", found, err)
+	}
+	if !proto.Equal(in, out) {
+		t.Fatalf("Retrieved message doesn't match: %v", out)
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	c := gocache.New(0)
+
+	in := timestamppb.New(time.Unix(1700000000, 123456789))
+	Set(c, "ts", in, 0)
+
+	out := &timestamppb.Timestamp{}
+	if _, err := Get(c, "ts", out); err != nil || !proto.Equal(in, out) {
+		t.Fatalf("Expected %v, got %v (err=%v)", in, out, err)
+	}
+}
+
+func TestRejectsNonMessages(t *testing.T) {
+	c := gocache.New(0)
+	if err := c.SetWithOptions("bad", "plain string", gocache.WithCodec(Codec)); err == nil {
+		t.Fatal("Expected an error for a non-proto value")
+	}
+}
@@ -0,0 +1,75 @@
+package gocache
+
+import "time"
+
+// SetRaw stores already serialized bytes under key, skipping type detection
+// and JSON encoding. The slice is stored without copying, so the caller must
+// not modify it after the call.
+func (c *Cache) SetRaw(key string, value []byte, duration time.Duration) error {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return err
+	}
+	item := Item{Value: value, Expiration: expirationFor(duration)}
+
+	if c.middleware != nil {
+		return c.storeThrough(key, item, func(item Item) error {
+			c.mu.Lock()
+			c.setLocked(key, item)
+			c.mu.Unlock()
+			return nil
+		})
+	}
+
+	c.mu.Lock()
+	c.setLocked(key, item)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// SetRawMulti stores several pre-serialized values with the same expiration
+// under a single lock acquisition. Like SetRaw, the slices are not copied.
+// If any key is invalid, nothing is stored. With WithMiddleware, each item
+// goes through the chain and is stored on its own, and a middleware error
+// stops the items not stored yet.
+func (c *Cache) SetRawMulti(items map[string][]byte, duration time.Duration) error {
+	expiration := expirationFor(duration)
+
+	checked := items
+	if c.keyPolicy != nil {
+		checked = make(map[string][]byte, len(items))
+	}
+	for key, value := range items {
+		k, err := c.checkKey(key)
+		if err != nil {
+			return err
+		}
+		if c.keyPolicy != nil {
+			checked[k] = value
+		}
+	}
+
+	if c.middleware != nil {
+		for key, value := range checked {
+			err := c.storeThrough(key, Item{Value: value, Expiration: expiration}, func(item Item) error {
+				c.mu.Lock()
+				c.setLocked(key, item)
+				c.mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c.mu.Lock()
+	for key, value := range checked {
+		c.setLocked(key, Item{Value: value, Expiration: expiration})
+	}
+	c.mu.Unlock()
+
+	return nil
+}
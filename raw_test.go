@@ -0,0 +1,42 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetRaw(t *testing.T) {
+	c := New(0)
+
+	c.SetRaw("raw", []byte(`{"Name":"John","Age":30}`), time.Minute)
+
+	var item testStruct
+	found, err := c.Get("raw", &item)
+	if !found || err != nil {
+		t.Fatalf("Expected to decode raw JSON, found=%v err=%v", found, err)
+	}
+	if item.Name != "John" || item.Age != 30 {
+		t.Fatalf("Retrieved item doesn't match: %+v", item)
+	}
+
+	ttl, err := c.TTL("raw")
+	if err != nil || ttl <= 0 {
+		t.Fatalf("Expected a positive TTL, got %v (err=%v)", ttl, err)
+	}
+}
+
+func TestSetRawMulti(t *testing.T) {
+	c := New(0)
+
+	c.SetRawMulti(map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+	}, 0)
+
+	if c.Count() != 2 {
+		t.Fatalf("Expected 2 items, have %d", c.Count())
+	}
+	if val, _ := c.GetString("b"); val != "2" {
+		t.Fatalf("Expected '2', got '%s'", val)
+	}
+}
@@ -0,0 +1,49 @@
+package gocache
+
+import "sync"
+
+// WithReadOptimized mirrors the live items in a sync.Map so reads of
+// stored keys don't take the cache lock, for workloads that are mostly
+// reads. Writes pay for a second index entry and an allocation each, and
+// misses still take the lock to check the overflow store. BumpGeneration
+// empties the index, so invalidated keys are read through the lock until
+// they're set again.
+func WithReadOptimized() Option {
+	return func(c *Cache) {
+		c.reads = new(sync.Map)
+	}
+}
+
+// indexReadLocked records item as the live item under key in the read
+// index. The caller must hold the write lock.
+func (c *Cache) indexReadLocked(key string, item Item) {
+	if c.reads != nil {
+		c.reads.Store(key, item)
+	}
+}
+
+// unindexReadLocked removes key from the read index. The caller must hold
+// the write lock.
+func (c *Cache) unindexReadLocked(key string) {
+	if c.reads != nil {
+		c.reads.Delete(key)
+	}
+}
+
+// clearReadsLocked empties the read index. The caller must hold the write
+// lock.
+func (c *Cache) clearReadsLocked() {
+	if c.reads != nil {
+		c.reads.Clear()
+	}
+}
+
+// readIndexed returns the item under key from the read index without
+// taking the lock
+func (c *Cache) readIndexed(key string) (Item, bool) {
+	v, found := c.reads.Load(key)
+	if !found {
+		return Item{}, false
+	}
+	return v.(Item), true
+}
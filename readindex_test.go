@@ -0,0 +1,97 @@
+package gocache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadOptimized(t *testing.T) {
+	c := New(0, WithReadOptimized())
+	c.Set("a", "1")
+	c.SetWithExpiration("short", "2", 20*time.Millisecond)
+
+	var value string
+	if found, err := c.Get("a", &value); err != nil || !found || value != "1" {
+		t.Fatalf("Expected a to be 1, got %q (found %v, err %v)", value, found, err)
+	}
+
+	c.Set("a", "3")
+	if found, _ := c.Get("a", &value); !found || value != "3" {
+		t.Fatalf("Expected the overwritten value 3, got %q", value)
+	}
+
+	c.Delete("a")
+	if c.Exists("a") {
+		t.Fatal("Expected a deleted key not to be read from the index")
+	}
+
+	if !c.Pin("short") {
+		t.Fatal("Expected Pin to succeed")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if !c.Exists("short") {
+		t.Fatal("Expected a pinned item to be read past its expiration")
+	}
+	c.Unpin("short")
+	if c.Exists("short") {
+		t.Fatal("Expected an unpinned item to expire")
+	}
+
+	c.Set("b", "4")
+	c.ExpireByPrefix("b", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if c.Exists("b") {
+		t.Fatal("Expected ExpireByPrefix to reach the index")
+	}
+
+	c.Set("c", "5")
+	c.BumpGeneration()
+	if c.Exists("c") {
+		t.Fatal("Expected BumpGeneration to invalidate indexed items")
+	}
+	c.Set("c", "6")
+	if found, _ := c.Get("c", &value); !found || value != "6" {
+		t.Fatalf("Expected c to be set again after BumpGeneration, got %q", value)
+	}
+
+	c.Flush()
+	if c.Exists("c") {
+		t.Fatal("Expected Flush to clear the index")
+	}
+}
+
+func TestReadOptimizedConcurrent(t *testing.T) {
+	c := New(0, WithReadOptimized(), WithMaxBytes(200))
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa(i % 100)
+				if (i+w)%10 == 0 {
+					c.Set(key, key)
+					continue
+				}
+				var value string
+				if found, err := c.Get(key, &value); err != nil || found && value != key {
+					t.Errorf("Expected %s to hold %q, got %q (err %v)", key, key, value, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Evicted keys must have left the index too
+	indexed := 0
+	c.reads.Range(func(any, any) bool {
+		indexed++
+		return true
+	})
+	if indexed != c.Count() {
+		t.Fatalf("Expected the index to hold the %d live items, got %d", c.Count(), indexed)
+	}
+}
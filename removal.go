@@ -0,0 +1,100 @@
+package gocache
+
+import "sync"
+
+// RemovalFunc is called with an item removed from the cache. value is the
+// encoded value and op says why it was removed: EventEvict, EventExpire,
+// EventDelete or EventInvalidate.
+type RemovalFunc func(key string, value []byte, op EventOp)
+
+// WithRemovalListener calls fn for every item removed from memory by
+// eviction, expiration, Delete or dependency invalidation. Keys are passed as
+// stored, after any key policy was applied. Calls are made in order from a
+// separate goroutine once the cache's lock is released, so fn may use the
+// cache; a slow listener delays later notifications, not cache operations.
+// Overwritten items, Flush and items in the overflow store aren't reported.
+// The option can be given several times.
+func WithRemovalListener(fn RemovalFunc) Option {
+	return withRemovalListener(func(key string, item Item, op EventOp) {
+		fn(key, item.Value, op)
+	})
+}
+
+// OnRemovalOf is like WithRemovalListener, but decodes each removed value
+// into a T first, in the format or codec it was stored with, so listeners
+// don't each duplicate the unmarshalling. Values that don't decode into a T,
+// such as values of other types sharing the cache, are skipped and reported
+// to onError if it isn't nil.
+func OnRemovalOf[T any](fn func(key string, value T, op EventOp), onError func(key string, err error)) Option {
+	return withRemovalListener(func(key string, item Item, op EventOp) {
+		var value T
+		if err := decodeItem(item, &value); err != nil {
+			if onError != nil {
+				onError(key, err)
+			}
+			return
+		}
+		fn(key, value, op)
+	})
+}
+
+// withRemovalListener adds a listener to the cache's removal queue
+func withRemovalListener(fn func(key string, item Item, op EventOp)) Option {
+	return func(c *Cache) {
+		if c.removals == nil {
+			c.removals = &removalQueue{}
+		}
+		c.removals.listeners = append(c.removals.listeners, fn)
+	}
+}
+
+// removal is an item waiting to be passed to the removal listeners
+type removal struct {
+	key  string
+	item Item
+	op   EventOp
+}
+
+// removalQueue delivers removals to the listeners from a goroutine started
+// on demand, which exits once the queue is drained
+type removalQueue struct {
+	listeners []func(key string, item Item, op EventOp)
+
+	mu      sync.Mutex
+	pending []removal
+	running bool
+}
+
+// push queues a removal. It never blocks on the listeners, so it's safe to
+// call with the cache's lock held.
+func (q *removalQueue) push(r removal) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(q.pending, r)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+// run calls the listeners until the queue is empty
+func (q *removalQueue) run() {
+	for {
+		q.mu.Lock()
+		batch := q.pending
+		q.pending = nil
+		if len(batch) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+
+		for _, r := range batch {
+			for _, fn := range q.listeners {
+				fn(r.key, r.item, r.op)
+			}
+		}
+	}
+}
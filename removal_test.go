@@ -0,0 +1,83 @@
+package gocache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type removed struct {
+	key   string
+	value string
+	op    EventOp
+}
+
+func TestRemovalListener(t *testing.T) {
+	events := make(chan removed, 10)
+	var c *Cache
+	c = New(0, WithMaxBytes(20), WithRemovalListener(func(key string, value []byte, op EventOp) {
+		c.Exists(key) // listeners may use the cache
+		events <- removed{key, string(value), op}
+	}))
+
+	c.Set("k1", "value-01")
+	c.Set("k1", "value-02") // replaced, not reported
+	c.Set("k2", "value-03")
+	c.Set("k3", "value-04") // evicts k1
+	c.Delete("k2")
+	c.SetWithExpiration("k4", "value-05", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+
+	want := []removed{
+		{"k1", "value-02", EventEvict},
+		{"k2", "value-03", EventDelete},
+		{"k4", "value-05", EventExpire},
+	}
+	for _, w := range want {
+		select {
+		case got := <-events:
+			if got != w {
+				t.Fatalf("Expected %v, got %v", w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %v to be reported", w)
+		}
+	}
+}
+
+type session struct {
+	User string `json:"user"`
+}
+
+func TestOnRemovalOf(t *testing.T) {
+	sessions := make(chan session, 10)
+	errs := make(chan string, 10)
+	c := New(0, OnRemovalOf(func(key string, s session, op EventOp) {
+		sessions <- s
+	}, func(key string, err error) {
+		errs <- key
+	}))
+
+	c.Set("session:1", session{User: "alice"})
+	c.Set("other", "not a session")
+	c.Delete("session:1")
+	c.Delete("other")
+
+	select {
+	case s := <-sessions:
+		if s.User != "alice" {
+			t.Fatalf("Expected the decoded session of alice, got %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the session to be reported")
+	}
+	select {
+	case key := <-errs:
+		if !strings.HasPrefix(key, "other") {
+			t.Fatalf("Expected the other value to fail to decode, got %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the undecodable value to be reported")
+	}
+}
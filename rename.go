@@ -0,0 +1,91 @@
+package gocache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when an operation needs a live item that isn't in
+// the cache
+var ErrNotFound = errors.New("gocache: key not found")
+
+// ErrKeyExists is returned when an operation would overwrite a live item
+// without being allowed to
+var ErrKeyExists = errors.New("gocache: key already exists")
+
+// Rename atomically moves the item stored under oldKey to newKey, keeping
+// its value, expiration, tags, dependencies, priority and pin. It returns
+// ErrNotFound if oldKey holds no live item, and ErrKeyExists if newKey holds
+// one and overwrite is false. Items that depended on oldKey are invalidated,
+// like on a Delete, since that key no longer exists.
+//
+// This suits computing a value under a staging key and promoting it into
+// place once complete.
+func (c *Cache) Rename(oldKey, newKey string, overwrite bool) error {
+	if c.overflow != nil {
+		c.lookup(oldKey) // fault the item in if it was spilled
+	}
+	oldKey = c.mapKey(oldKey)
+	newKey, err := c.checkKey(newKey)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	item, found := c.items[oldKey]
+	if !found || c.staleLocked(item, now) {
+		return ErrNotFound
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if !overwrite && c.liveVersionLocked(newKey, now) != 0 {
+		return ErrKeyExists
+	}
+
+	// The move is a new write for last-write-wins merging
+	item.stamp = Timestamp{}
+	c.setLocked(newKey, item)
+	c.deleteLocked(oldKey, EventDelete)
+	return nil
+}
+
+// Copy atomically stores the value of the item under srcKey under dstKey as
+// well, expiring after ttl (0 means no expiration), without decoding and
+// encoding it again. The copy keeps the source's tags and priority but not
+// its dependencies or pin, and replaces any item stored under dstKey. It
+// returns ErrNotFound if srcKey holds no live item.
+func (c *Cache) Copy(srcKey, dstKey string, ttl time.Duration) error {
+	if c.overflow != nil {
+		c.lookup(srcKey) // fault the item in if it was spilled
+	}
+	srcKey = c.mapKey(srcKey)
+	dstKey, err := c.checkKey(dstKey)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	src, found := c.items[srcKey]
+	if !found || c.staleLocked(src, time.Now().UnixNano()) {
+		return ErrNotFound
+	}
+	if srcKey == dstKey {
+		return nil
+	}
+
+	c.setLocked(dstKey, Item{
+		Value:      src.Value,
+		Expiration: expirationFor(ttl),
+		tags:       src.tags,
+		priority:   src.priority,
+		format:     src.format,
+		typeID:     src.typeID,
+	})
+	return nil
+}
@@ -0,0 +1,73 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRename(t *testing.T) {
+	c := New(0)
+	c.SetWithOptions("staging", "v2", WithTTL(time.Hour), WithTags("report"))
+	c.Set("live", "v1")
+	c.SetWithDeps("summary", "s", "staging")
+
+	if err := c.Rename("staging", "live", false); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("Expected ErrKeyExists, got %v", err)
+	}
+	if err := c.Rename("staging", "live", true); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.GetString("live"); v != "v2" || c.Exists("staging") {
+		t.Fatalf("Expected live to hold v2 and staging to be gone, got %q", v)
+	}
+	if ttl, _ := c.TTL("live"); ttl <= 59*time.Minute {
+		t.Fatalf("Expected the TTL to be kept, got %v", ttl)
+	}
+	if c.Exists("summary") {
+		t.Fatal("Expected the dependents of the old key to be invalidated")
+	}
+
+	c.InvalidateTag("report")
+	if c.Exists("live") {
+		t.Fatal("Expected the tags to move with the item")
+	}
+
+	if err := c.Rename("missing", "other", true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	c.SetWithExpiration("expired", "v", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := c.Rename("expired", "other", true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for an expired item, got %v", err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	c := New(0)
+	c.SetWithOptions("price", map[string]int{"eur": 10}, WithTTL(time.Hour), WithTags("prices"))
+
+	if err := c.Copy("price", "price:de", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]int
+	if found, err := c.Get("price:de", &m); !found || err != nil || m["eur"] != 10 {
+		t.Fatalf("Expected the copied value, got %v (found=%v err=%v)", m, found, err)
+	}
+	if ttl, _ := c.TTL("price:de"); ttl > time.Minute {
+		t.Fatalf("Expected the copy to have its own TTL, got %v", ttl)
+	}
+
+	c.Delete("price")
+	if !c.Exists("price:de") {
+		t.Fatal("Expected the copy to outlive the source")
+	}
+	c.InvalidateTag("prices")
+	if c.Exists("price:de") {
+		t.Fatal("Expected the copy to keep the source's tags")
+	}
+
+	if err := c.Copy("missing", "other", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
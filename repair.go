@@ -0,0 +1,99 @@
+package gocache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// QuarantinePrefix is prepended to the keys of entries quarantined by read
+// repair
+const QuarantinePrefix = "quarantine:"
+
+// defaultQuarantineTTL is how long quarantined entries are kept without a
+// ReadRepair.QuarantineTTL
+const defaultQuarantineTTL = time.Hour
+
+// ReadRepair configures what Get and GetOrLoad do with entries that fail to
+// decode, see WithReadRepair
+type ReadRepair struct {
+	// Quarantine moves a corrupt entry to QuarantinePrefix+key, where it
+	// can be inspected, instead of deleting it
+	Quarantine bool
+
+	// QuarantineTTL is how long quarantined entries are kept, an hour if 0
+	QuarantineTTL time.Duration
+
+	// Loader, if set, is called by Get to reload a removed entry, so the
+	// read still succeeds. GetOrLoad always reloads with its own loader.
+	Loader Loader
+
+	// OnRepair, if set, is called with the key and the decode error of
+	// every entry removed
+	OnRepair func(key string, err error)
+}
+
+// WithReadRepair removes entries that fail to decode, so a corrupt or
+// incompatible value makes one read fail over to a reload instead of failing
+// every read until it expires. Get then reports a miss, or the value from
+// the repair Loader; GetOrLoad reloads with its loader. Errors that point to
+// the target rather than the entry, ErrTypeMismatch, ErrFormatMismatch and
+// JSON type errors, leave the entry alone.
+func WithReadRepair(repair ReadRepair) Option {
+	return func(c *Cache) {
+		c.repair = &repair
+	}
+}
+
+// corrupt reports whether a decode error means the entry itself is bad
+func corrupt(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	var targetErr *json.InvalidUnmarshalError
+	return !errors.Is(err, ErrTypeMismatch) && !errors.Is(err, ErrFormatMismatch) &&
+		!errors.As(err, &typeErr) && !errors.As(err, &targetErr)
+}
+
+// repairItem removes the item read under key if decoding it failed with a
+// corrupt entry error, and reports whether it did. The item is only removed
+// if it wasn't rewritten since it was read.
+func (c *Cache) repairItem(key string, item Item, err error) bool {
+	r := c.repair
+	if r == nil || !corrupt(err) {
+		return false
+	}
+	mapped := c.mapKey(key)
+
+	c.mu.Lock()
+	current, found := c.items[mapped]
+	if !found || current.version != item.version {
+		c.mu.Unlock()
+		return false
+	}
+	if r.Quarantine {
+		ttl := r.QuarantineTTL
+		if ttl <= 0 {
+			ttl = defaultQuarantineTTL
+		}
+		c.setLocked(QuarantinePrefix+mapped, Item{Value: item.Value, Expiration: expirationFor(ttl), format: item.format})
+	}
+	c.deleteLocked(mapped, EventInvalidate)
+	c.mu.Unlock()
+
+	if r.OnRepair != nil {
+		r.OnRepair(key, err)
+	}
+	return true
+}
+
+// reloadRepaired reloads a repaired entry into target with the repair
+// Loader, reporting a miss without one
+func (c *Cache) reloadRepaired(key string, target interface{}) (bool, error) {
+	if c.repair.Loader == nil {
+		return false, nil
+	}
+	if err := c.GetOrLoad(context.Background(), key, target, c.repair.Loader); err != nil {
+		return false, err
+	}
+	return true, nil
+}
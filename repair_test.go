@@ -0,0 +1,68 @@
+package gocache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type repairUser struct {
+	Name string
+}
+
+func TestReadRepair(t *testing.T) {
+	var repaired []string
+	c := New(0, WithReadRepair(ReadRepair{
+		Quarantine: true,
+		OnRepair:   func(key string, err error) { repaired = append(repaired, key) },
+	}))
+	c.Set("user:1", []byte(`{"Name": "trunc`))
+
+	var u repairUser
+	if found, err := c.Get("user:1", &u); found || err != nil {
+		t.Fatalf("Expected the corrupt entry to read as a miss, got found=%v err=%v", found, err)
+	}
+	if c.Exists("user:1") || len(repaired) != 1 {
+		t.Fatalf("Expected the entry to be removed and reported, got %v", repaired)
+	}
+	if v, _ := c.GetString(QuarantinePrefix + "user:1"); v != `{"Name": "trunc` {
+		t.Fatalf("Expected the entry to be quarantined, got %q", v)
+	}
+
+	// Errors caused by the target leave the entry alone
+	c.Set("count", 42)
+	if _, err := c.Get("count", &u); err == nil {
+		t.Fatal("Expected a type error")
+	}
+	if !c.Exists("count") || len(repaired) != 1 {
+		t.Fatal("Expected the entry to be kept")
+	}
+}
+
+func TestReadRepairReloads(t *testing.T) {
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return repairUser{Name: "Ada"}, time.Minute, nil
+	}
+	c := New(0, WithReadRepair(ReadRepair{Loader: loader}))
+	c.Set("user:1", []byte("garbage"))
+
+	var u repairUser
+	if found, err := c.Get("user:1", &u); !found || err != nil || u.Name != "Ada" {
+		t.Fatalf("Expected the reloaded value, got %+v (found=%v err=%v)", u, found, err)
+	}
+
+	c.Set("user:2", []byte("garbage"))
+	u = repairUser{}
+	if err := c.GetOrLoad(context.Background(), "user:2", &u, loader); err != nil || u.Name != "Ada" {
+		t.Fatalf("Expected GetOrLoad to reload, got %+v (%v)", u, err)
+	}
+}
+
+func TestNoReadRepair(t *testing.T) {
+	c := New(0)
+	c.Set("user:1", []byte("garbage"))
+	var u repairUser
+	if found, err := c.Get("user:1", &u); !found || err == nil {
+		t.Fatal("Expected the decode error without read repair")
+	}
+}
@@ -0,0 +1,327 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeKind is the kind of write described by a Change
+type ChangeKind uint8
+
+const (
+	// ChangeSet stores Value under Key
+	ChangeSet ChangeKind = iota + 1
+	// ChangeDelete removes Key
+	ChangeDelete
+	// ChangeFlush removes every key
+	ChangeFlush
+)
+
+// Change is a single write streamed from a primary cache to its replicas
+type Change struct {
+	Seq        uint64 // position in the primary's change stream
+	Kind       ChangeKind
+	Key        string
+	Value      []byte
+	Expiration int64 // absolute, in UnixNano, 0 means no expiration
+	Format     Format
+}
+
+// Replica receives the changes of a primary cache. Apply is called from a
+// single goroutine with batches in stream order. A snapshot transfer starts
+// with a ChangeFlush.
+type Replica interface {
+	Apply(changes []Change) error
+}
+
+// Apply makes *Cache a Replica, so a cache can replicate to another cache
+// in the same process or behind a transport that calls Apply remotely
+func (c *Cache) Apply(changes []Change) error {
+	for _, change := range changes {
+		switch change.Kind {
+		case ChangeSet:
+			item := Item{Value: change.Value, Expiration: change.Expiration, format: change.Format}
+			if err := c.storeItem(change.Key, item); err != nil {
+				return err
+			}
+		case ChangeDelete:
+			c.Delete(change.Key)
+		case ChangeFlush:
+			c.Flush()
+		}
+	}
+	return nil
+}
+
+// subscriber buffers the changes published to a Replicator
+type subscriber struct {
+	ch       chan Change
+	overflow atomic.Bool // set when a change was dropped
+}
+
+// publishLocked assigns change the next sequence number and sends it to the
+// subscribers. Subscribers with a full buffer miss the change and resync.
+// The caller must hold the write lock.
+func (c *Cache) publishLocked(change Change) {
+	c.changeSeq++
+	change.Seq = c.changeSeq
+	if c.changeLog != nil {
+		c.changeLog.add(change)
+	}
+	for _, s := range c.subscribers {
+		select {
+		case s.ch <- change:
+		default:
+			s.overflow.Store(true)
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns it with a snapshot of the
+// live items, including spilled ones, that it receives the changes after
+func (c *Cache) subscribe(buffer int) (*subscriber, []Change) {
+	s := &subscriber{ch: make(chan Change, buffer)}
+
+	// Read the spilled items without holding the lock; only those spilled
+	// again meanwhile are read under it
+	c.mu.RLock()
+	spilled := c.liveSpilledLocked(nil)
+	c.mu.RUnlock()
+	loaded := make(map[string]Item, len(spilled))
+	for k, version := range spilled {
+		if item, ok := c.peekSpilled(k); ok {
+			item.version = version
+			loaded[k] = item
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	snapshot := make([]Change, 0, len(c.items)+len(c.spilled)+1)
+	snapshot = append(snapshot, Change{Seq: c.changeSeq, Kind: ChangeFlush})
+	for k, v := range c.items {
+		if c.staleLocked(v, now) {
+			continue
+		}
+		snapshot = append(snapshot, Change{Seq: c.changeSeq, Kind: ChangeSet, Key: k, Value: v.Value, Expiration: v.Expiration, Format: v.format})
+	}
+	for k, version := range c.liveSpilledLocked(nil) {
+		v, ok := loaded[k]
+		if !ok || v.version != version {
+			if v, ok = c.peekSpilled(k); !ok {
+				continue
+			}
+		}
+		snapshot = append(snapshot, Change{Seq: c.changeSeq, Kind: ChangeSet, Key: k, Value: v.Value, Expiration: v.Expiration, Format: v.format})
+	}
+	c.subscribers = append(c.subscribers, s)
+	return s, snapshot
+}
+
+func (c *Cache) unsubscribe(s *subscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, sub := range c.subscribers {
+		if sub == s {
+			c.subscribers = append(c.subscribers[:i], c.subscribers[i+1:]...)
+			break
+		}
+	}
+}
+
+// ReplicationConfig configures a Replicator
+type ReplicationConfig struct {
+	// BatchSize is the maximum number of changes per Apply call, 256 if 0
+	BatchSize int
+
+	// Buffer is the number of changes queued for a slow replica before it
+	// falls back to a full snapshot transfer, 4096 if 0
+	Buffer int
+
+	// RetryInterval is how long to wait after a failed Apply before
+	// resyncing the replica, 1s if 0
+	RetryInterval time.Duration
+
+	// AntiEntropyInterval is how often the replica's digest is compared
+	// with the primary's to repair diverged entries. 0 disables anti-entropy;
+	// it also requires the replica to implement DigestReplica.
+	AntiEntropyInterval time.Duration
+
+	// AntiEntropyBuckets is the number of digest buckets, 256 if 0. More
+	// buckets transfer fewer keys per repair but larger digests.
+	AntiEntropyBuckets int
+}
+
+// ReplicationStats describes the progress of a Replicator
+type ReplicationStats struct {
+	Applied   uint64 // sequence number of the last change applied by the replica
+	Lag       uint64 // changes published by the primary and not yet applied
+	Resyncs   int    // full snapshot transfers, including the initial one
+	Repairs   int    // changes sent to repair diverged entries
+	LastError error  // last error returned by Apply, nil after a successful resync
+}
+
+// errResync is returned by stream when changes were dropped
+var errResync = errors.New("replica fell behind")
+
+// Replicator streams a cache's writes to a Replica
+type Replicator struct {
+	cache   *Cache
+	replica Replica
+	config  ReplicationConfig
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu    sync.Mutex
+	stats ReplicationStats
+}
+
+// Replicate starts asynchronously replicating c to replica, which first
+// receives a snapshot of the live items and then every Set and Delete,
+// including deletes caused by dependency and tag invalidation, as well as
+// Flush and BumpGeneration. Expiration and eviction aren't replicated: the
+// replica expires items itself and evicts according to its own limits.
+// Dependencies, tags and other per-item options aren't replicated either.
+func (c *Cache) Replicate(replica Replica, config ReplicationConfig) *Replicator {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 256
+	}
+	if config.Buffer <= 0 {
+		config.Buffer = 4096
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+	if config.AntiEntropyBuckets <= 0 {
+		config.AntiEntropyBuckets = 256
+	}
+
+	r := &Replicator{
+		cache:   c,
+		replica: replica,
+		config:  config,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Stop stops replicating and waits for an in-flight Apply to return
+func (r *Replicator) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+// Stats returns the replication progress
+func (r *Replicator) Stats() ReplicationStats {
+	r.mu.Lock()
+	stats := r.stats
+	r.mu.Unlock()
+
+	r.cache.mu.RLock()
+	stats.Lag = r.cache.changeSeq - stats.Applied
+	r.cache.mu.RUnlock()
+	return stats
+}
+
+func (r *Replicator) run() {
+	defer close(r.done)
+	for {
+		s, snapshot := r.cache.subscribe(r.config.Buffer)
+		r.mu.Lock()
+		r.stats.Resyncs++
+		r.mu.Unlock()
+
+		err := r.apply(snapshot)
+		if err == nil {
+			r.mu.Lock()
+			r.stats.LastError = nil
+			r.mu.Unlock()
+			err = r.stream(s)
+		}
+		r.cache.unsubscribe(s)
+
+		if err == nil {
+			return // stopped
+		}
+		if err != errResync {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(r.config.RetryInterval):
+			}
+		}
+	}
+}
+
+// stream applies the changes sent to s in batches until the Replicator is
+// stopped (returning nil), changes were dropped or Apply fails
+func (r *Replicator) stream(s *subscriber) error {
+	var antiEntropy <-chan time.Time
+	replica, digests := r.replica.(DigestReplica)
+	if digests && r.config.AntiEntropyInterval > 0 {
+		ticker := time.NewTicker(r.config.AntiEntropyInterval)
+		defer ticker.Stop()
+		antiEntropy = ticker.C
+	}
+
+	batch := make([]Change, 0, r.config.BatchSize)
+	for {
+		batch = batch[:0]
+		select {
+		case <-r.stop:
+			return nil
+		case <-antiEntropy:
+			if err := r.repair(replica, s); err != nil {
+				return err
+			}
+			continue
+		case change := <-s.ch:
+			batch = append(batch, change)
+		}
+	drain:
+		for len(batch) < r.config.BatchSize {
+			select {
+			case change := <-s.ch:
+				batch = append(batch, change)
+			default:
+				break drain
+			}
+		}
+
+		if s.overflow.Load() {
+			return errResync
+		}
+		if err := r.apply(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// fail records err as the last replication error and returns it
+func (r *Replicator) fail(err error) error {
+	r.mu.Lock()
+	r.stats.LastError = err
+	r.mu.Unlock()
+	return err
+}
+
+// apply sends changes to the replica in batches
+func (r *Replicator) apply(changes []Change) error {
+	for len(changes) > 0 {
+		n := min(len(changes), r.config.BatchSize)
+		if err := r.replica.Apply(changes[:n]); err != nil {
+			return r.fail(err)
+		}
+		r.mu.Lock()
+		r.stats.Applied = changes[n-1].Seq
+		r.mu.Unlock()
+		changes = changes[n:]
+	}
+	return nil
+}
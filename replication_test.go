@@ -0,0 +1,134 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	primary := New(0)
+	replica := New(0)
+	primary.Set("before", "snapshot")
+
+	r := primary.Replicate(replica, ReplicationConfig{})
+	defer r.Stop()
+
+	primary.SetWithExpiration("ttl", "v", time.Hour)
+	primary.Set("deleted", "v")
+	primary.Delete("deleted")
+	primary.SetWithDeps("derived", "v", "ttl")
+	primary.Set("ttl", "v2") // invalidates derived
+
+	waitFor(t, "replication", func() bool { return r.Stats().Lag == 0 })
+
+	if v, _ := replica.GetString("before"); v != "snapshot" {
+		t.Fatalf("Expected the snapshot to be transferred, got %q", v)
+	}
+	if v, _ := replica.GetString("ttl"); v != "v2" {
+		t.Fatalf("Expected v2, got %q", v)
+	}
+	if replica.Exists("deleted") || replica.Exists("derived") {
+		t.Fatal("Expected deletes and invalidations to be replicated")
+	}
+	if ttl, _ := replica.TTL("ttl"); ttl != -1 {
+		// "ttl" was overwritten without expiration
+		t.Fatalf("Expected no expiration, got %v", ttl)
+	}
+
+	primary.Flush()
+	waitFor(t, "flush", func() bool { return replica.Count() == 0 })
+}
+
+// flakyReplica fails its first Apply calls and blocks while gate is held
+type flakyReplica struct {
+	*Cache
+	mu       sync.Mutex
+	failures int
+	gate     sync.Mutex
+}
+
+func (f *flakyReplica) Apply(changes []Change) error {
+	f.gate.Lock()
+	defer f.gate.Unlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("replica unavailable")
+	}
+	return f.Cache.Apply(changes)
+}
+
+func TestReplicateRetry(t *testing.T) {
+	primary := New(0)
+	primary.Set("k", "v")
+	replica := &flakyReplica{Cache: New(0), failures: 1}
+
+	r := primary.Replicate(replica, ReplicationConfig{RetryInterval: time.Millisecond})
+	defer r.Stop()
+
+	waitFor(t, "retry", func() bool { return replica.Exists("k") })
+	if stats := r.Stats(); stats.Resyncs < 2 || stats.LastError != nil {
+		t.Fatalf("Expected a successful resync after the failure, got %+v", stats)
+	}
+}
+
+func TestReplicateOverflowResync(t *testing.T) {
+	primary := New(0)
+	replica := &flakyReplica{Cache: New(0)}
+
+	r := primary.Replicate(replica, ReplicationConfig{Buffer: 2})
+	defer r.Stop()
+	waitFor(t, "initial sync", func() bool { return r.Stats().Resyncs == 1 && r.Stats().Lag == 0 })
+
+	replica.gate.Lock()
+	primary.Set("a", "1") // may be picked up by the blocked Apply
+	for _, k := range []string{"b", "c", "d", "e"} {
+		primary.Set(k, k)
+	}
+	replica.gate.Unlock()
+
+	waitFor(t, "resync", func() bool { return r.Stats().Lag == 0 && replica.Count() == 5 })
+	if r.Stats().Resyncs < 2 {
+		t.Fatalf("Expected dropped changes to trigger a resync, got %+v", r.Stats())
+	}
+}
+
+func TestReplicateSpilled(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	primary := New(0, WithMaxBytes(10), WithOverflow(store))
+	primary.Set("x", "value-x")
+	primary.Set("y", "value-y") // spills x
+	if !primary.spilledKey("x") {
+		t.Fatal("Expected x to be spilled")
+	}
+
+	replica := New(0)
+	r := primary.Replicate(replica, ReplicationConfig{})
+	defer r.Stop()
+	waitFor(t, "replication", func() bool { return r.Stats().Lag == 0 })
+
+	if v, _ := replica.GetString("x"); v != "value-x" {
+		t.Fatalf("Expected the spilled item in the snapshot, got %q", v)
+	}
+	if v, _ := replica.GetString("y"); v != "value-y" {
+		t.Fatalf("Expected value-y, got %q", v)
+	}
+	if !primary.spilledKey("x") {
+		t.Fatal("The snapshot shouldn't fault spilled items in")
+	}
+}
@@ -0,0 +1,97 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryableError marks a loader error as transient, see Retryable
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable marks err as transient so that a RetryPolicy retries it. Errors
+// that aren't marked are treated as permanent and returned immediately.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err}
+}
+
+// IsRetryable reports whether err was marked with Retryable
+func IsRetryable(err error) bool {
+	var r *RetryableError
+	return errors.As(err, &r)
+}
+
+// RetryPolicy configures retries of failed loader calls
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls, including the first one
+	Attempts int
+
+	// BaseDelay is the delay before the first retry. It doubles after each
+	// further attempt, up to MaxDelay.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between attempts. 0 means no cap.
+	MaxDelay time.Duration
+
+	// Jitter randomizes each delay by up to this fraction (0-1) of its
+	// length, so that many callers don't retry in lockstep
+	Jitter float64
+}
+
+// delay returns the backoff before the given retry (1 for the first retry)
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// wrap returns a loader that retries loader according to the policy
+func (p RetryPolicy) wrap(loader Loader) Loader {
+	return func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		for attempt := 1; ; attempt++ {
+			value, ttl, err := loader(ctx, key)
+			if err == nil || attempt >= p.Attempts || !IsRetryable(err) {
+				return value, ttl, err
+			}
+
+			timer := time.NewTimer(p.delay(attempt))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, 0, err
+			}
+		}
+	}
+}
+
+// LoadRetry retries GetOrLoad's loader on errors marked with Retryable.
+// Combined with LoadBreaker, every attempt goes through the breaker, and
+// ErrCircuitOpen stops the retries.
+func LoadRetry(policy RetryPolicy) LoadOption {
+	return func(o *loadOptions) {
+		o.retry = &policy
+	}
+}
@@ -0,0 +1,64 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadRetry(t *testing.T) {
+	c := New(0)
+	policy := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, Jitter: 0.5}
+
+	calls := 0
+	flaky := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		calls++
+		if calls < 3 {
+			return nil, 0, Retryable(errors.New("timeout"))
+		}
+		return "value", 0, nil
+	}
+
+	var val string
+	if err := c.GetOrLoad(context.Background(), "flaky", &val, flaky, LoadRetry(policy)); err != nil || val != "value" {
+		t.Fatalf("Expected success on the third attempt, got '%s' (err=%v)", val, err)
+	}
+	if calls != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", calls)
+	}
+
+	// Permanent errors aren't retried
+	calls = 0
+	notFound := errors.New("not found")
+	permanent := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		calls++
+		return nil, 0, notFound
+	}
+	err := c.GetOrLoad(context.Background(), "permanent", &val, permanent, LoadRetry(policy))
+	if !errors.Is(err, notFound) || calls != 1 {
+		t.Fatalf("Permanent error should fail after one attempt, got %v after %d calls", err, calls)
+	}
+
+	// Retries give up after Attempts
+	calls = 0
+	down := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		calls++
+		return nil, 0, Retryable(errors.New("down"))
+	}
+	err = c.GetOrLoad(context.Background(), "down", &val, down, LoadRetry(policy))
+	if !IsRetryable(err) || calls != 3 {
+		t.Fatalf("Expected to give up after 3 attempts, got %v after %d calls", err, calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if d := p.delay(i + 1); d != w*time.Millisecond {
+			t.Fatalf("Retry %d: expected %v, got %v", i+1, w*time.Millisecond, d)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package sessions provides a gorilla/sessions Store that keeps session data
+// in a gocache.Cache. Only a signed (and optionally encrypted) session ID is
+// sent to the client; the values stay on the server:
+//
+//	store := sessions.NewStore(c, []byte("hash-key"), []byte("block-key"))
+//	session, err := store.Get(r, "session")
+//	session.Values["user"] = "john"
+//	err = session.Save(r, w)
+//
+// Expiration is sliding: every load of a session extends its lifetime in
+// the cache by Options.MaxAge, and every Save reissues the cookie.
+package sessions
+
+import (
+	"encoding/base32"
+	"net/http"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// keyPrefix namespaces the keys written by Store
+const keyPrefix = "session:"
+
+var base32RawStdEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Store stores sessions in a cache. It implements gorilla/sessions' Store
+// interface.
+type Store struct {
+	Cache   *gocache.Cache
+	Codecs  []securecookie.Codec
+	Options *gsessions.Options // default configuration
+}
+
+// NewStore returns a Store keeping sessions in c for 30 days since their
+// last use. keyPairs are hash and block keys for the session cookie, as
+// for gorilla/sessions.NewCookieStore. Session values are encoded with
+// gocache.GobCodec, so custom types must be registered with
+// gocache.RegisterGobTypes.
+func NewStore(c *gocache.Cache, keyPairs ...[]byte) *Store {
+	s := &Store{
+		Cache:  c,
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &gsessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+	}
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// Get returns a session for the given name after adding it to the registry
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the
+// registry. It loads the session named by the request's cookie if it's
+// still in the cache, and returns a new session otherwise.
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+	found, err := s.Cache.GetInto(keyPrefix+session.ID, &session.Values, gocache.GobCodec)
+	if err != nil {
+		return session, err
+	}
+	if !found {
+		// Expired or deleted, so start over under a new ID
+		session.ID = ""
+		return session, nil
+	}
+	session.IsNew = false
+	return session, s.save(session)
+}
+
+// Save stores the session and sets its cookie. A session with an
+// Options.MaxAge of 0 or less is deleted instead, along with its cookie.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			s.Cache.Delete(keyPrefix + session.ID)
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = base32RawStdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+	if err := s.save(session); err != nil {
+		return err
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// MaxAge sets the maximum age of sessions and their cookies. Individual
+// sessions can be deleted by setting their Options.MaxAge to -1.
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// save writes the session's values to the cache, restarting its lifetime
+func (s *Store) save(session *gsessions.Session) error {
+	ttl := time.Duration(session.Options.MaxAge) * time.Second
+	return s.Cache.SetWithOptions(keyPrefix+session.ID, session.Values, gocache.WithCodec(gocache.GobCodec), gocache.WithTTL(ttl))
+}
@@ -0,0 +1,99 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+	gsessions "github.com/gorilla/sessions"
+)
+
+var _ gsessions.Store = (*Store)(nil)
+
+// roundTrip loads the "s" session for a request carrying cookie, applies
+// fn and saves it, returning the response's cookie
+func roundTrip(t *testing.T, store *Store, cookie *http.Cookie, fn func(*gsessions.Session)) (*gsessions.Session, *http.Cookie) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+
+	session, err := store.New(r, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn(session)
+	if err := session.Save(r, w); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected one cookie, got %v", cookies)
+	}
+	return session, cookies[0]
+}
+
+func TestStore(t *testing.T) {
+	c := gocache.New(0)
+	store := NewStore(c, []byte("0123456789abcdef0123456789abcdef"))
+
+	session, cookie := roundTrip(t, store, nil, func(s *gsessions.Session) {
+		s.Values["user"] = "john"
+	})
+	if !session.IsNew || strings.Contains(cookie.Value, "john") {
+		t.Fatalf("Expected a new session with only its ID in the cookie, got %q", cookie.Value)
+	}
+
+	session, _ = roundTrip(t, store, cookie, func(s *gsessions.Session) {})
+	if session.IsNew || session.Values["user"] != "john" {
+		t.Fatalf("Expected to load the session, got %v", session.Values)
+	}
+
+	tampered := *cookie
+	tampered.Value = "x" + cookie.Value
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&tampered)
+	if session, err := store.New(r, "s"); err == nil || !session.IsNew {
+		t.Fatal("Expected a tampered cookie to be rejected")
+	}
+
+	_, cookie = roundTrip(t, store, cookie, func(s *gsessions.Session) {
+		s.Options.MaxAge = -1
+	})
+	if cookie.MaxAge >= 0 || c.Count() != 0 {
+		t.Fatal("Expected the session and its cookie to be deleted")
+	}
+}
+
+func TestStoreSlidingExpiration(t *testing.T) {
+	c := gocache.New(0)
+	store := NewStore(c, []byte("0123456789abcdef0123456789abcdef"))
+	store.MaxAge(1)
+
+	session, cookie := roundTrip(t, store, nil, func(s *gsessions.Session) {
+		s.Values["n"] = 1
+	})
+	key := keyPrefix + session.ID
+	first, _ := c.TTL(key)
+
+	time.Sleep(50 * time.Millisecond)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	if _, err := store.New(r, "s"); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := c.TTL(key); second <= first-25*time.Millisecond {
+		t.Fatalf("Expected loading the session to extend its TTL, got %v then %v", first, second)
+	}
+
+	c.Delete(key)
+	session, _ = roundTrip(t, store, cookie, func(s *gsessions.Session) {})
+	if !session.IsNew || session.ID == "" || session.ID == strings.TrimPrefix(key, keyPrefix) {
+		t.Fatal("Expected an expired session to start over under a new ID")
+	}
+}
@@ -0,0 +1,92 @@
+package gocache
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SimulationConfig is a cache configuration a trace is replayed against
+type SimulationConfig struct {
+	Name    string
+	Config  Config   // usually differing in MaxBytes
+	Options []Option // e.g. WithAccessTracking to evict least recently used items first
+}
+
+// SimulationResult is the outcome of replaying a trace against one
+// configuration
+type SimulationResult struct {
+	Name     string
+	Reads    uint64
+	Hits     uint64
+	HitRatio float64
+	Items    int   // items left at the end of the trace
+	Bytes    int64 // size of those items
+}
+
+func (r SimulationResult) String() string {
+	return fmt.Sprintf("%s: %.2f%% hit ratio over %d reads, %d items in %d bytes at the end",
+		r.Name, 100*r.HitRatio, r.Reads, r.Items, r.Bytes)
+}
+
+// Simulate replays an access trace, such as one recorded with
+// WithTraceRecording, against a fresh cache for each configuration and
+// reports the hit ratio each achieves, to help choose MaxBytes and eviction
+// settings before a rollout. The trace is replayed as fast as possible, so
+// items are stored without a TTL and removed when the trace recorded them
+// expiring.
+func Simulate(trace io.Reader, configs ...SimulationConfig) ([]SimulationResult, error) {
+	tr, err := NewTraceReader(trace)
+	if err != nil {
+		return nil, err
+	}
+
+	caches := make([]*Cache, len(configs))
+	results := make([]SimulationResult, len(configs))
+	for i, cfg := range configs {
+		cfg.Config.CleanupInterval = 0
+		caches[i] = NewFromConfig(cfg.Config, cfg.Options...)
+		results[i].Name = cfg.Name
+	}
+
+	// Values are never read back, so every item shares one zeroed buffer
+	var zeros []byte
+	for {
+		rec, err := tr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := strconv.FormatUint(rec.KeyHash, 16)
+		for i, c := range caches {
+			switch rec.Op {
+			case EventGet:
+				results[i].Reads++
+				if _, found := c.GetBytes(key); found {
+					results[i].Hits++
+				}
+			case EventSet:
+				n := max(rec.Size-len(key), 0)
+				if n > len(zeros) {
+					zeros = make([]byte, 2*n)
+				}
+				c.SetWithOptions(key, zeros[:n], WithNoCopy())
+			case EventDelete, EventExpire, EventInvalidate:
+				// Evictions are left to the simulated cache's own policy
+				c.Delete(key)
+			}
+		}
+	}
+
+	for i, c := range caches {
+		if results[i].Reads > 0 {
+			results[i].HitRatio = float64(results[i].Hits) / float64(results[i].Reads)
+		}
+		results[i].Items = c.Count()
+		results[i].Bytes = c.Size()
+	}
+	return results, nil
+}
@@ -0,0 +1,39 @@
+package gocache
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	// Read 10 keys of 100 bytes in a loop, storing them on a miss
+	var buf bytes.Buffer
+	tw := NewTraceWriter(&buf)
+	now := time.Now()
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 10; i++ {
+			hash := TraceKeyHash("key:" + strconv.Itoa(i))
+			tw.Write(TraceRecord{Op: EventGet, Time: now, KeyHash: hash})
+			tw.Write(TraceRecord{Op: EventSet, Time: now, KeyHash: hash, Size: 100})
+		}
+	}
+	tw.Flush()
+
+	results, err := Simulate(&buf,
+		SimulationConfig{Name: "small", Config: Config{MaxBytes: 500}},
+		SimulationConfig{Name: "large", Config: Config{MaxBytes: 1000}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A loop larger than the cache always misses with FIFO eviction
+	if small := results[0]; small.Reads != 100 || small.Hits != 0 || small.Bytes > 500 {
+		t.Fatalf("Expected no hits for the small cache, got %v", small)
+	}
+	if large := results[1]; large.Hits != 90 || large.HitRatio != 0.9 || large.Items != 10 {
+		t.Fatalf("Expected only the first round to miss for the large cache, got %v", large)
+	}
+}
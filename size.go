@@ -0,0 +1,75 @@
+package gocache
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"unsafe"
+)
+
+// mapEntryOverhead approximates the memory a map entry costs beyond its key
+// and value data: the string header, the Item struct, one tophash byte, all
+// scaled by the map's maximum load factor of 6.5/8
+const mapEntryOverhead = (unsafe.Sizeof("") + unsafe.Sizeof(Item{}) + 1) * 8 / 6
+
+// EstimatedSize returns the number of entries and an estimate of the memory
+// they use, accounting for key strings, value bytes, dependency and tag
+// metadata and map overhead. Capacity planning can use it instead of heap
+// profiling the whole process.
+func (c *Cache) EstimatedSize() (entries int, bytes int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for k, v := range c.items {
+		bytes += v.estimatedSize(k)
+	}
+	return len(c.items), bytes
+}
+
+// estimatedSize approximates the memory held by an entry
+func (item Item) estimatedSize(key string) int64 {
+	size := int64(mapEntryOverhead) + int64(len(key)) + int64(cap(item.Value))
+	for _, s := range item.deps {
+		size += int64(unsafe.Sizeof(s)) + int64(len(s))
+	}
+	for _, s := range item.tags {
+		size += int64(unsafe.Sizeof(s)) + int64(len(s))
+	}
+	return size
+}
+
+// DebugDumpSizes writes the estimated cache size followed by the topN largest
+// entries to w, one per line
+func (c *Cache) DebugDumpSizes(w io.Writer, topN int) error {
+	type entry struct {
+		key  string
+		size int64
+	}
+
+	c.mu.RLock()
+	entries := make([]entry, 0, len(c.items))
+	var total int64
+	for k, v := range c.items {
+		size := v.estimatedSize(k)
+		entries = append(entries, entry{key: k, size: size})
+		total += size
+	}
+	c.mu.RUnlock()
+	count := len(entries)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].size > entries[j].size
+	})
+	if topN >= 0 && topN < len(entries) {
+		entries = entries[:topN]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "entries: %d\testimated bytes: %d\n", count, total)
+	fmt.Fprintf(tw, "BYTES\tKEY\n")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%d\t%q\n", e.size, e.key)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,46 @@
+package gocache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEstimatedSize(t *testing.T) {
+	c := New(0)
+
+	c.Set("small", "x")
+	c.Set("large", strings.Repeat("x", 1000))
+
+	entries, size := c.EstimatedSize()
+	if entries != 2 {
+		t.Fatalf("Expected 2 entries, got %d", entries)
+	}
+	if size < c.Size() {
+		t.Fatalf("Estimate %d should include at least the %d key and value bytes", size, c.Size())
+	}
+}
+
+func TestDebugDumpSizes(t *testing.T) {
+	c := New(0)
+
+	c.Set("small", "x")
+	c.Set("medium", strings.Repeat("x", 100))
+	c.Set("large", strings.Repeat("x", 1000))
+
+	var buf bytes.Buffer
+	if err := c.DebugDumpSizes(&buf, 2); err != nil {
+		t.Fatalf("Error dumping sizes: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a summary, a header and 2 entries, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], "entries: 3") {
+		t.Fatalf("Summary should count all entries: %s", lines[0])
+	}
+	if !strings.Contains(lines[2], `"large"`) || !strings.Contains(lines[3], `"medium"`) {
+		t.Fatalf("Entries should be sorted by size:\n%s", buf.String())
+	}
+}
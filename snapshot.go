@@ -0,0 +1,194 @@
+package gocache
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// snapshotLoadBatch is the number of entries stored per lock acquisition by
+// LoadSnapshot
+const snapshotLoadBatch = 256
+
+// SnapshotEntry is an item as written by SaveSnapshot
+type SnapshotEntry struct {
+	Key        string
+	Value      []byte
+	Expiration int64 // 0 means no expiration
+	Format     Format
+	Priority   Priority
+	Tags       []string
+
+	// Hits is how many times the item was read, 0 without WithAccessTracking
+	Hits uint64
+	// LastUsed is when the item was last read or written, in UnixNano
+	LastUsed int64
+}
+
+// size returns the number of bytes the entry occupies once loaded
+func (e SnapshotEntry) size() int64 {
+	return int64(len(e.Key) + len(e.Value))
+}
+
+// SnapshotOption configures a LoadSnapshot call
+type SnapshotOption func(*snapshotOptions)
+
+// snapshotOptions holds the settings collected from SnapshotOptions
+type snapshotOptions struct {
+	less     func(a, b SnapshotEntry) bool
+	maxItems int
+	maxBytes int64
+}
+
+// WarmupOrder makes LoadSnapshot load the entries for which less reports
+// true first. Combined with a limit, this decides which entries a partial
+// warm start keeps.
+func WarmupOrder(less func(a, b SnapshotEntry) bool) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.less = less
+	}
+}
+
+// HottestFirst makes LoadSnapshot load the most read entries first, and the
+// most recently used first among entries read equally often
+func HottestFirst() SnapshotOption {
+	return WarmupOrder(func(a, b SnapshotEntry) bool {
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return a.LastUsed > b.LastUsed
+	})
+}
+
+// LoadLimit caps how much of a snapshot LoadSnapshot loads: at most maxItems
+// entries whose keys and values add up to at most maxBytes. Entries that
+// don't fit are skipped. 0 means no limit.
+func LoadLimit(maxItems int, maxBytes int64) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.maxItems = maxItems
+		o.maxBytes = maxBytes
+	}
+}
+
+// SaveSnapshot writes the live items held in memory to w, so a restarted
+// process can warm up with LoadSnapshot. Items spilled to the overflow store
+// are not included. Entries are written sorted by key in the format
+// described by WriteSnapshot, so equal contents produce equal snapshots.
+func (c *Cache) SaveSnapshot(w io.Writer) error {
+	c.mu.RLock()
+	now := time.Now().UnixNano()
+	entries := make([]SnapshotEntry, 0, len(c.items))
+	for k, v := range c.items {
+		if c.staleLocked(v, now) {
+			continue
+		}
+		entries = append(entries, SnapshotEntry{
+			Key:        k,
+			Value:      v.Value,
+			Expiration: v.Expiration,
+			Format:     v.format,
+			Priority:   v.priority,
+			Tags:       v.tags,
+			Hits:       v.hits(),
+			LastUsed:   v.lastUsed(),
+		})
+	}
+	c.mu.RUnlock()
+
+	// Values are never modified in place, so they can be encoded unlocked
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return WriteSnapshot(w, entries)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot, by this or an
+// earlier version of the package, and stores its entries, skipping expired
+// ones and keys that already hold a live item, including items spilled to
+// the overflow store. It returns the number of entries stored. Read counts
+// are carried over when the cache tracks access, so the next snapshot keeps
+// its ordering.
+func (c *Cache) LoadSnapshot(r io.Reader, opts ...SnapshotOption) (int, error) {
+	var o snapshotOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	all, err := ReadSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UnixNano()
+	entries := all[:0]
+	for _, e := range all {
+		if e.Expiration > 0 && now > e.Expiration {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	if o.less != nil {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return o.less(entries[i], entries[j])
+		})
+	}
+	entries = o.limit(entries)
+
+	loaded := 0
+	for len(entries) > 0 {
+		n := min(len(entries), snapshotLoadBatch)
+
+		c.mu.Lock()
+		for _, e := range entries[:n] {
+			if c.loadEntryLocked(e, now) {
+				loaded++
+			}
+		}
+		c.mu.Unlock()
+
+		entries = entries[n:]
+	}
+	return loaded, nil
+}
+
+// limit returns the entries that fit the LoadLimit, in order
+func (o snapshotOptions) limit(entries []SnapshotEntry) []SnapshotEntry {
+	if o.maxItems <= 0 && o.maxBytes <= 0 {
+		return entries
+	}
+	kept := entries[:0]
+	var bytes int64
+	for _, e := range entries {
+		if o.maxItems > 0 && len(kept) == o.maxItems {
+			break
+		}
+		if o.maxBytes > 0 && bytes+e.size() > o.maxBytes {
+			continue
+		}
+		kept = append(kept, e)
+		bytes += e.size()
+	}
+	return kept
+}
+
+// loadEntryLocked stores a snapshot entry unless its key holds a live item,
+// in memory or spilled, and reports whether it did. The caller must hold the
+// write lock.
+func (c *Cache) loadEntryLocked(e SnapshotEntry, now int64) bool {
+	// Keys are saved as stored, after any key policy was applied
+	key := e.Key
+	if c.liveVersionLocked(key, now) != 0 {
+		return false
+	}
+	c.setLocked(key, Item{
+		Value:      e.Value,
+		Expiration: e.Expiration,
+		priority:   e.Priority,
+		tags:       e.Tags,
+		format:     e.Format,
+	})
+	if item, found := c.items[key]; found && item.accessed != nil {
+		item.accessed.count.Store(e.Hits)
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package gocache
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	c := New(0)
+	c.Set("a", "1")
+	c.SetWithOptions("b", map[string]int{"x": 1}, WithTTL(time.Hour), WithTags("t"))
+	c.SetWithExpiration("gone", "v", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := New(0)
+	restored.Set("a", "newer")
+	n, err := restored.LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Expected 1 entry loaded, got %d", n)
+	}
+	if v, _ := restored.GetString("a"); v != "newer" {
+		t.Fatalf("Expected the live item to be kept, got %s", v)
+	}
+	var m map[string]int
+	if found, err := restored.Get("b", &m); !found || err != nil || m["x"] != 1 {
+		t.Fatalf("Expected b to be restored, got %v (found=%v err=%v)", m, found, err)
+	}
+	if ttl, _ := restored.TTL("b"); ttl <= 59*time.Minute {
+		t.Fatalf("Expected b to keep its expiration, got %v", ttl)
+	}
+	restored.InvalidateTag("t")
+	if restored.Exists("b") {
+		t.Fatal("Expected b to keep its tags")
+	}
+}
+
+func TestSnapshotHottestFirst(t *testing.T) {
+	c := New(0, WithAccessTracking(0))
+	for i := 0; i < 10; i++ {
+		key := strconv.Itoa(i)
+		c.Set(key, "0123456789")
+		for j := 0; j < i; j++ {
+			c.GetBytes(key)
+		}
+	}
+	var buf bytes.Buffer
+	c.SaveSnapshot(&buf)
+	data := buf.Bytes()
+
+	restored := New(0, WithAccessTracking(0))
+	n, err := restored.LoadSnapshot(bytes.NewReader(data), HottestFirst(), LoadLimit(3, 0))
+	if err != nil || n != 3 {
+		t.Fatalf("Expected 3 entries loaded, got %d (%v)", n, err)
+	}
+	if hits, _ := restored.AccessCount("9"); hits != 9 {
+		t.Fatalf("Expected the read count to carry over, got %d", hits)
+	}
+	for _, key := range []string{"9", "8", "7"} {
+		if !restored.Exists(key) {
+			t.Fatalf("Expected the hot key %s to be loaded", key)
+		}
+	}
+
+	// Each entry is 11 bytes, so 40 bytes fit three of them
+	restored = New(0)
+	n, _ = restored.LoadSnapshot(bytes.NewReader(data), HottestFirst(), LoadLimit(0, 40))
+	if n != 3 || !restored.Exists("9") || restored.Exists("6") {
+		t.Fatalf("Expected the 3 hottest entries within the byte limit, got %d", n)
+	}
+}
+
+func TestLoadSnapshotKeepsSpilled(t *testing.T) {
+	source := New(0)
+	source.Set("k", "old")
+	var buf bytes.Buffer
+	if err := source.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	store, _ := NewDirStore(t.TempDir())
+	c := New(0, WithMaxBytes(10), WithOverflow(store))
+	c.Set("k", "value-k")
+	c.Set("x", "value-x") // spills k
+	if !c.spilledKey("k") {
+		t.Fatal("Expected k to be spilled")
+	}
+
+	if n, err := c.LoadSnapshot(&buf); err != nil || n != 0 {
+		t.Fatalf("Expected no entry loaded, got %d (%v)", n, err)
+	}
+	if v, _ := c.GetString("k"); v != "value-k" {
+		t.Fatalf("Expected the spilled item to be kept, got %q", v)
+	}
+}
@@ -0,0 +1,236 @@
+package gocache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// snapshotMagic starts every snapshot since version 1, followed by the
+// format version as a uvarint. Version 0 snapshots are gob streams with no
+// header.
+const snapshotMagic = "GCSNAP"
+
+// SnapshotVersion is the version of the snapshot format written by
+// SaveSnapshot and WriteSnapshot
+const SnapshotVersion = 1
+
+// ErrSnapshotVersion is returned when reading a snapshot written in a newer,
+// incompatible version of the format
+var ErrSnapshotVersion = errors.New("gocache: unsupported snapshot version")
+
+// ErrInvalidSnapshot is returned when reading data that isn't a snapshot
+var ErrInvalidSnapshot = errors.New("gocache: invalid snapshot")
+
+// Field numbers of a snapshot entry record
+const (
+	snapshotFieldKey        = 1
+	snapshotFieldValue      = 2
+	snapshotFieldExpiration = 3
+	snapshotFieldFormat     = 4
+	snapshotFieldPriority   = 5
+	snapshotFieldTag        = 6
+	snapshotFieldHits       = 7
+	snapshotFieldLastUsed   = 8
+)
+
+// Wire types of snapshot fields
+const (
+	snapshotWireVarint = 0 // a uvarint
+	snapshotWireBytes  = 2 // a uvarint length followed by that many bytes
+)
+
+// WriteSnapshot writes entries in the current snapshot format, version 1:
+//
+//	snapshot = "GCSNAP" version:uvarint record*
+//	record   = length:uvarint field*     (length of the fields in bytes)
+//	field    = tag:uvarint payload       (tag is number<<3 | wire type)
+//	payload  = uvarint                   (wire type 0)
+//	         | length:uvarint byte*      (wire type 2)
+//
+// The fields of an entry are 1 key, 2 value, 3 expiration in UnixNano,
+// 4 format, 5 priority (zigzag encoded), 6 tag (repeated), 7 hits and
+// 8 last used in UnixNano. Fields holding their zero value are omitted.
+// Readers skip fields they don't know, so new fields can be added without a
+// new version; the version only changes when older readers can't load a
+// snapshot correctly anymore. The output only depends on entries, so equal
+// entries in the same order always produce the same bytes.
+func WriteSnapshot(w io.Writer, entries []SnapshotEntry) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(snapshotMagic)
+	bw.Write(binary.AppendUvarint(nil, SnapshotVersion))
+
+	var record []byte
+	for _, e := range entries {
+		record = appendSnapshotEntry(record[:0], e)
+		bw.Write(binary.AppendUvarint(nil, uint64(len(record))))
+		if _, err := bw.Write(record); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// appendSnapshotEntry appends the fields of e to b
+func appendSnapshotEntry(b []byte, e SnapshotEntry) []byte {
+	b = appendSnapshotBytes(b, snapshotFieldKey, []byte(e.Key))
+	b = appendSnapshotBytes(b, snapshotFieldValue, e.Value)
+	b = appendSnapshotVarint(b, snapshotFieldExpiration, uint64(e.Expiration))
+	b = appendSnapshotVarint(b, snapshotFieldFormat, uint64(e.Format))
+	b = appendSnapshotVarint(b, snapshotFieldPriority, zigzag(int64(e.Priority)))
+	for _, tag := range e.Tags {
+		b = appendSnapshotBytes(b, snapshotFieldTag, []byte(tag))
+	}
+	b = appendSnapshotVarint(b, snapshotFieldHits, e.Hits)
+	b = appendSnapshotVarint(b, snapshotFieldLastUsed, uint64(e.LastUsed))
+	return b
+}
+
+func appendSnapshotVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field<<3|snapshotWireVarint))
+	return binary.AppendUvarint(b, v)
+}
+
+func appendSnapshotBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 && field != snapshotFieldKey {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field<<3|snapshotWireBytes))
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// zigzag maps signed integers to unsigned ones so that small negative
+// numbers stay small
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// ReadSnapshot reads a snapshot written by any version of SaveSnapshot or
+// WriteSnapshot, including the headerless gob streams of version 0, so
+// snapshots survive package upgrades. Rewriting the entries with
+// WriteSnapshot migrates a snapshot to the current version.
+func ReadSnapshot(r io.Reader) ([]SnapshotEntry, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(snapshotMagic))
+	if errors.Is(err, io.EOF) && len(header) == 0 {
+		return nil, nil // An empty version 0 snapshot
+	}
+	if string(header) != snapshotMagic {
+		return readSnapshotV0(br)
+	}
+	br.Discard(len(snapshotMagic))
+
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if version != SnapshotVersion {
+		return nil, fmt.Errorf("%w %d, this package reads up to %d", ErrSnapshotVersion, version, SnapshotVersion)
+	}
+
+	var entries []SnapshotEntry
+	var record bytes.Buffer
+	for {
+		n, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, truncated(err)
+		}
+		if n > math.MaxInt64 {
+			return nil, ErrInvalidSnapshot
+		}
+		// The length isn't trusted: the buffer only grows as data arrives
+		record.Reset()
+		if _, err := io.CopyN(&record, br, int64(n)); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, truncated(err))
+		}
+		e, err := parseSnapshotEntry(bytes.Clone(record.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+// parseSnapshotEntry decodes the fields of a record, skipping unknown ones
+func parseSnapshotEntry(record []byte) (SnapshotEntry, error) {
+	var e SnapshotEntry
+	for len(record) > 0 {
+		tag, n := binary.Uvarint(record)
+		if n <= 0 {
+			return e, ErrInvalidSnapshot
+		}
+		record = record[n:]
+
+		var v uint64
+		var payload []byte
+		switch tag & 7 {
+		case snapshotWireVarint:
+			v, n = binary.Uvarint(record)
+			if n <= 0 {
+				return e, ErrInvalidSnapshot
+			}
+			record = record[n:]
+		case snapshotWireBytes:
+			length, n := binary.Uvarint(record)
+			if n <= 0 || length > uint64(len(record)-n) {
+				return e, ErrInvalidSnapshot
+			}
+			payload = record[n : n+int(length)]
+			record = record[n+int(length):]
+		default:
+			return e, ErrInvalidSnapshot
+		}
+
+		switch tag >> 3 {
+		case snapshotFieldKey:
+			e.Key = string(payload)
+		case snapshotFieldValue:
+			e.Value = payload
+		case snapshotFieldExpiration:
+			e.Expiration = int64(v)
+		case snapshotFieldFormat:
+			e.Format = Format(v)
+		case snapshotFieldPriority:
+			e.Priority = Priority(unzigzag(v))
+		case snapshotFieldTag:
+			e.Tags = append(e.Tags, string(payload))
+		case snapshotFieldHits:
+			e.Hits = v
+		case snapshotFieldLastUsed:
+			e.LastUsed = int64(v)
+		}
+	}
+	return e, nil
+}
+
+// readSnapshotV0 reads a version 0 snapshot, a gob stream of SnapshotEntry
+func readSnapshotV0(r io.Reader) ([]SnapshotEntry, error) {
+	var entries []SnapshotEntry
+	dec := gob.NewDecoder(r)
+	for {
+		var e SnapshotEntry
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, ErrInvalidSnapshot
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,93 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFormatRoundTrip(t *testing.T) {
+	entries := []SnapshotEntry{
+		{Key: "a", Value: []byte("1"), Format: FormatString},
+		{Key: "b", Value: []byte(`{"x":1}`), Expiration: time.Now().Add(time.Hour).UnixNano(), Format: FormatJSON,
+			Priority: PriorityLow, Tags: []string{"t1", "t2"}, Hits: 7, LastUsed: time.Now().UnixNano()},
+		{Key: "", Value: []byte("empty key")},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Fatalf("Expected %+v, got %+v", entries, got)
+	}
+}
+
+func TestSnapshotDeterministic(t *testing.T) {
+	c := New(0)
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	snapshot := func() []byte {
+		var buf bytes.Buffer
+		c.SaveSnapshot(&buf)
+		return buf.Bytes()
+	}
+	if first, second := snapshot(), snapshot(); !bytes.Equal(first, second) {
+		t.Fatalf("Expected the same contents to produce the same snapshot")
+	}
+}
+
+func TestSnapshotSkipsUnknownFields(t *testing.T) {
+	record := appendSnapshotEntry(nil, SnapshotEntry{Key: "a", Value: []byte("1")})
+	// Fields a newer version might add
+	record = appendSnapshotVarint(record, 15, 42)
+	record = appendSnapshotBytes(record, 16, []byte("future"))
+
+	buf := bytes.NewBufferString(snapshotMagic)
+	buf.Write(binary.AppendUvarint(nil, SnapshotVersion))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(record))))
+	buf.Write(record)
+
+	entries, err := ReadSnapshot(buf)
+	if err != nil || len(entries) != 1 || entries[0].Key != "a" || string(entries[0].Value) != "1" {
+		t.Fatalf("Expected unknown fields to be skipped, got %+v (err=%v)", entries, err)
+	}
+}
+
+func TestSnapshotVersions(t *testing.T) {
+	// Version 0 snapshots are gob streams
+	var v0 bytes.Buffer
+	enc := gob.NewEncoder(&v0)
+	enc.Encode(SnapshotEntry{Key: "old", Value: []byte("value"), Format: FormatString})
+
+	c := New(0)
+	if n, err := c.LoadSnapshot(&v0); err != nil || n != 1 {
+		t.Fatalf("Expected the version 0 snapshot to load, got %d entries (err=%v)", n, err)
+	}
+	if value, _ := c.GetString("old"); value != "value" {
+		t.Fatalf("Expected the migrated value, got %q", value)
+	}
+
+	future := bytes.NewBufferString(snapshotMagic)
+	future.Write(binary.AppendUvarint(nil, SnapshotVersion+1))
+	if _, err := ReadSnapshot(future); !errors.Is(err, ErrSnapshotVersion) {
+		t.Fatalf("Expected newer versions to be rejected, got %v", err)
+	}
+
+	if _, err := ReadSnapshot(bytes.NewBufferString("GCSNAP\x01\x80\x80\x80\x80\x80\x80\x80\x80\x40")); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("Expected ErrInvalidSnapshot for a huge record length, got %v", err)
+	}
+	if _, err := ReadSnapshot(bytes.NewBufferString("not a snapshot")); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("Expected garbage to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package gocache
+
+import "time"
+
+// WithSoftTTL makes the item stale after ttl, while WithTTL or WithExpireAt
+// still bound how long it's served at all. GetOrLoad serves a stale item and
+// refreshes it in the background, so callers only wait on the origin once
+// the hard expiration has passed: stale-while-revalidate with explicit
+// bounds. 0 means the item never goes stale.
+func WithSoftTTL(ttl time.Duration) SetOption {
+	return func(o *setOptions) {
+		o.softTTL = ttl
+	}
+}
+
+// LoadSoftTTL makes the items GetOrLoad stores stale after ttl, see
+// WithSoftTTL. The loader's TTL is their hard expiration.
+func LoadSoftTTL(ttl time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.softTTL = ttl
+	}
+}
+
+// SoftExpired reports whether the live item stored under key is past its soft
+// TTL, so a caller not using GetOrLoad knows to refresh it
+func (c *Cache) SoftExpired(key string) bool {
+	key = c.mapKey(key)
+	now := time.Now().UnixNano()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, found := c.items[key]
+	return found && !c.staleLocked(item, now) && item.softExpired(now)
+}
+
+// softExpired reports whether the item is past its soft expiration at now
+func (item Item) softExpired(now int64) bool {
+	return item.softExpiration > 0 && now > item.softExpiration
+}
@@ -0,0 +1,59 @@
+package gocache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSoftExpired(t *testing.T) {
+	c := New(0)
+	c.SetWithOptions("key", "value", WithTTL(time.Hour), WithSoftTTL(5*time.Millisecond))
+	if c.SoftExpired("key") {
+		t.Fatalf("Expected a fresh item")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if !c.SoftExpired("key") {
+		t.Fatalf("Expected the item to be past its soft TTL")
+	}
+	if value, found := c.GetString("key"); !found || value != "value" {
+		t.Fatalf("Expected the item to be served until its hard TTL, got %q (found=%v)", value, found)
+	}
+}
+
+func TestGetOrLoadRefreshesSoftExpired(t *testing.T) {
+	c := New(0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return int(calls.Load()), time.Hour, nil
+	}
+
+	var value int
+	if err := c.GetOrLoad(context.Background(), "key", &value, loader, LoadSoftTTL(5*time.Millisecond)); err != nil || value != 1 {
+		t.Fatalf("Expected the first load, got %d (err=%v)", value, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// The stale value is served without waiting for the refresh
+	if err := c.GetOrLoad(context.Background(), "key", &value, loader, LoadSoftTTL(5*time.Millisecond)); err != nil || value != 1 {
+		t.Fatalf("Expected the stale value, got %d (err=%v)", value, err)
+	}
+	close(release)
+
+	waitFor(t, "the background refresh", func() bool {
+		c.Get("key", &value)
+		return value == 2
+	})
+	if c.SoftExpired("key") {
+		t.Fatalf("Expected the refreshed item to be fresh")
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("Expected a single refresh, got %d loads", calls.Load())
+	}
+}
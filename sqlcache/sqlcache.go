@@ -0,0 +1,191 @@
+// Package sqlcache caches the results of database/sql queries in a
+// gocache.Cache, keyed by the query and its arguments:
+//
+//	q := &sqlcache.Querier{Cache: c, DB: db, TTL: time.Minute}
+//	rows, err := q.Query(ctx, []string{"users"}, "SELECT id, name FROM users WHERE team = ?", team)
+//	for i := 0; i < rows.Len(); i++ {
+//		var id int64
+//		var name string
+//		if err := rows.Scan(i, &id, &name); err != nil { ... }
+//	}
+//
+// Results are tagged with the tables a query reads, so writes to a table can
+// drop every cached result depending on it with Invalidate.
+package sqlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+func init() {
+	// time.Time is the only driver.Value type gob doesn't know in interfaces
+	gocache.RegisterGobTypes(time.Time{})
+}
+
+// keyPrefix namespaces the keys written by Querier
+const keyPrefix = "sql:"
+
+// tagPrefix namespaces the table tags attached to cached results
+const tagPrefix = "sql:table:"
+
+// DB runs queries, e.g. a *sql.DB, *sql.Tx or *sql.Conn
+type DB interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Querier runs queries through a cache
+type Querier struct {
+	Cache *gocache.Cache
+	DB    DB
+
+	// TTL is how long results are cached, 0 for no expiration
+	TTL time.Duration
+
+	// OnInvalidate, if set, is called with the tables passed to Invalidate
+	// and the number of cached results dropped, e.g. for metrics
+	OnInvalidate func(tables []string, dropped int)
+}
+
+// Rows is a fully read query result. Values holds the driver values of each
+// row: nil, int64, float64, bool, []byte, string or time.Time. Rows are
+// stored with gocache.GobCodec, which keeps these types intact.
+type Rows struct {
+	Columns []string
+	Values  [][]interface{}
+}
+
+// Query returns the result of the query, running it on a cache miss. tables
+// names the tables the query reads, for Invalidate.
+func (q *Querier) Query(ctx context.Context, tables []string, query string, args ...interface{}) (*Rows, error) {
+	key := Key(query, args...)
+
+	var rows Rows
+	if found, err := q.Cache.GetInto(key, &rows, gocache.GobCodec); found && err == nil {
+		return &rows, nil
+	}
+
+	result, err := q.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	if rows.Columns, err = result.Columns(); err != nil {
+		return nil, err
+	}
+	rows.Values = nil
+	for result.Next() {
+		values := make([]interface{}, len(rows.Columns))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := result.Scan(dest...); err != nil {
+			return nil, err
+		}
+		rows.Values = append(rows.Values, values)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, len(tables))
+	for i, table := range tables {
+		tags[i] = tagPrefix + table
+	}
+	if err := q.Cache.SetWithOptions(key, rows, gocache.WithCodec(gocache.GobCodec), gocache.WithTTL(q.TTL), gocache.WithTags(tags...)); err != nil {
+		return nil, err
+	}
+	return &rows, nil
+}
+
+// Invalidate drops the cached results of all queries that read any of the
+// given tables. Call it after writing to them.
+func (q *Querier) Invalidate(tables ...string) int {
+	dropped := 0
+	for _, table := range tables {
+		dropped += q.Cache.InvalidateTag(tagPrefix + table)
+	}
+	if q.OnInvalidate != nil {
+		q.OnInvalidate(tables, dropped)
+	}
+	return dropped
+}
+
+// Key returns the cache key of a query with the given arguments. Arguments
+// of different types, such as 1 and "1", give different keys.
+func Key(query string, args ...interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	for _, arg := range args {
+		fmt.Fprintf(h, "\x00%T:%v", arg, arg)
+	}
+	return keyPrefix + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Len returns the number of rows
+func (r *Rows) Len() int {
+	return len(r.Values)
+}
+
+// Scan copies the columns of row i into dest, like sql.Rows.Scan. Each dest
+// must be a pointer to a type the column's value converts to, or to
+// interface{}. NULL columns set dest to its zero value.
+func (r *Rows) Scan(i int, dest ...interface{}) error {
+	if i < 0 || i >= len(r.Values) {
+		return fmt.Errorf("sqlcache: row %d out of range [0,%d)", i, len(r.Values))
+	}
+	row := r.Values[i]
+	if len(dest) != len(row) {
+		return fmt.Errorf("sqlcache: expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+
+	for col, d := range dest {
+		if err := assign(d, row[col]); err != nil {
+			return fmt.Errorf("sqlcache: column %q: %w", r.Columns[col], err)
+		}
+	}
+	return nil
+}
+
+// assign stores src in the value dest points to, converting between
+// compatible types
+func assign(dest, src interface{}) error {
+	if p, ok := dest.(*interface{}); ok {
+		*p = src
+		return nil
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination not a pointer: %T", dest)
+	}
+	dv = dv.Elem()
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	switch {
+	case sv.Type().AssignableTo(dv.Type()):
+		dv.Set(sv)
+	case sv.Kind() == reflect.Slice && dv.Kind() == reflect.String:
+		dv.SetString(string(src.([]byte)))
+	case sv.Kind() == reflect.String && dv.Kind() == reflect.Slice && dv.Type().Elem().Kind() == reflect.Uint8:
+		dv.SetBytes([]byte(src.(string)))
+	case sv.Kind() != reflect.String && dv.Kind() != reflect.String && sv.Type().ConvertibleTo(dv.Type()):
+		dv.Set(sv.Convert(dv.Type()))
+	default:
+		return fmt.Errorf("cannot store %T in %T", src, dest)
+	}
+	return nil
+}
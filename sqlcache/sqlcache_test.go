@@ -0,0 +1,139 @@
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// fakeDriver answers every query with the same users table and counts queries
+type fakeDriver struct {
+	queries atomic.Int64
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+var created = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.queries.Add(1)
+	return &fakeRows{rows: [][]driver.Value{
+		{int64(1), "John", []byte{1, 2}, created, nil},
+		{int64(2), "Jane", []byte{3}, created, 1.5},
+	}}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name", "avatar", "created", "score"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+func newQuerier(t *testing.T) (*Querier, *fakeDriver) {
+	d := &fakeDriver{}
+	db := sql.OpenDB(connector{d})
+	t.Cleanup(func() { db.Close() })
+	return &Querier{Cache: gocache.New(0), DB: db, TTL: time.Minute}, d
+}
+
+type connector struct{ d *fakeDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.d}, nil }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+func TestQuery(t *testing.T) {
+	q, d := newQuerier(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		rows, err := q.Query(ctx, []string{"users"}, "SELECT * FROM users WHERE team = ?", 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows.Len() != 2 {
+			t.Fatalf("Expected 2 rows, got %d", rows.Len())
+		}
+
+		var id int
+		var name string
+		var avatar []byte
+		var at time.Time
+		var score float64
+		if err := rows.Scan(0, &id, &name, &avatar, &at, &score); err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 || name != "John" || len(avatar) != 2 || !at.Equal(created) || score != 0 {
+			t.Fatalf("Row doesn't match: %v %v %v %v %v", id, name, avatar, at, score)
+		}
+		var anyScore interface{}
+		if err := rows.Scan(1, &id, &name, &avatar, &at, &anyScore); err != nil || anyScore != 1.5 {
+			t.Fatalf("Expected the float64 to survive the cache, got %#v (%v)", anyScore, err)
+		}
+	}
+	if n := d.queries.Load(); n != 1 {
+		t.Fatalf("Expected the second query to be served from the cache, got %d queries", n)
+	}
+
+	q.Query(ctx, []string{"users"}, "SELECT * FROM users WHERE team = ?", "7")
+	if n := d.queries.Load(); n != 2 {
+		t.Fatalf("Expected arguments of another type to miss, got %d queries", n)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	q, d := newQuerier(t)
+	ctx := context.Background()
+	var dropped int
+	q.OnInvalidate = func(tables []string, n int) { dropped = n }
+
+	q.Query(ctx, []string{"users", "teams"}, "SELECT * FROM users JOIN teams")
+	q.Query(ctx, []string{"orders"}, "SELECT * FROM orders")
+
+	if n := q.Invalidate("teams"); n != 1 || dropped != 1 {
+		t.Fatalf("Expected one result to be dropped, got %d (hook saw %d)", n, dropped)
+	}
+	q.Query(ctx, []string{"users", "teams"}, "SELECT * FROM users JOIN teams")
+	q.Query(ctx, []string{"orders"}, "SELECT * FROM orders")
+	if n := d.queries.Load(); n != 3 {
+		t.Fatalf("Expected only the invalidated query to run again, got %d queries", n)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	rows := &Rows{Columns: []string{"name"}, Values: [][]interface{}{{"John"}}}
+	var n int
+	if err := rows.Scan(0, &n); err == nil {
+		t.Fatal("Expected an error scanning a string into an int")
+	}
+	if err := rows.Scan(1, &n); err == nil {
+		t.Fatal("Expected an error for a row out of range")
+	}
+	var a, b string
+	if err := rows.Scan(0, &a, &b); err == nil {
+		t.Fatal("Expected an error for the wrong number of columns")
+	}
+}
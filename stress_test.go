@@ -0,0 +1,135 @@
+package gocache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestStress hammers a cache with concurrent sets, gets, deletes and
+// expirations and checks invariants that must hold under any interleaving:
+// no expired value is ever returned, each reader sees the writes to a key
+// in order, and the cache's counters match its items once it's quiet.
+func TestStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping stress test in short mode")
+	}
+
+	const (
+		keys     = 64
+		writers  = 4 // each writer owns keys with index%writers == its ID
+		readers  = 8
+		duration = 300 * time.Millisecond
+	)
+
+	c := New(time.Millisecond, WithMaxBytes(2048))
+	defer c.StopJanitor()
+
+	stop := make(chan struct{})
+	errs := make(chan error, readers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for seq := 1; ; seq++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := fmt.Sprintf("key%d", rng.Intn(keys/writers)*writers+w)
+				switch rng.Intn(10) {
+				case 0:
+					c.Delete(key)
+				case 1:
+					c.Set(key, fmt.Sprintf("%d:%d", seq, 0))
+				default:
+					at := time.Now().Add(time.Duration(rng.Intn(2000)) * time.Microsecond)
+					c.SetWithExpireAt(key, fmt.Sprintf("%d:%d", seq, at.UnixNano()), at)
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(writers + r)))
+			lastSeq := make(map[string]int)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := fmt.Sprintf("key%d", rng.Intn(keys))
+				before := time.Now().UnixNano()
+				value, found := c.GetString(key)
+				if !found {
+					continue
+				}
+
+				var seq int
+				var expiration int64
+				if _, err := fmt.Sscanf(value, "%d:%d", &seq, &expiration); err != nil {
+					errs <- fmt.Errorf("%s: corrupt value %q", key, value)
+					return
+				}
+				if expiration > 0 && expiration < before {
+					errs <- fmt.Errorf("%s: got value expired %v ago", key, time.Duration(before-expiration))
+					return
+				}
+				if seq < lastSeq[key] {
+					errs <- fmt.Errorf("%s: read seq %d after seq %d", key, seq, lastSeq[key])
+					return
+				}
+				lastSeq[key] = seq
+			}
+		}(r)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				c.DeleteExpired()
+			}
+		}
+	}()
+
+	time.Sleep(duration)
+	close(stop)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var size int64
+	for key, item := range c.items {
+		size += item.size(key)
+	}
+	if size != c.size {
+		t.Fatalf("Expected size %d to match the items, got %d", size, c.size)
+	}
+	if c.size > 2048 {
+		t.Fatalf("Expected size to stay within WithMaxBytes, got %d", c.size)
+	}
+	if len(c.dependents) != 0 || len(c.tags) != 0 {
+		t.Fatalf("Expected no leftover dependency or tag links, got %d and %d", len(c.dependents), len(c.tags))
+	}
+}
@@ -0,0 +1,56 @@
+package gocache
+
+// InvalidateTag removes every item carrying the given tag and returns how
+// many items were removed
+func (c *Cache) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	set, ok := c.tags[tag]
+	if !ok {
+		return 0
+	}
+
+	before := len(c.items)
+	for key := range set {
+		c.deleteLocked(key, EventInvalidate)
+	}
+	return before - len(c.items)
+}
+
+// Tagged returns the keys of the items carrying the given tag
+func (c *Cache) Tagged(tag string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.tags[tag]))
+	for k := range c.tags[tag] {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// linkTagsLocked adds key to the index of each of its tags.
+// The caller must hold the write lock.
+func (c *Cache) linkTagsLocked(key string, tags []string) {
+	for _, tag := range tags {
+		set, ok := c.tags[tag]
+		if !ok {
+			set = make(map[string]struct{})
+			c.tags[tag] = set
+		}
+		set[key] = struct{}{}
+	}
+}
+
+// unlinkTagsLocked removes key from the index of each of its tags.
+// The caller must hold the write lock.
+func (c *Cache) unlinkTagsLocked(key string, tags []string) {
+	for _, tag := range tags {
+		set := c.tags[tag]
+		delete(set, key)
+		if len(set) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
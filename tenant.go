@@ -0,0 +1,264 @@
+package gocache
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTenant is returned for tenant IDs that are empty or contain '/'
+var ErrInvalidTenant = errors.New("gocache: invalid tenant ID")
+
+// tenantPrefix starts the keys of all tenant items. A key is stored as
+// tenantPrefix + tenant ID + "/" + key, and tenant IDs can't contain '/', so
+// no key of one tenant can name an item of another.
+const tenantPrefix = "tenant/"
+
+// TenantQuota bounds the items a single tenant may hold. When a write pushes
+// a tenant past its quota, that tenant's own items are evicted in the usual
+// eviction order until it fits again, so a noisy tenant can't push out the
+// items of others.
+type TenantQuota struct {
+	MaxBytes int64 // total key and value bytes, 0 means unbounded
+	MaxItems int   // number of items, 0 means unbounded
+}
+
+// TenantUsage reports what a tenant currently holds
+type TenantUsage struct {
+	Items int
+	Bytes int64
+}
+
+// tenantTable tracks the usage and quotas of the tenants of a cache
+type tenantTable struct {
+	quota  TenantQuota            // applies to tenants without an override
+	quotas map[string]TenantQuota // per-tenant overrides
+	usage  map[string]*TenantUsage
+	keys   map[string]map[string]struct{} // stored keys by tenant
+}
+
+// TenantCache partitions a cache between tenants for multi-tenant use.
+// Each tenant sees only its own keys, has its own quota and can be flushed
+// on its own, while all tenants share the cache's memory and janitor.
+type TenantCache struct {
+	cache *Cache
+}
+
+// Tenant is the view of a TenantCache restricted to a single tenant
+type Tenant struct {
+	cache  *Cache
+	id     string
+	prefix string
+}
+
+// NewTenantCache partitions c between tenants, applying quota to every
+// tenant without its own quota. Only one TenantCache should be created per
+// cache; creating another replaces the default quota.
+func NewTenantCache(c *Cache, quota TenantQuota) *TenantCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tenants == nil {
+		c.tenants = &tenantTable{
+			quotas: make(map[string]TenantQuota),
+			usage:  make(map[string]*TenantUsage),
+			keys:   make(map[string]map[string]struct{}),
+		}
+		// Account for tenant items written before partitioning
+		for key, item := range c.items {
+			c.tenants.add(key, item)
+		}
+	}
+	c.tenants.quota = quota
+	return &TenantCache{cache: c}
+}
+
+// Tenant returns the view of the tenant with the given ID
+func (tc *TenantCache) Tenant(id string) (*Tenant, error) {
+	if id == "" || strings.Contains(id, "/") {
+		return nil, ErrInvalidTenant
+	}
+	return &Tenant{cache: tc.cache, id: id, prefix: tenantPrefix + id + "/"}, nil
+}
+
+// SetQuota overrides the quota of a single tenant, evicting its items if it
+// already exceeds the new quota
+func (tc *TenantCache) SetQuota(id string, quota TenantQuota) error {
+	if id == "" || strings.Contains(id, "/") {
+		return ErrInvalidTenant
+	}
+
+	c := tc.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tenants.quotas[id] = quota
+	c.enforceTenantQuotaLocked(id)
+	return nil
+}
+
+// Usage returns the usage of every tenant holding items, by tenant ID
+func (tc *TenantCache) Usage() map[string]TenantUsage {
+	c := tc.cache
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	usage := make(map[string]TenantUsage, len(c.tenants.usage))
+	for id, u := range c.tenants.usage {
+		usage[id] = *u
+	}
+	return usage
+}
+
+// ID returns the tenant's ID
+func (t *Tenant) ID() string {
+	return t.id
+}
+
+// Set adds an item for the tenant with the cache's default expiration
+func (t *Tenant) Set(key string, value interface{}) error {
+	return t.cache.Set(t.prefix+key, value)
+}
+
+// SetWithExpiration adds an item for the tenant with a specific expiration time
+func (t *Tenant) SetWithExpiration(key string, value interface{}, duration time.Duration) error {
+	return t.cache.SetWithExpiration(t.prefix+key, value, duration)
+}
+
+// GetBytes retrieves raw byte data of the tenant
+func (t *Tenant) GetBytes(key string) ([]byte, bool) {
+	return t.cache.GetBytes(t.prefix + key)
+}
+
+// GetString gets a string value of the tenant
+func (t *Tenant) GetString(key string) (string, bool) {
+	return t.cache.GetString(t.prefix + key)
+}
+
+// Get retrieves and unmarshals an item of the tenant
+func (t *Tenant) Get(key string, target interface{}) (bool, error) {
+	return t.cache.Get(t.prefix+key, target)
+}
+
+// Exists checks if the tenant has a key that is not expired
+func (t *Tenant) Exists(key string) bool {
+	return t.cache.Exists(t.prefix + key)
+}
+
+// Delete removes an item of the tenant
+func (t *Tenant) Delete(key string) {
+	t.cache.Delete(t.prefix + key)
+}
+
+// Flush removes all items of the tenant, leaving other tenants untouched
+func (t *Tenant) Flush() {
+	c := t.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, t.prefix) {
+			c.deleteLocked(key, EventDelete)
+		}
+	}
+	for key := range c.spilled {
+		if strings.HasPrefix(key, t.prefix) {
+			c.deleteLocked(key, EventDelete)
+		}
+	}
+}
+
+// Usage returns what the tenant currently holds
+func (t *Tenant) Usage() TenantUsage {
+	c := t.cache
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if u := c.tenants.usage[t.id]; u != nil {
+		return *u
+	}
+	return TenantUsage{}
+}
+
+// tenantOf returns the tenant ID of a key, or "" if it isn't a tenant key
+func tenantOf(key string) string {
+	if !strings.HasPrefix(key, tenantPrefix) {
+		return ""
+	}
+	id, _, found := strings.Cut(key[len(tenantPrefix):], "/")
+	if !found {
+		return ""
+	}
+	return id
+}
+
+// add charges an item to its tenant
+func (t *tenantTable) add(key string, item Item) {
+	id := tenantOf(key)
+	if id == "" {
+		return
+	}
+	u := t.usage[id]
+	if u == nil {
+		u = &TenantUsage{}
+		t.usage[id] = u
+		t.keys[id] = make(map[string]struct{})
+	}
+	u.Items++
+	u.Bytes += item.size(key)
+	t.keys[id][key] = struct{}{}
+}
+
+// remove releases an item from its tenant
+func (t *tenantTable) remove(key string, item Item) {
+	id := tenantOf(key)
+	if id == "" {
+		return
+	}
+	u := t.usage[id]
+	if u == nil {
+		return
+	}
+	u.Items--
+	u.Bytes -= item.size(key)
+	delete(t.keys[id], key)
+	if u.Items == 0 {
+		delete(t.usage, id)
+		delete(t.keys, id)
+	}
+}
+
+// quotaFor returns the quota that applies to tenant id
+func (t *tenantTable) quotaFor(id string) TenantQuota {
+	if q, ok := t.quotas[id]; ok {
+		return q
+	}
+	return t.quota
+}
+
+// overQuota reports whether tenant id exceeds its quota
+func (t *tenantTable) overQuota(id string) bool {
+	u := t.usage[id]
+	if u == nil {
+		return false
+	}
+	q := t.quotaFor(id)
+	return (q.MaxBytes > 0 && u.Bytes > q.MaxBytes) ||
+		(q.MaxItems > 0 && u.Items > q.MaxItems)
+}
+
+// enforceTenantQuotaLocked evicts items of tenant id until it fits within
+// its quota, sorting only that tenant's keys. The caller must hold the
+// write lock.
+func (c *Cache) enforceTenantQuotaLocked(id string) {
+	if !c.tenants.overQuota(id) {
+		return
+	}
+	for _, key := range c.evictionOrderOfLocked(maps.Keys(c.tenants.keys[id])) {
+		if !c.tenants.overQuota(id) {
+			return
+		}
+		c.evictKeyLocked(key)
+	}
+}
@@ -0,0 +1,99 @@
+package gocache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	c := New(0)
+	tc := NewTenantCache(c, TenantQuota{})
+	a, _ := tc.Tenant("a")
+	b, _ := tc.Tenant("b")
+
+	a.Set("key", "from a")
+	if _, found := b.GetString("key"); found {
+		t.Fatal("Expected tenant b not to see tenant a's key")
+	}
+	b.Set("key", "from b")
+	if value, _ := a.GetString("key"); value != "from a" {
+		t.Fatalf("Expected 'from a', got %q", value)
+	}
+
+	for _, id := range []string{"", "a/b"} {
+		if _, err := tc.Tenant(id); err != ErrInvalidTenant {
+			t.Fatalf("Expected ErrInvalidTenant for %q, got %v", id, err)
+		}
+	}
+
+	c.Set("global", "x")
+	a.Flush()
+	if a.Exists("key") || !b.Exists("key") || !c.Exists("global") {
+		t.Fatal("Expected Flush to only remove the tenant's items")
+	}
+	if usage := a.Usage(); usage != (TenantUsage{}) {
+		t.Fatalf("Expected no usage after Flush, got %+v", usage)
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	c := New(0)
+	tc := NewTenantCache(c, TenantQuota{MaxItems: 2})
+	noisy, _ := tc.Tenant("noisy")
+	quiet, _ := tc.Tenant("quiet")
+
+	quiet.Set("keep", "value")
+	for _, key := range []string{"1", "2", "3", "4"} {
+		noisy.Set(key, "value")
+	}
+
+	if usage := noisy.Usage(); usage.Items != 2 {
+		t.Fatalf("Expected the noisy tenant to be held to 2 items, got %+v", usage)
+	}
+	if noisy.Exists("1") || !noisy.Exists("4") {
+		t.Fatal("Expected the oldest items of the noisy tenant to be evicted")
+	}
+	if !quiet.Exists("keep") {
+		t.Fatal("Expected the quiet tenant to keep its items")
+	}
+
+	value := strings.Repeat("x", 100)
+	tc.SetQuota("quiet", TenantQuota{MaxBytes: 150})
+	quiet.Set("big1", value)
+	quiet.Set("big2", value)
+	if usage := quiet.Usage(); usage.Items != 1 || usage.Bytes > 150 {
+		t.Fatalf("Expected the byte quota to hold, got %+v", usage)
+	}
+	if total := tc.Usage(); len(total) != 2 || total["noisy"].Items != 2 {
+		t.Fatalf("Expected usage of both tenants, got %+v", total)
+	}
+
+	c.Flush()
+	if usage := tc.Usage(); len(usage) != 0 {
+		t.Fatalf("Expected no usage after Flush, got %+v", usage)
+	}
+
+	// Quotas only consider the tenant's own keys, which are tracked
+	// through deletes and Flush
+	noisy.Set("5", "value")
+	noisy.Delete("5")
+	for _, key := range []string{"6", "7", "8"} {
+		noisy.Set(key, "value")
+	}
+	if noisy.Exists("6") || !noisy.Exists("7") || !noisy.Exists("8") {
+		t.Fatal("Expected the quota to evict the oldest remaining item after Flush")
+	}
+	if keys := c.tenants.keys["noisy"]; len(keys) != 2 {
+		t.Fatalf("Expected 2 tracked keys for the noisy tenant, got %v", keys)
+	}
+}
+
+func TestTenantCacheCountsExistingItems(t *testing.T) {
+	c := New(0)
+	c.Set("tenant/a/key", "value")
+	tc := NewTenantCache(c, TenantQuota{})
+	a, _ := tc.Tenant("a")
+	if usage := a.Usage(); usage.Items != 1 {
+		t.Fatalf("Expected the existing item to be counted, got %+v", usage)
+	}
+}
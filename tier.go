@@ -0,0 +1,193 @@
+package gocache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OverflowStore is a secondary, usually disk-backed, tier that receives items
+// evicted from memory. Implementations must be safe for concurrent use.
+type OverflowStore interface {
+	// Store saves a value and its expiration (0 means none) under key
+	Store(key string, value []byte, expiration int64) error
+	// Load returns the value stored under key. found is false if there is none.
+	Load(key string) (value []byte, expiration int64, found bool, err error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// WithOverflow spills items evicted by WithMaxBytes or the memory watcher to
+// store instead of dropping them. Spilled items are faulted back into memory
+// transparently when they are next read, so the cache's effective capacity
+// extends to the store. Only the value, its format and type fingerprint (in
+// a short header before the value) and the expiration are spilled: tags,
+// dependencies, priority and pins are lost, and items that depend on a
+// spilled item are invalidated as with any eviction. Count and Size only
+// account for items held in memory.
+func WithOverflow(store OverflowStore) Option {
+	return func(c *Cache) {
+		c.overflow = store
+	}
+}
+
+// spillLocked hands an evicted item to the overflow store.
+// The caller must hold the write lock.
+func (c *Cache) spillLocked(key string, item Item) {
+	if c.staleLocked(item, time.Now().UnixNano()) {
+		return
+	}
+	c.overflowErr = c.overflow.Store(key, spilledValue(item), item.Expiration)
+	if c.overflowErr != nil {
+		return // The overflow tier is best effort, losing the item is fine
+	}
+	c.spilled[key] = item.version
+}
+
+// faultIn moves a spilled item back into memory. version is the version the
+// item had when it was spilled, which guards against a newer write racing
+// with the load.
+func (c *Cache) faultIn(key string, version uint64) (Item, bool) {
+	value, expiration, found, err := c.overflow.Load(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.overflowErr = err
+
+	// Another goroutine may have faulted the item in or replaced it meanwhile
+	if item, ok := c.items[key]; ok {
+		return item, true
+	}
+	if current, cold := c.spilled[key]; !cold || current != version || version <= c.flushedAt {
+		return Item{}, false
+	}
+
+	c.deleteLocked(key, opReplace)
+	if err != nil || !found {
+		return Item{}, false
+	}
+
+	item, err := unspillItem(value, expiration)
+	if err != nil {
+		c.overflowErr = err
+		return Item{}, false
+	}
+	if item.expired(time.Now().UnixNano()) {
+		return Item{}, false
+	}
+	c.setLocked(key, item)
+	item.version = c.version
+	return item, true
+}
+
+// peekSpilled returns the live item spilled under key without faulting it
+// in, or false if there is none or it can't be read
+func (c *Cache) peekSpilled(key string) (Item, bool) {
+	value, expiration, found, err := c.overflow.Load(key)
+	if err != nil || !found {
+		return Item{}, false
+	}
+	item, err := unspillItem(value, expiration)
+	if err != nil || item.expired(time.Now().UnixNano()) {
+		return Item{}, false
+	}
+	return item, true
+}
+
+// spilledValue returns the bytes spilled for item: its format and type
+// fingerprint, so codec-encoded values decode the same after a round trip,
+// followed by the value
+func spilledValue(item Item) []byte {
+	data := make([]byte, 0, 1+binary.MaxVarintLen64+len(item.Value))
+	data = append(data, byte(item.format))
+	data = binary.AppendUvarint(data, item.typeID)
+	return append(data, item.Value...)
+}
+
+// unspillItem rebuilds an item from the bytes written by spilledValue
+func unspillItem(data []byte, expiration int64) (Item, error) {
+	if len(data) < 2 {
+		return Item{}, errors.New("gocache: truncated overflow value")
+	}
+	typeID, n := binary.Uvarint(data[1:])
+	if n <= 0 {
+		return Item{}, errors.New("gocache: invalid overflow value header")
+	}
+	return Item{Value: data[1+n:], Expiration: expiration, format: Format(data[0]), typeID: typeID}, nil
+}
+
+// DirStore is an OverflowStore keeping one file per item in a directory
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore returns a DirStore writing to dir, creating it if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// path returns the file holding key. Keys are hashed so that any key maps to
+// a valid file name.
+func (s *DirStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// Store writes the item to a temporary file and renames it into place, so
+// concurrent loads never see a partial write
+func (s *DirStore) Store(key string, value []byte, expiration int64) error {
+	f, err := os.CreateTemp(s.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+
+	var header [8]byte
+	binary.BigEndian.PutUint64(header[:], uint64(expiration))
+	_, err = f.Write(header[:])
+	if err == nil {
+		_, err = f.Write(value)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Load reads the item stored under key
+func (s *DirStore) Load(key string) ([]byte, int64, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(data) < 8 {
+		return nil, 0, false, errors.New("gocache: truncated overflow file")
+	}
+	return data[8:], int64(binary.BigEndian.Uint64(data[:8])), true, nil
+}
+
+// Delete removes the file holding key
+func (s *DirStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package gocache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverflow(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+
+	// Room for two 10 byte items in memory
+	c := New(0, WithMaxBytes(20), WithOverflow(store))
+
+	c.Set("k1", "value-01")
+	c.SetWithExpiration("k2", "value-02", time.Hour)
+	c.Set("k3", "value-03")
+
+	if c.Count() != 2 {
+		t.Fatalf("Expected 2 items in memory, have %d", c.Count())
+	}
+
+	// k1 was spilled and is faulted back in transparently, pushing k2 out
+	val, found := c.GetString("k1")
+	if !found || val != "value-01" {
+		t.Fatalf("Expected spilled k1 to be read back, got '%s' (found=%v)", val, found)
+	}
+
+	// k2 keeps its expiration through the round trip
+	ttl, err := c.TTL("k2")
+	if err != nil || ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("Expected k2 to keep its TTL, got %v (err=%v)", ttl, err)
+	}
+
+	// Deleting a spilled key removes it from the store too
+	c.Set("k4", "value-04") // spills k3
+	c.Delete("k3")
+	if c.Exists("k3") {
+		t.Fatal("Deleted spilled key must not come back")
+	}
+
+	// Overwriting a spilled key hides the spilled value
+	c.Set("k5", "value-05")
+	c.Set("k1", "new-1")
+	if val, _ := c.GetString("k1"); val != "new-1" {
+		t.Fatalf("Expected overwritten value, got '%s'", val)
+	}
+}
+
+func TestOverflowKeepsFormat(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	c := New(0, WithMaxBytes(200), WithOverflow(store), WithTypeChecks())
+
+	in := testStruct{Name: "Alice", Age: 30}
+	c.SetWithOptions("gob", in, WithCodec(GobCodec))
+	c.SetWithOptions("xml", in, WithCodec(XMLCodec))
+	c.SetWithOptions("codec", in, WithCodec(upperCodec{}))
+	c.Set("string", "text")
+	c.Set("raw", []byte("raw"))
+	want := map[string]Format{"gob": FormatGob, "xml": FormatXML, "codec": FormatCodec, "string": FormatString, "raw": FormatRaw}
+
+	for key, format := range want {
+		// Fill memory so key is spilled, then read it back
+		for i := 0; c.spilledKey(key) == false; i++ {
+			c.Set(fmt.Sprintf("filler-%d", i), strings.Repeat("x", 50))
+		}
+		switch key {
+		case "codec":
+			var out testStruct
+			if _, err := c.GetInto(key, &out, upperCodec{}); err != nil || out != in {
+				t.Fatalf("Expected %s to decode after a round trip, got %+v %v", key, out, err)
+			}
+		case "gob", "xml":
+			var out testStruct
+			if _, err := c.Get(key, &out); err != nil || out != in {
+				t.Fatalf("Expected %s to decode after a round trip, got %+v %v", key, out, err)
+			}
+			var wrong string
+			if _, err := c.Get(key, &wrong); err == nil {
+				t.Fatalf("Expected %s to keep rejecting the wrong type", key)
+			}
+		default:
+			if _, found := c.GetBytes(key); !found {
+				t.Fatalf("Expected %s to be faulted in", key)
+			}
+		}
+		if got, _ := c.Format(key); got != format {
+			t.Fatalf("Expected %s to keep format %s, got %s", key, format, got)
+		}
+	}
+}
+
+func TestWatchSpilledKey(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	c := New(0, WithMaxBytes(10), WithOverflow(store))
+
+	c.Set("k", "value-0")
+	c.Set("x", "value-x") // spills k
+	w := c.Watch("k")
+
+	// Another writer changes k, which is then spilled again
+	c.Set("k", "value-1")
+	c.Set("x", "value-y")
+	if !c.spilledKey("k") {
+		t.Fatal("Expected k to be spilled")
+	}
+
+	if err := w.Exec(SetOp("k", "lost", 0)); err != ErrConflict {
+		t.Fatalf("Expected ErrConflict, got %v", err)
+	}
+	if v, _ := c.GetString("k"); v != "value-1" {
+		t.Fatalf("Expected value-1, got %q", v)
+	}
+}
+
+func TestOverflowFlush(t *testing.T) {
+	store, _ := NewDirStore(t.TempDir())
+	c := New(0, WithMaxBytes(10), WithOverflow(store))
+
+	c.Set("k1", "value-01")
+	c.Set("k2", "value-02")
+	c.Flush()
+
+	if c.Exists("k1") || c.Exists("k2") {
+		t.Fatal("Flush should remove spilled items too")
+	}
+	if _, _, found, _ := store.Load("k1"); found {
+		t.Fatal("Flush should clear the overflow store")
+	}
+}
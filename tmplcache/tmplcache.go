@@ -0,0 +1,114 @@
+// Package tmplcache caches rendered html/template fragments in a
+// gocache.Cache, keyed by the template name and a hash of the data:
+//
+//	r := tmplcache.New(c, time.Minute)
+//	r.Register("sidebar", sidebarTmpl)
+//	err := r.Render(w, "sidebar", user)
+//
+// Registering a template again, e.g. after re-parsing it in development,
+// drops every fragment rendered by the previous version.
+package tmplcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"time"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+// keyPrefix namespaces the keys and tags written by Renderer
+const keyPrefix = "tmpl:"
+
+// Renderer renders registered templates through a cache
+type Renderer struct {
+	cache *gocache.Cache
+	ttl   time.Duration
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// New creates a Renderer caching fragments in c for ttl, 0 for no expiration
+func New(c *gocache.Cache, ttl time.Duration) *Renderer {
+	return &Renderer{
+		cache:     c,
+		ttl:       ttl,
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// Register makes t available to Render under name. Registering a name
+// again replaces its template and invalidates its cached fragments.
+func (r *Renderer) Register(name string, t *template.Template) {
+	r.mu.Lock()
+	_, replaced := r.templates[name]
+	r.templates[name] = t
+	r.mu.Unlock()
+
+	if replaced {
+		r.Invalidate(name)
+	}
+}
+
+// Invalidate drops the cached fragments of the named template
+func (r *Renderer) Invalidate(name string) int {
+	return r.cache.InvalidateTag(keyPrefix + name)
+}
+
+// Render writes the output of the named template applied to data to w,
+// rendering it only if it isn't cached yet. data must be JSON encodable
+// to be hashed; other data is rendered without caching.
+func (r *Renderer) Render(w io.Writer, name string, data interface{}) error {
+	r.mu.RLock()
+	t, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("tmplcache: no template registered as %q", name)
+	}
+
+	key, err := fragmentKey(name, data)
+	if err != nil {
+		return t.Execute(w, data)
+	}
+	if html, found := r.cache.GetBytes(key); found {
+		_, err := w.Write(html)
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	// A failure to cache shouldn't fail the render
+	r.cache.SetWithOptions(key, buf.Bytes(), gocache.WithTTL(r.ttl), gocache.WithTags(keyPrefix+name), gocache.WithNoCopy())
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// RenderHTML is Render returning the output as template.HTML, for embedding
+// a cached fragment in an enclosing template
+func (r *Renderer) RenderHTML(name string, data interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := r.Render(&buf, name, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// fragmentKey returns the key of the output of the named template applied
+// to data
+func fragmentKey(name string, data interface{}) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return keyPrefix + name + ":" + hex.EncodeToString(sum[:16]), nil
+}
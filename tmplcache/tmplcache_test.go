@@ -0,0 +1,88 @@
+package tmplcache
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+type user struct {
+	Name string
+}
+
+// countingTemplate parses text with a count function reporting how often
+// the template was executed
+func countingTemplate(t *testing.T, text string, executions *int) *template.Template {
+	t.Helper()
+	return template.Must(template.New("").Funcs(template.FuncMap{
+		"count": func() string { *executions++; return "" },
+	}).Parse(text))
+}
+
+func render(t *testing.T, r *Renderer, name string, data interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.Render(&buf, name, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRender(t *testing.T) {
+	r := New(gocache.New(0), 0)
+	executions := 0
+	r.Register("hello", countingTemplate(t, "{{count}}Hello, {{.Name}}!", &executions))
+
+	for i := 0; i < 2; i++ {
+		if out := render(t, r, "hello", user{Name: "<John>"}); out != "Hello, &lt;John&gt;!" {
+			t.Fatalf("Expected escaped output, got %q", out)
+		}
+	}
+	if executions != 1 {
+		t.Fatalf("Expected the second render to be cached, got %d executions", executions)
+	}
+
+	render(t, r, "hello", user{Name: "Jane"})
+	if executions != 2 {
+		t.Fatalf("Expected other data to render again, got %d executions", executions)
+	}
+
+	if _, err := r.RenderHTML("missing", nil); err == nil {
+		t.Fatal("Expected an error for an unregistered template")
+	}
+}
+
+func TestRegisterInvalidates(t *testing.T) {
+	c := gocache.New(0)
+	r := New(c, 0)
+	executions := 0
+	r.Register("hello", countingTemplate(t, "{{count}}v1 {{.Name}}", &executions))
+	r.Register("other", countingTemplate(t, "{{count}}other", &executions))
+	render(t, r, "hello", user{Name: "John"})
+	render(t, r, "other", nil)
+
+	r.Register("hello", countingTemplate(t, "{{count}}v2 {{.Name}}", &executions))
+	if out := render(t, r, "hello", user{Name: "John"}); out != "v2 John" {
+		t.Fatalf("Expected the re-registered template to render, got %q", out)
+	}
+	render(t, r, "other", nil)
+	if executions != 3 {
+		t.Fatalf("Expected only the re-registered template to render again, got %d executions", executions)
+	}
+}
+
+func TestRenderUnhashableData(t *testing.T) {
+	r := New(gocache.New(0), 0)
+	executions := 0
+	r.Register("fn", countingTemplate(t, "{{count}}ok", &executions))
+
+	data := map[string]interface{}{"fn": func() {}}
+	render(t, r, "fn", data)
+	html, err := r.RenderHTML("fn", data)
+	if err != nil || !strings.Contains(string(html), "ok") || executions != 2 {
+		t.Fatalf("Expected data that can't be hashed to render uncached, got %q (%v) after %d executions", html, err, executions)
+	}
+}
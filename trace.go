@@ -0,0 +1,184 @@
+package gocache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// traceMagic starts every trace, followed by the format version
+const traceMagic = "GCTRACE"
+
+// traceVersion is the version of the trace format written by TraceWriter
+const traceVersion = 1
+
+// ErrInvalidTrace is returned when reading data that isn't a trace
+var ErrInvalidTrace = errors.New("gocache: invalid trace")
+
+// TraceRecord is one operation in an access trace. Keys are only recorded as
+// hashes, so traces can be shared without exposing them.
+type TraceRecord struct {
+	Op      EventOp // EventGet, EventSet, EventDelete, EventExpire, EventEvict or EventInvalidate
+	Time    time.Time
+	KeyHash uint64
+	Size    int // size of the key and value in bytes, 0 for reads and deletes
+}
+
+// TraceKeyHash returns the hash a trace records for key
+func TraceKeyHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// TraceWriter writes access traces in a compact binary format: the magic
+// "GCTRACE" and a version byte, followed by one record per operation made of
+// the op byte, the uvarint nanoseconds since the previous record (since the
+// Unix epoch for the first), the key hash as 8 little-endian bytes and the
+// uvarint size.
+type TraceWriter struct {
+	w    *bufio.Writer
+	last int64
+	err  error
+}
+
+// NewTraceWriter writes the trace header to w and returns a TraceWriter.
+// Call Flush when done.
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	tw := &TraceWriter{w: bufio.NewWriter(w)}
+	tw.w.WriteString(traceMagic)
+	tw.err = tw.w.WriteByte(traceVersion)
+	return tw
+}
+
+// Write appends a record to the trace. Records are expected in time order;
+// earlier times are recorded as simultaneous with the previous record.
+func (tw *TraceWriter) Write(rec TraceRecord) error {
+	if tw.err != nil {
+		return tw.err
+	}
+	now := rec.Time.UnixNano()
+	delta := max(now-tw.last, 0)
+	tw.last = max(now, tw.last)
+
+	var buf [1 + 2*binary.MaxVarintLen64 + 8]byte
+	buf[0] = byte(rec.Op)
+	n := 1 + binary.PutUvarint(buf[1:], uint64(delta))
+	binary.LittleEndian.PutUint64(buf[n:], rec.KeyHash)
+	n += 8
+	n += binary.PutUvarint(buf[n:], uint64(rec.Size))
+	_, tw.err = tw.w.Write(buf[:n])
+	return tw.err
+}
+
+// Flush writes buffered records to the underlying writer
+func (tw *TraceWriter) Flush() error {
+	if tw.err != nil {
+		return tw.err
+	}
+	tw.err = tw.w.Flush()
+	return tw.err
+}
+
+// TraceReader reads traces written by TraceWriter
+type TraceReader struct {
+	r    *bufio.Reader
+	last int64
+}
+
+// NewTraceReader reads the trace header from r and returns a TraceReader
+func NewTraceReader(r io.Reader) (*TraceReader, error) {
+	tr := &TraceReader{r: bufio.NewReader(r)}
+	header := make([]byte, len(traceMagic)+1)
+	if _, err := io.ReadFull(tr.r, header); err != nil {
+		return nil, ErrInvalidTrace
+	}
+	if string(header[:len(traceMagic)]) != traceMagic || header[len(traceMagic)] != traceVersion {
+		return nil, ErrInvalidTrace
+	}
+	return tr, nil
+}
+
+// Read returns the next record, or io.EOF at the end of the trace
+func (tr *TraceReader) Read() (TraceRecord, error) {
+	op, err := tr.r.ReadByte()
+	if err != nil {
+		return TraceRecord{}, err
+	}
+	delta, err := binary.ReadUvarint(tr.r)
+	if err != nil {
+		return TraceRecord{}, truncated(err)
+	}
+	var hash [8]byte
+	if _, err := io.ReadFull(tr.r, hash[:]); err != nil {
+		return TraceRecord{}, truncated(err)
+	}
+	size, err := binary.ReadUvarint(tr.r)
+	if err != nil {
+		return TraceRecord{}, truncated(err)
+	}
+
+	tr.last += int64(delta)
+	return TraceRecord{
+		Op:      EventOp(op),
+		Time:    time.Unix(0, tr.last),
+		KeyHash: binary.LittleEndian.Uint64(hash[:]),
+		Size:    int(size),
+	}, nil
+}
+
+// truncated turns an EOF in the middle of a record into an error
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// WithTraceRecording records an access trace of reads, writes and removals
+// to w, for offline analysis and Simulate. With a sampleRate of n, only keys
+// whose hash is divisible by n are recorded, so the trace keeps the complete
+// history of 1 in n keys; 1 or less records every key. Records are buffered,
+// call FlushTrace before reading the trace. Recording stops at the first
+// write error, which FlushTrace returns.
+func WithTraceRecording(w io.Writer, sampleRate int) Option {
+	return func(c *Cache) {
+		c.trace = &traceRecorder{w: NewTraceWriter(w), sample: uint64(max(sampleRate, 1))}
+	}
+}
+
+// traceRecorder writes sampled operations to a trace
+type traceRecorder struct {
+	mu     sync.Mutex
+	w      *TraceWriter
+	sample uint64
+}
+
+// add records an operation on key if the key is sampled
+func (t *traceRecorder) add(op EventOp, key string, size int64) {
+	hash := TraceKeyHash(key)
+	if hash%t.sample != 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Taking the time under the lock keeps records in time order
+	t.w.Write(TraceRecord{Op: op, Time: time.Now(), KeyHash: hash, Size: int(size)})
+}
+
+// FlushTrace writes buffered trace records, see WithTraceRecording. It
+// returns the error that stopped recording, if any.
+func (c *Cache) FlushTrace() error {
+	if c.trace == nil {
+		return nil
+	}
+
+	c.trace.mu.Lock()
+	defer c.trace.mu.Unlock()
+	return c.trace.w.Flush()
+}
@@ -0,0 +1,129 @@
+package gocache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceRoundTrip(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	records := []TraceRecord{
+		{Op: EventSet, Time: start, KeyHash: TraceKeyHash("a"), Size: 120},
+		{Op: EventGet, Time: start.Add(time.Millisecond), KeyHash: TraceKeyHash("a")},
+		{Op: EventDelete, Time: start.Add(2 * time.Millisecond), KeyHash: TraceKeyHash("b")},
+	}
+
+	var buf bytes.Buffer
+	tw := NewTraceWriter(&buf)
+	for _, rec := range records {
+		tw.Write(rec)
+	}
+	if err := tw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := NewTraceReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []TraceRecord
+	for {
+		rec, err := tr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, rec)
+	}
+	for i := range got {
+		if !got[i].Time.Equal(records[i].Time) {
+			t.Fatalf("Expected time %v, got %v", records[i].Time, got[i].Time)
+		}
+		got[i].Time = records[i].Time
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Fatalf("Expected %+v, got %+v", records, got)
+	}
+}
+
+func TestTraceReaderErrors(t *testing.T) {
+	if _, err := NewTraceReader(strings.NewReader("not a trace")); !errors.Is(err, ErrInvalidTrace) {
+		t.Fatalf("Expected ErrInvalidTrace, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	tw := NewTraceWriter(&buf)
+	tw.Write(TraceRecord{Op: EventGet, Time: time.Now(), KeyHash: 1})
+	tw.Flush()
+	tr, _ := NewTraceReader(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if _, err := tr.Read(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestTraceRecording(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(0, WithTraceRecording(&buf, 1))
+	c.GetString("a")
+	c.Set("a", "value")
+	c.GetString("a")
+	c.Delete("a")
+	if err := c.FlushTrace(); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := NewTraceReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []EventOp
+	for {
+		rec, err := tr.Read()
+		if err == io.EOF {
+			break
+		}
+		if rec.KeyHash != TraceKeyHash("a") {
+			t.Fatalf("Expected the hash of a, got %x", rec.KeyHash)
+		}
+		if rec.Op == EventSet && rec.Size != 6 {
+			t.Fatalf("Expected a size of 6, got %d", rec.Size)
+		}
+		ops = append(ops, rec.Op)
+	}
+	if want := []EventOp{EventGet, EventSet, EventGet, EventDelete}; !reflect.DeepEqual(ops, want) {
+		t.Fatalf("Expected %v, got %v", want, ops)
+	}
+}
+
+func TestTraceRecordingSamplesKeys(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(0, WithTraceRecording(&buf, 10))
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	c.FlushTrace()
+
+	tr, _ := NewTraceReader(&buf)
+	n := 0
+	for {
+		rec, err := tr.Read()
+		if err == io.EOF {
+			break
+		}
+		if rec.KeyHash%10 != 0 {
+			t.Fatalf("Expected only sampled keys, got %x", rec.KeyHash)
+		}
+		n++
+	}
+	if n < 50 || n > 150 {
+		t.Fatalf("Expected about 100 of 1000 keys recorded, got %d", n)
+	}
+}
@@ -0,0 +1,41 @@
+package gocache
+
+import "time"
+
+// TTLProvider is implemented by values that know how long they stay valid,
+// such as API responses carrying a max-age
+type TTLProvider interface {
+	// CacheTTL returns how long the value may be cached, 0 or less for no
+	// limit of its own
+	CacheTTL() time.Duration
+}
+
+// ExpiryProvider is implemented by values that expire at a known time, such
+// as tokens and signed URLs
+type ExpiryProvider interface {
+	// CacheExpiresAt returns when the value stops being valid, the zero time
+	// for no limit of its own
+	CacheExpiresAt() time.Time
+}
+
+// valueExpiration returns the expiration a value implementing TTLProvider or
+// ExpiryProvider sets for itself, 0 if it sets none. ExpiryProvider wins if a
+// value implements both.
+func valueExpiration(value interface{}) int64 {
+	switch v := value.(type) {
+	case ExpiryProvider:
+		return expirationAt(v.CacheExpiresAt())
+	case TTLProvider:
+		return expirationFor(v.CacheTTL())
+	}
+	return 0
+}
+
+// capToValue caps expiration at the one value sets for itself, so an item
+// never outlives the validity of its value whatever TTL it was stored with
+func capToValue(value interface{}, expiration int64) int64 {
+	if own := valueExpiration(value); own > 0 {
+		return clampExpiration(expiration, own)
+	}
+	return expiration
+}
@@ -0,0 +1,59 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+type signedURL struct {
+	URL     string
+	Expires time.Time
+}
+
+func (u signedURL) CacheExpiresAt() time.Time { return u.Expires }
+
+type apiResponse struct {
+	Body   string
+	MaxAge int
+}
+
+func (r apiResponse) CacheTTL() time.Duration { return time.Duration(r.MaxAge) * time.Second }
+
+func TestSetHonorsValueExpiration(t *testing.T) {
+	c := New(0, WithDefaultTTL(time.Minute))
+
+	c.Set("url", signedURL{URL: "https://example.com/a", Expires: time.Now().Add(time.Hour)})
+	if ttl, _ := c.TTL("url"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("Expected the value's own expiry to replace the default TTL, got %v", ttl)
+	}
+
+	c.Set("response", apiResponse{Body: "ok", MaxAge: 10})
+	if ttl, _ := c.TTL("response"); ttl <= 9*time.Second || ttl > 10*time.Second {
+		t.Fatalf("Expected the value's own TTL, got %v", ttl)
+	}
+
+	// No limit of its own falls back to the default TTL
+	c.Set("forever", apiResponse{Body: "ok"})
+	if ttl, _ := c.TTL("forever"); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("Expected the default TTL, got %v", ttl)
+	}
+}
+
+func TestValueExpirationCapsTTL(t *testing.T) {
+	c := New(0)
+
+	c.SetWithExpiration("short", apiResponse{MaxAge: 10}, time.Hour)
+	if ttl, _ := c.TTL("short"); ttl > 10*time.Second {
+		t.Fatalf("Expected the value's TTL to cap the given one, got %v", ttl)
+	}
+
+	c.SetWithExpiration("long", apiResponse{MaxAge: 3600}, time.Minute)
+	if ttl, _ := c.TTL("long"); ttl > time.Minute {
+		t.Fatalf("Expected the given TTL to be kept when shorter, got %v", ttl)
+	}
+
+	c.SetWithOptions("expired", signedURL{Expires: time.Now().Add(-time.Second)}, WithTTL(time.Hour))
+	if c.Exists("expired") {
+		t.Fatalf("Expected an already expired value to be missing")
+	}
+}
@@ -0,0 +1,87 @@
+package gocache
+
+import (
+	"errors"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+// ErrTypeMismatch is returned by Get when WithTypeChecks is enabled and the
+// target's type differs from the type of the value that was stored
+var ErrTypeMismatch = errors.New("gocache: target type differs from the stored type")
+
+// WithTypeChecks records a fingerprint of each value's Go type when it's
+// stored and verifies it when the value is decoded by Get, GetInto or
+// GetOrLoad. Without it, decoding a JSON value into the wrong struct
+// silently succeeds with whatever fields happen to match. Pointers are
+// ignored, so a value stored as *User can be read into a User and vice
+// versa, and targets of interface type accept any value.
+func WithTypeChecks() Option {
+	return func(c *Cache) {
+		c.typeChecks = true
+	}
+}
+
+// typeFingerprints caches the fingerprint of each type
+var typeFingerprints sync.Map // reflect.Type -> uint64
+
+// fingerprint returns the type fingerprint to store with value, or 0 if type
+// checks are disabled
+func (c *Cache) fingerprint(value interface{}) uint64 {
+	if !c.typeChecks {
+		return 0
+	}
+	return typeFingerprint(reflect.TypeOf(value))
+}
+
+// typeFingerprint hashes the name of t, without pointers. Names include the
+// package path so they're stable across processes.
+func typeFingerprint(t reflect.Type) uint64 {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return 0
+	}
+	if fp, ok := typeFingerprints.Load(t); ok {
+		return fp.(uint64)
+	}
+
+	name := t.String()
+	if t.Name() != "" {
+		name = t.PkgPath() + "." + t.Name()
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	fp := h.Sum64()
+
+	typeFingerprints.Store(t, fp)
+	return fp
+}
+
+// checkType verifies that target can hold the value of item
+func checkType(item Item, target interface{}) error {
+	if item.typeID == 0 || target == nil {
+		return nil
+	}
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if typeFingerprint(t) != item.typeID {
+		return ErrTypeMismatch
+	}
+	return nil
+}
+
+// decodeItem verifies the type of item and decodes it into target
+func decodeItem(item Item, target interface{}) error {
+	if err := checkType(item, target); err != nil {
+		return err
+	}
+	return decodeFormat(item.Value, item.format, target)
+}
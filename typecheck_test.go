@@ -0,0 +1,90 @@
+package gocache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type otherStruct struct {
+	Name string
+}
+
+func TestTypeChecks(t *testing.T) {
+	c := New(0, WithTypeChecks())
+	c.Set("user", testStruct{Name: "Alice", Age: 30})
+	c.Set("ptr", &testStruct{Name: "Bob"})
+
+	var other otherStruct
+	if _, err := c.Get("user", &other); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := c.GetInto("user", &other, nil); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch from GetInto, got %v", err)
+	}
+
+	var user testStruct
+	if _, err := c.Get("user", &user); err != nil || user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %+v %v", user, err)
+	}
+	var ptr *testStruct
+	if _, err := c.Get("ptr", &ptr); err != nil || ptr.Name != "Bob" {
+		t.Fatalf("Expected pointers to be ignored, got %+v %v", ptr, err)
+	}
+	var any interface{}
+	if _, err := c.Get("user", &any); err != nil {
+		t.Fatalf("Expected interface targets to accept any value, got %v", err)
+	}
+}
+
+func TestTypeChecksDisabled(t *testing.T) {
+	c := New(0)
+	c.Set("user", testStruct{Name: "Alice"})
+	var other otherStruct
+	if _, err := c.Get("user", &other); err != nil || other.Name != "Alice" {
+		t.Fatalf("Expected the lenient JSON decode without type checks, got %+v %v", other, err)
+	}
+}
+
+func TestTypeChecksOtherWritePaths(t *testing.T) {
+	c := New(0, WithTypeChecks())
+	c.SetWithOptions("opts", testStruct{}, WithTTL(time.Minute))
+	c.Watch().Exec(SetOp("exec", testStruct{}, 0))
+	o := c.Overlay()
+	o.Set("overlay", testStruct{})
+
+	var other otherStruct
+	if _, err := o.Get("overlay", &other); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch from the overlay, got %v", err)
+	}
+	o.Commit()
+	c.GetOrLoad(context.Background(), "loaded", &testStruct{}, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return testStruct{}, 0, nil
+	})
+
+	for _, key := range []string{"opts", "exec", "overlay", "loaded"} {
+		if _, err := c.Get(key, &other); err != ErrTypeMismatch {
+			t.Errorf("Expected ErrTypeMismatch for %s, got %v", key, err)
+		}
+	}
+}
+
+func TestTypeChecksGetOrLoad(t *testing.T) {
+	c := New(0, WithTypeChecks())
+	loader := func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return testStruct{Name: "Alice"}, 0, nil
+	}
+
+	// The loading call is checked like the hits after it
+	var other otherStruct
+	if err := c.GetOrLoad(context.Background(), "user", &other, loader); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch from the load, got %v", err)
+	}
+	if err := c.GetOrLoad(context.Background(), "user", &other, loader); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch from the hit, got %v", err)
+	}
+	var user testStruct
+	if err := c.GetOrLoad(context.Background(), "user", &user, loader); err != nil || user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %+v %v", user, err)
+	}
+}
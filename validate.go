@@ -0,0 +1,145 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"slices"
+)
+
+// Validate walks the cache's internal structures and returns an error
+// describing every inconsistency it finds, or nil if there is none: size and
+// cost totals that don't match the items, dangling or missing dependency and
+// tag links, tenant usage that drifted, items both in memory and spilled,
+// expirations missing from the expiry index, keys missing from the bloom
+// filter, live keys in the ghost list and wrong dedup reference counts.
+//
+// It holds the read lock for a full walk of the cache, so it's meant for
+// tests and debugging rather than production paths. Builds with the
+// gocache_debug tag also run it after every janitor pass and panic when it
+// fails.
+func (c *Cache) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var errs []error
+	report := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("gocache: "+format, args...))
+	}
+
+	indexed := make(map[string]bool)
+	if c.expiries != nil {
+		for _, e := range c.expiries.entries {
+			if c.currentExpiryLocked(e) {
+				indexed[e.key] = true
+			}
+		}
+	}
+
+	var size, cost int64
+	usage := make(map[string]*TenantUsage)
+	shared := make(map[uint64]int)
+	for key, item := range c.items {
+		size += item.size(key)
+		cost += item.cost
+
+		if _, cold := c.spilled[key]; cold {
+			report("key %q is both in memory and spilled", c.DisplayKey(key))
+		}
+		for _, dep := range item.deps {
+			if _, ok := c.dependents[dep][key]; !ok {
+				report("key %q is missing from the dependents of %q", c.DisplayKey(key), c.DisplayKey(dep))
+			}
+		}
+		for _, tag := range item.tags {
+			if _, ok := c.tags[tag][key]; !ok {
+				report("key %q is missing from the index of tag %q", c.DisplayKey(key), tag)
+			}
+		}
+		if c.expiries != nil && item.Expiration > 0 && !indexed[key] {
+			report("key %q is missing from the expiry index", c.DisplayKey(key))
+		}
+		if c.bloom != nil && !c.bloom.mayContain(key) {
+			report("key %q is missing from the bloom filter", c.DisplayKey(key))
+		}
+		if c.tenants != nil {
+			if id := tenantOf(key); id != "" {
+				u := usage[id]
+				if u == nil {
+					u = &TenantUsage{}
+					usage[id] = u
+				}
+				u.Items++
+				u.Bytes += item.size(key)
+			}
+		}
+		if item.shared && c.dedup != nil {
+			shared[maphash.Bytes(c.dedup.seed, item.Value)]++
+		}
+		if item.version > c.version {
+			report("key %q has version %d, past the cache's %d", c.DisplayKey(key), item.version, c.version)
+		}
+	}
+
+	if size != c.size {
+		report("size is %d bytes, items add up to %d", c.size, size)
+	}
+	if cost != c.cost {
+		report("cost is %d, items add up to %d", c.cost, cost)
+	}
+
+	for dep, set := range c.dependents {
+		for key := range set {
+			if item, found := c.items[key]; !found || !slices.Contains(item.deps, dep) {
+				report("dangling dependent %q of %q", c.DisplayKey(key), c.DisplayKey(dep))
+			}
+		}
+	}
+	for tag, set := range c.tags {
+		if len(set) == 0 {
+			report("tag %q has an empty index", tag)
+		}
+		for key := range set {
+			if item, found := c.items[key]; !found || !slices.Contains(item.tags, tag) {
+				report("dangling key %q in the index of tag %q", c.DisplayKey(key), tag)
+			}
+		}
+	}
+
+	if c.tenants != nil {
+		for id, u := range c.tenants.usage {
+			want := usage[id]
+			if want == nil {
+				want = &TenantUsage{}
+			}
+			if *u != *want {
+				report("tenant %q uses %d items and %d bytes, items add up to %d and %d", id, u.Items, u.Bytes, want.Items, want.Bytes)
+			}
+		}
+		for id := range usage {
+			if _, ok := c.tenants.usage[id]; !ok {
+				report("tenant %q holds items but has no usage", id)
+			}
+		}
+	}
+
+	if c.dedup != nil {
+		for h, v := range c.dedup.values {
+			if v.refs != shared[h] {
+				report("shared value %x has %d references, %d items use it", h, v.refs, shared[h])
+			}
+		}
+	}
+
+	if c.ghosts != nil {
+		c.ghosts.mu.Lock()
+		for key := range c.ghosts.index {
+			if _, found := c.items[key]; found {
+				report("live key %q is in the ghost list", c.DisplayKey(key))
+			}
+		}
+		c.ghosts.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
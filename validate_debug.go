@@ -0,0 +1,6 @@
+//go:build gocache_debug
+
+package gocache
+
+// validateOnCleanup makes the janitor run Validate after every pass
+const validateOnCleanup = true
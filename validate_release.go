@@ -0,0 +1,7 @@
+//go:build !gocache_debug
+
+package gocache
+
+// validateOnCleanup makes the janitor run Validate after every pass, see
+// validate_debug.go
+const validateOnCleanup = false
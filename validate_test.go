@@ -0,0 +1,43 @@
+package gocache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateHealthyCache(t *testing.T) {
+	c := New(0, WithBloomFilter(100, 0.01), WithGhostList(10), WithExpiryIndex(), WithValueDedup(), WithMaxBytes(200))
+
+	c.SetWithOptions("a", "value", WithTags("red"))
+	c.SetWithDeps("b", "value", "a")
+	c.SetWithExpiration("c", "other", time.Minute)
+	for i := 0; i < 20; i++ {
+		c.Set(strings.Repeat("k", i+1), "value")
+	}
+	c.Delete("c")
+
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Expected a consistent cache, got %v", err)
+	}
+}
+
+func TestValidateReportsInconsistencies(t *testing.T) {
+	c := New(0)
+	c.SetWithOptions("a", "value", WithTags("red"))
+
+	c.mu.Lock()
+	c.size += 10
+	delete(c.tags["red"], "a")
+	c.mu.Unlock()
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("Expected inconsistencies to be reported")
+	}
+	for _, want := range []string{"size is", `key "a" is missing from the index of tag "red"`, `tag "red" has an empty index`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Expected the error to mention %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package gocache
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ErrConflict is returned by Exec when a watched key changed after Watch was
+// called. The caller may retry by watching the keys again.
+var ErrConflict = errors.New("watched key changed")
+
+// Watch tracks the state of a set of keys for an optimistic transaction
+type Watch struct {
+	cache    *Cache
+	versions map[string]uint64
+}
+
+// Op is a single operation applied by Exec
+type Op struct {
+	key      string
+	value    []byte
+	format   Format
+	typ      reflect.Type
+	duration time.Duration
+	own      int64 // the expiration the value sets for itself, see TTLProvider
+	delete   bool
+	err      error
+
+	admitted           bool  // passed through middleware, see WithMiddleware
+	admittedExpiration int64 // the expiration admitted by middleware
+}
+
+// SetOp returns an operation that sets key to value with the given expiration
+func SetOp(key string, value interface{}, duration time.Duration) Op {
+	bytes, err := encode(value)
+	return Op{key: key, value: bytes, format: formatOf(value), typ: reflect.TypeOf(value), duration: duration, own: valueExpiration(value), err: err}
+}
+
+// expiration returns the expiration of the item a set operation stores,
+// capped at the one its value sets for itself
+func (op Op) expiration() int64 {
+	if op.own > 0 {
+		return clampExpiration(expirationFor(op.duration), op.own)
+	}
+	return expirationFor(op.duration)
+}
+
+// DeleteOp returns an operation that deletes key
+func DeleteOp(key string) Op {
+	return Op{key: key, delete: true}
+}
+
+// Watch records the current state of the given keys. A later Exec on the
+// returned Watch only applies its operations if none of these keys were set,
+// deleted or expired in the meantime, like Redis WATCH/MULTI/EXEC.
+func (c *Cache) Watch(keys ...string) *Watch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	w := &Watch{
+		cache:    c,
+		versions: make(map[string]uint64, len(keys)),
+	}
+	now := time.Now().UnixNano()
+	for _, key := range c.mapKeys(keys) {
+		w.versions[key] = c.liveVersionLocked(key, now)
+	}
+	return w
+}
+
+// Exec atomically applies ops if no watched key has changed since Watch was
+// called, and returns ErrConflict otherwise. If any operation failed to
+// encode its value or has an invalid key, that error is returned and nothing
+// is applied.
+func (w *Watch) Exec(ops ...Op) error {
+	c := w.cache
+
+	keys := make([]string, len(ops))
+	for i, op := range ops {
+		if op.err != nil {
+			return op.err
+		}
+		if op.delete {
+			keys[i] = c.mapKey(op.key)
+			continue
+		}
+		key, err := c.checkKey(op.key)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	// Middleware can't run under the lock, so the operations are admitted
+	// first and applied as admitted
+	if c.middleware != nil {
+		ops = append([]Op(nil), ops...)
+		for i := range ops {
+			var err error
+			if ops[i], err = c.admitOp(keys[i], ops[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for key, version := range w.versions {
+		if c.liveVersionLocked(key, now) != version {
+			return ErrConflict
+		}
+	}
+
+	for i, op := range ops {
+		if op.delete {
+			c.deleteLocked(keys[i], EventDelete)
+			continue
+		}
+		item := Item{Value: op.value, Expiration: op.expiration(), own: op.own, format: op.format}
+		if op.admitted {
+			item.Expiration = op.admittedExpiration
+		}
+		if c.typeChecks {
+			item.typeID = typeFingerprint(op.typ)
+		}
+		c.setLocked(keys[i], item)
+	}
+	return nil
+}
+
+// liveVersionLocked returns the version of the item stored under key, or 0 if
+// there's no live item. Items spilled to the overflow store keep the version
+// they were spilled with, so a write made while a key was spilled is still
+// seen as a change. The caller must hold the lock.
+func (c *Cache) liveVersionLocked(key string, now int64) uint64 {
+	item, found := c.items[key]
+	if !found {
+		if version, cold := c.spilled[key]; cold && version > c.flushedAt {
+			return version
+		}
+		return 0
+	}
+	if c.staleLocked(item, now) {
+		return 0
+	}
+	return item.version
+}
@@ -0,0 +1,57 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatchExec(t *testing.T) {
+	c := New(0)
+	c.Set("balance", "100")
+
+	w := c.Watch("balance", "missing")
+	err := w.Exec(SetOp("balance", "90", 0), SetOp("log", "debit 10", 0))
+	if err != nil {
+		t.Fatalf("Exec should succeed when nothing changed: %v", err)
+	}
+	if val, _ := c.GetString("balance"); val != "90" {
+		t.Fatalf("Expected balance 90, got %s", val)
+	}
+
+	// A concurrent write makes the transaction fail
+	w = c.Watch("balance")
+	c.Set("balance", "50")
+	err = w.Exec(SetOp("balance", "80", 0))
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict, got %v", err)
+	}
+	if val, _ := c.GetString("balance"); val != "50" {
+		t.Fatalf("Conflicting Exec must not apply, balance is %s", val)
+	}
+
+	// Rewriting the same value still counts as a change
+	w = c.Watch("balance")
+	c.Set("balance", "50")
+	if err := w.Exec(DeleteOp("balance")); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict after rewrite, got %v", err)
+	}
+
+	// Creating a watched key that was missing is a change too
+	w = c.Watch("new")
+	c.Set("new", "x")
+	if err := w.Exec(); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict after creation, got %v", err)
+	}
+}
+
+func TestExecValueExpiration(t *testing.T) {
+	c := New(0)
+	w := c.Watch("url")
+	if err := w.Exec(SetOp("url", signedURL{Expires: time.Now().Add(time.Minute)}, time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ttl, _ := c.TTL("url"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected the value's own expiry to cap the TTL, got %v", ttl)
+	}
+}
@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"sort"
+	"time"
+)
+
+// SetWeak adds an item that the cache may drop early to free memory.
+// Weak items behave like regular items until ReleaseMemory is called, which
+// removes them (largest first) before any regular item is touched.
+func (c *Cache) SetWeak(key string, value interface{}, duration time.Duration) error {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	item := Item{Value: bytes, Expiration: capToValue(value, expirationFor(duration)), own: valueExpiration(value), weak: true, format: formatOf(value), typeID: c.fingerprint(value)}
+	store := func(item Item) error {
+		c.mu.Lock()
+		c.setLocked(key, item)
+		c.mu.Unlock()
+		return nil
+	}
+	if c.middleware != nil {
+		return c.storeThrough(key, item, store)
+	}
+	return store(item)
+}
+
+// ReleaseMemory drops weak items until at least targetBytes of key and value
+// data has been freed, or no weak items remain. It returns the number of
+// bytes actually released. Call it when the application receives a memory
+// pressure signal, e.g. when nearing the limit set with debug.SetMemoryLimit.
+func (c *Cache) ReleaseMemory(targetBytes int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type candidate struct {
+		key  string
+		size int64
+	}
+
+	var candidates []candidate
+	for k, v := range c.items {
+		if v.weak {
+			candidates = append(candidates, candidate{key: k, size: v.size(k)})
+		}
+	}
+
+	// Free the largest items first so as few entries as possible are lost
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].size > candidates[j].size
+	})
+
+	var released int64
+	for _, cand := range candidates {
+		if released >= targetBytes {
+			break
+		}
+		// An earlier cascade may already have removed this item
+		if _, found := c.items[cand.key]; !found {
+			continue
+		}
+		c.deleteLocked(cand.key, EventEvict)
+		released += cand.size
+	}
+
+	return released
+}
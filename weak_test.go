@@ -0,0 +1,35 @@
+package gocache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReleaseMemory(t *testing.T) {
+	c := New(0)
+
+	c.Set("config", strings.Repeat("c", 1000))
+	c.SetWeak("page:1", strings.Repeat("a", 500), 0)
+	c.SetWeak("page:2", strings.Repeat("b", 100), 0)
+
+	// Only the largest weak item is needed to reach the target
+	released := c.ReleaseMemory(200)
+	if released < 200 {
+		t.Fatalf("Expected at least 200 bytes released, got %d", released)
+	}
+	if c.Exists("page:1") {
+		t.Fatal("page:1 should have been released")
+	}
+	if !c.Exists("page:2") {
+		t.Fatal("page:2 should have survived")
+	}
+
+	// Regular items are never released
+	c.ReleaseMemory(1 << 20)
+	if !c.Exists("config") {
+		t.Fatal("config is not weak and must not be released")
+	}
+	if c.Count() != 1 {
+		t.Fatalf("Expected only config to remain, have %d items", c.Count())
+	}
+}
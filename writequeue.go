@@ -0,0 +1,128 @@
+package gocache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// WithWriteQueue makes Set, SetWithExpiration and SetWithExpireAt encode
+// and validate the value, then queue the write for a background applier
+// instead of taking the write lock. The applier stores queued writes in
+// batches under one lock acquisition, keeping only the last write of a key
+// within a batch, which smooths lock contention during write bursts. Once
+// size writes are queued, Set blocks until the applier catches up.
+//
+// Queued writes aren't visible until applied: a Get right after a Set may
+// still return the old value, and Delete and the other write methods, which
+// apply immediately, aren't ordered with queued writes. Call FlushWrites to
+// wait for the queue to drain where ordering matters.
+func WithWriteQueue(size int) Option {
+	return func(c *Cache) {
+		q := &writeQueue{ch: make(chan queuedWrite, max(size, 1))}
+		q.drained = sync.NewCond(&q.mu)
+		c.writes = q
+	}
+}
+
+// queuedWrite is a write waiting for the applier
+type queuedWrite struct {
+	key  string
+	item Item
+}
+
+// writeQueue holds writes made with WithWriteQueue. The applier goroutine
+// only runs while there are writes to apply.
+type writeQueue struct {
+	ch      chan queuedWrite
+	running atomic.Bool
+
+	mu      sync.Mutex
+	pending int // queued writes not yet applied
+	drained *sync.Cond
+}
+
+// enqueueWrite queues an item for the applier, starting it if needed
+func (c *Cache) enqueueWrite(key string, item Item) error {
+	key, err := c.checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	q := c.writes
+	q.mu.Lock()
+	q.pending++
+	q.mu.Unlock()
+
+	q.ch <- queuedWrite{key: key, item: item}
+	if q.running.CompareAndSwap(false, true) {
+		go c.applyWrites()
+	}
+	return nil
+}
+
+// applyWrites stores queued writes in batches until the queue is empty
+func (c *Cache) applyWrites() {
+	q := c.writes
+	batch := make(map[string]Item)
+	var order []string
+	for {
+		n := 0
+	collect:
+		for n < cap(q.ch) {
+			select {
+			case w := <-q.ch:
+				if _, dup := batch[w.key]; !dup {
+					order = append(order, w.key)
+				}
+				batch[w.key] = w.item
+				n++
+			default:
+				break collect
+			}
+		}
+
+		if n > 0 {
+			c.mu.Lock()
+			// Writes that raced with Close are dropped, like those made after it
+			if !c.closed.Load() {
+				for _, key := range order {
+					c.setLocked(key, batch[key])
+				}
+			}
+			c.mu.Unlock()
+
+			clear(batch)
+			order = order[:0]
+
+			q.mu.Lock()
+			q.pending -= n
+			if q.pending == 0 {
+				q.drained.Broadcast()
+			}
+			q.mu.Unlock()
+			continue
+		}
+
+		// A write queued after the check above either sees running false
+		// and starts a new applier, or is seen here
+		q.running.Store(false)
+		if len(q.ch) == 0 || !q.running.CompareAndSwap(false, true) {
+			return
+		}
+	}
+}
+
+// FlushWrites waits until every write queued with WithWriteQueue is applied
+// and the queue is empty. It returns immediately without a write queue.
+func (c *Cache) FlushWrites() {
+	q := c.writes
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	for q.pending > 0 {
+		q.drained.Wait()
+	}
+	q.mu.Unlock()
+}
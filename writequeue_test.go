@@ -0,0 +1,64 @@
+package gocache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteQueue(t *testing.T) {
+	c := New(0, WithWriteQueue(16))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				if err := c.Set("key:"+strconv.Itoa(i%50), w*1000+i); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	c.FlushWrites()
+
+	if c.Count() != 50 {
+		t.Fatalf("Expected 50 keys, got %d", c.Count())
+	}
+
+	// The last write of a key wins
+	c.Set("last", 1)
+	c.Set("last", 2)
+	c.Set("last", 3)
+	c.FlushWrites()
+	var v int
+	if found, _ := c.Get("last", &v); !found || v != 3 {
+		t.Fatalf("Expected 3, got %d", v)
+	}
+}
+
+func TestWriteQueueRejectsInvalidKeys(t *testing.T) {
+	c := New(0, WithWriteQueue(4), WithKeyPolicy(KeyPolicy{RejectEmpty: true}))
+	if err := c.Set("", "v"); err == nil {
+		t.Fatal("Expected the key to be validated before queueing")
+	}
+	c.FlushWrites()
+	New(0).FlushWrites()
+}
+
+func TestWriteQueueClose(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		c := New(0, WithWriteQueue(1024))
+		for j := 0; j < 500; j++ {
+			c.Set(strconv.Itoa(j), j)
+		}
+		c.Close()
+		time.Sleep(time.Millisecond)
+		if n := c.Count(); n != 0 {
+			t.Fatalf("Expected no items after Close, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package yamlcodec
+
+import (
+	"fmt"
+	"os"
+
+	gocache "github.com/babashankar/go-cache"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a YAML cache configuration file:
+//
+//	cleanup_interval: 1m
+//	default_ttl: 10m
+//	max_bytes: 67108864
+//	namespaces:
+//	  - prefix: "session:"
+//	    max_ttl: 30m
+func LoadConfig(path string) (gocache.Config, error) {
+	var cfg gocache.Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("yamlcodec: %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,32 @@
+package yamlcodec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	os.WriteFile(path, []byte(`
+cleanup_interval: 1m
+default_ttl: 10m
+max_bytes: 1024
+namespaces:
+  - prefix: "session:"
+    max_ttl: 30m
+    no_store: ["session:*:card"]
+`), 0o600)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.DefaultTTL) != 10*time.Minute || cfg.MaxBytes != 1024 {
+		t.Fatalf("Expected the YAML values, got %+v", cfg)
+	}
+	if len(cfg.Namespaces) != 1 || time.Duration(cfg.Namespaces[0].MaxTTL) != 30*time.Minute || cfg.Namespaces[0].NoStore[0] != "session:*:card" {
+		t.Fatalf("Expected the session namespace, got %+v", cfg.Namespaces)
+	}
+}
@@ -0,0 +1,26 @@
+// Package yamlcodec provides a gocache.Codec that stores values as YAML.
+//
+// It lives in its own package so that users who don't cache YAML documents
+// don't pull in the YAML dependency:
+//
+//	c.SetWithOptions("config", cfg, gocache.WithCodec(yamlcodec.Codec))
+//	found, err := c.GetInto("config", &cfg, yamlcodec.Codec)
+package yamlcodec
+
+import (
+	gocache "github.com/babashankar/go-cache"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes values as YAML
+var Codec gocache.Codec = codec{}
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
@@ -0,0 +1,41 @@
+package yamlcodec
+
+import (
+	"testing"
+
+	gocache "github.com/babashankar/go-cache"
+)
+
+type config struct {
+	Name    string   `yaml:"name"`
+	Replica int      `yaml:"replicas"`
+	Hosts   []string `yaml:"hosts"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := gocache.New(0)
+
+	in := config{Name: "api", Replica: 3, Hosts: []string{"a", "b"}}
+	if err := c.SetWithOptions("config", in, gocache.WithCodec(Codec)); err != nil {
+		t.Fatalf("Error setting value: %v", err)
+	}
+
+	var out config
+	found, err := c.GetInto("config", &out, Codec)
+	if !found || err != nil {
+		t.Fatalf("Expected to decode YAML, found=%v err=%v", found, err)
+	}
+	if out.Name != "api" || out.Replica != 3 || len(out.Hosts) != 2 {
+		t.Fatalf("Retrieved config doesn't match: %+v", out)
+	}
+}
+
+func TestDecodeFetchedDocument(t *testing.T) {
+	c := gocache.New(0)
+	c.SetRaw("config", []byte("name: web\nreplicas: 2\n"), 0)
+
+	var out config
+	if _, err := c.GetInto("config", &out, Codec); err != nil || out.Name != "web" {
+		t.Fatalf("Expected to decode raw YAML, got %+v (err=%v)", out, err)
+	}
+}